@@ -8,12 +8,18 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"syscall"
 
-	"github.com/erik/feeds/internal/ai"
 	"github.com/erik/feeds/internal/api"
 	"github.com/erik/feeds/internal/db"
+	"github.com/erik/feeds/internal/downloader"
+	"github.com/erik/feeds/internal/ippool"
+	"github.com/erik/feeds/internal/youtube"
 	"github.com/erik/feeds/internal/ytdlp"
+	"github.com/erik/feeds/internal/ytnative"
 	"github.com/erik/feeds/web"
 )
 
@@ -54,6 +60,48 @@ func getEnvOrDefault(key, defaultVal string) string {
 	return defaultVal
 }
 
+// getEnvIntOrDefault returns the environment variable value parsed as an
+// int, or a default if it's unset or not a valid int.
+func getEnvIntOrDefault(key string, defaultVal int) int {
+	val := os.Getenv(key)
+	if val == "" {
+		return defaultVal
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil {
+		return defaultVal
+	}
+	return n
+}
+
+// getEnvInt64OrDefault returns the environment variable value parsed as an
+// int64, or a default if it's unset or not a valid int64.
+func getEnvInt64OrDefault(key string, defaultVal int64) int64 {
+	val := os.Getenv(key)
+	if val == "" {
+		return defaultVal
+	}
+	n, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return defaultVal
+	}
+	return n
+}
+
+// getEnvBoolOrDefault returns the environment variable value parsed as a
+// bool, or a default if it's unset or not a valid bool.
+func getEnvBoolOrDefault(key string, defaultVal bool) bool {
+	val := os.Getenv(key)
+	if val == "" {
+		return defaultVal
+	}
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		return defaultVal
+	}
+	return b
+}
+
 // Set via ldflags at build time
 var (
 	Version   = "dev"
@@ -89,6 +137,20 @@ func main() {
 	addr := flag.String("addr", getEnvOrDefault("FEEDS_ADDR", ":8080"), "HTTP server address")
 	dbPath := flag.String("db", getEnvOrDefault("FEEDS_DB", "feeds.db"), "SQLite database path")
 	ytdlpPath := flag.String("ytdlp", getEnvOrDefault("FEEDS_YTDLP", "yt-dlp"), "Path to yt-dlp binary")
+	cookiesPath := flag.String("cookies", getEnvOrDefault("FEEDS_COOKIES", ""), "Path to yt-dlp cookies file (Netscape format)")
+	ffprobePath := flag.String("ffprobe", getEnvOrDefault("FEEDS_FFPROBE", "ffprobe"), "Path to ffprobe binary, used to verify downloads")
+	backend := flag.String("backend", getEnvOrDefault("FEEDS_BACKEND", "auto"), "Extraction backend: ytdlp, native, or auto")
+	dlConcurrency := flag.Int("dl-concurrency", getEnvIntOrDefault("FEEDS_DL_CONCURRENCY", downloader.DefaultConcurrency), "Max concurrent yt-dlp downloads in the crash-resumable download queue")
+	maxDownloadLibraryBytes := flag.Int64("dl-max-library-bytes", getEnvInt64OrDefault("FEEDS_DL_MAX_LIBRARY_BYTES", 0), "Disk quota in bytes for the download queue's finished library, evicting oldest fully-watched videos first (0 disables the GC)")
+	sponsorblockPrivacyMode := flag.Bool("sponsorblock-privacy-mode", getEnvBoolOrDefault("FEEDS_SPONSORBLOCK_PRIVACY_MODE", true), "Look up SponsorBlock segments via hash-prefix instead of sending plaintext video IDs")
+	clusterHostname := flag.String("cluster-hostname", getEnvOrDefault("FEEDS_CLUSTER_HOSTNAME", ""), "Identity this instance claims feed-refresh leases under when sharing a database with other instances (default: os.Hostname())")
+	s3Bucket := flag.String("s3-bucket", getEnvOrDefault("FEEDS_S3_BUCKET", ""), "S3-compatible bucket to offload cached video downloads to, serving presigned URLs instead of proxying bytes through this process (default: disabled, local disk cache)")
+	s3Region := flag.String("s3-region", getEnvOrDefault("FEEDS_S3_REGION", ""), "Region for the S3-compatible bucket")
+	s3Prefix := flag.String("s3-prefix", getEnvOrDefault("FEEDS_S3_PREFIX", ""), "Key prefix for objects written to the S3-compatible bucket")
+	s3Endpoint := flag.String("s3-endpoint", getEnvOrDefault("FEEDS_S3_ENDPOINT", ""), "Override endpoint for non-AWS S3-compatible services (e.g. MinIO, Cloudflare R2)")
+	grpcAddr := flag.String("grpc-addr", getEnvOrDefault("FEEDS_GRPC_ADDR", ":9090"), "gRPC server address, serving DownloadService alongside the HTTP API")
+	packsDir := flag.String("packs-dir", getEnvOrDefault("FEEDS_PACKS_DIR", "packs-data"), "Writable directory for user-authored channel packs, overlaid on top of the embedded read-only set")
+	episodesDir := flag.String("episodes-dir", getEnvOrDefault("FEEDS_EPISODES_DIR", "episodes-data"), "Writable directory PodcastEpisodeWorker downloads podcast episode audio files into")
 	showVersion := flag.Bool("version", false, "Show version and exit")
 
 	flag.Usage = func() {
@@ -100,7 +162,22 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  FEEDS_ADDR        Server address (default :8080)\n")
 		fmt.Fprintf(os.Stderr, "  FEEDS_DB          Database path (default feeds.db)\n")
 		fmt.Fprintf(os.Stderr, "  FEEDS_YTDLP       Path to yt-dlp binary (default yt-dlp)\n")
+		fmt.Fprintf(os.Stderr, "  FEEDS_COOKIES     Path to yt-dlp cookies file (Netscape format)\n")
+		fmt.Fprintf(os.Stderr, "  FEEDS_FFPROBE     Path to ffprobe binary, used to verify downloads (default ffprobe)\n")
+		fmt.Fprintf(os.Stderr, "  FEEDS_BACKEND     Extraction backend: ytdlp, native, or auto (default auto)\n")
+		fmt.Fprintf(os.Stderr, "  FEEDS_DL_CONCURRENCY  Max concurrent yt-dlp downloads in the download queue (default %d)\n", downloader.DefaultConcurrency)
+		fmt.Fprintf(os.Stderr, "  FEEDS_DL_MAX_LIBRARY_BYTES  Disk quota for the download queue's finished library, 0 disables GC (default 0)\n")
+		fmt.Fprintf(os.Stderr, "  FEEDS_CLUSTER_HOSTNAME  Identity this instance claims feed-refresh leases under (default: os.Hostname())\n")
+		fmt.Fprintf(os.Stderr, "  FEEDS_S3_BUCKET   S3-compatible bucket to offload cached downloads to (default: disabled, local disk cache)\n")
+		fmt.Fprintf(os.Stderr, "  FEEDS_S3_REGION   Region for the S3-compatible bucket\n")
+		fmt.Fprintf(os.Stderr, "  FEEDS_S3_PREFIX   Key prefix for objects written to the S3-compatible bucket\n")
+		fmt.Fprintf(os.Stderr, "  FEEDS_S3_ENDPOINT  Override endpoint for non-AWS S3-compatible services (e.g. MinIO, Cloudflare R2)\n")
 		fmt.Fprintf(os.Stderr, "  OPENAI_API_KEY    Enable AI-powered subscription organization\n")
+		fmt.Fprintf(os.Stderr, "  YOUTUBE_API_KEY   Backfill new channels' full history via the Data API instead of Piped\n")
+		fmt.Fprintf(os.Stderr, "  PIPED_INSTANCES   Comma-separated Piped API base URLs\n")
+		fmt.Fprintf(os.Stderr, "  INVIDIOUS_INSTANCES  Comma-separated Invidious API base URLs\n")
+		fmt.Fprintf(os.Stderr, "  FEEDS_IPS         Comma-separated source IPs for yt-dlp to rotate through\n")
+		fmt.Fprintf(os.Stderr, "  FEEDS_PROXIES     Comma-separated proxy URLs for yt-dlp to rotate through\n")
 	}
 
 	flag.Parse()
@@ -116,22 +193,72 @@ func main() {
 	}
 	defer database.Close()
 
-	yt := ytdlp.New(*ytdlpPath)
+	yt := ytdlp.New(*ytdlpPath, *cookiesPath)
+	yt.FFProbePath = *ffprobePath
+	if ips, proxies := os.Getenv("FEEDS_IPS"), os.Getenv("FEEDS_PROXIES"); ips != "" || proxies != "" {
+		yt.Pool = ippool.New(strings.Split(ips, ","), strings.Split(proxies, ","), ippool.DefaultCooldown)
+		log.Printf("yt-dlp IP/proxy pool configured (%d entries) - rotating on 429s", yt.Pool.Size())
+	}
+
+	// Select the extraction backend. Whenever yt-dlp is in play ("ytdlp" or
+	// "auto" with the binary present), it's wrapped in a FallbackClient that
+	// retries each call against the native Piped-backed backend on failure -
+	// so a yt-dlp outage, rate limit, or missing binary partway through the
+	// process's life doesn't need a restart to route around. FEEDS_BACKEND=
+	// native disables yt-dlp entirely for users who prefer the lighter path.
+	var ytClient ytdlp.Client
+	switch *backend {
+	case "ytdlp":
+		ytClient = ytdlp.NewFallbackClient(yt, ytnative.New(nil))
+	case "native":
+		ytClient = ytnative.New(nil)
+		log.Println("FEEDS_BACKEND=native - using the pure-Go extraction backend, no yt-dlp binary required")
+	case "auto":
+		if version, err := yt.Version(); err == nil {
+			ytClient = ytdlp.NewFallbackClient(yt, ytnative.New(nil))
+			log.Printf("yt-dlp binary found (%s) - using it as the extraction backend, with native Piped fallback on failure", version)
+		} else {
+			ytClient = ytnative.New(nil)
+			log.Println("yt-dlp binary not found - falling back to the native Go extraction backend")
+		}
+	default:
+		log.Fatalf("invalid FEEDS_BACKEND %q (expected ytdlp, native, or auto)", *backend)
+	}
 
-	// OpenAI client (optional - for AI grouping)
-	var aiClient *ai.Client
-	if key := os.Getenv("OPENAI_API_KEY"); key != "" {
-		aiClient = ai.New(key)
-		log.Println("OpenAI API key found - AI grouping enabled")
+	youtubeAPIKey := os.Getenv("YOUTUBE_API_KEY")
+	if youtubeAPIKey != "" {
+		log.Println("YouTube API key found - full-history channel backfill uses the Data API")
 	} else {
-		log.Println("No OPENAI_API_KEY set - AI grouping disabled")
+		log.Println("No YOUTUBE_API_KEY set - new channels fall back to Piped for full-history backfill")
+	}
+
+	if instances := os.Getenv("PIPED_INSTANCES"); instances != "" {
+		youtube.SetPipedInstances(strings.Split(instances, ","))
+	}
+	if instances := os.Getenv("INVIDIOUS_INSTANCES"); instances != "" {
+		youtube.SetInvidiousInstances(strings.Split(instances, ","))
+	}
+	if order := os.Getenv("SOURCE_ORDER"); order != "" {
+		if err := youtube.DefaultChain.SetOrder(strings.Split(order, ",")); err != nil {
+			log.Fatalf("Invalid SOURCE_ORDER: %v", err)
+		}
 	}
 
-	server, err := api.NewServer(database, yt, aiClient, web.Templates, web.Packs)
+	s3Config := api.S3Config{
+		Bucket:   *s3Bucket,
+		Region:   *s3Region,
+		Prefix:   *s3Prefix,
+		Endpoint: *s3Endpoint,
+	}
+	server, err := api.NewServer(database, ytClient, youtubeAPIKey, *dlConcurrency, *maxDownloadLibraryBytes, *sponsorblockPrivacyMode, *clusterHostname, s3Config, web.Templates, web.Packs, *packsDir, *episodesDir)
 	if err != nil {
 		log.Fatalf("Failed to create server: %v", err)
 	}
 
+	if err := server.StartGRPC(*grpcAddr); err != nil {
+		log.Fatalf("Failed to start gRPC server: %v", err)
+	}
+
 	mux := http.NewServeMux()
 	server.RegisterRoutes(mux)
 
@@ -143,6 +270,17 @@ func main() {
 	spaHandler := api.NewSPAHandler(spaFS)
 	mux.Handle("GET /", spaHandler)
 
+	// Drain the scheduler's in-flight refreshes on SIGINT/SIGTERM before the
+	// process exits.
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		log.Println("Shutting down, draining scheduler...")
+		server.Shutdown()
+		os.Exit(0)
+	}()
+
 	log.Printf("Starting server on %s", *addr)
 	if err := http.ListenAndServe(*addr, corsMiddleware(mux)); err != nil {
 		log.Fatalf("Server failed: %v", err)
@@ -0,0 +1,197 @@
+package ai
+
+import (
+	"math"
+	"strings"
+)
+
+// kMeans partitions vectors into k clusters via naive Lloyd's algorithm,
+// seeded deterministically (evenly spaced points rather than math/rand) so
+// the same input always produces the same grouping.
+func kMeans(vectors [][]float64, k int) []int {
+	n := len(vectors)
+	if k <= 0 || k > n {
+		k = n
+	}
+
+	step := n / k
+	if step == 0 {
+		step = 1
+	}
+	centroids := make([][]float64, k)
+	for i := range centroids {
+		centroids[i] = append([]float64(nil), vectors[(i*step)%n]...)
+	}
+
+	assignments := make([]int, n)
+	const maxIterations = 50
+	for iter := 0; iter < maxIterations; iter++ {
+		changed := false
+		for i, v := range vectors {
+			best, bestDist := 0, math.Inf(1)
+			for c, centroid := range centroids {
+				if d := sqDistance(v, centroid); d < bestDist {
+					best, bestDist = c, d
+				}
+			}
+			if assignments[i] != best {
+				assignments[i] = best
+				changed = true
+			}
+		}
+		if !changed && iter > 0 {
+			break
+		}
+
+		sums := make([][]float64, k)
+		counts := make([]int, k)
+		for i, v := range vectors {
+			c := assignments[i]
+			if sums[c] == nil {
+				sums[c] = make([]float64, len(v))
+			}
+			for d, x := range v {
+				sums[c][d] += x
+			}
+			counts[c]++
+		}
+		for c := range centroids {
+			if counts[c] == 0 {
+				continue // no members this round; leave the centroid where it was
+			}
+			for d := range centroids[c] {
+				centroids[c][d] = sums[c][d] / float64(counts[c])
+			}
+		}
+	}
+	return assignments
+}
+
+func sqDistance(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+	return sum
+}
+
+// bestK chooses the cluster count in [min, max] with the highest average
+// silhouette score, clamping to the number of available points when
+// there are too few to support the requested range.
+func bestK(vectors [][]float64, min, max int) int {
+	if len(vectors) == 0 {
+		return 1
+	}
+	if len(vectors) <= min {
+		return len(vectors)
+	}
+	if max > len(vectors) {
+		max = len(vectors)
+	}
+
+	best, bestScore := min, math.Inf(-1)
+	for k := min; k <= max; k++ {
+		assignments := kMeans(vectors, k)
+		if score := silhouetteScore(vectors, assignments, k); score > bestScore {
+			bestScore, best = score, k
+		}
+	}
+	return best
+}
+
+// silhouetteScore averages each point's silhouette coefficient: how much
+// closer it is to its own cluster than to the nearest other one.
+func silhouetteScore(vectors [][]float64, assignments []int, k int) float64 {
+	if k <= 1 {
+		return 0
+	}
+
+	var total float64
+	for i := range vectors {
+		a := meanDistanceToCluster(vectors, assignments, i, assignments[i])
+
+		b := math.Inf(1)
+		for c := 0; c < k; c++ {
+			if c == assignments[i] {
+				continue
+			}
+			if d := meanDistanceToCluster(vectors, assignments, i, c); d < b {
+				b = d
+			}
+		}
+
+		m := math.Max(a, b)
+		if m == 0 {
+			continue
+		}
+		total += (b - a) / m
+	}
+	return total / float64(len(vectors))
+}
+
+func meanDistanceToCluster(vectors [][]float64, assignments []int, point, cluster int) float64 {
+	var sum float64
+	var count int
+	for i, c := range assignments {
+		if c != cluster || i == point {
+			continue
+		}
+		sum += math.Sqrt(sqDistance(vectors[point], vectors[i]))
+		count++
+	}
+	if count == 0 {
+		return 0
+	}
+	return sum / float64(count)
+}
+
+// stopWords is filtered out of video titles before counting tokens, so
+// topTFIDFToken doesn't just pick "the" or "how" for every cluster.
+var stopWords = map[string]bool{
+	"the": true, "a": true, "an": true, "and": true, "or": true, "of": true,
+	"to": true, "in": true, "for": true, "on": true, "with": true, "is": true,
+	"how": true, "you": true, "your": true, "this": true, "that": true,
+	"it": true, "at": true, "vs": true, "new": true, "best": true, "are": true,
+}
+
+func tokenize(title string) []string {
+	var tokens []string
+	for _, word := range strings.Fields(strings.ToLower(title)) {
+		word = strings.Trim(word, ".,!?:;\"'()[]|")
+		if len(word) < 3 || stopWords[word] {
+			continue
+		}
+		tokens = append(tokens, word)
+	}
+	return tokens
+}
+
+// topTFIDFToken returns the most frequent non-stopword token across a
+// cluster's video titles, as a cheap stand-in for naming it when no local
+// LLM is configured. The score is plain term frequency within this one
+// cluster; tokens are not weighted by how common they are in other
+// clusters. Falls back to the cluster's first channel name when there are
+// no video titles to draw from.
+func topTFIDFToken(videoTitles, fallbackNames []string) string {
+	counts := make(map[string]int)
+	for _, title := range videoTitles {
+		for _, tok := range tokenize(title) {
+			counts[tok]++
+		}
+	}
+	if len(counts) == 0 {
+		if len(fallbackNames) > 0 {
+			return fallbackNames[0]
+		}
+		return "Uncategorized"
+	}
+
+	best, bestCount := "", 0
+	for tok, count := range counts {
+		if count > bestCount || (count == bestCount && tok < best) {
+			best, bestCount = tok, count
+		}
+	}
+	return strings.ToUpper(best[:1]) + best[1:]
+}
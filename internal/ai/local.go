@@ -0,0 +1,219 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/erik/feeds/internal/models"
+)
+
+// Embedder produces a vector embedding for a document of text. Swappable so
+// LocalGrouper isn't tied to one backend.
+type Embedder interface {
+	Embed(text string) ([]float64, error)
+}
+
+// OllamaEmbedder is the default Embedder: it calls an Ollama-compatible
+// /api/embeddings endpoint, since users running this app already tend to
+// self-host Ollama for other local-LLM features.
+type OllamaEmbedder struct {
+	BaseURL    string
+	Model      string
+	HTTPClient *http.Client
+}
+
+// NewOllamaEmbedder creates an embedder against model (e.g.
+// "nomic-embed-text" or "all-minilm") served at baseURL (e.g.
+// "http://localhost:11434").
+func NewOllamaEmbedder(baseURL, model string) *OllamaEmbedder {
+	return &OllamaEmbedder{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		Model:      model,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type embeddingRequest struct {
+	Model  string `json:"model"`
+	Prompt string `json:"prompt"`
+}
+
+type embeddingResponse struct {
+	Embedding []float64 `json:"embedding"`
+}
+
+// Embed fetches a single embedding vector for text via POST /api/embeddings.
+func (e *OllamaEmbedder) Embed(text string) ([]float64, error) {
+	body, err := json.Marshal(embeddingRequest{Model: e.Model, Prompt: text})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := e.HTTPClient.Post(e.BaseURL+"/api/embeddings", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("ollama embeddings request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ollama embeddings returned status %d", resp.StatusCode)
+	}
+
+	var parsed embeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, err
+	}
+	if len(parsed.Embedding) == 0 {
+		return nil, fmt.Errorf("ollama returned an empty embedding for model %q", e.Model)
+	}
+	return parsed.Embedding, nil
+}
+
+// Grouper suggests topical groups for a set of subscriptions given optional
+// per-channel metadata. Client (OpenAI) and LocalGrouper both implement it,
+// so the HTTP handler can pick a provider by config without caring which
+// one it's talking to.
+type Grouper interface {
+	SuggestGroupsWithMetadata(subs []models.NewPipeSubscription, metadata map[string]ChannelInfo) ([]GroupSuggestion, error)
+}
+
+// LocalGrouper clusters channel subscriptions entirely on the user's
+// machine instead of shipping subscription names and video titles to
+// OpenAI: it embeds each channel's name plus recent video titles and runs
+// k-means over the result, choosing a cluster count by silhouette score.
+// Naming a cluster asks a local LLM when one's configured, falling back to
+// the cluster's most frequent video-title token otherwise.
+type LocalGrouper struct {
+	Embedder Embedder
+
+	// LLMBaseURL and LLMModel, if both set, name each cluster by asking a
+	// local LLM (e.g. Ollama's /api/generate) for a short name given its
+	// member channels. Leaving LLMBaseURL empty falls back to labeling
+	// clusters by their most common video-title token.
+	LLMBaseURL string
+	LLMModel   string
+
+	HTTPClient *http.Client
+}
+
+// NewLocalGrouper creates a grouper using embedder for clustering. LLM-based
+// labeling stays off until LLMBaseURL/LLMModel are set directly.
+func NewLocalGrouper(embedder Embedder) *LocalGrouper {
+	return &LocalGrouper{
+		Embedder:   embedder,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// SuggestGroups clusters subs with no video-title/language context.
+func (g *LocalGrouper) SuggestGroups(subs []models.NewPipeSubscription) ([]GroupSuggestion, error) {
+	return g.SuggestGroupsWithMetadata(subs, nil)
+}
+
+// SuggestGroupsWithMetadata embeds each channel's name plus recent video
+// titles, clusters the embeddings with k-means (choosing k by silhouette
+// score between 5 and 12), and labels each resulting cluster.
+func (g *LocalGrouper) SuggestGroupsWithMetadata(subs []models.NewPipeSubscription, metadata map[string]ChannelInfo) ([]GroupSuggestion, error) {
+	if len(subs) == 0 {
+		return nil, nil
+	}
+
+	vectors := make([][]float64, len(subs))
+	for i, sub := range subs {
+		doc := sub.Name
+		if metadata != nil {
+			if info, ok := metadata[sub.URL]; ok && len(info.VideoTitles) > 0 {
+				doc = fmt.Sprintf("%s: %s", sub.Name, strings.Join(info.VideoTitles, ". "))
+			}
+		}
+		vec, err := g.Embedder.Embed(doc)
+		if err != nil {
+			return nil, fmt.Errorf("embedding %q: %w", sub.Name, err)
+		}
+		vectors[i] = vec
+	}
+
+	k := bestK(vectors, 5, 12)
+	assignments := kMeans(vectors, k)
+
+	clusters := make([][]int, k)
+	for i, c := range assignments {
+		clusters[c] = append(clusters[c], i)
+	}
+
+	var suggestions []GroupSuggestion
+	for _, members := range clusters {
+		if len(members) == 0 {
+			continue
+		}
+
+		var channels []models.NewPipeSubscription
+		var names []string
+		var titles []string
+		for _, i := range members {
+			channels = append(channels, subs[i])
+			names = append(names, subs[i].Name)
+			if metadata != nil {
+				titles = append(titles, metadata[subs[i].URL].VideoTitles...)
+			}
+		}
+
+		suggestions = append(suggestions, GroupSuggestion{
+			Name:     g.labelCluster(names, titles),
+			Channels: channels,
+		})
+	}
+	return suggestions, nil
+}
+
+// labelCluster names a cluster via the local LLM when configured, falling
+// back to its most frequent video-title token (see topTFIDFToken).
+func (g *LocalGrouper) labelCluster(channelNames, videoTitles []string) string {
+	if g.LLMBaseURL != "" && g.LLMModel != "" {
+		if name, err := g.askLLMForLabel(channelNames); err == nil && name != "" {
+			return name
+		}
+	}
+	return topTFIDFToken(videoTitles, channelNames)
+}
+
+type generateRequest struct {
+	Model  string `json:"model"`
+	Prompt string `json:"prompt"`
+	Stream bool   `json:"stream"`
+}
+
+type generateResponse struct {
+	Response string `json:"response"`
+}
+
+// askLLMForLabel asks the configured local LLM for a 1-3 word category
+// name given a cluster's member channel names, via Ollama's /api/generate.
+func (g *LocalGrouper) askLLMForLabel(channelNames []string) (string, error) {
+	prompt := fmt.Sprintf("Give a short (1-3 word) category name for a group of YouTube channels: %s. Respond with ONLY the category name, nothing else.", strings.Join(channelNames, ", "))
+
+	body, err := json.Marshal(generateRequest{Model: g.LLMModel, Prompt: prompt, Stream: false})
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := g.HTTPClient.Post(strings.TrimRight(g.LLMBaseURL, "/")+"/api/generate", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("local LLM returned status %d", resp.StatusCode)
+	}
+
+	var parsed generateResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return "", err
+	}
+	return strings.Trim(strings.TrimSpace(parsed.Response), "\""), nil
+}
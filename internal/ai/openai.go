@@ -57,12 +57,48 @@ type ChannelInfo struct {
 	Name        string
 	URL         string
 	VideoTitles []string
+
+	// Language is the channel's dominant ISO-639-1 language code (e.g. from
+	// its videos' detected language), used by SuggestGroupsScopedByLanguage
+	// to cluster each language separately. Empty is treated as its own
+	// "unknown" bucket.
+	Language string
 }
 
 func (c *Client) SuggestGroups(subs []models.NewPipeSubscription) ([]GroupSuggestion, error) {
 	return c.SuggestGroupsWithMetadata(subs, nil)
 }
 
+// SuggestGroupsScopedByLanguage buckets subs by metadata's Language field
+// and clusters each bucket independently, so a multilingual subscription
+// list doesn't get grouped by topics the model can only infer from titles
+// it recognizes in one language. Falls back to a single unscoped
+// SuggestGroupsWithMetadata call when fewer than two languages are present.
+func (c *Client) SuggestGroupsScopedByLanguage(subs []models.NewPipeSubscription, metadata map[string]ChannelInfo) ([]GroupSuggestion, error) {
+	buckets := make(map[string][]models.NewPipeSubscription)
+	for _, sub := range subs {
+		var lang string
+		if metadata != nil {
+			lang = metadata[sub.URL].Language
+		}
+		buckets[lang] = append(buckets[lang], sub)
+	}
+
+	if len(buckets) <= 1 {
+		return c.SuggestGroupsWithMetadata(subs, metadata)
+	}
+
+	var all []GroupSuggestion
+	for _, bucketSubs := range buckets {
+		suggestions, err := c.SuggestGroupsWithMetadata(bucketSubs, metadata)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, suggestions...)
+	}
+	return all, nil
+}
+
 func (c *Client) SuggestGroupsWithMetadata(subs []models.NewPipeSubscription, metadata map[string]ChannelInfo) ([]GroupSuggestion, error) {
 	// Build channel list for prompt with metadata if available
 	var channelEntries []string
@@ -0,0 +1,417 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	ffprobe "gopkg.in/vansante/go-ffprobe.v2"
+
+	"github.com/erik/feeds/internal/ytdlp"
+)
+
+const (
+	adaptiveHLSCacheDir = "/tmp/feeds-adaptive-hls-cache"
+
+	// adaptiveChunkSeconds is the fixed length every chunk (besides the
+	// last, which is whatever's left over) is split into.
+	adaptiveChunkSeconds = 6
+
+	// adaptiveIdleTimeout is how long a video's resolved source and cached
+	// chunk locks are kept around with no requests before sweepIdle evicts
+	// them and closeLoop clears its on-disk chunk cache.
+	adaptiveIdleTimeout     = 5 * time.Minute
+	adaptiveIdleSweepPeriod = 1 * time.Minute
+)
+
+// qualityRung is one rendition in the adaptive ladder TranscodeManager
+// offers, named to match the {quality} path segment.
+type qualityRung struct {
+	Name       string
+	Height     int
+	BitrateBps int
+}
+
+// adaptiveQualityLadder is the full rung set a source is pruned down to -
+// see pruneLadder - so a source never advertises a rendition upscaled past
+// its own resolution.
+var adaptiveQualityLadder = []qualityRung{
+	{"360", 360, 800_000},
+	{"480", 480, 1_500_000},
+	{"720", 720, 3_000_000},
+	{"1080", 1080, 5_000_000},
+	{"1440", 1440, 9_000_000},
+	{"2160", 2160, 14_000_000},
+}
+
+// TranscodeManager serves an adaptive HLS ladder for any video ID,
+// transcoding chunks from the source stream on demand via ffmpeg instead of
+// pre-downloading full files. Unlike DownloadManager.StartHLSDownload (which
+// repackages one fixed quality end-to-end as a live event playlist), this
+// builds a VOD playlist per ladder rung and only ever transcodes the one
+// chunk a client actually requested, memoizing it to disk for next time.
+type TranscodeManager struct {
+	ytdlp ytdlp.Client
+
+	// dm is reused only for its SSE broadcast pipeline, so transcode
+	// progress shows up to the same subscribers as an ordinary download -
+	// see handleDownloadStatus.
+	dm *DownloadManager
+
+	mu     sync.Mutex
+	videos map[string]*transcodeSource
+
+	// close receives a video ID once sweepIdle has evicted it, so closeLoop
+	// can clear its on-disk chunk cache off the request path.
+	close chan string
+}
+
+// transcodeSource is one video's resolved source and ladder, probed once on
+// first request and reused until sweepIdle evicts it.
+type transcodeSource struct {
+	sourceURL string
+	width     int
+	height    int
+	duration  float64
+	ladder    []qualityRung
+
+	mu sync.Mutex
+
+	// chunkLocks serializes concurrent requests for the same quality/chunk
+	// so only one ffmpeg transcode ever runs for it; see chunkLock.
+	chunkLocks map[string]*sync.Mutex
+
+	// inactive counts consecutive idle sweeps since this source was last
+	// touched by a request; see TranscodeManager.sweepIdle.
+	inactive int
+}
+
+// NewTranscodeManager creates a TranscodeManager and starts its idle-source
+// sweep. dm's broadcast pipeline is reused to surface transcode progress
+// alongside ordinary download progress.
+func NewTranscodeManager(yt ytdlp.Client, dm *DownloadManager) *TranscodeManager {
+	tm := &TranscodeManager{
+		ytdlp:  yt,
+		dm:     dm,
+		videos: make(map[string]*transcodeSource),
+		close:  make(chan string),
+	}
+	go tm.sweepLoop()
+	go tm.closeLoop()
+	return tm
+}
+
+// MasterPlaylist resolves videoID's source - probing it via ffprobe on
+// first request - and returns a master playlist listing every ladder rung
+// that fits inside the source's own resolution.
+func (tm *TranscodeManager) MasterPlaylist(videoID string) (string, error) {
+	src, err := tm.ensureSource(videoID)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n")
+	for _, rung := range src.ladder {
+		fmt.Fprintf(&b, "#EXT-X-STREAM-INF:BANDWIDTH=%d,RESOLUTION=%dx%d\n", rung.BitrateBps, rungWidth(rung, src), rung.Height)
+		fmt.Fprintf(&b, "%s/index.m3u8\n", rung.Name)
+	}
+	return b.String(), nil
+}
+
+// MediaPlaylist returns a VOD media playlist for videoID/quality, splitting
+// the source's duration into fixed adaptiveChunkSeconds chunks - the last
+// one truncated to whatever time is left over.
+func (tm *TranscodeManager) MediaPlaylist(videoID, quality string) (string, error) {
+	src, err := tm.ensureSource(videoID)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := rungFor(src.ladder, quality); !ok {
+		return "", fmt.Errorf("quality %q not offered for this source", quality)
+	}
+
+	count := chunkCount(src.duration)
+
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n")
+	b.WriteString("#EXT-X-VERSION:3\n")
+	fmt.Fprintf(&b, "#EXT-X-TARGETDURATION:%d\n", adaptiveChunkSeconds)
+	b.WriteString("#EXT-X-PLAYLIST-TYPE:VOD\n")
+	b.WriteString("#EXT-X-MEDIA-SEQUENCE:0\n")
+
+	remaining := src.duration
+	for i := 0; i < count; i++ {
+		segDuration := float64(adaptiveChunkSeconds)
+		if remaining < segDuration {
+			segDuration = remaining
+		}
+		fmt.Fprintf(&b, "#EXTINF:%.3f,\nchunk-%d.ts\n", segDuration, i)
+		remaining -= adaptiveChunkSeconds
+	}
+	b.WriteString("#EXT-X-ENDLIST\n")
+	return b.String(), nil
+}
+
+// Chunk returns the transcoded TS bytes for videoID/quality's chunk index,
+// transcoding it on demand - seeking the source to its start via -ss - if
+// it isn't already memoized on disk from a previous request. Concurrent
+// requests for the same chunk are serialized so only one ffmpeg transcode
+// ever runs for it.
+func (tm *TranscodeManager) Chunk(videoID, quality string, index int) ([]byte, error) {
+	src, err := tm.ensureSource(videoID)
+	if err != nil {
+		return nil, err
+	}
+	rung, ok := rungFor(src.ladder, quality)
+	if !ok {
+		return nil, fmt.Errorf("quality %q not offered for this source", quality)
+	}
+
+	start := float64(index) * adaptiveChunkSeconds
+	length := adaptiveChunkSeconds
+	if remaining := src.duration - start; remaining < float64(adaptiveChunkSeconds) {
+		length = int(math.Ceil(remaining))
+	}
+	if length <= 0 {
+		return nil, fmt.Errorf("chunk %d is past the end of this video", index)
+	}
+
+	path := chunkPath(videoID, quality, index)
+	if data, err := os.ReadFile(path); err == nil {
+		return data, nil
+	}
+
+	lock := src.chunkLock(quality, index)
+	lock.Lock()
+	defer lock.Unlock()
+
+	// Another request may have finished the transcode while we waited on
+	// the lock.
+	if data, err := os.ReadFile(path); err == nil {
+		return data, nil
+	}
+
+	if tm.dm != nil {
+		tm.dm.broadcast(videoID, DownloadProgress{
+			Quality: quality,
+			Status:  "transcoding",
+			Percent: float64(index) / float64(chunkCount(src.duration)) * 100,
+		})
+	}
+
+	if err := transcodeChunk(src.sourceURL, path, start, length, rung); err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(path)
+}
+
+// ensureSource returns videoID's resolved source, probing it via ffprobe and
+// building its pruned ladder on first request. Every call - a first probe or
+// a later playlist/chunk request - resets the source's idle counter.
+func (tm *TranscodeManager) ensureSource(videoID string) (*transcodeSource, error) {
+	tm.mu.Lock()
+	if src, ok := tm.videos[videoID]; ok {
+		src.inactive = 0
+		tm.mu.Unlock()
+		return src, nil
+	}
+	tm.mu.Unlock()
+
+	videoURL := "https://www.youtube.com/watch?v=" + videoID
+	streamURL, err := tm.ytdlp.GetStreamURL(videoURL, "2160")
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve source stream: %w", err)
+	}
+
+	width, height, duration, err := probeSource(streamURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe source: %w", err)
+	}
+
+	ladder := pruneLadder(height)
+
+	src := &transcodeSource{
+		sourceURL:  streamURL,
+		width:      width,
+		height:     height,
+		duration:   duration,
+		ladder:     ladder,
+		chunkLocks: make(map[string]*sync.Mutex),
+	}
+
+	tm.mu.Lock()
+	tm.videos[videoID] = src
+	tm.mu.Unlock()
+
+	return src, nil
+}
+
+// chunkLock returns the mutex guarding quality/index's transcode, creating
+// one on first request for that chunk.
+func (src *transcodeSource) chunkLock(quality string, index int) *sync.Mutex {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	key := quality + "_" + strconv.Itoa(index)
+	lock, ok := src.chunkLocks[key]
+	if !ok {
+		lock = &sync.Mutex{}
+		src.chunkLocks[key] = lock
+	}
+	return lock
+}
+
+// sweepLoop periodically ages every tracked source, evicting ones that have
+// gone untouched for longer than adaptiveIdleTimeout.
+func (tm *TranscodeManager) sweepLoop() {
+	ticker := time.NewTicker(adaptiveIdleSweepPeriod)
+	defer ticker.Stop()
+	for range ticker.C {
+		tm.sweepIdle()
+	}
+}
+
+func (tm *TranscodeManager) sweepIdle() {
+	maxInactive := int(adaptiveIdleTimeout / adaptiveIdleSweepPeriod)
+
+	tm.mu.Lock()
+	var expired []string
+	for videoID, src := range tm.videos {
+		src.inactive++
+		if src.inactive >= maxInactive {
+			expired = append(expired, videoID)
+			delete(tm.videos, videoID)
+		}
+	}
+	tm.mu.Unlock()
+
+	for _, videoID := range expired {
+		tm.close <- videoID
+	}
+}
+
+// closeLoop clears an evicted video's on-disk chunk cache off the request
+// path, one video at a time as sweepIdle reports them.
+func (tm *TranscodeManager) closeLoop() {
+	for videoID := range tm.close {
+		if err := os.RemoveAll(adaptiveVideoDir(videoID)); err != nil && !os.IsNotExist(err) {
+			log.Printf("adaptivehls: failed to clean up idle cache for %s: %v", videoID, err)
+			continue
+		}
+		log.Printf("adaptivehls: closed idle transcode worker for %s", videoID)
+	}
+}
+
+// probeSource runs ffprobe against a resolved stream URL to get the
+// dimensions and duration TranscodeManager needs to build a pruned ladder
+// and chunk the timeline.
+func probeSource(streamURL string) (width, height int, duration float64, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	data, err := ffprobe.ProbeURL(ctx, streamURL)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	videoStream := data.FirstVideoStream()
+	if videoStream == nil {
+		return 0, 0, 0, fmt.Errorf("no video stream found")
+	}
+
+	return videoStream.Width, videoStream.Height, data.Format.DurationSeconds, nil
+}
+
+// pruneLadder drops every rung whose height exceeds sourceHeight, so a
+// source never advertises a rendition upscaled past its own resolution. A
+// source lower than the smallest rung still gets that one rung, so playback
+// isn't left with an empty ladder.
+func pruneLadder(sourceHeight int) []qualityRung {
+	var ladder []qualityRung
+	for _, rung := range adaptiveQualityLadder {
+		if rung.Height <= sourceHeight {
+			ladder = append(ladder, rung)
+		}
+	}
+	if len(ladder) == 0 && len(adaptiveQualityLadder) > 0 {
+		ladder = []qualityRung{adaptiveQualityLadder[0]}
+	}
+	return ladder
+}
+
+// rungWidth computes rung's width preserving the source's aspect ratio,
+// rounded down to an even number as most encoders require.
+func rungWidth(rung qualityRung, src *transcodeSource) int {
+	if src.height == 0 {
+		return 0
+	}
+	w := rung.Height * src.width / src.height
+	if w%2 != 0 {
+		w--
+	}
+	return w
+}
+
+func rungFor(ladder []qualityRung, quality string) (qualityRung, bool) {
+	for _, rung := range ladder {
+		if rung.Name == quality {
+			return rung, true
+		}
+	}
+	return qualityRung{}, false
+}
+
+// chunkCount is ceil(duration / adaptiveChunkSeconds), the number of chunks
+// a source's timeline is split into.
+func chunkCount(duration float64) int {
+	n := int(math.Ceil(duration / adaptiveChunkSeconds))
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+func adaptiveVideoDir(videoID string) string {
+	return filepath.Join(adaptiveHLSCacheDir, videoID)
+}
+
+func chunkPath(videoID, quality string, index int) string {
+	return filepath.Join(adaptiveVideoDir(videoID), quality, fmt.Sprintf("chunk-%d.ts", index))
+}
+
+// transcodeChunk runs ffmpeg against source, seeking to start and encoding
+// exactly length seconds scaled to rung's height and bitrate, writing a
+// standalone .ts segment to outPath.
+func transcodeChunk(sourceURL, outPath string, start float64, length int, rung qualityRung) error {
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		return err
+	}
+
+	cmd := exec.Command(
+		"ffmpeg",
+		"-y",
+		"-ss", fmt.Sprintf("%.3f", start),
+		"-i", sourceURL,
+		"-t", strconv.Itoa(length),
+		"-vf", fmt.Sprintf("scale=-2:%d", rung.Height),
+		"-c:v", "libx264",
+		"-b:v", strconv.Itoa(rung.BitrateBps),
+		"-c:a", "aac",
+		"-f", "mpegts",
+		outPath,
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ffmpeg transcode failed: %w: %s", err, string(out))
+	}
+	return nil
+}
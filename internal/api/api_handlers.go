@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -14,8 +15,12 @@ import (
 	"sync"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+
 	"github.com/erik/feeds/internal/db"
+	"github.com/erik/feeds/internal/metrics"
 	"github.com/erik/feeds/internal/models"
+	"github.com/erik/feeds/internal/sources"
 	yt "github.com/erik/feeds/internal/youtube"
 )
 
@@ -37,9 +42,108 @@ func jsonError(w http.ResponseWriter, message string, status int) {
 func (s *Server) handleAPIConfig(w http.ResponseWriter, r *http.Request) {
 	jsonResponse(w, map[string]any{
 		"ytdlpCookiesConfigured": s.ytdlpCookiesConfigured(),
+		"pipedInstances":         yt.PipedInstanceStatus(),
+		"sourceOrder":            yt.DefaultChain.Order(),
+	})
+}
+
+// handleAPISetSourceOrder reorders the import flow's fallback chain, e.g. to
+// prefer Piped or Invidious over the official RSS path on networks where
+// YouTube itself is blocked. See yt.Chain.SetOrder.
+func (s *Server) handleAPISetSourceOrder(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Order []string `json:"order"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := yt.DefaultChain.SetOrder(req.Order); err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	jsonResponse(w, map[string]any{
+		"sourceOrder": yt.DefaultChain.Order(),
 	})
 }
 
+// handleAPISetPipedInstances reconfigures the pool of Piped/Invidious API
+// hosts used as the fast, no-yt-dlp path for metadata and shorts checks.
+func (s *Server) handleAPISetPipedInstances(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Instances []string `json:"instances"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	yt.SetPipedInstances(req.Instances)
+	jsonResponse(w, map[string]any{
+		"pipedInstances": yt.PipedInstanceStatus(),
+	})
+}
+
+// handleAPISourcesHealth reports which import-flow sources (Piped,
+// Invidious) have instances currently disabled and when they'll next be
+// retried, for operators debugging a degraded import flow.
+func (s *Server) handleAPISourcesHealth(w http.ResponseWriter, r *http.Request) {
+	jsonResponse(w, map[string]any{
+		"sources": yt.DefaultChain.Health(),
+	})
+}
+
+// handleAPIClusterWorkers lists every feeds instance sharing this database
+// that has heartbeated, for operators scaling refresh horizontally behind a
+// shared SQLite-via-litestream or Postgres backend to see which peers are
+// alive and confirm work is actually being split between them.
+func (s *Server) handleAPIClusterWorkers(w http.ResponseWriter, r *http.Request) {
+	workers, err := s.db.GetSyncWorkers()
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	jsonResponse(w, map[string]any{
+		"self":    s.scheduler.Hostname(),
+		"workers": workers,
+	})
+}
+
+// handleAPIClusterReassign force-releases a feed-refresh claim for manual
+// rebalancing: pass feed_id to free a single feed, or hostname to free
+// every feed claimed by that instance (e.g. before decommissioning it). The
+// freed feed(s) are picked up by whichever instance's scheduler next polls.
+func (s *Server) handleAPIClusterReassign(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		FeedID   int64  `json:"feed_id,omitempty"`
+		Hostname string `json:"hostname,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case req.FeedID != 0:
+		if err := s.db.ReassignFeed(req.FeedID); err != nil {
+			jsonError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		jsonResponse(w, map[string]any{"ok": true, "feedID": req.FeedID})
+	case req.Hostname != "":
+		count, err := s.db.ReassignHostClaims(req.Hostname)
+		if err != nil {
+			jsonError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		jsonResponse(w, map[string]any{"ok": true, "released": count})
+	default:
+		jsonError(w, "Must specify feed_id or hostname", http.StatusBadRequest)
+	}
+}
+
 func (s *Server) handleAPISetYTDLPCookies(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		Cookies string `json:"cookies"`
@@ -50,7 +154,7 @@ func (s *Server) handleAPISetYTDLPCookies(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	cookiesPath := s.ytdlp.CookiesPath
+	cookiesPath := s.cookiesPath
 	if cookiesPath == "" {
 		jsonError(w, "Cookies path not configured", http.StatusInternalServerError)
 		return
@@ -99,10 +203,10 @@ func (s *Server) handleAPISetYTDLPCookies(w http.ResponseWriter, r *http.Request
 }
 
 func (s *Server) ytdlpCookiesConfigured() bool {
-	if s.ytdlp == nil || s.ytdlp.CookiesPath == "" {
+	if s.cookiesPath == "" {
 		return false
 	}
-	info, err := os.Stat(s.ytdlp.CookiesPath)
+	info, err := os.Stat(s.cookiesPath)
 	if err != nil || info.IsDir() {
 		return false
 	}
@@ -135,7 +239,7 @@ func normalizeNetscapeCookies(contents string) string {
 // Feed endpoints
 
 func (s *Server) handleAPIGetFeeds(w http.ResponseWriter, r *http.Request) {
-	feeds, err := s.db.GetFeeds()
+	feeds, err := s.db.GetFeeds(s.currentUserID(r))
 	if err != nil {
 		jsonError(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -143,6 +247,89 @@ func (s *Server) handleAPIGetFeeds(w http.ResponseWriter, r *http.Request) {
 	jsonResponse(w, feeds)
 }
 
+// handleAPIExportAll dumps every one of the caller's feeds in one file, in
+// the same formats handleExportFeed offers per-feed (?format=feeds|newpipe
+// |opml, default feeds), so a whole subscription list can migrate to or
+// from another client in one request instead of one export per feed.
+func (s *Server) handleAPIExportAll(w http.ResponseWriter, r *http.Request) {
+	userID := s.currentUserID(r)
+
+	format := r.URL.Query().Get("format")
+
+	if format == "opml" {
+		w.Header().Set("Content-Type", "text/x-opml")
+		w.Header().Set("Content-Disposition", "attachment; filename=feeds.opml")
+		if err := s.db.ExportOPML(userID, w); err != nil {
+			jsonError(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	feeds, err := s.db.GetFeeds(userID)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if format == "newpipe" {
+		export := models.NewPipeExport{AppVersion: newPipeExportAppVersion}
+		for _, feed := range feeds {
+			channels, err := s.db.GetChannelsByFeed(feed.ID)
+			if err != nil {
+				jsonError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			for _, ch := range channels {
+				export.Subscriptions = append(export.Subscriptions, models.NewPipeSubscription{
+					ServiceID: 0,
+					URL:       ch.URL,
+					Name:      ch.Name,
+				})
+			}
+		}
+		w.Header().Set("Content-Disposition", "attachment; filename=subscriptions.json")
+		json.NewEncoder(w).Encode(export)
+		return
+	}
+
+	// Default: Feeds format, one FeedExport per feed.
+	exports := make([]models.FeedExport, 0, len(feeds))
+	for _, feed := range feeds {
+		channels, err := s.db.GetChannelsByFeed(feed.ID)
+		if err != nil {
+			jsonError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		var tags []string
+		if feed.Tags != "" {
+			tags = strings.Split(feed.Tags, ",")
+			for i := range tags {
+				tags[i] = strings.TrimSpace(tags[i])
+			}
+		}
+
+		export := models.FeedExport{
+			Version:     1,
+			Name:        feed.Name,
+			Description: feed.Description,
+			Author:      feed.Author,
+			Tags:        tags,
+			Updated:     feed.UpdatedAt,
+			Channels:    make([]models.ExportChannel, 0, len(channels)),
+		}
+		for _, ch := range channels {
+			export.Channels = append(export.Channels, models.ExportChannel{URL: ch.URL, Name: ch.Name})
+		}
+		exports = append(exports, export)
+	}
+
+	w.Header().Set("Content-Disposition", "attachment; filename=feeds.json")
+	json.NewEncoder(w).Encode(exports)
+}
+
 func (s *Server) handleAPICreateFeed(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		Name        string `json:"name"`
@@ -158,7 +345,7 @@ func (s *Server) handleAPICreateFeed(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	feed, err := s.db.CreateFeed(req.Name)
+	feed, err := s.db.CreateFeed(s.currentUserID(r), req.Name)
 	if err != nil {
 		jsonError(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -175,7 +362,9 @@ func (s *Server) handleAPIGetFeed(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	feed, err := s.db.GetFeed(id)
+	userID := s.currentUserID(r)
+
+	feed, err := s.db.GetFeed(userID, id)
 	if err != nil {
 		jsonError(w, "Feed not found", http.StatusNotFound)
 		return
@@ -201,7 +390,7 @@ func (s *Server) handleAPIGetFeed(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	videos, total, err := s.db.GetVideosByFeed(id, limit, offset)
+	videos, total, err := s.db.GetVideosByFeed(userID, id, limit, offset)
 	if err != nil {
 		jsonError(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -229,10 +418,10 @@ func (s *Server) handleAPIGetFeed(w http.ResponseWriter, r *http.Request) {
 	for i, v := range videos {
 		videoIDs[i] = v.ID
 	}
-	progressMap, _ := s.db.GetWatchProgressMap(videoIDs)
+	progressMap, _ := s.db.GetWatchProgressMap(userID, videoIDs)
 
 	// Get all feeds for move dialog
-	allFeeds, _ := s.db.GetFeeds()
+	allFeeds, _ := s.db.GetFeeds(userID)
 
 	jsonResponse(w, map[string]any{
 		"feed":        feed,
@@ -253,7 +442,7 @@ func (s *Server) handleAPIDeleteFeed(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.db.DeleteFeed(id); err != nil {
+	if err := s.db.DeleteFeed(s.currentUserID(r), id); err != nil {
 		if errors.Is(err, db.ErrSystemFeed) {
 			jsonError(w, "Cannot delete system feed", http.StatusForbidden)
 			return
@@ -267,7 +456,8 @@ func (s *Server) handleAPIDeleteFeed(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleAPIReorderFeeds(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		FeedIDs []int64 `json:"feed_ids"`
+		ParentID int64   `json:"parent_id"`
+		FeedIDs  []int64 `json:"feed_ids"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		jsonError(w, "Invalid request body", http.StatusBadRequest)
@@ -279,13 +469,13 @@ func (s *Server) handleAPIReorderFeeds(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.db.ReorderFeeds(req.FeedIDs); err != nil {
+	if err := s.db.ReorderFeeds(req.ParentID, req.FeedIDs); err != nil {
 		jsonError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	// Return updated feeds list
-	feeds, err := s.db.GetFeeds()
+	feeds, err := s.db.GetFeeds(s.currentUserID(r))
 	if err != nil {
 		jsonError(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -294,27 +484,220 @@ func (s *Server) handleAPIReorderFeeds(w http.ResponseWriter, r *http.Request) {
 	jsonResponse(w, feeds)
 }
 
-func (s *Server) handleAPIRefreshFeed(w http.ResponseWriter, r *http.Request) {
+// handleAPISetFeedSchedule configures how often the scheduler refreshes a
+// feed, or pauses it entirely. IntervalSeconds <= 0 is rejected rather than
+// defaulted, since silently substituting a value would surprise a caller
+// trying to tighten or loosen an existing schedule.
+func (s *Server) handleAPISetFeedSchedule(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
 		jsonError(w, "Invalid feed ID", http.StatusBadRequest)
 		return
 	}
 
-	channels, err := s.db.GetChannelsByFeed(id)
+	var req struct {
+		IntervalSeconds int  `json:"refresh_interval_seconds"`
+		Paused          bool `json:"paused"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.IntervalSeconds <= 0 {
+		jsonError(w, "refresh_interval_seconds must be positive", http.StatusBadRequest)
+		return
+	}
+
+	userID := s.currentUserID(r)
+
+	if _, err := s.db.GetFeed(userID, id); err != nil {
+		jsonError(w, "Feed not found", http.StatusNotFound)
+		return
+	}
+
+	if err := s.db.UpdateFeedSchedule(id, req.IntervalSeconds, req.Paused); err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	feed, err := s.db.GetFeed(userID, id)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	jsonResponse(w, feed)
+}
+
+// handleAPISetFeedShortsFilter toggles whether a feed's refresh excludes
+// YouTube Shorts. See youtube.FetchLatestVideos's includeShorts parameter
+// for how this speeds up refreshes for channels it can fully apply to.
+func (s *Server) handleAPISetFeedShortsFilter(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		jsonError(w, "Invalid feed ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		HideShorts bool `json:"hide_shorts"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := s.currentUserID(r)
+
+	if _, err := s.db.GetFeed(userID, id); err != nil {
+		jsonError(w, "Feed not found", http.StatusNotFound)
+		return
+	}
+
+	if err := s.db.UpdateFeedHideShorts(id, req.HideShorts); err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	feed, err := s.db.GetFeed(userID, id)
 	if err != nil {
 		jsonError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	jsonResponse(w, feed)
+}
+
+// handleAPISetFeedAutoDownload toggles whether this feed's newly-discovered
+// videos are automatically queued into internal/downloader's download queue
+// on refresh - see (*Server).refreshFeedCore. An empty Quality turns
+// auto-download off.
+func (s *Server) handleAPISetFeedAutoDownload(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		jsonError(w, "Invalid feed ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Quality string `json:"quality"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := s.currentUserID(r)
+
+	if _, err := s.db.GetFeed(userID, id); err != nil {
+		jsonError(w, "Feed not found", http.StatusNotFound)
+		return
+	}
+
+	if err := s.db.UpdateFeedAutoDownload(id, req.Quality); err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	feed, err := s.db.GetFeed(userID, id)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	jsonResponse(w, feed)
+}
+
+// handleAPISetFeedPodcast configures this feed's podcast export - Format is
+// "audio", "video", "custom", or "" to disable export and episode
+// downloading; Quality controls the transcode bitrate PodcastEpisodeWorker
+// uses; RetentionDays is how long a downloaded episode's file is kept
+// before the worker's cleanup sweep deletes it (0 = forever).
+func (s *Server) handleAPISetFeedPodcast(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		jsonError(w, "Invalid feed ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Format        string `json:"format"`
+		Quality       string `json:"quality"`
+		RetentionDays int    `json:"retention_days"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := s.currentUserID(r)
+
+	if _, err := s.db.GetFeed(userID, id); err != nil {
+		jsonError(w, "Feed not found", http.StatusNotFound)
+		return
+	}
+
+	if err := s.db.UpdateFeedPodcastSettings(id, req.Format, req.Quality, req.RetentionDays); err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	feed, err := s.db.GetFeed(userID, id)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	jsonResponse(w, feed)
+}
+
+// handleAPIRefreshFeed used to fetch every channel in a feed inline and block
+// until it was done. It's now a thin wrapper: the scheduler owns the worker
+// pool and rate limiter, so this just enqueues the feed for an immediate run
+// and returns right away, the same way a scheduled refresh would be picked up.
+func (s *Server) handleAPIRefreshFeed(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		jsonError(w, "Invalid feed ID", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := s.db.GetFeed(s.currentUserID(r), id); err != nil {
+		jsonError(w, "Feed not found", http.StatusNotFound)
+		return
+	}
+
+	s.scheduler.EnqueueNow(id)
+
+	w.WriteHeader(http.StatusAccepted)
+	jsonResponse(w, map[string]any{"queued": true, "feedId": id})
+}
+
+// refreshFeedCore fetches latest videos for every channel in a feed and
+// saves the new ones. It's shared by the scheduler (which calls it on a
+// per-feed interval) and the manual refresh endpoint (via EnqueueNow), so
+// both go through the same worker pool and per-source rate limiter.
+func (s *Server) refreshFeedCore(ctx context.Context, id int64) (videosFound, newVideos, channelCount int, errs []string, err error) {
+	timer := prometheus.NewTimer(metrics.RefreshDuration.WithLabelValues(strconv.FormatInt(id, 10), ""))
+	defer timer.ObserveDuration()
+
+	channels, err := s.db.GetChannelsByFeed(id)
+	if err != nil {
+		return 0, 0, 0, nil, err
+	}
+
+	includeShorts := true
+	autoDownloadQuality := ""
+	if feed, err := s.db.GetFeedByID(id); err == nil && feed != nil {
+		includeShorts = !feed.HideShorts
+		autoDownloadQuality = feed.AutoDownloadQuality
+	}
 
 	// Use worker pool for parallel fetching with rate limiting
 	const maxWorkers = 5
 
 	type result struct {
-		videos []models.Video
-		err    error
-		chName string
-		chID   int64
+		videos              []models.Video
+		err                 error
+		chName              string
+		chID                int64
+		viaLongFormPlaylist bool
 	}
 
 	jobs := make(chan *models.Channel, len(channels))
@@ -327,8 +710,23 @@ func (s *Server) handleAPIRefreshFeed(w http.ResponseWriter, r *http.Request) {
 		go func() {
 			defer wg.Done()
 			for ch := range jobs {
-				videos, err := yt.FetchLatestVideos(ch.URL, 5)
-				results <- result{videos: videos, err: err, chName: ch.Name, chID: ch.ID}
+				if waitErr := s.scheduler.Limiter().Wait(ctx, ch.Source); waitErr != nil {
+					results <- result{err: waitErr, chName: ch.Name, chID: ch.ID}
+					continue
+				}
+				videos, err := yt.FetchLatestVideos(ch.URL, 5, includeShorts)
+				if err != nil {
+					metrics.SourceErrors.WithLabelValues("youtube").Inc()
+				} else {
+					metrics.VideosFetched.WithLabelValues(strconv.FormatInt(ch.ID, 10), "youtube").Add(float64(len(videos)))
+				}
+				results <- result{
+					videos:              videos,
+					err:                 err,
+					chName:              ch.Name,
+					chID:                ch.ID,
+					viaLongFormPlaylist: !includeShorts && strings.HasPrefix(yt.ExtractChannelID(ch.URL), "UC"),
+				}
 			}
 		}()
 	}
@@ -346,29 +744,33 @@ func (s *Server) handleAPIRefreshFeed(w http.ResponseWriter, r *http.Request) {
 	}()
 
 	// Collect results
-	var totalVideos int
-	var errors []string
 	var allVideos []models.Video
 
+	notShort := false
 	for res := range results {
 		if res.err != nil {
-			errors = append(errors, res.chName+": "+res.err.Error())
+			errs = append(errs, res.chName+": "+res.err.Error())
 			continue
 		}
 
 		for _, v := range res.videos {
 			v.ChannelID = res.chID
+			if res.viaLongFormPlaylist {
+				// Already filtered server-side by the UULF playlist - no
+				// need to classify this one below.
+				v.IsShort = &notShort
+			}
 			allVideos = append(allVideos, v)
 		}
 	}
 
-	var newVideos int
-
 	// Check shorts status only for videos that don't already have it
 	if len(allVideos) > 0 {
-		videoIDs := make([]string, len(allVideos))
-		for i, v := range allVideos {
-			videoIDs[i] = v.ID
+		var videoIDs []string
+		for _, v := range allVideos {
+			if v.IsShort == nil {
+				videoIDs = append(videoIDs, v.ID)
+			}
 		}
 
 		// Get existing shorts status from DB
@@ -390,7 +792,9 @@ func (s *Server) handleAPIRefreshFeed(w http.ResponseWriter, r *http.Request) {
 		var newShortsStatus map[string]bool
 		if len(needsCheck) > 0 {
 			log.Printf("Checking shorts status for %d new videos (skipping %d with existing status)", len(needsCheck), len(existingStatus))
+			shortsTimer := prometheus.NewTimer(metrics.ShortsCheckDuration.WithLabelValues("youtube"))
 			newShortsStatus = yt.CheckShortsStatus(needsCheck)
+			shortsTimer.ObserveDuration()
 		} else {
 			log.Printf("All %d videos already have shorts status, skipping check", len(videoIDs))
 			newShortsStatus = map[string]bool{}
@@ -409,9 +813,16 @@ func (s *Server) handleAPIRefreshFeed(w http.ResponseWriter, r *http.Request) {
 				log.Printf("Failed to save video %s: %v", allVideos[i].ID, err)
 				continue
 			}
-			totalVideos++
+			videosFound++
 			if isNew {
 				newVideos++
+				if autoDownloadQuality != "" && s.downloadQueue != nil {
+					videoID := allVideos[i].ID
+					outputPath := s.videoCache.CachePath(CacheKey(videoID, autoDownloadQuality))
+					if _, err := s.downloadQueue.Enqueue(videoID, autoDownloadQuality, outputPath); err != nil {
+						log.Printf("Failed to auto-queue download for video %s: %v", videoID, err)
+					}
+				}
 			}
 		}
 
@@ -421,12 +832,22 @@ func (s *Server) handleAPIRefreshFeed(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	jsonResponse(w, map[string]any{
-		"videosFound": totalVideos,
-		"newVideos":   newVideos,
-		"channels":    len(channels),
-		"errors":      errors,
-	})
+	return videosFound, newVideos, len(channels), errs, nil
+}
+
+// runScheduledFeedRefresh adapts refreshFeedCore to scheduler.RefreshFunc.
+// Any per-channel fetch failure fails the whole run so the scheduler backs
+// off and retries, surfacing the channel errors via the feed's
+// last_refresh_error.
+func (s *Server) runScheduledFeedRefresh(ctx context.Context, feedID int64) error {
+	_, _, _, errs, err := s.refreshFeedCore(ctx, feedID)
+	if err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%d channel(s) failed: %s", len(errs), strings.Join(errs, "; "))
+	}
+	return nil
 }
 
 // Channel endpoints
@@ -478,13 +899,13 @@ func (s *Server) handleAPIGetChannel(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get all feeds for "add to feed" dropdown
-	allFeeds, _ := s.db.GetFeeds()
+	allFeeds, _ := s.db.GetFeeds(s.currentUserID(r))
 
 	videoIDs := make([]string, len(videos))
 	for i, v := range videos {
 		videoIDs[i] = v.ID
 	}
-	progressMap, _ := s.db.GetWatchProgressMap(videoIDs)
+	progressMap, _ := s.db.GetWatchProgressMap(s.currentUserID(r), videoIDs)
 
 	jsonResponse(w, map[string]any{
 		"channel":     channel,
@@ -503,6 +924,14 @@ func (s *Server) handleAPIAddChannel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if exists, err := s.db.FeedExists(s.currentUserID(r), feedID); err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	} else if !exists {
+		jsonError(w, "Feed not found", http.StatusNotFound)
+		return
+	}
+
 	var req struct {
 		URL string `json:"url"`
 	}
@@ -516,14 +945,20 @@ func (s *Server) handleAPIAddChannel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Resolve channel info
-	channelInfo, err := yt.ResolveChannelURL(req.URL)
+	// Sniff the URL to find the right backend (YouTube, PeerTube, or a
+	// generic Atom/RSS feed) and resolve channel info through it.
+	src, ok := sources.Resolve(req.URL)
+	if !ok {
+		jsonError(w, "Unrecognized channel URL", http.StatusBadRequest)
+		return
+	}
+	channelInfo, err := src.ResolveChannelURL(req.URL)
 	if err != nil {
-		jsonError(w, "Invalid YouTube channel URL: "+err.Error(), http.StatusBadRequest)
+		jsonError(w, "Invalid channel URL: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	channel, isNew, err := s.db.AddChannelToFeed(feedID, channelInfo.URL, channelInfo.Name)
+	channel, isNew, err := s.db.AddChannelToFeedWithSourceType(feedID, channelInfo.URL, channelInfo.Name, src.Name(), channelInfo.SourceType)
 	if err != nil {
 		jsonError(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -531,13 +966,16 @@ func (s *Server) handleAPIAddChannel(w http.ResponseWriter, r *http.Request) {
 
 	// Fetch initial videos only if channel is new
 	if isNew {
-		videos, err := yt.FetchLatestVideos(channelInfo.URL, 5)
+		videos, err := src.FetchLatestVideos(channelInfo.URL, 5)
 		if err == nil && len(videos) > 0 {
-			videoIDs := make([]string, len(videos))
-			for i, v := range videos {
-				videoIDs[i] = v.ID
+			var shortsStatus map[string]bool
+			if classifier, ok := src.(sources.ShortsClassifier); ok {
+				videoIDs := make([]string, len(videos))
+				for i, v := range videos {
+					videoIDs[i] = v.ID
+				}
+				shortsStatus = classifier.CheckShortsStatus(videoIDs)
 			}
-			shortsStatus := yt.CheckShortsStatus(videoIDs)
 
 			for i := range videos {
 				videos[i].ChannelID = channel.ID
@@ -547,12 +985,133 @@ func (s *Server) handleAPIAddChannel(w http.ResponseWriter, r *http.Request) {
 				_, _ = s.db.UpsertVideo(&videos[i])
 			}
 		}
+
+		// The initial fetch above only grabs the 5 latest videos, so kick
+		// off a background full-history backfill (via the Data API when a
+		// key is configured, via Piped otherwise) so the channel doesn't
+		// stay stuck at 5.
+		if src.Name() == "youtube" && s.channelBackfillManager.Enabled() {
+			s.channelBackfillManager.Start(channel)
+		}
 	}
 
 	w.WriteHeader(http.StatusCreated)
 	jsonResponse(w, channel)
 }
 
+// handleAPIGetChannelBackfillProgress reports progress for the full-history
+// backfill (Data API or Piped) started for a channel when it was added. It
+// is distinct from /api/backfill/jobs, which tracks the older yt-dlp-based
+// BackfillManager jobs.
+func (s *Server) handleAPIGetChannelBackfillProgress(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		jsonError(w, "Invalid channel ID", http.StatusBadRequest)
+		return
+	}
+
+	progress := s.channelBackfillManager.Progress(id)
+	if progress == nil {
+		jsonResponse(w, ChannelBackfillProgress{})
+		return
+	}
+	jsonResponse(w, progress)
+}
+
+// handleChannelBackfillStream is an SSE alternative to
+// handleAPIGetChannelBackfillProgress for callers that want to watch a
+// channel's full-history backfill run to completion instead of polling,
+// the same way handleRefreshFeedStream does for feed refreshes. Each
+// progress event also reports the channel's persisted Data-API page token
+// (see db.GetChannelBackfillCursor), so a client can see the resume cursor
+// advance. Progress itself is held in memory only, and a backfill is not
+// restarted automatically after a process restart.
+func (s *Server) handleChannelBackfillStream(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		jsonError(w, "Invalid channel ID", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		jsonError(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	sendEvent := func(eventType string, data any) {
+		jsonData, _ := json.Marshal(data)
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", eventType, jsonData)
+		flusher.Flush()
+	}
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			progress := s.channelBackfillManager.Progress(id)
+			if progress == nil {
+				progress = &ChannelBackfillProgress{}
+			}
+			pageToken, _, _ := s.db.GetChannelBackfillCursor(id)
+
+			event := map[string]any{
+				"current":   progress.Fetched,
+				"total":     progress.Total,
+				"pageToken": pageToken,
+				"newVideos": progress.Fetched,
+			}
+
+			if progress.Running {
+				sendEvent("progress", event)
+				continue
+			}
+
+			if progress.Error != "" {
+				event["error"] = progress.Error
+			}
+			sendEvent("complete", event)
+			return
+		}
+	}
+}
+
+// handleAPISetChannelLanguage pins a channel's videos to a language,
+// overriding the automatic detection in ytdlp.VideoInfo.ToModel for
+// channels it misclassifies. Passing an empty language clears the override
+// back to automatic detection.
+func (s *Server) handleAPISetChannelLanguage(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		jsonError(w, "Invalid channel ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Language string `json:"language"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.db.SetChannelLanguageOverride(id, strings.TrimSpace(req.Language)); err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (s *Server) handleAPIDeleteChannel(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
@@ -560,11 +1119,25 @@ func (s *Server) handleAPIDeleteChannel(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Fetch the URL before deleting so it can be recorded in the
+	// recommendation ignore-list - a full channel deletion is a clear
+	// "unsubscribe everywhere" signal, unlike handleAPIRemoveChannelFromFeed
+	// which only drops the channel from one Feed.
+	channel, err := s.db.GetChannel(id)
+	if err != nil {
+		jsonError(w, "Channel not found", http.StatusNotFound)
+		return
+	}
+
 	if err := s.db.DeleteChannel(id); err != nil {
 		jsonError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	if err := s.db.IgnoreChannelForRecommendations(s.currentUserID(r), channel.URL); err != nil {
+		log.Printf("Failed to record ignored recommendation channel: %v", err)
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -581,6 +1154,14 @@ func (s *Server) handleAPIRemoveChannelFromFeed(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	if exists, err := s.db.FeedExists(s.currentUserID(r), feedID); err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	} else if !exists {
+		jsonError(w, "Feed not found", http.StatusNotFound)
+		return
+	}
+
 	deleted, err := s.db.RemoveChannelFromFeed(feedID, channelID)
 	if err != nil {
 		jsonError(w, err.Error(), http.StatusInternalServerError)
@@ -607,6 +1188,14 @@ func (s *Server) handleAPIAddChannelToFeed(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	if exists, err := s.db.FeedExists(s.currentUserID(r), req.FeedID); err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	} else if !exists {
+		jsonError(w, "Feed not found", http.StatusNotFound)
+		return
+	}
+
 	// Get channel to get its URL
 	channel, err := s.db.GetChannel(channelID)
 	if err != nil {
@@ -635,6 +1224,10 @@ func (s *Server) handleAPIRefreshChannel(w http.ResponseWriter, r *http.Request)
 		jsonError(w, "Invalid channel ID", http.StatusBadRequest)
 		return
 	}
+	channelIDLabel := strconv.FormatInt(id, 10)
+
+	timer := prometheus.NewTimer(metrics.RefreshDuration.WithLabelValues("", channelIDLabel))
+	defer timer.ObserveDuration()
 
 	channel, err := s.db.GetChannel(id)
 	if err != nil {
@@ -642,11 +1235,20 @@ func (s *Server) handleAPIRefreshChannel(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	videos, err := yt.FetchLatestVideos(channel.URL, 20)
+	// Draw from the same per-source-backend budget as scheduled feed
+	// refreshes, so a manual click can't starve the scheduler's rate limit.
+	if err := s.scheduler.Limiter().Wait(r.Context(), channel.Source); err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	videos, err := yt.FetchLatestVideos(channel.URL, 20, true)
 	if err != nil {
+		metrics.SourceErrors.WithLabelValues("youtube").Inc()
 		jsonError(w, "Failed to fetch videos: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	metrics.VideosFetched.WithLabelValues(channelIDLabel, "youtube").Add(float64(len(videos)))
 
 	var savedCount int
 	if len(videos) > 0 {
@@ -655,7 +1257,9 @@ func (s *Server) handleAPIRefreshChannel(w http.ResponseWriter, r *http.Request)
 		for i, v := range videos {
 			videoIDs[i] = v.ID
 		}
+		shortsTimer := prometheus.NewTimer(metrics.ShortsCheckDuration.WithLabelValues("youtube"))
 		shortsStatus := yt.CheckShortsStatus(videoIDs)
+		shortsTimer.ObserveDuration()
 
 		for i := range videos {
 			videos[i].ChannelID = channel.ID
@@ -678,6 +1282,11 @@ func (s *Server) handleAPIRefreshChannel(w http.ResponseWriter, r *http.Request)
 
 // handleAPIFetchMoreVideos uses yt-dlp to fetch older videos from a channel's history
 // It streams progress updates via Server-Sent Events (SSE)
+// handleAPIFetchMoreVideos is a thin wrapper kept for backwards compatibility
+// with the old bounded (5x10) fetch-more button: it now creates a real
+// backfill job, which starts from the channel's newest upload rather than
+// from a prior job's position, and streams it the same way
+// handleBackfillJobStream does.
 func (s *Server) handleAPIFetchMoreVideos(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
@@ -685,116 +1294,21 @@ func (s *Server) handleAPIFetchMoreVideos(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	channel, err := s.db.GetChannel(id)
-	if err != nil {
+	timer := prometheus.NewTimer(metrics.RefreshDuration.WithLabelValues("", strconv.FormatInt(id, 10)))
+	defer timer.ObserveDuration()
+
+	if _, err := s.db.GetChannel(id); err != nil {
 		jsonError(w, "Channel not found", http.StatusNotFound)
 		return
 	}
 
-	// Get the current video count to determine offset
-	currentCount, err := s.db.GetVideoCountByChannel(id)
+	job, err := s.db.CreateBackfillJob(id)
 	if err != nil {
-		jsonError(w, "Failed to get video count", http.StatusInternalServerError)
-		return
-	}
-
-	// Set up SSE headers
-	w.Header().Set("Content-Type", "text/event-stream")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("X-Accel-Buffering", "no") // Disable nginx buffering
-
-	flusher, ok := w.(http.Flusher)
-	if !ok {
-		jsonError(w, "Streaming not supported", http.StatusInternalServerError)
+		jsonError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Helper to send SSE events
-	sendEvent := func(eventType string, data any) {
-		jsonData, _ := json.Marshal(data)
-		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", eventType, jsonData)
-		flusher.Flush()
-	}
-
-	// Fetch 10 videos at a time, up to 50 total
-	const batchSize = 10
-	const maxBatches = 5
-	totalSaved := 0
-	hasMore := true
-
-	for batch := 0; batch < maxBatches && hasMore; batch++ {
-		start := currentCount + (batch * batchSize) + 1
-		end := start + batchSize - 1
-
-		// Send progress update
-		sendEvent("progress", map[string]any{
-			"batch":      batch + 1,
-			"maxBatches": maxBatches,
-			"fetching":   batchSize,
-			"totalSaved": totalSaved,
-			"status":     "fetching",
-		})
-
-		log.Printf("Fetching videos for channel %s (ID: %d), positions %d-%d", channel.URL, id, start, end)
-
-		videos, err := s.ytdlp.GetChannelVideos(channel.URL, start, end)
-		if err != nil {
-			log.Printf("Failed to fetch videos: %v", err)
-			sendEvent("error", map[string]any{
-				"message": "Failed to fetch videos: " + err.Error(),
-			})
-			return
-		}
-
-		log.Printf("yt-dlp returned %d videos in batch %d", len(videos), batch+1)
-
-		// If we got fewer videos than requested, there are no more
-		if len(videos) < batchSize {
-			hasMore = false
-		}
-
-		if len(videos) == 0 {
-			break
-		}
-
-		// Check shorts status before saving
-		videoIDs := make([]string, len(videos))
-		for i, v := range videos {
-			videoIDs[i] = v.ID
-		}
-		shortsStatus := yt.CheckShortsStatus(videoIDs)
-
-		batchSaved := 0
-		for _, v := range videos {
-			video := v.ToModel(channel.ID, channel.Name)
-			if isShort, ok := shortsStatus[video.ID]; ok {
-				video.IsShort = &isShort
-			}
-			if _, err := s.db.UpsertVideo(video); err != nil {
-				log.Printf("Failed to save video %s: %v", video.ID, err)
-				continue
-			}
-			batchSaved++
-		}
-		totalSaved += batchSaved
-
-		// Send batch complete update
-		sendEvent("progress", map[string]any{
-			"batch":      batch + 1,
-			"maxBatches": maxBatches,
-			"saved":      batchSaved,
-			"totalSaved": totalSaved,
-			"status":     "saved",
-		})
-	}
-
-	// Send final complete event
-	sendEvent("complete", map[string]any{
-		"videosFound": totalSaved,
-		"channel":     channel.Name,
-		"hasMore":     hasMore,
-	})
+	s.streamBackfillJob(w, r, job.ID)
 }
 
 // handleAPIGetChannelFeeds returns all feeds that contain a channel
@@ -856,7 +1370,7 @@ func (s *Server) handleAPIGetRecentVideos(w http.ResponseWriter, r *http.Request
 	for i, v := range videos {
 		videoIDs[i] = v.ID
 	}
-	progressMap, _ := s.db.GetWatchProgressMap(videoIDs)
+	progressMap, _ := s.db.GetWatchProgressMap(s.currentUserID(r), videoIDs)
 
 	jsonResponse(w, map[string]any{
 		"videos":      videos,
@@ -888,7 +1402,7 @@ func (s *Server) handleAPIGetShuffledVideos(w http.ResponseWriter, r *http.Reque
 		}
 	}
 
-	videos, total, err := s.db.GetShuffledVideosByFeed(id, limit, offset)
+	videos, total, err := s.db.GetShuffledVideosByFeed(s.currentUserID(r), id, limit, offset)
 	if err != nil {
 		jsonError(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -902,6 +1416,95 @@ func (s *Server) handleAPIGetShuffledVideos(w http.ResponseWriter, r *http.Reque
 	})
 }
 
+// handleAPIVideosWithChapter returns a feed's videos that have a cached
+// chapter matching the ?name= query param, for jumping straight to every
+// video containing, say, a "Q&A" or "Outro" chapter.
+func (s *Server) handleAPIVideosWithChapter(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		jsonError(w, "Invalid feed ID", http.StatusBadRequest)
+		return
+	}
+
+	name := strings.TrimSpace(r.URL.Query().Get("name"))
+	if name == "" {
+		jsonError(w, "name query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	videos, err := s.db.GetVideosWithChapter(id, name)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, map[string]any{"videos": videos})
+}
+
+// handleAPIFilterVideos composes a db.VideoQueryBuilder from query
+// parameters, letting callers combine filters (unwatched, shorts, search,
+// language, date range) without a dedicated DB method for every
+// combination.
+func (s *Server) handleAPIFilterVideos(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		jsonError(w, "Invalid feed ID", http.StatusBadRequest)
+		return
+	}
+
+	limit := 100
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+	offset := 0
+	if o := r.URL.Query().Get("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
+			offset = parsed
+		}
+	}
+
+	q := s.db.NewVideoQueryBuilder(s.currentUserID(r)).WithFeedID(id)
+
+	if r.URL.Query().Get("unwatched") == "true" {
+		q = q.WithUnwatched()
+	}
+	if r.URL.Query().Get("exclude_shorts") == "true" {
+		q = q.WithoutShorts()
+	}
+	if search := strings.TrimSpace(r.URL.Query().Get("q")); search != "" {
+		q = q.WithSearch(search)
+	}
+	if lang := r.URL.Query().Get("lang"); lang != "" {
+		q = q.WithLanguages(strings.Split(lang, ","))
+	}
+	if since := r.URL.Query().Get("since"); since != "" {
+		if t, err := time.Parse(time.RFC3339, since); err == nil {
+			q = q.WithPublishedAfter(t)
+		}
+	}
+	if until := r.URL.Query().Get("until"); until != "" {
+		if t, err := time.Parse(time.RFC3339, until); err == nil {
+			q = q.WithPublishedBefore(t)
+		}
+	}
+
+	total, err := q.Count()
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	videos, err := q.WithLimit(limit).WithOffset(offset).Fetch()
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, map[string]any{"videos": videos, "total": total})
+}
+
 func (s *Server) handleAPIGetHistory(w http.ResponseWriter, r *http.Request) {
 	limit := 100
 	if l := r.URL.Query().Get("limit"); l != "" {
@@ -910,7 +1513,9 @@ func (s *Server) handleAPIGetHistory(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	videos, err := s.db.GetWatchHistory(limit)
+	userID := s.currentUserID(r)
+
+	videos, err := s.db.GetWatchHistory(userID, limit)
 	if err != nil {
 		jsonError(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -921,7 +1526,7 @@ func (s *Server) handleAPIGetHistory(w http.ResponseWriter, r *http.Request) {
 	for i, v := range videos {
 		videoIDs[i] = v.ID
 	}
-	progressMap, _ := s.db.GetWatchProgressMap(videoIDs)
+	progressMap, _ := s.db.GetWatchProgressMap(userID, videoIDs)
 
 	jsonResponse(w, map[string]any{
 		"videos":      videos,
@@ -931,7 +1536,7 @@ func (s *Server) handleAPIGetHistory(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleAPIMarkWatched(w http.ResponseWriter, r *http.Request) {
 	videoID := r.PathValue("id")
-	if err := s.db.MarkAsWatched(videoID); err != nil {
+	if err := s.db.MarkAsWatched(s.currentUserID(r), videoID); err != nil {
 		jsonError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -940,7 +1545,7 @@ func (s *Server) handleAPIMarkWatched(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleAPIMarkUnwatched(w http.ResponseWriter, r *http.Request) {
 	videoID := r.PathValue("id")
-	if err := s.db.DeleteWatchProgress(videoID); err != nil {
+	if err := s.db.DeleteWatchProgress(s.currentUserID(r), videoID); err != nil {
 		jsonError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -977,22 +1582,23 @@ func (s *Server) handleAPIImportYouTube(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Verify feed exists
-	feed, err := s.db.GetFeed(req.FeedID)
+	feed, err := s.db.GetFeed(s.currentUserID(r), req.FeedID)
 	if err != nil {
 		jsonError(w, "Feed not found", http.StatusBadRequest)
 		return
 	}
 
-	// Resolve to channel (detect video vs channel URL)
+	// Resolve to channel (detect video vs channel URL), trying each
+	// configured source (Piped, Invidious, official) in order.
 	var channelInfo *yt.ChannelInfo
 	if isVideoURL(req.URL) {
-		channelInfo, err = yt.ResolveVideoToChannel(req.URL)
+		channelInfo, err = yt.DefaultChain.ResolveVideoToChannel(req.URL)
 		if err != nil {
 			jsonError(w, "Could not resolve channel from video URL: "+err.Error(), http.StatusBadRequest)
 			return
 		}
 	} else {
-		channelInfo, err = yt.ResolveChannelURL(req.URL)
+		channelInfo, err = yt.DefaultChain.ResolveChannelURL(req.URL)
 		if err != nil {
 			jsonError(w, "Could not resolve channel from URL: "+err.Error(), http.StatusBadRequest)
 			return
@@ -1000,7 +1606,7 @@ func (s *Server) handleAPIImportYouTube(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Add channel to feed
-	channel, isNew, err := s.db.AddChannelToFeed(req.FeedID, channelInfo.URL, channelInfo.Name)
+	channel, isNew, err := s.db.AddChannelToFeedWithSourceType(req.FeedID, channelInfo.URL, channelInfo.Name, "youtube", channelInfo.SourceType)
 	if err != nil {
 		jsonError(w, "Failed to add channel: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -1008,7 +1614,7 @@ func (s *Server) handleAPIImportYouTube(w http.ResponseWriter, r *http.Request)
 
 	// If new channel, fetch initial videos
 	if isNew {
-		videos, err := yt.FetchLatestVideos(channel.URL, 5)
+		videos, err := yt.DefaultChain.FetchLatestVideos(channel.URL, 5, true)
 		if err != nil {
 			log.Printf("Failed to fetch initial videos for channel %s: %v", channel.URL, err)
 		} else {
@@ -1017,7 +1623,7 @@ func (s *Server) handleAPIImportYouTube(w http.ResponseWriter, r *http.Request)
 			for _, v := range videos {
 				videoIDs = append(videoIDs, v.ID)
 			}
-			shortsMap := yt.CheckShortsStatus(videoIDs)
+			shortsMap := yt.DefaultChain.CheckShortsStatus(videoIDs)
 
 			// Upsert videos
 			for _, video := range videos {
@@ -1072,7 +1678,7 @@ func (s *Server) handleAPIImportURL(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	feed, err := s.importFeedFromJSON(body, feedURL)
+	feed, err := s.importFeedFromJSON(s.currentUserID(r), body, feedURL)
 	if err != nil {
 		jsonError(w, err.Error(), http.StatusBadRequest)
 		return
@@ -1101,7 +1707,7 @@ func (s *Server) handleAPIImportFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	feed, err := s.importFeedFromJSON(body, header.Filename)
+	feed, err := s.importFeedFromJSON(s.currentUserID(r), body, header.Filename)
 	if err != nil {
 		jsonError(w, err.Error(), http.StatusBadRequest)
 		return
@@ -1111,7 +1717,7 @@ func (s *Server) handleAPIImportFile(w http.ResponseWriter, r *http.Request) {
 	jsonResponse(w, feed)
 }
 
-func (s *Server) importFeedFromJSON(body []byte, source string) (*models.Feed, error) {
+func (s *Server) importFeedFromJSON(userID int64, body []byte, source string) (*models.Feed, error) {
 	// Try Feeds format first
 	var feedExport models.FeedExport
 	if err := json.Unmarshal(body, &feedExport); err == nil && len(feedExport.Channels) > 0 {
@@ -1120,7 +1726,7 @@ func (s *Server) importFeedFromJSON(body []byte, source string) (*models.Feed, e
 			tags = strings.Join(feedExport.Tags, ", ")
 		}
 
-		feed, err := s.db.CreateFeedWithMetadata(feedExport.Name, feedExport.Description, feedExport.Author, tags)
+		feed, err := s.db.CreateFeedWithMetadata(userID, feedExport.Name, feedExport.Description, feedExport.Author, tags)
 		if err != nil {
 			return nil, err
 		}
@@ -1149,7 +1755,7 @@ func (s *Server) importFeedFromJSON(body []byte, source string) (*models.Feed, e
 		}
 
 		// Add to Inbox instead of creating a new feed
-		inbox, err := s.db.GetInbox()
+		inbox, err := s.db.GetInbox(userID)
 		if err != nil {
 			return nil, err
 		}
@@ -1163,7 +1769,24 @@ func (s *Server) importFeedFromJSON(body []byte, source string) (*models.Feed, e
 		return inbox, nil
 	}
 
-	return nil, &importError{"Unrecognized format - expected Feeds or NewPipe JSON"}
+	// Try OPML format (RSS readers, and Google Takeout's YouTube export)
+	// - add channels to Inbox
+	if opmlChannels := parseOPMLChannels(body); len(opmlChannels) > 0 {
+		inbox, err := s.db.GetInbox(userID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ch := range opmlChannels {
+			if _, err := s.db.AddChannel(inbox.ID, ch.URL, ch.Name); err != nil {
+				log.Printf("Failed to add channel %s: %v", ch.URL, err)
+			}
+		}
+
+		return inbox, nil
+	}
+
+	return nil, &importError{"Unrecognized format - expected Feeds, NewPipe, or OPML"}
 }
 
 type importError struct {
@@ -1174,12 +1797,17 @@ func (e *importError) Error() string {
 	return e.message
 }
 
+// importGroup is one suggested feed's worth of channels awaiting
+// confirmation, the shape both handleAPIConfirmOrganize's request body and
+// handleImportSubscriptions' dry-run preview use - see commitImportGroups.
+type importGroup struct {
+	Name     string   `json:"name"`
+	Channels []string `json:"channels"` // URLs
+}
+
 func (s *Server) handleAPIConfirmOrganize(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		Groups []struct {
-			Name     string   `json:"name"`
-			Channels []string `json:"channels"` // URLs
-		} `json:"groups"`
+		Groups       []importGroup     `json:"groups"`
 		ChannelNames map[string]string `json:"channelNames"` // URL -> Name
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -1187,21 +1815,37 @@ func (s *Server) handleAPIConfirmOrganize(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	createdFeeds, err := s.commitImportGroups(s.currentUserID(r), req.Groups, req.ChannelNames)
+	if err != nil {
+		jsonError(w, "Failed to create feed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, map[string]any{
+		"feeds": createdFeeds,
+	})
+}
+
+// commitImportGroups creates one feed per group and adds its channels,
+// using channelNames[url] as the channel's display name (falling back to
+// the bare URL), and is the common tail of handleAPIConfirmOrganize and
+// handleImportSubscriptions' non-dry-run path. Groups with no channels are
+// skipped rather than creating an empty feed.
+func (s *Server) commitImportGroups(userID int64, groups []importGroup, channelNames map[string]string) ([]*models.Feed, error) {
 	var createdFeeds []*models.Feed
 
-	for _, group := range req.Groups {
+	for _, group := range groups {
 		if len(group.Channels) == 0 {
 			continue
 		}
 
-		feed, err := s.db.CreateFeed(group.Name)
+		feed, err := s.db.CreateFeed(userID, group.Name)
 		if err != nil {
-			jsonError(w, "Failed to create feed: "+err.Error(), http.StatusInternalServerError)
-			return
+			return nil, err
 		}
 
 		for _, url := range group.Channels {
-			name := req.ChannelNames[url]
+			name := channelNames[url]
 			if name == "" {
 				name = url
 			}
@@ -1213,9 +1857,7 @@ func (s *Server) handleAPIConfirmOrganize(w http.ResponseWriter, r *http.Request
 		createdFeeds = append(createdFeeds, feed)
 	}
 
-	jsonResponse(w, map[string]any{
-		"feeds": createdFeeds,
-	})
+	return createdFeeds, nil
 }
 
 // Watch History Import endpoints
@@ -1246,9 +1888,18 @@ func (s *Server) handleAPIImportWatchHistory(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	groups := clusterWatchHistory(body, channels)
+
+	recommended, err := s.buildForYouFeed(s.currentUserID(r), body)
+	if err != nil {
+		log.Printf("Failed to rebuild For You feed: %v", err)
+	}
+
 	jsonResponse(w, map[string]any{
 		"channels":    channels,
 		"totalVideos": totalVideos,
+		"groups":      groups,
+		"recommended": recommended,
 	})
 }
 
@@ -1267,25 +1918,56 @@ func (s *Server) handleAPISearch(w http.ResponseWriter, r *http.Request) {
 			limit = parsed
 		}
 	}
+	offset := 0
+	if o := r.URL.Query().Get("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
+			offset = parsed
+		}
+	}
+	var feedID *int64
+	if f := r.URL.Query().Get("feed_id"); f != "" {
+		if parsed, err := strconv.ParseInt(f, 10, 64); err == nil {
+			feedID = &parsed
+		}
+	}
+	excludeShorts := r.URL.Query().Get("exclude_shorts") == "true"
+	unwatchedOnly := r.URL.Query().Get("unwatched") == "true"
 
 	// Search videos
-	videos, err := s.db.SearchVideos(query, limit)
+	videos, total, err := s.db.SearchVideos(s.currentUserID(r), query, feedID, excludeShorts, unwatchedOnly, limit, offset)
 	if err != nil {
 		jsonError(w, "Failed to search videos: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	// Search channels
-	channels, err := s.db.SearchChannels(query, limit)
+	channels, err := s.db.SearchChannels(query)
 	if err != nil {
 		jsonError(w, "Failed to search channels: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	// Facet counts, so the UI can render per-feed/per-channel filter chips
+	feedFacets, err := s.db.SearchFeedFacets(query)
+	if err != nil {
+		jsonError(w, "Failed to compute feed facets: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	channelFacets, err := s.db.SearchChannelFacets(query)
+	if err != nil {
+		jsonError(w, "Failed to compute channel facets: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	jsonResponse(w, map[string]any{
-		"videos":   videos,
-		"channels": channels,
-		"query":    query,
+		"videos":      videos,
+		"videosTotal": total,
+		"channels":    channels,
+		"query":       query,
+		"facets": map[string]any{
+			"feeds":    feedFacets,
+			"channels": channelFacets,
+		},
 	})
 }
 
@@ -0,0 +1,324 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/erik/feeds/internal/db"
+	"github.com/erik/feeds/internal/metrics"
+	yt "github.com/erik/feeds/internal/youtube"
+	"github.com/erik/feeds/internal/ytdlp"
+)
+
+const (
+	backfillBatchSize    = 10
+	backfillPollInterval = 2 * time.Second
+)
+
+// BackfillManager runs resumable, unbounded channel backfills as background
+// jobs, persisting progress after every batch so a crash/restart resumes
+// exactly where a job left off.
+type BackfillManager struct {
+	db    *db.DB
+	ytdlp ytdlp.Client
+
+	channelLocksMu sync.Mutex
+	channelLocks   map[int64]bool
+}
+
+// NewBackfillManager creates a manager and starts its background poller,
+// which picks up queued jobs and runs them one at a time per channel.
+func NewBackfillManager(database *db.DB, ydl ytdlp.Client) *BackfillManager {
+	bm := &BackfillManager{
+		db:           database,
+		ytdlp:        ydl,
+		channelLocks: make(map[int64]bool),
+	}
+	// Jobs a crashed process left "running" go back to "queued" so poll
+	// resumes them from their persisted last_position.
+	if jobs, err := database.ListBackfillJobs(); err == nil {
+		for _, job := range jobs {
+			if job.Status == "running" {
+				bm.setStatus(job.ID, "queued", "")
+			}
+		}
+	}
+	go bm.poll()
+	return bm
+}
+
+// poll periodically scans for queued jobs and starts any whose channel
+// isn't already backfilling.
+func (bm *BackfillManager) poll() {
+	for {
+		jobs, err := bm.db.GetQueuedBackfillJobs()
+		if err != nil {
+			log.Printf("backfill: failed to list queued jobs: %v", err)
+		}
+		for _, job := range jobs {
+			if bm.tryLockChannel(job.ChannelID) {
+				go bm.run(job)
+			}
+		}
+		time.Sleep(backfillPollInterval)
+	}
+}
+
+func (bm *BackfillManager) tryLockChannel(channelID int64) bool {
+	bm.channelLocksMu.Lock()
+	defer bm.channelLocksMu.Unlock()
+	if bm.channelLocks[channelID] {
+		return false
+	}
+	bm.channelLocks[channelID] = true
+	return true
+}
+
+func (bm *BackfillManager) unlockChannel(channelID int64) {
+	bm.channelLocksMu.Lock()
+	defer bm.channelLocksMu.Unlock()
+	delete(bm.channelLocks, channelID)
+}
+
+// run walks a channel's upload history in fixed-size batches, persisting
+// last_position after each one, until the job is paused, canceled, fails,
+// or runs out of videos.
+func (bm *BackfillManager) run(job db.BackfillJob) {
+	defer bm.unlockChannel(job.ChannelID)
+
+	if err := bm.setStatus(job.ID, "running", ""); err != nil {
+		log.Printf("backfill job %d: failed to mark running: %v", job.ID, err)
+		return
+	}
+
+	channel, err := bm.db.GetChannel(job.ChannelID)
+	if err != nil {
+		bm.setStatus(job.ID, "failed", fmt.Sprintf("channel not found: %v", err))
+		return
+	}
+
+	position := job.LastPosition
+	saved := job.VideosSaved
+
+	for {
+		current, err := bm.db.GetBackfillJob(job.ID)
+		if err != nil {
+			log.Printf("backfill job %d: failed to reload: %v", job.ID, err)
+			return
+		}
+		if current.Status == "paused" || current.Status == "failed" {
+			return
+		}
+
+		start := position + 1
+		end := start + backfillBatchSize - 1
+
+		videos, err := bm.ytdlp.GetChannelVideos(channel.URL, start, end)
+		if err != nil {
+			metrics.YTDLPInvocations.WithLabelValues("failure").Inc()
+			bm.setStatus(job.ID, "failed", fmt.Sprintf("fetch failed at position %d: %v", start, err))
+			return
+		}
+		metrics.YTDLPInvocations.WithLabelValues("success").Inc()
+		metrics.VideosFetched.WithLabelValues(strconv.FormatInt(channel.ID, 10), "ytdlp").Add(float64(len(videos)))
+
+		if len(videos) == 0 {
+			position = start - 1
+			if err := bm.db.UpdateBackfillJobProgress(job.ID, position, saved, position); err != nil {
+				log.Printf("backfill job %d: failed to persist progress: %v", job.ID, err)
+			}
+			bm.setStatus(job.ID, "done", "")
+			return
+		}
+
+		videoIDs := make([]string, len(videos))
+		for i, v := range videos {
+			videoIDs[i] = v.ID
+		}
+		shortsTimer := prometheus.NewTimer(metrics.ShortsCheckDuration.WithLabelValues("ytdlp"))
+		shortsStatus := yt.CheckShortsStatus(videoIDs)
+		shortsTimer.ObserveDuration()
+
+		for _, v := range videos {
+			video := v.ToModel(channel.ID, channel.Name)
+			if isShort, ok := shortsStatus[video.ID]; ok {
+				video.IsShort = &isShort
+			}
+			if _, err := bm.db.UpsertVideo(video); err != nil {
+				log.Printf("backfill job %d: failed to save video %s: %v", job.ID, video.ID, err)
+				continue
+			}
+			saved++
+		}
+
+		position += len(videos)
+		if err := bm.db.UpdateBackfillJobProgress(job.ID, position, saved, position); err != nil {
+			log.Printf("backfill job %d: failed to persist progress: %v", job.ID, err)
+		}
+
+		if len(videos) < backfillBatchSize {
+			bm.setStatus(job.ID, "done", "")
+			return
+		}
+	}
+}
+
+// setStatus persists a job's status and records the transition for the
+// backfill_job_state_transitions metric.
+func (bm *BackfillManager) setStatus(jobID int64, status, lastError string) error {
+	err := bm.db.SetBackfillJobStatus(jobID, status, lastError)
+	if err == nil {
+		metrics.BackfillJobTransitions.WithLabelValues(status).Inc()
+	}
+	return err
+}
+
+// Pause marks a running or queued job paused; the next poll tick will skip it.
+func (bm *BackfillManager) Pause(id int64) error {
+	return bm.setStatus(id, "paused", "")
+}
+
+// Resume re-queues a paused job so the poller picks it back up.
+func (bm *BackfillManager) Resume(id int64) error {
+	job, err := bm.db.GetBackfillJob(id)
+	if err != nil {
+		return err
+	}
+	if job.Status != "paused" {
+		return fmt.Errorf("backfill job %d is %s, only paused jobs can be resumed", id, job.Status)
+	}
+	return bm.setStatus(id, "queued", "")
+}
+
+// Cancel stops a job permanently; unlike Pause, it cannot be resumed.
+func (bm *BackfillManager) Cancel(id int64) error {
+	return bm.setStatus(id, "failed", "canceled by user")
+}
+
+// handleAPIBackfillChannel queues a new backfill job for a channel and
+// returns it immediately; progress is tailed via handleBackfillJobStream
+// or polled via handleAPIListBackfillJobs.
+func (s *Server) handleAPIBackfillChannel(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		jsonError(w, "Invalid channel ID", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := s.db.GetChannel(id); err != nil {
+		jsonError(w, "Channel not found", http.StatusNotFound)
+		return
+	}
+
+	job, err := s.db.CreateBackfillJob(id)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	jsonResponse(w, job)
+}
+
+// handleAPIListBackfillJobs returns all backfill jobs, most recent first.
+func (s *Server) handleAPIListBackfillJobs(w http.ResponseWriter, r *http.Request) {
+	jobs, err := s.db.ListBackfillJobs()
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	jsonResponse(w, jobs)
+}
+
+// handleAPIBackfillJobAction pauses, resumes, or cancels a backfill job.
+func (s *Server) handleAPIBackfillJobAction(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		jsonError(w, "Invalid job ID", http.StatusBadRequest)
+		return
+	}
+
+	var actionErr error
+	switch r.PathValue("action") {
+	case "pause":
+		actionErr = s.backfillManager.Pause(id)
+	case "resume":
+		actionErr = s.backfillManager.Resume(id)
+	case "cancel":
+		actionErr = s.backfillManager.Cancel(id)
+	default:
+		jsonError(w, "Unknown action", http.StatusBadRequest)
+		return
+	}
+	if actionErr != nil {
+		jsonError(w, actionErr.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	job, err := s.db.GetBackfillJob(id)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	jsonResponse(w, job)
+}
+
+// handleBackfillJobStream streams a job's progress via SSE by tailing its
+// row until it reaches a terminal status.
+func (s *Server) handleBackfillJobStream(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		jsonError(w, "Invalid job ID", http.StatusBadRequest)
+		return
+	}
+	s.streamBackfillJob(w, r, id)
+}
+
+// streamBackfillJob tails a job's row via SSE until it reaches a terminal
+// status. Shared by handleBackfillJobStream and the legacy fetch-more
+// endpoint, which creates a job and streams it for compatibility.
+func (s *Server) streamBackfillJob(w http.ResponseWriter, r *http.Request, id int64) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		jsonError(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	sendEvent := func(eventType string, data any) {
+		jsonData, _ := json.Marshal(data)
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", eventType, jsonData)
+		flusher.Flush()
+	}
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			job, err := s.db.GetBackfillJob(id)
+			if err != nil {
+				sendEvent("error", map[string]any{"message": err.Error()})
+				return
+			}
+			sendEvent("progress", job)
+
+			if job.Status == "done" || job.Status == "failed" {
+				sendEvent("complete", job)
+				return
+			}
+		}
+	}
+}
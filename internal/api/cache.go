@@ -1,19 +1,36 @@
 package api
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
 	"log"
+	"math/rand"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 )
 
 const (
-	videoCacheDir        = "/tmp/feeds-video-cache"
-	videoCacheTTL        = 1 * time.Hour
-	cacheCleanupInterval = 5 * time.Minute
-	maxCacheSize         = 5 * 1024 * 1024 * 1024  // 5GB max cache size
-	orphanedTmpTTL       = 30 * time.Minute        // Clean .tmp files older than 30 min (stale downloads)
+	// defaultMaxCacheSize is DefaultVideoCacheConfig's MaxSize, and the
+	// fallback used if a configured MaxSize can't be parsed.
+	defaultMaxCacheSize = 5 * 1024 * 1024 * 1024 // 5GB
+
+	// integrityChunkSize is the block size manifests hash over. /tmp on cheap
+	// VPS/container hosts is prone to silent bit flips and truncation, so
+	// every cached .mp4 gets a companion manifest of per-chunk SHA-256 hashes
+	// it's checked against before being served.
+	integrityChunkSize = 4 * 1024 * 1024
+
+	// manifestSuffix is appended to a cache key's .mp4 filename to get its
+	// integrity manifest's path (see VideoCache.manifestPath).
+	manifestSuffix = ".sha256"
 )
 
 const (
@@ -55,17 +72,111 @@ func GetFileSize(path string) int64 {
 	return info.Size()
 }
 
+// VideoCacheConfig configures a VideoCache's storage location and eviction
+// policy.
+type VideoCacheConfig struct {
+	// Dir is the directory cached .mp4s and their manifests are stored in.
+	Dir string
+
+	// MaxSize bounds the cache's total size. It's either an absolute byte
+	// count ("5368709120") or a percentage of Dir's filesystem ("50%"),
+	// resolved via syscall.Statfs - re-resolved on every cleanup tick so a
+	// percentage stays proportional to a volume that's resized at runtime
+	// (e.g. a container's mounted disk growing), rather than a stale
+	// snapshot taken at startup.
+	MaxSize string
+
+	MaxAge          time.Duration
+	CleanupInterval time.Duration
+
+	// OrphanTTL is how long a stale .tmp file (left behind by a crashed or
+	// timed-out download) is kept before cleanup removes it.
+	OrphanTTL time.Duration
+}
+
+// DefaultVideoCacheConfig returns the settings the cache used before it
+// became configurable.
+func DefaultVideoCacheConfig() VideoCacheConfig {
+	return VideoCacheConfig{
+		Dir:             "/tmp/feeds-video-cache",
+		MaxSize:         strconv.Itoa(defaultMaxCacheSize),
+		MaxAge:          1 * time.Hour,
+		CleanupInterval: 5 * time.Minute,
+		OrphanTTL:       30 * time.Minute,
+	}
+}
+
 // VideoCache manages cached muxed video files
-type VideoCache struct{}
+type VideoCache struct {
+	cfg VideoCacheConfig
+
+	// mu guards refcounts and pendingEvict, which together let Acquire pin a
+	// cache file against the two cleanup passes (TTL and max-size eviction)
+	// while an HTTP handler is actively streaming it: a file with a positive
+	// refcount is never removed outright, only marked pendingEvict so its
+	// last Release can finish the job.
+	mu           sync.Mutex
+	refcounts    map[string]int
+	pendingEvict map[string]bool
+
+	// fills tracks keys currently being populated via OpenForFill, so
+	// GetRange knows to wait on a partial write instead of treating the key
+	// as a plain cache miss, and evict knows not to remove a sparse file
+	// that's still being filled.
+	fills map[string]*FillWriter
+
+	// stats records hit counts and last-access times, persisted to
+	// cacheStatsFile. cleanup's over-quota pass uses it to score files by
+	// GDSF priority instead of evicting strictly oldest-first.
+	stats *cacheStats
+
+	// gdsfClock is GDSF's monotonic watermark: each eviction during the
+	// over-quota pass raises it to the priority of the file just evicted,
+	// so priorities computed on a later cleanup tick build on where the
+	// previous pass left off rather than starting cold every time.
+	gdsfClock float64
+}
+
+// sharedCache holds one VideoCache per absolute Dir path, so that two
+// NewVideoCache calls pointed at the same directory (e.g. multiple worker
+// processes sharing an NFS/tmp mount, or a library consumer constructing its
+// own DownloadManager alongside the server's) coordinate through a single
+// cleanup goroutine and a single set of refcount/pendingEvict/fills state,
+// instead of two caches independently evicting and racing over the same
+// files.
+var (
+	sharedCacheMu sync.Mutex
+	sharedCache   = make(map[string]*VideoCache)
+)
+
+// NewVideoCache creates a new video cache manager, or returns the existing
+// one already managing cfg.Dir (see sharedCache).
+func NewVideoCache(cfg VideoCacheConfig) *VideoCache {
+	dir, err := filepath.Abs(cfg.Dir)
+	if err != nil {
+		dir = cfg.Dir
+	}
+	cfg.Dir = dir
+
+	sharedCacheMu.Lock()
+	defer sharedCacheMu.Unlock()
+
+	if vc, ok := sharedCache[dir]; ok {
+		return vc
+	}
 
-// NewVideoCache creates a new video cache manager
-func NewVideoCache() *VideoCache {
 	// Ensure cache directory exists
-	if err := os.MkdirAll(videoCacheDir, 0755); err != nil {
+	if err := os.MkdirAll(dir, 0755); err != nil {
 		log.Printf("Warning: could not create video cache dir: %v", err)
 	}
 
-	vc := &VideoCache{}
+	vc := &VideoCache{
+		cfg:          cfg,
+		refcounts:    make(map[string]int),
+		pendingEvict: make(map[string]bool),
+		fills:        make(map[string]*FillWriter),
+		stats:        loadCacheStats(dir),
+	}
 
 	// Run cleanup immediately on startup to clear stale files from previous sessions
 	log.Printf("Running initial cache cleanup...")
@@ -74,6 +185,7 @@ func NewVideoCache() *VideoCache {
 	// Start cleanup goroutine
 	go vc.cleanupLoop()
 
+	sharedCache[dir] = vc
 	return vc
 }
 
@@ -82,12 +194,154 @@ func CacheKey(videoID, quality string) string {
 	return videoID + "_" + quality
 }
 
+// Dir returns the directory cached files are stored in.
+func (vc *VideoCache) Dir() string {
+	return vc.cfg.Dir
+}
+
 // CachePath returns the file path for a cached video
 func (vc *VideoCache) CachePath(key string) string {
-	return filepath.Join(videoCacheDir, key+".mp4")
+	return filepath.Join(vc.cfg.Dir, key+".mp4")
+}
+
+// resolveMaxSize returns cfg's current byte budget. A percentage MaxSize is
+// resolved against Dir's filesystem via syscall.Statfs on every call, rather
+// than once at startup, so it tracks a volume that's resized at runtime. A
+// MaxSize that fails to parse, or a failed Statfs call, falls back to
+// defaultMaxCacheSize.
+func resolveMaxSize(cfg VideoCacheConfig) int64 {
+	pct, isPercent := strings.CutSuffix(cfg.MaxSize, "%")
+	if !isPercent {
+		n, err := strconv.ParseInt(cfg.MaxSize, 10, 64)
+		if err != nil {
+			log.Printf("Cache: invalid MaxSize %q, falling back to default max size", cfg.MaxSize)
+			return defaultMaxCacheSize
+		}
+		return n
+	}
+
+	percent, err := strconv.ParseFloat(pct, 64)
+	if err != nil {
+		log.Printf("Cache: invalid MaxSize %q, falling back to default max size", cfg.MaxSize)
+		return defaultMaxCacheSize
+	}
+
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(cfg.Dir, &stat); err != nil {
+		log.Printf("Cache: statfs failed for %s, falling back to default max size: %v", cfg.Dir, err)
+		return defaultMaxCacheSize
+	}
+
+	total := int64(stat.Blocks) * int64(stat.Bsize)
+	return int64(float64(total) * percent / 100)
+}
+
+// manifestPath returns the integrity manifest path for a cached video.
+func (vc *VideoCache) manifestPath(key string) string {
+	return vc.CachePath(key) + manifestSuffix
+}
+
+// integrityManifest records a cached file's size and the SHA-256 hash of
+// each integrityChunkSize block, so a later read can detect disk corruption
+// (truncation or bit flips) before the file is streamed to a client.
+type integrityManifest struct {
+	Size   int64    `json:"size"`
+	Chunks []string `json:"chunks"`
 }
 
-// Get returns the path to a cached video if it exists and is valid
+// WriteManifest hashes the cached file at key in integrityChunkSize blocks
+// and writes its integrity manifest alongside it. Call this once a cache
+// file has finished being written (see DownloadManager.runDownload); a
+// missing manifest is treated as "no integrity data recorded" rather than
+// corruption, so older cache entries written before this existed keep
+// working.
+func (vc *VideoCache) WriteManifest(key string) error {
+	path := vc.CachePath(key)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var manifest integrityManifest
+	buf := make([]byte, integrityChunkSize)
+	for {
+		n, err := io.ReadFull(f, buf)
+		if n > 0 {
+			sum := sha256.Sum256(buf[:n])
+			manifest.Chunks = append(manifest.Chunks, hex.EncodeToString(sum[:]))
+			manifest.Size += int64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+
+	tmp := vc.manifestPath(key) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, vc.manifestPath(key))
+}
+
+// verify re-hashes path's blocks and compares them against its manifest,
+// reporting false on any mismatch (corruption) or size disagreement. A
+// cache file with no manifest passes verification, since there's nothing to
+// check it against.
+func (vc *VideoCache) verify(path, manifestPath string) bool {
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return true
+	}
+
+	var manifest integrityManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return true
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	var size int64
+	buf := make([]byte, integrityChunkSize)
+	for i := 0; ; i++ {
+		n, err := io.ReadFull(f, buf)
+		if n > 0 {
+			if i >= len(manifest.Chunks) {
+				return false
+			}
+			sum := sha256.Sum256(buf[:n])
+			if hex.EncodeToString(sum[:]) != manifest.Chunks[i] {
+				return false
+			}
+			size += int64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return false
+		}
+	}
+
+	return size == manifest.Size
+}
+
+// Get returns the path to a cached video if it exists, hasn't expired, and
+// passes integrity verification against its manifest (see WriteManifest).
 func (vc *VideoCache) Get(key string) (string, bool) {
 	path := vc.CachePath(key)
 
@@ -97,23 +351,143 @@ func (vc *VideoCache) Get(key string) (string, bool) {
 	}
 
 	// Check if file is too old
-	if time.Since(info.ModTime()) > videoCacheTTL {
-		os.Remove(path)
+	if time.Since(info.ModTime()) > vc.cfg.MaxAge {
+		vc.evict(key, path)
 		return "", false
 	}
 
 	// Check if file has content
 	if info.Size() == 0 {
-		os.Remove(path)
+		vc.evict(key, path)
 		return "", false
 	}
 
+	if !vc.verify(path, vc.manifestPath(key)) {
+		log.Printf("Cache integrity check failed for %s, evicting", key)
+		vc.evict(key, path)
+		return "", false
+	}
+
+	vc.stats.recordHit(key)
 	return path, true
 }
 
+// Acquire is Get plus a refcount increment, so cleanup won't remove the
+// file out from under an in-progress stream: the caller MUST call the
+// returned release func (typically via defer) once it's done reading,
+// which decrements the refcount and, if the file was marked for eviction
+// while pinned, finishes removing it.
+func (vc *VideoCache) Acquire(key string) (path string, release func(), ok bool) {
+	path, ok = vc.Get(key)
+	if !ok {
+		return "", nil, false
+	}
+	return path, vc.acquireRef(key, path), true
+}
+
+// GetRange is Acquire for a specific byte range: if key is still being
+// populated via an in-progress OpenForFill, it blocks until every block
+// [start, end) overlaps has been written - enabling a client to seek ahead
+// of the download head and get served as soon as that range lands, instead
+// of waiting for the whole file - then behaves exactly like Acquire. If no
+// fill is in progress for key, it falls straight through to Acquire. ok is
+// false if the fill closed early (e.g. the download failed) before the
+// range was ready, or key isn't cached at all.
+func (vc *VideoCache) GetRange(key string, start, end int64) (path string, release func(), ok bool) {
+	vc.mu.Lock()
+	fw := vc.fills[key]
+	vc.mu.Unlock()
+
+	if fw == nil {
+		return vc.Acquire(key)
+	}
+
+	if !fw.WaitRange(start, end) {
+		return "", nil, false
+	}
+	path = vc.CachePath(key)
+	return path, vc.acquireRef(key, path), true
+}
+
+// FillWriterFor returns the FillWriter currently populating key, if any, so
+// a caller that already knows a fill is underway (e.g.
+// DownloadManager.EnsureRange) can write additional bytes into it directly
+// instead of waiting on GetRange. Returns nil if key isn't being filled.
+func (vc *VideoCache) FillWriterFor(key string) *FillWriter {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+	return vc.fills[key]
+}
+
+// acquireRef increments key's refcount and returns the matching release
+// func, shared by Acquire and GetRange.
+func (vc *VideoCache) acquireRef(key, path string) func() {
+	vc.mu.Lock()
+	vc.refcounts[key]++
+	vc.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			vc.mu.Lock()
+			vc.refcounts[key]--
+			evictNow := vc.refcounts[key] <= 0 && vc.pendingEvict[key]
+			if vc.refcounts[key] <= 0 {
+				delete(vc.refcounts, key)
+				delete(vc.pendingEvict, key)
+			}
+			vc.mu.Unlock()
+
+			if evictNow {
+				os.Remove(path)
+				os.Remove(vc.manifestPath(key))
+				vc.stats.remove(key)
+			}
+		})
+	}
+}
+
+// registerFill and unregisterFill track in-progress OpenForFill writes so
+// GetRange and evict know a key is being actively populated.
+func (vc *VideoCache) registerFill(key string, fw *FillWriter) {
+	vc.mu.Lock()
+	vc.fills[key] = fw
+	vc.mu.Unlock()
+}
+
+func (vc *VideoCache) unregisterFill(key string) {
+	vc.mu.Lock()
+	delete(vc.fills, key)
+	vc.mu.Unlock()
+}
+
+// evict removes a cache file and its manifest, unless it's currently
+// Acquire'd by an in-progress stream or still being written via
+// OpenForFill - in which case evict only marks it pendingEvict and reports
+// false so the caller knows the file is still on disk. The last release call
+// that brings its refcount to zero finishes the removal; a file that was only
+// being filled is not removed when its fill closes, but by the next release
+// or cleanup pass.
+func (vc *VideoCache) evict(key, path string) bool {
+	vc.mu.Lock()
+	if vc.refcounts[key] > 0 || vc.fills[key] != nil {
+		vc.pendingEvict[key] = true
+		vc.mu.Unlock()
+		return false
+	}
+	vc.mu.Unlock()
+
+	if err := os.Remove(path); err != nil {
+		return false
+	}
+	os.Remove(vc.manifestPath(key))
+	vc.stats.remove(key)
+	return true
+}
+
 // cleanupLoop periodically removes expired cache files
 func (vc *VideoCache) cleanupLoop() {
-	ticker := time.NewTicker(cacheCleanupInterval)
+	ticker := time.NewTicker(vc.cfg.CleanupInterval)
 	defer ticker.Stop()
 
 	for range ticker.C {
@@ -129,12 +503,13 @@ type cacheFileInfo struct {
 }
 
 func (vc *VideoCache) cleanup() {
-	entries, err := os.ReadDir(videoCacheDir)
+	entries, err := os.ReadDir(vc.cfg.Dir)
 	if err != nil {
 		log.Printf("Cache cleanup: failed to read directory: %v", err)
 		return
 	}
 
+	maxSize := resolveMaxSize(vc.cfg)
 	now := time.Now()
 	var files []cacheFileInfo
 	var totalSize int64
@@ -151,11 +526,11 @@ func (vc *VideoCache) cleanup() {
 			continue
 		}
 
-		path := filepath.Join(videoCacheDir, entry.Name())
+		path := filepath.Join(vc.cfg.Dir, entry.Name())
 		name := entry.Name()
 
 		// Clean orphaned .tmp files (stale downloads from crashes or timeouts)
-		if strings.HasSuffix(name, ".tmp") && now.Sub(info.ModTime()) > orphanedTmpTTL {
+		if strings.HasSuffix(name, ".tmp") && now.Sub(info.ModTime()) > vc.cfg.OrphanTTL {
 			if err := os.Remove(path); err == nil {
 				log.Printf("Cleaned up orphaned temp file: %s (age: %v)", name, now.Sub(info.ModTime()).Round(time.Minute))
 				removedCount++
@@ -163,6 +538,18 @@ func (vc *VideoCache) cleanup() {
 			continue
 		}
 
+		// Integrity manifests ride alongside their .mp4 and are removed
+		// together with it below; they don't count toward cache size or get
+		// TTL/size-evicted on their own.
+		if strings.HasSuffix(name, manifestSuffix) {
+			continue
+		}
+
+		// The stats file isn't a cached video - skip it too.
+		if name == cacheStatsFile {
+			continue
+		}
+
 		files = append(files, cacheFileInfo{
 			path:    path,
 			size:    info.Size(),
@@ -171,10 +558,13 @@ func (vc *VideoCache) cleanup() {
 		totalSize += info.Size()
 	}
 
-	// First pass: remove files older than TTL
+	// First pass: remove files older than TTL. A file an HTTP handler is
+	// still streaming (positive refcount) is skipped here - evict marks it
+	// pendingEvict instead, and its last release finishes the removal.
 	for i := len(files) - 1; i >= 0; i-- {
-		if now.Sub(files[i].modTime) > videoCacheTTL {
-			if err := os.Remove(files[i].path); err == nil {
+		if now.Sub(files[i].modTime) > vc.cfg.MaxAge {
+			key := strings.TrimSuffix(filepath.Base(files[i].path), ".mp4")
+			if vc.evict(key, files[i].path) {
 				log.Printf("Cleaned up expired cache file: %s (age: %v)", filepath.Base(files[i].path), now.Sub(files[i].modTime).Round(time.Minute))
 				totalSize -= files[i].size
 				removedCount++
@@ -184,28 +574,56 @@ func (vc *VideoCache) cleanup() {
 		}
 	}
 
-	// Second pass: if still over max size, remove oldest files until under limit
-	if totalSize > maxCacheSize {
-		log.Printf("Cache size %.2f GB exceeds max %.2f GB, cleaning oldest files", float64(totalSize)/(1024*1024*1024), float64(maxCacheSize)/(1024*1024*1024))
+	// Second pass: if still over max size, evict by Greedy-Dual-Size-Frequency
+	// priority instead of strict oldest-first, so a large 4K file a handful
+	// of users keep rewatching isn't repeatedly evicted ahead of tiny,
+	// never-touched 360p files just because it's older.
+	if totalSize > maxSize {
+		log.Printf("Cache size %.2f GB exceeds max %.2f GB, cleaning lowest-priority files", float64(totalSize)/(1024*1024*1024), float64(maxSize)/(1024*1024*1024))
+
+		type scoredFile struct {
+			info     cacheFileInfo
+			key      string
+			priority float64
+		}
+
+		scored := make([]scoredFile, len(files))
+		for i, f := range files {
+			key := strings.TrimSuffix(filepath.Base(f.path), ".mp4")
+			st := vc.stats.get(key)
+			frequency := st.Hits
+			if frequency < 1 {
+				frequency = 1
+			}
+			sizeMB := float64(f.size) / (1024 * 1024)
+			if sizeMB < 1 {
+				sizeMB = 1
+			}
+			cost := gdsfCost(qualityFromKey(key))
 
-		// Sort by modification time (oldest first)
-		for i := 0; i < len(files)-1; i++ {
-			for j := i + 1; j < len(files); j++ {
-				if files[i].modTime.After(files[j].modTime) {
-					files[i], files[j] = files[j], files[i]
-				}
+			scored[i] = scoredFile{
+				info:     f,
+				key:      key,
+				priority: vc.gdsfClock + float64(frequency)*cost/sizeMB,
 			}
 		}
 
-		// Remove oldest files until under limit
-		for _, f := range files {
-			if totalSize <= maxCacheSize {
+		sort.Slice(scored, func(i, j int) bool {
+			return scored[i].priority < scored[j].priority
+		})
+
+		// Evict lowest priority first until under budget. A file with a
+		// positive refcount is skipped (see evict) and stays counted
+		// against totalSize, since its space isn't actually reclaimed yet.
+		for _, sf := range scored {
+			if totalSize <= maxSize {
 				break
 			}
-			if err := os.Remove(f.path); err == nil {
-				log.Printf("Cleaned up cache file to reduce size: %s (%.2f MB)", filepath.Base(f.path), float64(f.size)/(1024*1024))
-				totalSize -= f.size
+			if vc.evict(sf.key, sf.info.path) {
+				log.Printf("Evicted cache file under GDSF pressure (priority %.4f): %s (%.2f MB)", sf.priority, filepath.Base(sf.info.path), float64(sf.info.size)/(1024*1024))
+				totalSize -= sf.info.size
 				removedCount++
+				vc.gdsfClock = sf.priority
 			}
 		}
 	}
@@ -213,4 +631,25 @@ func (vc *VideoCache) cleanup() {
 	if removedCount > 0 || totalSize > 0 {
 		log.Printf("Cache cleanup complete: removed %d files, current size: %.2f GB", removedCount, float64(totalSize)/(1024*1024*1024))
 	}
+
+	vc.scrubOne(files)
+}
+
+// scrubOne verifies one random surviving cache file per cleanup tick against
+// its manifest, so silent disk corruption on the /tmp mount is caught even
+// for videos nobody is actively streaming (and would otherwise only be
+// noticed, evicted, and re-downloaded on the next Get).
+func (vc *VideoCache) scrubOne(files []cacheFileInfo) {
+	if len(files) == 0 {
+		return
+	}
+
+	f := files[rand.Intn(len(files))]
+	key := strings.TrimSuffix(filepath.Base(f.path), ".mp4")
+	if vc.verify(f.path, vc.manifestPath(key)) {
+		return
+	}
+
+	log.Printf("Scrub found corrupted cache file: %s, evicting", filepath.Base(f.path))
+	vc.evict(key, f.path)
 }
@@ -0,0 +1,205 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cacheStatsFile is the name of the on-disk record of per-key hit counts and
+// last-access times, used by the GDSF eviction pass and the admin stats
+// endpoint. It lives alongside the cached .mp4s in VideoCacheConfig.Dir.
+const cacheStatsFile = "cachestats.json"
+
+// cacheEntryStats is a cached key's access history.
+type cacheEntryStats struct {
+	Hits       int       `json:"hits"`
+	LastAccess time.Time `json:"lastAccess"`
+}
+
+// cacheStats tracks cacheEntryStats for every key a VideoCache has ever
+// served, persisted to cacheStatsFile so hit counts survive a restart
+// instead of every file looking equally "cold" to GDSF right after one.
+type cacheStats struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]cacheEntryStats
+}
+
+func loadCacheStats(dir string) *cacheStats {
+	cs := &cacheStats{
+		path:    filepath.Join(dir, cacheStatsFile),
+		entries: make(map[string]cacheEntryStats),
+	}
+
+	data, err := os.ReadFile(cs.path)
+	if err != nil {
+		return cs
+	}
+	if err := json.Unmarshal(data, &cs.entries); err != nil {
+		log.Printf("Cache stats: failed to parse %s, starting fresh: %v", cs.path, err)
+		cs.entries = make(map[string]cacheEntryStats)
+	}
+	return cs
+}
+
+// recordHit bumps key's hit count and last-access time and persists it.
+func (cs *cacheStats) recordHit(key string) {
+	cs.mu.Lock()
+	e := cs.entries[key]
+	e.Hits++
+	e.LastAccess = time.Now()
+	cs.entries[key] = e
+	cs.mu.Unlock()
+
+	cs.save()
+}
+
+// remove drops key's stats, typically once its cache file has been evicted.
+func (cs *cacheStats) remove(key string) {
+	cs.mu.Lock()
+	_, ok := cs.entries[key]
+	if ok {
+		delete(cs.entries, key)
+	}
+	cs.mu.Unlock()
+
+	if ok {
+		cs.save()
+	}
+}
+
+// get returns key's recorded stats, or the zero value if it's never been hit.
+func (cs *cacheStats) get(key string) cacheEntryStats {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	return cs.entries[key]
+}
+
+// snapshot copies every tracked entry, for the admin stats endpoint.
+func (cs *cacheStats) snapshot() map[string]cacheEntryStats {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	out := make(map[string]cacheEntryStats, len(cs.entries))
+	for k, v := range cs.entries {
+		out[k] = v
+	}
+	return out
+}
+
+func (cs *cacheStats) save() {
+	cs.mu.Lock()
+	data, err := json.Marshal(cs.entries)
+	cs.mu.Unlock()
+	if err != nil {
+		log.Printf("Cache stats: failed to marshal: %v", err)
+		return
+	}
+
+	tmp := cs.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		os.Remove(tmp)
+		log.Printf("Cache stats: failed to write %s: %v", cs.path, err)
+		return
+	}
+	if err := os.Rename(tmp, cs.path); err != nil {
+		log.Printf("Cache stats: failed to rename into place: %v", err)
+	}
+}
+
+// gdsfCost is a per-quality constant approximating the expense of
+// re-fetching and re-muxing an evicted file, higher for heavier qualities so
+// GDSF eviction leans towards keeping valuable 4K files warm over cheaply
+// re-downloaded 360p ones, all else being equal.
+func gdsfCost(quality string) float64 {
+	switch quality {
+	case "2160", "4K", "best":
+		return 8
+	case "1440":
+		return 5
+	case "1080":
+		return 3
+	case "720":
+		return 2
+	case "480":
+		return 1.5
+	case "360":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// qualityFromKey extracts the quality suffix from a CacheKey-formatted key
+// ("videoID_quality").
+func qualityFromKey(key string) string {
+	if idx := strings.LastIndex(key, "_"); idx >= 0 {
+		return key[idx+1:]
+	}
+	return ""
+}
+
+// CacheStatEntry summarizes one cached file for the admin stats endpoint.
+type CacheStatEntry struct {
+	Key        string    `json:"key"`
+	SizeBytes  int64     `json:"sizeBytes"`
+	Hits       int       `json:"hits"`
+	LastAccess time.Time `json:"lastAccess"`
+}
+
+// CacheStatsSummary is VideoCache.StatsSummary's return value.
+type CacheStatsSummary struct {
+	Entries        []CacheStatEntry `json:"entries"`
+	TotalSizeBytes int64            `json:"totalSizeBytes"`
+	TotalHits      int              `json:"totalHits"`
+
+	// BytesServedEstimate approximates total bytes served out of the cache
+	// across every recorded hit (each entry's size * hit count) - i.e. the
+	// bytes saved from being re-downloaded and re-muxed thanks to caching.
+	BytesServedEstimate int64 `json:"bytesServedEstimate"`
+}
+
+// StatsSummary lists every currently cached file alongside its access
+// history, for an admin endpoint to inspect cache effectiveness.
+func (vc *VideoCache) StatsSummary() CacheStatsSummary {
+	entries, err := os.ReadDir(vc.cfg.Dir)
+	if err != nil {
+		return CacheStatsSummary{}
+	}
+
+	snapshot := vc.stats.snapshot()
+
+	var summary CacheStatsSummary
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, ".mp4") {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		key := strings.TrimSuffix(name, ".mp4")
+		st := snapshot[key]
+		size := info.Size()
+
+		summary.Entries = append(summary.Entries, CacheStatEntry{
+			Key:        key,
+			SizeBytes:  size,
+			Hits:       st.Hits,
+			LastAccess: st.LastAccess,
+		})
+		summary.TotalSizeBytes += size
+		summary.TotalHits += st.Hits
+		summary.BytesServedEstimate += size * int64(st.Hits)
+	}
+
+	return summary
+}
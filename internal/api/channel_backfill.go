@@ -0,0 +1,250 @@
+package api
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/erik/feeds/internal/db"
+	"github.com/erik/feeds/internal/models"
+	yt "github.com/erik/feeds/internal/youtube"
+)
+
+const (
+	// maxQuotaRetries caps how many times a page retries after a 403 quota
+	// error before the backfill gives up for this run; the cursor is left
+	// unadvanced, so a later Start for the same channel resumes from the
+	// same page. Nothing here schedules that later run.
+	maxQuotaRetries = 5
+)
+
+// quotaBackoff returns an increasing delay between quota-error retries.
+func quotaBackoff(attempt int) time.Duration {
+	return time.Duration(attempt) * 30 * time.Second
+}
+
+// ChannelBackfillManager runs full upload-history backfills for a channel
+// using the YouTube Data API's playlistItems pagination, a much faster
+// alternative to BackfillManager's yt-dlp flat-playlist scraping when
+// YOUTUBE_API_KEY is configured. Progress is tracked in memory per channel;
+// pagination state itself is persisted via channels.next_page_token /
+// last_backfilled_at so a restart resumes instead of re-paging from scratch.
+type ChannelBackfillManager struct {
+	db         *db.DB
+	backfiller *yt.ChannelBackfiller
+
+	mu       sync.Mutex
+	progress map[int64]*ChannelBackfillProgress
+}
+
+// ChannelBackfillProgress is the snapshot returned by the progress endpoint.
+type ChannelBackfillProgress struct {
+	Fetched int    `json:"fetched"`
+	Total   int    `json:"total"`
+	Running bool   `json:"running"`
+	Error   string `json:"error,omitempty"`
+}
+
+// NewChannelBackfillManager creates a manager. apiKey may be empty: Start
+// falls back to paging via Piped instead of the Data API, just slower and
+// without a persisted resume cursor.
+func NewChannelBackfillManager(database *db.DB, apiKey string) *ChannelBackfillManager {
+	return &ChannelBackfillManager{
+		db:         database,
+		backfiller: yt.NewChannelBackfiller(apiKey),
+		progress:   make(map[int64]*ChannelBackfillProgress),
+	}
+}
+
+// Enabled always reports true: a full-history backfill is available either
+// way, via the Data API when a key is configured or via Piped otherwise.
+func (m *ChannelBackfillManager) Enabled() bool {
+	return true
+}
+
+// Start kicks off a background full-history backfill for a channel unless
+// one is already running for it.
+func (m *ChannelBackfillManager) Start(channel *models.Channel) {
+	m.mu.Lock()
+	if p, ok := m.progress[channel.ID]; ok && p.Running {
+		m.mu.Unlock()
+		return
+	}
+	m.progress[channel.ID] = &ChannelBackfillProgress{Running: true}
+	m.mu.Unlock()
+
+	go m.run(channel)
+}
+
+// Progress returns the current backfill progress for a channel, or nil if
+// a backfill has never been started for it.
+func (m *ChannelBackfillManager) Progress(channelID int64) *ChannelBackfillProgress {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.progress[channelID]
+	if !ok {
+		return nil
+	}
+	snapshot := *p
+	return &snapshot
+}
+
+// run pages through the channel's entire upload history and drains it
+// through a two-stage pipeline: needsMetadata batches raw pages into
+// fixed-size groups, and needsShortsCheck classifies and upserts each
+// batch. Splitting the stages keeps the shorts-classification batching
+// independent of the Data API's own page size.
+func (m *ChannelBackfillManager) run(channel *models.Channel) {
+	needsMetadata := make(chan models.Video, 50)
+	needsShortsCheck := make(chan []models.Video, 10)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go m.metadataStage(needsMetadata, needsShortsCheck, &wg)
+	go m.shortsCheckStage(channel.ID, needsShortsCheck, &wg)
+
+	if m.backfiller.APIKey != "" {
+		m.runViaDataAPI(channel, needsMetadata)
+	} else {
+		m.runViaPiped(channel, needsMetadata)
+	}
+
+	close(needsMetadata)
+	wg.Wait()
+	m.finish(channel.ID)
+}
+
+// runViaDataAPI pages the channel's uploads playlist via the Data API,
+// persisting the page token after each page so a restart resumes instead of
+// re-paging from scratch, and backing off on quota errors.
+func (m *ChannelBackfillManager) runViaDataAPI(channel *models.Channel, needsMetadata chan<- models.Video) {
+	channelID := yt.ExtractChannelID(channel.URL)
+	pageToken, done, err := m.db.GetChannelBackfillCursor(channel.ID)
+	if err != nil {
+		m.fail(channel.ID, err)
+		return
+	}
+	if done {
+		return
+	}
+
+	quotaRetries := 0
+	for {
+		videos, next, total, err := m.backfiller.FetchPage(channelID, pageToken)
+		if err != nil {
+			if yt.IsQuotaExceeded(err) && quotaRetries < maxQuotaRetries {
+				quotaRetries++
+				time.Sleep(quotaBackoff(quotaRetries))
+				continue
+			}
+			m.fail(channel.ID, err)
+			return
+		}
+		quotaRetries = 0
+
+		m.mu.Lock()
+		m.progress[channel.ID].Total = total
+		m.mu.Unlock()
+
+		for _, v := range videos {
+			v.ChannelID = channel.ID
+			needsMetadata <- v
+		}
+
+		if saveErr := m.db.SaveChannelBackfillCursor(channel.ID, next, next == ""); saveErr != nil {
+			log.Printf("channel backfill %d: failed to persist page token: %v", channel.ID, saveErr)
+		}
+
+		if next == "" {
+			return
+		}
+		pageToken = next
+	}
+}
+
+// runViaPiped is the fallback used when no Data API key is configured: it
+// drains yt.BackfillChannel's continuation-token iterator directly. Unlike
+// runViaDataAPI it has no persisted resume cursor - Piped's nextpage tokens
+// aren't page numbers and expire, so a restart simply starts over from the
+// channel's newest upload. Rate-limit-aware backoff across a pool of
+// channels backfilling at once comes from piped.Client itself: any instance
+// that errors or rate-limits gets disabled with exponentially growing
+// backoff (see piped.Client.markFailure), so a bad instance drops out of the
+// race instead of getting hammered.
+func (m *ChannelBackfillManager) runViaPiped(channel *models.Channel, needsMetadata chan<- models.Video) {
+	channelID := yt.ExtractChannelID(channel.URL)
+
+	for v, err := range yt.BackfillChannel(channelID, "", yt.BackfillOptions{}) {
+		if err != nil {
+			m.fail(channel.ID, err)
+			return
+		}
+		v.ChannelID = channel.ID
+		needsMetadata <- v
+	}
+}
+
+// metadataStage batches incoming videos for the shorts-check stage. The
+// playlistItems response already carries title/thumbnail/publish metadata,
+// so this stage only needs to batch, not fetch anything further.
+func (m *ChannelBackfillManager) metadataStage(in <-chan models.Video, out chan<- []models.Video, wg *sync.WaitGroup) {
+	defer wg.Done()
+	defer close(out)
+
+	const batchSize = 25
+	var batch []models.Video
+	for v := range in {
+		batch = append(batch, v)
+		if len(batch) >= batchSize {
+			out <- batch
+			batch = nil
+		}
+	}
+	if len(batch) > 0 {
+		out <- batch
+	}
+}
+
+// shortsCheckStage classifies each batch via CheckShortsStatus and upserts
+// it, updating fetched progress as videos land.
+func (m *ChannelBackfillManager) shortsCheckStage(channelID int64, in <-chan []models.Video, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	for batch := range in {
+		videoIDs := make([]string, len(batch))
+		for i, v := range batch {
+			videoIDs[i] = v.ID
+		}
+		shortsStatus := yt.CheckShortsStatus(videoIDs)
+
+		for i := range batch {
+			if isShort, ok := shortsStatus[batch[i].ID]; ok {
+				batch[i].IsShort = &isShort
+			}
+			if _, err := m.db.UpsertVideo(&batch[i]); err != nil {
+				log.Printf("channel backfill %d: failed to save video %s: %v", channelID, batch[i].ID, err)
+				continue
+			}
+
+			m.mu.Lock()
+			m.progress[channelID].Fetched++
+			m.mu.Unlock()
+		}
+	}
+}
+
+func (m *ChannelBackfillManager) fail(channelID int64, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if p, ok := m.progress[channelID]; ok {
+		p.Error = err.Error()
+	}
+}
+
+func (m *ChannelBackfillManager) finish(channelID int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if p, ok := m.progress[channelID]; ok {
+		p.Running = false
+	}
+}
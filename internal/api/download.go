@@ -1,6 +1,8 @@
 package api
 
 import (
+	"bufio"
+	"context"
 	"fmt"
 	"io"
 	"log"
@@ -8,19 +10,32 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/erik/feeds/internal/db"
 	"github.com/erik/feeds/internal/ytdlp"
 )
 
 // DownloadManager handles background video downloads and muxing
 type DownloadManager struct {
 	cache     *VideoCache
-	ytdlp     *ytdlp.YTDLP
+	store     CacheStore
+	ytdlp     ytdlp.Client
+	pool      *WorkerPool
+	persist   *db.DB // optional; enables crash-recovery of in-flight downloads
 	active    map[string]*Download
 	mu        sync.RWMutex
 	listeners map[string][]chan DownloadProgress
+
+	// throughput records the last measured delivery rate (bytes/sec) the
+	// stream proxy served a video at, keyed by video ID. SelectQuality uses
+	// it to pick a sustainable tier for "auto" quality requests; see
+	// RecordThroughput.
+	throughput map[string]float64
 }
 
 // Download represents an in-progress download
@@ -31,6 +46,22 @@ type Download struct {
 	Progress  float64
 	Error     string
 	StartedAt time.Time
+
+	// videoPath, audioPath, durationSeconds, and estimatedTotal are set once
+	// the raw streams finish downloading and muxing starts. EnsureRange uses
+	// them to spawn a secondary mux seeked near a requested byte range
+	// without re-resolving stream URLs or re-deriving the temp dir's paths.
+	videoPath       string
+	audioPath       string
+	durationSeconds float64
+	estimatedTotal  int64
+	secondaryActive bool
+
+	// TriggeredByStream is true when this download was started by
+	// handleStreamProxy buffering ahead of playback rather than by an
+	// explicit "download for offline" request - handleGetQualities uses
+	// this to report only explicit downloads as "downloading".
+	TriggeredByStream bool
 }
 
 // DownloadProgress is sent to SSE clients
@@ -41,15 +72,117 @@ type DownloadProgress struct {
 	TotalBytes      int64   `json:"totalBytes"`
 	Status          string  `json:"status"`
 	Error           string  `json:"error,omitempty"`
+	PlaylistURL     string  `json:"playlistUrl,omitempty"`
+}
+
+// streamState tracks download progress for a single video or audio stream
+type streamState struct {
+	downloaded int64 // atomic
+	total      int64 // atomic; 0 until Content-Length is known
+}
+
+func (s *streamState) addBytes(n int64) {
+	atomic.AddInt64(&s.downloaded, n)
+}
+
+func (s *streamState) setTotal(n int64) {
+	atomic.StoreInt64(&s.total, n)
+}
+
+func (s *streamState) snapshot() (downloaded, total int64) {
+	return atomic.LoadInt64(&s.downloaded), atomic.LoadInt64(&s.total)
+}
+
+// progressReader wraps an io.Reader and reports bytes read to a streamState,
+// similar to the progressReader pattern used for media fetches elsewhere.
+type progressReader struct {
+	r     io.Reader
+	state *streamState
+}
+
+func (p *progressReader) Read(buf []byte) (int, error) {
+	n, err := p.r.Read(buf)
+	if n > 0 {
+		p.state.addBytes(int64(n))
+	}
+	return n, err
+}
+
+// EnablePersistence wires up a DB so in-flight download state survives a
+// restart; call RecoverDownloads afterwards to resume anything left
+// in-flight by a crash.
+func (dm *DownloadManager) EnablePersistence(database *db.DB) {
+	dm.persist = database
+}
+
+// RecoverDownloads restarts any downloads that were left in-flight (status
+// not "complete"/"error") when the process last stopped. Segmented chunk
+// downloads resume from their .part.json manifests automatically.
+func (dm *DownloadManager) RecoverDownloads() {
+	if dm.persist == nil {
+		return
+	}
+
+	states, err := dm.persist.GetInFlightDownloads()
+	if err != nil {
+		log.Printf("[download] failed to load in-flight downloads: %v", err)
+		return
+	}
+
+	for _, s := range states {
+		log.Printf("[download] resuming in-flight download %s quality %s (was %s)", s.VideoID, s.Quality, s.Status)
+		if _, err := dm.StartDownload(s.VideoID, s.Quality, false); err != nil {
+			log.Printf("[download] failed to resume %s quality %s: %v", s.VideoID, s.Quality, err)
+		}
+	}
+}
+
+// persistState records the current status of a download for crash recovery.
+// A completed download removes the record instead, since there's nothing
+// left to recover; a failed one keeps its record with status "error", which
+// GetInFlightDownloads skips.
+func (dm *DownloadManager) persistState(videoID, quality, status string, downloaded, total int64, errMsg string) {
+	if dm.persist == nil {
+		return
+	}
+
+	if status == "complete" {
+		if err := dm.persist.DeleteDownloadState(videoID, quality); err != nil {
+			log.Printf("[download] failed to clear download state for %s/%s: %v", videoID, quality, err)
+		}
+		return
+	}
+
+	state := &db.DownloadState{
+		VideoID:         videoID,
+		Quality:         quality,
+		Status:          status,
+		BytesDownloaded: downloaded,
+		TotalBytes:      total,
+		Error:           errMsg,
+	}
+	if err := dm.persist.SaveDownloadState(state); err != nil {
+		log.Printf("[download] failed to persist download state for %s/%s: %v", videoID, quality, err)
+	}
 }
 
 // NewDownloadManager creates a new download manager
-func NewDownloadManager(cache *VideoCache, yt *ytdlp.YTDLP) *DownloadManager {
+func NewDownloadManager(cache *VideoCache, yt ytdlp.Client) *DownloadManager {
+	return NewDownloadManagerWithStore(cache, yt, NewLocalCacheStore(cache.Dir()))
+}
+
+// NewDownloadManagerWithStore creates a download manager backed by a
+// specific CacheStore, allowing deployments to swap in S3/GCS-backed
+// storage for cached MP4s instead of the local disk default.
+func NewDownloadManagerWithStore(cache *VideoCache, yt ytdlp.Client, store CacheStore) *DownloadManager {
 	return &DownloadManager{
-		cache:     cache,
-		ytdlp:     yt,
-		active:    make(map[string]*Download),
-		listeners: make(map[string][]chan DownloadProgress),
+		cache:      cache,
+		store:      store,
+		ytdlp:      yt,
+		pool:       NewWorkerPool(defaultMaxConcurrentDownloads, defaultMaxChunksPerHost),
+		active:     make(map[string]*Download),
+		listeners:  make(map[string][]chan DownloadProgress),
+		throughput: make(map[string]float64),
 	}
 }
 
@@ -57,8 +190,23 @@ func downloadKey(videoID, quality string) string {
 	return videoID + ":" + quality
 }
 
-// StartDownload initiates a background download for the given video and quality
-func (dm *DownloadManager) StartDownload(videoID, quality string) (*Download, error) {
+// presignTTL bounds how long a URL returned by CachedURL stays valid before
+// a client must mint a fresh one via GET /api/videos/{id}/{quality}/url.
+const presignTTL = 6 * time.Hour
+
+// CachedURL returns how a client should fetch cacheKey: a time-limited
+// presigned URL when dm's store is remote object storage, or "" when it
+// should stream through handleStreamProxy instead - the local disk store's
+// PresignedURL always returns "".
+func (dm *DownloadManager) CachedURL(cacheKey string) (string, error) {
+	return dm.store.PresignedURL(cacheKey, presignTTL)
+}
+
+// StartDownload initiates a background download for the given video and
+// quality. fromStream should be true only when called from
+// handleStreamProxy's playback-buffering path, not an explicit download
+// request - see Download.TriggeredByStream.
+func (dm *DownloadManager) StartDownload(videoID, quality string, fromStream bool) (*Download, error) {
 	key := downloadKey(videoID, quality)
 	cacheKey := CacheKey(videoID, quality)
 
@@ -78,12 +226,14 @@ func (dm *DownloadManager) StartDownload(videoID, quality string) (*Download, er
 		return d, nil
 	}
 
-	// Create new download
+	// Create new download. Status starts "queued" until a worker pool slot
+	// is free; runDownload flips it to "downloading" once it acquires one.
 	d := &Download{
-		VideoID:   videoID,
-		Quality:   quality,
-		Status:    "downloading",
-		StartedAt: time.Now(),
+		VideoID:           videoID,
+		Quality:           quality,
+		Status:            "queued",
+		StartedAt:         time.Now(),
+		TriggeredByStream: fromStream,
 	}
 	dm.active[key] = d
 	dm.mu.Unlock()
@@ -108,6 +258,28 @@ func (dm *DownloadManager) GetStatus(videoID string) map[string]*Download {
 	return result
 }
 
+// RecordThroughput records the delivery rate the stream proxy measured for
+// videoID's most recently served response, so the next "auto" quality
+// request for it can pick a tier that rate can sustain (see SelectQuality).
+func (dm *DownloadManager) RecordThroughput(videoID string, bytesPerSecond float64) {
+	dm.mu.Lock()
+	dm.throughput[videoID] = bytesPerSecond
+	dm.mu.Unlock()
+}
+
+// SelectQuality picks the quality an "auto" stream request should use: the
+// highest tier the last throughput measurement for videoID can sustain, or
+// selectBestQuality's default if nothing's been measured yet.
+func (dm *DownloadManager) SelectQuality(videoID string) string {
+	dm.mu.RLock()
+	bps, ok := dm.throughput[videoID]
+	dm.mu.RUnlock()
+	if !ok {
+		return selectBestQuality()
+	}
+	return qualityForThroughput(bps)
+}
+
 // Subscribe returns a channel that receives progress updates for a video
 func (dm *DownloadManager) Subscribe(videoID string) chan DownloadProgress {
 	ch := make(chan DownloadProgress, 10)
@@ -135,6 +307,8 @@ func (dm *DownloadManager) Unsubscribe(videoID string, ch chan DownloadProgress)
 }
 
 func (dm *DownloadManager) broadcast(videoID string, progress DownloadProgress) {
+	dm.persistState(videoID, progress.Quality, progress.Status, progress.BytesDownloaded, progress.TotalBytes, progress.Error)
+
 	dm.mu.RLock()
 	listeners := dm.listeners[videoID]
 	dm.mu.RUnlock()
@@ -170,6 +344,17 @@ func (dm *DownloadManager) runDownload(videoID, quality, key, cacheKey string) {
 		dm.mu.Unlock()
 	}()
 
+	// Wait for a worker pool slot; the download stays "queued" in the
+	// status API until one frees up.
+	release := dm.pool.AcquireDownload()
+	defer release()
+
+	dm.mu.Lock()
+	if d, exists := dm.active[key]; exists {
+		d.Status = "downloading"
+	}
+	dm.mu.Unlock()
+
 	videoURL := "https://www.youtube.com/watch?v=" + videoID
 
 	log.Printf("Starting download for %s quality %s", videoID, quality)
@@ -199,26 +384,28 @@ func (dm *DownloadManager) runDownload(videoID, quality, key, cacheKey string) {
 	videoPath := filepath.Join(tempDir, "video.mp4")
 	audioPath := filepath.Join(tempDir, "audio.m4a")
 
-	// Download video and audio in parallel
+	// Download video and audio in parallel, tracking real Content-Length totals
 	var wg sync.WaitGroup
 	var videoErr, audioErr error
 	var videoSize, audioSize int64
+	videoState := &streamState{}
+	audioState := &streamState{}
 
 	wg.Add(2)
 
 	go func() {
 		defer wg.Done()
-		videoSize, videoErr = dm.downloadFile(videoStreamURL, videoPath)
+		videoSize, videoErr = dm.downloadFileSegmented(videoStreamURL, videoPath, videoState)
 		log.Printf("Video download finished for %s: %d bytes, err=%v", videoID, videoSize, videoErr)
 	}()
 
 	go func() {
 		defer wg.Done()
-		audioSize, audioErr = dm.downloadFile(audioStreamURL, audioPath)
+		audioSize, audioErr = dm.downloadFileSegmented(audioStreamURL, audioPath, audioState)
 		log.Printf("Audio download finished for %s: %d bytes, err=%v", videoID, audioSize, audioErr)
 	}()
 
-	// Monitor progress while downloading
+	// Monitor progress while downloading, summing real totals across both streams
 	go func() {
 		ticker := time.NewTicker(500 * time.Millisecond)
 		defer ticker.Stop()
@@ -235,25 +422,21 @@ func (dm *DownloadManager) runDownload(videoID, quality, key, cacheKey string) {
 					return
 				}
 
-				var downloaded int64
-				if info, err := os.Stat(videoPath); err == nil {
-					downloaded += info.Size()
-				}
-				if info, err := os.Stat(audioPath); err == nil {
-					downloaded += info.Size()
-				}
+				videoDownloaded, videoTotal := videoState.snapshot()
+				audioDownloaded, audioTotal := audioState.snapshot()
+				downloaded := videoDownloaded + audioDownloaded
+				total := videoTotal + audioTotal
 
-				// Update with rough progress - estimate ~15MB total for a typical video
-				estimatedTotal := int64(15 * 1024 * 1024)
-				percent := float64(downloaded) / float64(estimatedTotal) * 100
-				if percent > 95 {
-					percent = 95 // Cap at 95% until muxing is done
+				var percent float64
+				if total > 0 {
+					percent = float64(downloaded) / float64(total) * 100
 				}
 
 				progress := DownloadProgress{
 					Quality:         quality,
 					Percent:         percent,
 					BytesDownloaded: downloaded,
+					TotalBytes:      total,
 					Status:          d.Status,
 				}
 				dm.broadcast(videoID, progress)
@@ -272,10 +455,18 @@ func (dm *DownloadManager) runDownload(videoID, quality, key, cacheKey string) {
 		return
 	}
 
-	// Update status to muxing
+	// Update status to muxing, and record the now-complete raw paths, probed
+	// duration, and estimated output size so EnsureRange can spawn a
+	// secondary seeked mux if a Range request lands ahead of the primary
+	// mux's progress.
+	durationSeconds := probeDurationSeconds(videoPath)
 	dm.mu.Lock()
 	if d, exists := dm.active[key]; exists {
 		d.Status = "muxing"
+		d.videoPath = videoPath
+		d.audioPath = audioPath
+		d.durationSeconds = durationSeconds
+		d.estimatedTotal = videoSize + audioSize
 	}
 	dm.mu.Unlock()
 
@@ -283,45 +474,301 @@ func (dm *DownloadManager) runDownload(videoID, quality, key, cacheKey string) {
 
 	dm.broadcast(videoID, DownloadProgress{
 		Quality:         quality,
-		Percent:         95,
+		Percent:         0,
 		BytesDownloaded: videoSize + audioSize,
 		TotalBytes:      videoSize + audioSize,
 		Status:          "muxing",
 	})
 
-	// Mux with ffmpeg
-	outputPath := dm.cache.CachePath(cacheKey)
-	tempOutput := outputPath + ".tmp"
+	// Mux with ffmpeg, streaming the muxed bytes straight into the cache
+	// store (local disk or S3/GCS) instead of writing to a temp file and
+	// renaming, while still reporting real mux progress via -progress pipe:2.
+	// videoSize+audioSize is a reasonable estimate of the remuxed output's
+	// final size (-c copy only remuxes, it doesn't re-encode), used to
+	// pre-size a sparse cache file readers can start range-requesting into
+	// before the mux finishes - see muxIntoStore.
+	if err := dm.muxIntoStore(context.Background(), videoID, quality, cacheKey, videoPath, audioPath, durationSeconds, videoSize+audioSize); err != nil {
+		dm.setError(key, videoID, quality, fmt.Sprintf("Muxing failed: %v", err))
+		return
+	}
+
+	log.Printf("Download complete: %s quality %s, cached as %s", videoID, quality, cacheKey)
+
+	// Record an integrity manifest for the file the local cache store just
+	// wrote, so VideoCache.Get can detect /tmp-mount corruption before
+	// serving it. Writing is skipped (with a log, not an error) when the
+	// store isn't local disk - cacheKey has nothing under the cache dir to
+	// hash in that case.
+	if err := dm.cache.WriteManifest(cacheKey); err != nil {
+		log.Printf("Failed to write integrity manifest for %s: %v", cacheKey, err)
+	}
 
+	dm.broadcast(videoID, DownloadProgress{
+		Quality: quality,
+		Percent: 100,
+		Status:  "complete",
+	})
+}
+
+// muxIntoStore runs ffmpeg to mux the given video/audio files and streams
+// the muxed fragmented-MP4 bytes from its stdout directly into the cache
+// store's Put, rather than muxing to a temp file and renaming. Progress is
+// parsed from `-progress pipe:2` (stderr), which is kept free of the muxed
+// payload carried on stdout.
+//
+// When the store is the local on-disk cache, the stream is instead routed
+// through fillLocalCache, which pre-sizes a sparse cache file (estimatedSize)
+// and fills it block by block, letting VideoCache.GetRange serve a range
+// request before the mux finishes. S3/GCS-backed stores don't support
+// partial reads the same way, so they keep using the existing Put(-1) path.
+func (dm *DownloadManager) muxIntoStore(ctx context.Context, videoID, quality, cacheKey, videoPath, audioPath string, durationSeconds float64, estimatedSize int64) error {
 	cmd := exec.Command(
 		"ffmpeg",
 		"-y",
 		"-i", videoPath,
 		"-i", audioPath,
 		"-c", "copy",
-		"-movflags", "+faststart",
+		"-movflags", "frag_keyframe+empty_moov+default_base_moof",
 		"-f", "mp4",
-		tempOutput,
+		"-progress", "pipe:2",
+		"-nostats",
+		"pipe:1",
 	)
 
-	if err := cmd.Run(); err != nil {
-		dm.setError(key, videoID, quality, fmt.Sprintf("Muxing failed: %v", err))
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	go dm.watchMuxProgress(videoID, quality, stderr, durationSeconds)
+
+	var putErr error
+	if _, ok := dm.store.(*localCacheStore); ok {
+		putErr = dm.fillLocalCache(cacheKey, stdout, estimatedSize)
+	} else {
+		putErr = dm.store.Put(ctx, cacheKey, stdout, -1)
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return err
+	}
+	return putErr
+}
+
+// fillLocalCache streams r into a sparse cache file via VideoCache.OpenForFill
+// so readers can start range-requesting the file before r is fully drained,
+// instead of waiting for the whole mux to finish as store.Put requires.
+func (dm *DownloadManager) fillLocalCache(cacheKey string, r io.Reader, estimatedSize int64) error {
+	fw, err := dm.cache.OpenForFill(cacheKey, estimatedSize)
+	if err != nil {
+		return err
+	}
+
+	var offset int64
+	buf := make([]byte, fillBlockSize)
+	var readErr error
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			if werr := fw.WriteBlock(offset, buf[:n]); werr != nil {
+				readErr = werr
+				break
+			}
+			offset += int64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			readErr = err
+			break
+		}
+	}
+
+	closeSize := int64(-1)
+	if readErr == nil {
+		closeSize = offset
+	}
+	if cerr := fw.Close(closeSize); cerr != nil && readErr == nil {
+		readErr = cerr
+	}
+	return readErr
+}
+
+// watchMuxProgress reads ffmpeg's `-progress` key=value stream and
+// broadcasts a 0-100 muxing percentage based on out_time vs source duration.
+func (dm *DownloadManager) watchMuxProgress(videoID, quality string, r io.Reader, durationSeconds float64) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "out_time_ms":
+			if durationSeconds <= 0 {
+				continue
+			}
+			outTimeMs, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				continue
+			}
+			percent := (outTimeMs / 1_000_000) / durationSeconds * 100
+			if percent > 100 {
+				percent = 100
+			}
+			dm.broadcast(videoID, DownloadProgress{
+				Quality: quality,
+				Percent: percent,
+				Status:  "muxing",
+			})
+		case "progress":
+			if value == "end" {
+				dm.broadcast(videoID, DownloadProgress{
+					Quality: quality,
+					Percent: 100,
+					Status:  "muxing",
+				})
+			}
+		}
+	}
+}
+
+// probeDurationSeconds uses ffprobe to get the duration of a media file, or
+// 0 if it can't be determined (muxing progress will then stay at 0 until done).
+func probeDurationSeconds(path string) float64 {
+	out, err := exec.Command(
+		"ffprobe",
+		"-v", "error",
+		"-show_entries", "format=duration",
+		"-of", "default=noprint_wrappers=1:nokey=1",
+		path,
+	).Output()
+	if err != nil {
+		return 0
+	}
+
+	duration, err := strconv.ParseFloat(strings.TrimSpace(string(out)), 64)
+	if err != nil {
+		return 0
+	}
+	return duration
+}
+
+// EnsureRange nudges an in-progress download to make [start, end) available
+// sooner, for a Range request that's seeked ahead of what the primary mux
+// has written so far. It only acts once the raw video/audio are fully
+// downloaded (status "muxing") - by then videoPath/audioPath are complete
+// local files ffmpeg can seek into directly - and only if the gap between
+// the requested start and the primary mux's sequential progress is big
+// enough to be worth a second ffmpeg run. At most one secondary fetch runs
+// per download at a time; a later call while one is already in flight is a
+// no-op, since a second seek will usually land further ahead anyway once the
+// client's next Range request arrives.
+func (dm *DownloadManager) EnsureRange(videoID, quality string, start, end int64) {
+	key := downloadKey(videoID, quality)
+	cacheKey := CacheKey(videoID, quality)
+
+	dm.mu.Lock()
+	d, exists := dm.active[key]
+	if !exists || d.Status != "muxing" || d.secondaryActive || d.estimatedTotal <= 0 || d.durationSeconds <= 0 {
+		dm.mu.Unlock()
 		return
 	}
+	videoPath, audioPath := d.videoPath, d.audioPath
+	durationSeconds, estimatedTotal := d.durationSeconds, d.estimatedTotal
+	dm.mu.Unlock()
 
-	// Move to final location
-	if err := os.Rename(tempOutput, outputPath); err != nil {
-		dm.setError(key, videoID, quality, fmt.Sprintf("Failed to save file: %v", err))
+	fw := dm.cache.FillWriterFor(cacheKey)
+	if fw == nil || start <= fw.WrittenThrough()+fillBlockSize {
+		// The primary mux is already at or near start - let it catch up
+		// naturally rather than paying for a redundant ffmpeg run.
 		return
 	}
 
-	log.Printf("Download complete: %s quality %s, saved to %s", videoID, quality, outputPath)
+	dm.mu.Lock()
+	d.secondaryActive = true
+	dm.mu.Unlock()
 
-	dm.broadcast(videoID, DownloadProgress{
-		Quality: quality,
-		Percent: 100,
-		Status:  "complete",
-	})
+	go dm.fetchSecondaryRange(key, videoID, quality, videoPath, audioPath, durationSeconds, estimatedTotal, start, fw)
+}
+
+// fetchSecondaryRange runs a second ffmpeg mux seeked to the playback
+// timestamp start's fraction of estimatedTotal corresponds to, and writes
+// its output into fw at the same fractional byte offset. The mapping from
+// byte offset to seek time is an estimate - codec-copy bitrate isn't
+// perfectly constant over a video - so the written bytes won't line up
+// exactly with what the primary sequential mux would eventually produce at
+// that offset, but they're close enough to unblock a GetRange wait well
+// before the primary mux arrives there on its own.
+func (dm *DownloadManager) fetchSecondaryRange(key, videoID, quality, videoPath, audioPath string, durationSeconds float64, estimatedTotal, start int64, fw *FillWriter) {
+	defer func() {
+		dm.mu.Lock()
+		if d, exists := dm.active[key]; exists {
+			d.secondaryActive = false
+		}
+		dm.mu.Unlock()
+	}()
+
+	seekSeconds := float64(start) / float64(estimatedTotal) * durationSeconds
+	if seekSeconds < 0 {
+		seekSeconds = 0
+	}
+	seek := strconv.FormatFloat(seekSeconds, 'f', 2, 64)
+
+	cmd := exec.Command(
+		"ffmpeg",
+		"-y",
+		"-ss", seek,
+		"-i", videoPath,
+		"-ss", seek,
+		"-i", audioPath,
+		"-c", "copy",
+		"-movflags", "frag_keyframe+empty_moov+default_base_moof",
+		"-f", "mp4",
+		"-nostats",
+		"-loglevel", "error",
+		"pipe:1",
+	)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		log.Printf("[download] secondary range fetch for %s quality %s: %v", videoID, quality, err)
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		log.Printf("[download] secondary range fetch for %s quality %s: %v", videoID, quality, err)
+		return
+	}
+
+	offset := (start / fillBlockSize) * fillBlockSize
+	buf := make([]byte, fillBlockSize)
+	for {
+		n, rerr := io.ReadFull(stdout, buf)
+		if n > 0 {
+			if werr := fw.WriteBlock(offset, buf[:n]); werr != nil {
+				log.Printf("[download] secondary range fetch for %s quality %s: write failed: %v", videoID, quality, werr)
+				break
+			}
+			offset += int64(n)
+		}
+		if rerr != nil {
+			break
+		}
+	}
+
+	if err := cmd.Wait(); err != nil {
+		log.Printf("[download] secondary range fetch for %s quality %s exited with error: %v", videoID, quality, err)
+	}
 }
 
 func (dm *DownloadManager) setError(key, videoID, quality, errMsg string) {
@@ -341,7 +788,9 @@ func (dm *DownloadManager) setError(key, videoID, quality, errMsg string) {
 	})
 }
 
-func (dm *DownloadManager) downloadFile(url, destPath string) (int64, error) {
+// downloadFile streams url to destPath, recording Content-Length and
+// bytes-read progress into state via a byte-counting reader.
+func (dm *DownloadManager) downloadFile(url, destPath string, state *streamState) (int64, error) {
 	resp, err := http.Get(url)
 	if err != nil {
 		return 0, err
@@ -352,12 +801,16 @@ func (dm *DownloadManager) downloadFile(url, destPath string) (int64, error) {
 		return 0, fmt.Errorf("HTTP %d", resp.StatusCode)
 	}
 
+	if resp.ContentLength > 0 {
+		state.setTotal(resp.ContentLength)
+	}
+
 	f, err := os.Create(destPath)
 	if err != nil {
 		return 0, err
 	}
 	defer f.Close()
 
-	n, err := io.Copy(f, resp.Body)
+	n, err := io.Copy(f, &progressReader{r: resp.Body, state: state})
 	return n, err
 }
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// fillBlockSize is the granularity OpenForFill tracks block presence at.
+// Smaller blocks let a range request start flowing sooner after the fill
+// reaches it; larger blocks shrink the presence bitmap. 1 MiB matches the
+// smallest GetBufferThreshold tier, so a range request at the low-quality
+// buffer threshold typically only waits on a couple of blocks.
+const fillBlockSize = 1 * 1024 * 1024
+
+// FillWriter lets a cache file be served to range-requesting readers before
+// it's fully written: OpenForFill creates a sparse file of the estimated
+// final size up front, and WriteBlock fills it in arbitrary order as bytes
+// become available (e.g. from a streaming ffmpeg mux), marking each block
+// present as it lands. Readers call WaitRange to block until every block
+// overlapping a byte range is present, then read the file directly.
+type FillWriter struct {
+	vc        *VideoCache
+	key       string
+	file      *os.File
+	numBlocks int
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	present []bool
+	closed  bool
+}
+
+// OpenForFill creates a sparse file for key sized totalSize (an estimate -
+// Close corrects it to the real size once known) and registers it so
+// GetRange and evict are aware a fill is in progress. The caller must call
+// Close when done writing, even on error, to release the file and
+// unregister the fill.
+func (vc *VideoCache) OpenForFill(key string, totalSize int64) (*FillWriter, error) {
+	path := vc.CachePath(key)
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Truncate(totalSize); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	numBlocks := int((totalSize + fillBlockSize - 1) / fillBlockSize)
+	fw := &FillWriter{
+		vc:        vc,
+		key:       key,
+		file:      f,
+		numBlocks: numBlocks,
+		present:   make([]bool, numBlocks),
+	}
+	fw.cond = sync.NewCond(&fw.mu)
+
+	vc.registerFill(key, fw)
+	return fw, nil
+}
+
+// WriteBlock writes data at offset and marks the blocks it covers present,
+// waking any reader waiting on WaitRange. offset need not be block-aligned,
+// but writes from a sequential streaming source (the expected use) always
+// are.
+func (fw *FillWriter) WriteBlock(offset int64, data []byte) error {
+	if _, err := fw.file.WriteAt(data, offset); err != nil {
+		return err
+	}
+
+	first := int(offset / fillBlockSize)
+	last := int((offset + int64(len(data)) - 1) / fillBlockSize)
+
+	fw.mu.Lock()
+	for b := first; b <= last && b < fw.numBlocks; b++ {
+		fw.present[b] = true
+	}
+	fw.mu.Unlock()
+	fw.cond.Broadcast()
+
+	return nil
+}
+
+// WaitRange blocks until every block overlapping [start, end) is present,
+// then returns true. It returns false without waiting further if the fill
+// is closed before the range becomes fully present (e.g. the download
+// failed partway through).
+func (fw *FillWriter) WaitRange(start, end int64) bool {
+	first := int(start / fillBlockSize)
+	last := int((end - 1) / fillBlockSize)
+
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	for {
+		ready := true
+		for b := first; b <= last && b < fw.numBlocks; b++ {
+			if !fw.present[b] {
+				ready = false
+				break
+			}
+		}
+		if ready {
+			return true
+		}
+		if fw.closed {
+			return false
+		}
+		fw.cond.Wait()
+	}
+}
+
+// WrittenThrough returns the byte offset up to which every block from the
+// start of the file is present - the sequential "head" of the primary
+// writer's progress, ignoring any blocks written out of order beyond the
+// first gap (e.g. by a secondary range fetch; see DownloadManager.EnsureRange).
+func (fw *FillWriter) WrittenThrough() int64 {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	for i, present := range fw.present {
+		if !present {
+			return int64(i) * fillBlockSize
+		}
+	}
+	return int64(fw.numBlocks) * fillBlockSize
+}
+
+// Done reports whether every block has been written.
+func (fw *FillWriter) Done() bool {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	for _, p := range fw.present {
+		if !p {
+			return false
+		}
+	}
+	return true
+}
+
+// Close finishes the fill: actualSize, when >= 0, corrects the file's
+// length to the real total written (the upfront OpenForFill estimate won't
+// exactly match a remuxed file's final size), unregisters the fill from its
+// VideoCache, and wakes any readers still blocked in WaitRange so they see
+// the fill as closed rather than hanging forever on a range that will now
+// never arrive.
+func (fw *FillWriter) Close(actualSize int64) error {
+	fw.mu.Lock()
+	fw.closed = true
+	fw.mu.Unlock()
+	fw.cond.Broadcast()
+
+	fw.vc.unregisterFill(fw.key)
+
+	var err error
+	if actualSize >= 0 {
+		err = fw.file.Truncate(actualSize)
+	}
+	if cerr := fw.file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("closing fill for %s: %w", fw.key, err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net"
+
+	"google.golang.org/grpc"
+
+	"github.com/erik/feeds/internal/rpc"
+	"github.com/erik/feeds/internal/rpc/downloadpb"
+)
+
+// rpcDownloadManager adapts DownloadManager to rpc.DownloadManager, so
+// internal/rpc's gRPC service can drive downloads without importing
+// internal/api (which would otherwise create an import cycle, since this
+// package registers that service - see StartGRPC).
+type rpcDownloadManager struct {
+	dm *DownloadManager
+}
+
+func (a *rpcDownloadManager) StartDownload(videoID, quality string) error {
+	_, err := a.dm.StartDownload(videoID, quality, false)
+	return err
+}
+
+// Subscribe bridges DownloadManager's Subscribe/Unsubscribe pair to
+// rpc.DownloadManager's single context-scoped Subscribe, translating each
+// DownloadProgress into rpc.Progress until ctx is canceled or a terminal
+// update (Status "complete" or "error") is delivered - unsubscribing from
+// dm either way.
+func (a *rpcDownloadManager) Subscribe(ctx context.Context, videoID string) <-chan rpc.Progress {
+	src := a.dm.Subscribe(videoID)
+	out := make(chan rpc.Progress)
+
+	go func() {
+		defer close(out)
+		defer a.dm.Unsubscribe(videoID, src)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case p, ok := <-src:
+				if !ok {
+					return
+				}
+
+				progress := rpc.Progress{
+					Quality:         p.Quality,
+					Percent:         p.Percent,
+					BytesDownloaded: p.BytesDownloaded,
+					TotalBytes:      p.TotalBytes,
+					Status:          p.Status,
+					Error:           p.Error,
+				}
+
+				select {
+				case out <- progress:
+				case <-ctx.Done():
+					return
+				}
+
+				if p.Status == "complete" || p.Status == "error" {
+					return
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// StartGRPC starts feeds' gRPC API (currently just DownloadService, mirroring
+// handleStartDownload+handleDownloadStatus for non-browser clients) on addr,
+// on its own listener alongside the HTTP mux RegisterRoutes wires up. It
+// returns once the listener is bound; the server itself runs in a background
+// goroutine until Shutdown calls GracefulStop.
+func (s *Server) StartGRPC(addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	s.grpcServer = grpc.NewServer()
+	downloadpb.RegisterDownloadServiceServer(s.grpcServer, rpc.NewDownloadService(&rpcDownloadManager{dm: s.downloadManager}))
+
+	go func() {
+		log.Printf("gRPC server listening on %s", addr)
+		if err := s.grpcServer.Serve(lis); err != nil {
+			log.Printf("gRPC server stopped: %v", err)
+		}
+	}()
+
+	return nil
+}
@@ -9,27 +9,59 @@ import (
 	"io/fs"
 	"log"
 	"net/http"
-	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"google.golang.org/grpc"
+
 	"github.com/erik/feeds/internal/db"
+	"github.com/erik/feeds/internal/downloader"
+	"github.com/erik/feeds/internal/jobs"
+	"github.com/erik/feeds/internal/livestream"
 	"github.com/erik/feeds/internal/models"
+	"github.com/erik/feeds/internal/scheduler"
+	"github.com/erik/feeds/internal/sources"
 	"github.com/erik/feeds/internal/sponsorblock"
 	"github.com/erik/feeds/internal/youtube"
 	"github.com/erik/feeds/internal/ytdlp"
 )
 
 type Server struct {
-	db           *db.DB
-	ytdlp        *ytdlp.YTDLP
-	sponsorblock *sponsorblock.Client
-	templates       *template.Template
-	packs           fs.FS
-	videoCache      *VideoCache
-	downloadManager *DownloadManager
+	db    *db.DB
+	ytdlp ytdlp.Client
+
+	// cookiesPath is the yt-dlp cookies file path, read from the concrete
+	// *ytdlp.YTDLP at construction time rather than through the ytdlp.Client
+	// interface - cookies are a yt-dlp-CLI-specific concept the native
+	// backend has no use for, so it's empty for that backend.
+	cookiesPath            string
+	sponsorblock           *sponsorblock.Client
+	sponsorblockWorker     *sponsorblock.Worker
+	livestreamPoller       *livestream.Poller
+	templates              *template.Template
+	packs                  fs.FS
+	packsDir               string
+	podcastWorker          *PodcastEpisodeWorker
+	episodesDir            string
+	videoCache             *VideoCache
+	downloadManager        *DownloadManager
+	transcodeManager       *TranscodeManager
+	downloadQueue          *downloader.Queue
+	backfillManager        *BackfillManager
+	channelBackfillManager *ChannelBackfillManager
+	scheduler              *scheduler.Scheduler
+	jobQueue               *jobs.Queue
+
+	// grpcServer is non-nil once StartGRPC has bound its listener, so
+	// Shutdown knows whether there's a gRPC server to drain.
+	grpcServer *grpc.Server
+
+	// qualityProbe enumerates a video's real available qualities instead of
+	// assuming a fixed rung list - see handleGetQualities.
+	qualityProbe *qualityProbe
 
 	// Stream URL cache (video ID -> cached entry)
 	streamCache   map[string]*streamCacheEntry
@@ -46,7 +78,7 @@ type streamCacheEntry struct {
 	expiresAt  time.Time
 }
 
-func NewServer(database *db.DB, yt *ytdlp.YTDLP, templatesFS fs.FS, packsFS fs.FS) (*Server, error) {
+func NewServer(database *db.DB, yt ytdlp.Client, youtubeAPIKey string, dlConcurrency int, maxDownloadLibraryBytes int64, sponsorblockPrivacyMode bool, clusterHostname string, s3Config S3Config, templatesFS fs.FS, packsFS fs.FS, packsDir string, episodesDir string) (*Server, error) {
 	funcMap := template.FuncMap{
 		"div": func(a, b int) int { return a / b },
 		"mod": func(a, b int) int { return a % b },
@@ -58,23 +90,101 @@ func NewServer(database *db.DB, yt *ytdlp.YTDLP, templatesFS fs.FS, packsFS fs.F
 		return nil, err
 	}
 
-	// Ensure Inbox system feed exists
-	if _, err := database.EnsureInboxExists(); err != nil {
+	// Ensure the default account's Inbox system feed exists
+	if _, err := database.EnsureInboxExists(db.DefaultUserID); err != nil {
 		return nil, fmt.Errorf("failed to create Inbox: %w", err)
 	}
 
-	videoCache := NewVideoCache()
+	videoCache := NewVideoCache(DefaultVideoCacheConfig())
+	cacheStore, err := newCacheStoreFromConfig(videoCache, s3Config)
+	if err != nil {
+		return nil, err
+	}
+	downloadManager := NewDownloadManagerWithStore(videoCache, yt, cacheStore)
+	downloadManager.EnablePersistence(database)
+	downloadManager.RecoverDownloads()
+
+	backfillManager := NewBackfillManager(database, yt)
+	channelBackfillManager := NewChannelBackfillManager(database, youtubeAPIKey)
+
+	// Register video source backends, most specific first; RSS is the
+	// generic catch-all so it's tried last.
+	sources.Register(sources.NewYouTubeSource(yt))
+	sources.Register(sources.NewPeerTubeSource())
+	sources.Register(sources.NewRSSSource())
+
+	sponsorblockClient := sponsorblock.NewClient()
+	sponsorblockClient.PrivacyMode = sponsorblockPrivacyMode
+
+	cookiesPath := ""
+	var downloadQueue *downloader.Queue
+	// yt may be wrapped in a *ytdlp.FallbackClient (see ytdlp.NewFallbackClient
+	// in cmd/server/main.go); unwrap it to see the concrete backend underneath.
+	underlyingYT := yt
+	if fb, ok := yt.(*ytdlp.FallbackClient); ok {
+		underlyingYT = fb.Primary()
+	}
+	if concrete, ok := underlyingYT.(*ytdlp.YTDLP); ok {
+		cookiesPath = concrete.CookiesPath
+		// The download queue drives yt-dlp directly, so it only exists when
+		// the yt-dlp CLI backend is in use; the native backend has no
+		// equivalent of DownloadVideoWithProgress.
+		downloadQueue = downloader.NewQueue(database, concrete, dlConcurrency, maxDownloadLibraryBytes)
+		downloadQueue.Start()
+	}
+
+	s := &Server{
+		db:                     database,
+		ytdlp:                  yt,
+		cookiesPath:            cookiesPath,
+		sponsorblock:           sponsorblockClient,
+		sponsorblockWorker:     sponsorblock.NewWorker(database, sponsorblockClient),
+		livestreamPoller:       livestream.NewPoller(database, yt),
+		templates:              tmpl,
+		packs:                  packsFS,
+		packsDir:               packsDir,
+		podcastWorker:          NewPodcastEpisodeWorker(database, yt, episodesDir),
+		episodesDir:            episodesDir,
+		videoCache:             videoCache,
+		downloadManager:        downloadManager,
+		transcodeManager:       NewTranscodeManager(yt, downloadManager),
+		downloadQueue:          downloadQueue,
+		backfillManager:        backfillManager,
+		channelBackfillManager: channelBackfillManager,
+		qualityProbe:           newQualityProbe(yt),
+		streamCache:            make(map[string]*streamCacheEntry),
+	}
+
+	// The scheduler replaces user-initiated refresh as the primary path: it
+	// calls back into s.runScheduledFeedRefresh on each feed's own interval.
+	// clusterHostname identifies this instance's claims when several
+	// instances share this database - see scheduler.Scheduler and
+	// GET /api/cluster/workers.
+	s.scheduler = scheduler.New(database, s.runScheduledFeedRefresh, clusterHostname)
+	s.scheduler.Start(context.Background())
+	s.sponsorblockWorker.Start(context.Background())
+	s.livestreamPoller.Start(context.Background())
+	s.podcastWorker.Start(context.Background())
+
+	s.jobQueue = jobs.NewQueue(database)
+	s.registerJobHandlers()
+	s.jobQueue.Start(3)
+
+	return s, nil
+}
 
-	return &Server{
-		db:              database,
-		ytdlp:           yt,
-		sponsorblock:    sponsorblock.NewClient(),
-		templates:       tmpl,
-		packs:           packsFS,
-		videoCache:      videoCache,
-		downloadManager: NewDownloadManager(videoCache, yt),
-		streamCache:     make(map[string]*streamCacheEntry),
-	}, nil
+// Shutdown drains the scheduler's in-flight refreshes, the SponsorBlock
+// worker's in-flight batch, the live-stream poller's in-flight batch, and
+// the podcast episode worker's in-flight batch before returning. It does
+// not close the database or stop the download manager.
+func (s *Server) Shutdown() {
+	s.scheduler.Stop()
+	s.sponsorblockWorker.Stop()
+	s.livestreamPoller.Stop()
+	s.podcastWorker.Stop()
+	if s.grpcServer != nil {
+		s.grpcServer.GracefulStop()
+	}
 }
 
 // htmx helpers
@@ -89,71 +199,134 @@ func htmxRedirect(w http.ResponseWriter, url string) {
 }
 
 func (s *Server) RegisterRoutes(mux *http.ServeMux) {
+	register := func(pattern string, handler http.HandlerFunc) {
+		mux.HandleFunc(pattern, s.requireAuth(handler))
+	}
+
 	// Legacy template-based routes (will be removed once SPA is complete)
-	mux.HandleFunc("GET /legacy/{$}", s.handleIndex)
-	mux.HandleFunc("GET /legacy/import", s.handleImportPage)
-	mux.HandleFunc("POST /legacy/import", s.handleImport)
-	mux.HandleFunc("POST /legacy/import/url", s.handleImportURL)
-	mux.HandleFunc("POST /legacy/import/file", s.handleImportFile)
-	mux.HandleFunc("POST /legacy/import/confirm", s.handleConfirmOrganize)
-	mux.HandleFunc("GET /legacy/feeds/{id}", s.handleFeedPage)
-	mux.HandleFunc("GET /legacy/channels/{id}", s.handleChannelPage)
-	mux.HandleFunc("GET /legacy/watch/{id}", s.handleWatchPage)
-	mux.HandleFunc("GET /legacy/all", s.handleAllRecent)
-	mux.HandleFunc("GET /legacy/history", s.handleHistory)
+	register("GET /legacy/{$}", s.handleIndex)
+	register("GET /legacy/import", s.handleImportPage)
+	register("POST /legacy/import", s.handleImport)
+	register("POST /legacy/import/url", s.handleImportURL)
+	register("POST /legacy/import/file", s.handleImportFile)
+	register("POST /legacy/import/confirm", s.handleConfirmOrganize)
+	register("GET /legacy/feeds/{id}", s.handleFeedPage)
+	register("GET /legacy/channels/{id}", s.handleChannelPage)
+	register("GET /legacy/watch/{id}", s.handleWatchPage)
+	register("GET /legacy/all", s.handleAllRecent)
+	register("GET /legacy/history", s.handleHistory)
 
 	// JSON API routes for SPA
-	mux.HandleFunc("GET /api/feeds", s.handleAPIGetFeeds)
-	mux.HandleFunc("POST /api/feeds", s.handleAPICreateFeed)
-	mux.HandleFunc("GET /api/feeds/{id}", s.handleAPIGetFeed)
-	mux.HandleFunc("DELETE /api/feeds/{id}", s.handleAPIDeleteFeed)
-	mux.HandleFunc("PUT /api/feeds/reorder", s.handleAPIReorderFeeds)
-	mux.HandleFunc("GET /api/feeds/{id}/export", s.handleExportFeed)
-	mux.HandleFunc("GET /api/feeds/{id}/shuffle", s.handleAPIGetShuffledVideos)
-	mux.HandleFunc("POST /api/feeds/{id}/refresh", s.handleAPIRefreshFeed)
-	mux.HandleFunc("GET /api/feeds/{id}/refresh/stream", s.handleRefreshFeedStream)
-
-	mux.HandleFunc("GET /api/channels/{id}", s.handleAPIGetChannel)
-	mux.HandleFunc("GET /api/channels/{id}/feeds", s.handleAPIGetChannelFeeds)
-	mux.HandleFunc("POST /api/feeds/{id}/channels", s.handleAPIAddChannel)
-	mux.HandleFunc("DELETE /api/channels/{id}", s.handleAPIDeleteChannel)
-	mux.HandleFunc("DELETE /api/feeds/{feedId}/channels/{channelId}", s.handleAPIRemoveChannelFromFeed)
-	mux.HandleFunc("POST /api/channels/{id}/feeds", s.handleAPIAddChannelToFeed)
-	mux.HandleFunc("POST /api/channels/{id}/refresh", s.handleAPIRefreshChannel)
-	mux.HandleFunc("GET /api/channels/{id}/fetch-more", s.handleAPIFetchMoreVideos)
-
-	mux.HandleFunc("GET /api/videos/recent", s.handleAPIGetRecentVideos)
-	mux.HandleFunc("GET /api/videos/history", s.handleAPIGetHistory)
-	mux.HandleFunc("GET /api/videos/{id}/info", s.handleWatchInfo)
-	mux.HandleFunc("GET /api/videos/{id}/nearby", s.handleAPINearbyVideos)
-	mux.HandleFunc("GET /api/videos/{id}/segments", s.handleAPIGetSegments)
-	mux.HandleFunc("POST /api/videos/{id}/progress", s.handleUpdateWatchProgress)
-	mux.HandleFunc("POST /api/videos/{id}/watched", s.handleAPIMarkWatched)
-	mux.HandleFunc("DELETE /api/videos/{id}/watched", s.handleAPIMarkUnwatched)
-
-	mux.HandleFunc("GET /api/download/{id}", s.handleDownload)
-	mux.HandleFunc("GET /api/stream/{id}", s.handleStreamProxy)
-
-	mux.HandleFunc("POST /api/videos/{id}/download", s.handleStartDownload)
-	mux.HandleFunc("GET /api/videos/{id}/download/status", s.handleDownloadStatus)
-	mux.HandleFunc("GET /api/videos/{id}/qualities", s.handleGetQualities)
-
-	mux.HandleFunc("POST /api/import/url", s.handleAPIImportURL)
-	mux.HandleFunc("POST /api/import/file", s.handleAPIImportFile)
-	mux.HandleFunc("POST /api/import/confirm", s.handleAPIConfirmOrganize)
-	mux.HandleFunc("POST /api/import/watch-history", s.handleAPIImportWatchHistory)
-
-	mux.HandleFunc("GET /api/packs", s.handlePacksList)
-	mux.HandleFunc("GET /api/packs/{name}", s.handlePackFile)
-
-	mux.HandleFunc("GET /api/config", s.handleAPIConfig)
-	mux.HandleFunc("POST /api/config/ytdlp-cookies", s.handleAPISetYTDLPCookies)
+	register("GET /api/feeds", s.handleAPIGetFeeds)
+	register("POST /api/feeds", s.handleAPICreateFeed)
+	register("GET /api/feeds/{id}", s.handleAPIGetFeed)
+	register("DELETE /api/feeds/{id}", s.handleAPIDeleteFeed)
+	register("PUT /api/feeds/reorder", s.handleAPIReorderFeeds)
+	register("GET /api/feeds/{id}/export", s.handleExportFeed)
+	register("GET /api/feeds/{id}/export.opml", s.handleExportFeedOPML)
+	register("GET /api/feeds/{id}/export.rss", s.handleExportFeedPodcast)
+	register("PUT /api/feeds/{id}/podcast", s.handleAPISetFeedPodcast)
+	register("GET /api/export", s.handleAPIExportAll)
+	register("GET /opml", s.handleExportOPML)
+	register("POST /opml", s.handleImportOPML)
+	register("GET /api/feeds/{id}/shuffle", s.handleAPIGetShuffledVideos)
+	register("GET /api/feeds/{id}/chapters", s.handleAPIVideosWithChapter)
+	register("GET /api/feeds/{id}/filter", s.handleAPIFilterVideos)
+	register("POST /api/feeds/{id}/refresh", s.handleAPIRefreshFeed)
+	register("GET /api/feeds/{id}/refresh/stream", s.handleRefreshFeedStream)
+	register("PUT /api/feeds/{id}/schedule", s.handleAPISetFeedSchedule)
+	register("PUT /api/feeds/{id}/shorts-filter", s.handleAPISetFeedShortsFilter)
+	register("PUT /api/feeds/{id}/auto-download", s.handleAPISetFeedAutoDownload)
+
+	register("GET /api/channels/{id}", s.handleAPIGetChannel)
+	register("GET /api/channels/{id}/feeds", s.handleAPIGetChannelFeeds)
+	register("POST /api/feeds/{id}/channels", s.handleAPIAddChannel)
+	register("DELETE /api/channels/{id}", s.handleAPIDeleteChannel)
+	register("POST /api/channels/{id}/ignore-recommendation", s.handleAPIIgnoreRecommendedChannel)
+	register("DELETE /api/feeds/{feedId}/channels/{channelId}", s.handleAPIRemoveChannelFromFeed)
+	register("POST /api/channels/{id}/feeds", s.handleAPIAddChannelToFeed)
+	register("POST /api/channels/{id}/refresh", s.handleAPIRefreshChannel)
+	register("PUT /api/channels/{id}/language", s.handleAPISetChannelLanguage)
+	register("GET /api/channels/{id}/fetch-more", s.handleAPIFetchMoreVideos)
+	register("POST /api/channels/{id}/backfill", s.handleAPIBackfillChannel)
+	register("GET /api/channels/{id}/backfill", s.handleAPIGetChannelBackfillProgress)
+	register("GET /api/channels/{id}/backfill/stream", s.handleChannelBackfillStream)
+	register("GET /api/backfill/jobs", s.handleAPIListBackfillJobs)
+	register("GET /api/backfill/jobs/{id}/stream", s.handleBackfillJobStream)
+	register("POST /api/backfill/jobs/{id}/{action}", s.handleAPIBackfillJobAction)
+	register("GET /api/jobs", s.handleAPIListJobs)
+	register("GET /api/jobs/{id}/stream", s.handleAPIJobStream)
+
+	register("GET /api/videos/recent", s.handleAPIGetRecentVideos)
+	register("GET /api/videos/history", s.handleAPIGetHistory)
+	register("GET /api/videos/{id}/info", s.handleWatchInfo)
+	register("GET /api/videos/{id}/nearby", s.handleAPINearbyVideos)
+	register("GET /api/videos/{id}/segments", s.handleAPIGetSegments)
+	register("GET /api/sponsorblock/prefs", s.handleAPIGetSponsorBlockPrefs)
+	register("PUT /api/sponsorblock/prefs", s.handleAPISetSponsorBlockPrefs)
+	register("POST /api/segments/{id}", s.handleAPISubmitSegment)
+	register("POST /api/segments/{uuid}/vote", s.handleAPIVoteSegment)
+	register("POST /api/segments/{id}/local", s.handleAPIAddLocalSegment)
+	register("POST /api/videos/{id}/progress", s.handleUpdateWatchProgress)
+	register("POST /api/videos/{id}/watched", s.handleAPIMarkWatched)
+	register("DELETE /api/videos/{id}/watched", s.handleAPIMarkUnwatched)
+
+	register("GET /api/download/{id}", s.handleDownload)
+	register("GET /api/stream/{id}", s.handleStreamProxy)
+	register("GET /api/downloads/stats", s.handleDownloadPoolStats)
+	register("GET /api/admin/cache/stats", s.handleAdminCacheStats)
+	register("GET /api/downloads/stream", s.handleDownloadQueueStream)
+	register("POST /api/videos/{id}/download/queue", s.handleEnqueueDownload)
+	register("GET /api/downloads", s.handleListDownloads)
+	register("DELETE /api/downloads/{id}", s.handleCancelDownload)
+
+	register("POST /api/videos/{id}/download", s.handleStartDownload)
+	register("GET /api/videos/{id}/download/status", s.handleDownloadStatus)
+	register("GET /api/videos/{id}/qualities", s.handleGetQualities)
+	register("GET /api/videos/{id}/{quality}/url", s.handleGetVideoURL)
+
+	register("POST /api/videos/{id}/{quality}/hls/start", s.handleStartHLS)
+	register("GET /api/videos/{id}/{quality}/hls/index.m3u8", s.handleHLSPlaylist)
+	register("GET /api/videos/{id}/{quality}/hls/{file}", s.handleHLSFile)
+
+	register("GET /episodes/{file}", s.handleEpisodeFile)
+
+	register("GET /api/videos/{id}/adaptive-hls/master.m3u8", s.handleAdaptiveHLSMaster)
+	register("GET /api/videos/{id}/adaptive-hls/{quality}/index.m3u8", s.handleAdaptiveHLSMediaPlaylist)
+	register("GET /api/videos/{id}/adaptive-hls/{quality}/{file}", s.handleAdaptiveHLSChunk)
+
+	register("POST /api/import/url", s.handleAPIImportURL)
+	register("POST /api/import/file", s.handleAPIImportFile)
+	register("POST /api/import/subscriptions", s.handleImportSubscriptions)
+	register("POST /api/import/confirm", s.handleAPIConfirmOrganize)
+	register("POST /api/import/watch-history", s.handleAPIImportWatchHistory)
+	register("POST /api/import/takeout", s.handleImportTakeoutArchive)
+
+	register("GET /api/packs", s.handlePacksList)
+	register("POST /api/packs", s.handlePackCreate)
+	register("GET /api/packs/{name}", s.handlePackFile)
+	register("PUT /api/packs/{name}", s.handlePackUpdate)
+	register("DELETE /api/packs/{name}", s.handlePackDelete)
+	register("POST /api/packs/{name}/subscribe", s.handlePackSubscribe)
+
+	register("GET /api/config", s.handleAPIConfig)
+	register("POST /api/config/ytdlp-cookies", s.handleAPISetYTDLPCookies)
+	register("POST /api/config/piped-instances", s.handleAPISetPipedInstances)
+	register("POST /api/config/source-order", s.handleAPISetSourceOrder)
+	register("GET /api/sources/health", s.handleAPISourcesHealth)
+
+	register("GET /api/cluster/workers", s.handleAPIClusterWorkers)
+	register("POST /api/cluster/reassign", s.handleAPIClusterReassign)
+
+	mux.Handle("GET /metrics", s.handleMetrics())
+	mux.HandleFunc("GET /healthz", s.handleHealthz)
+	mux.HandleFunc("GET /readyz", s.handleReadyz)
 }
 
 // Page handlers
 
 func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
-	feeds, err := s.db.GetFeeds()
+	feeds, err := s.db.GetFeeds(s.currentUserID(r))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -178,7 +351,7 @@ func (s *Server) handleAllRecent(w http.ResponseWriter, r *http.Request) {
 	for i, v := range videos {
 		videoIDs[i] = v.ID
 	}
-	progressMap, _ := s.db.GetWatchProgressMap(videoIDs)
+	progressMap, _ := s.db.GetWatchProgressMap(s.currentUserID(r), videoIDs)
 
 	data := map[string]any{
 		"Title":       "Everything",
@@ -234,7 +407,7 @@ func (s *Server) handleImport(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create feed
-	feed, err := s.db.CreateFeed(name)
+	feed, err := s.db.CreateFeed(s.currentUserID(r), name)
 	if err != nil {
 		s.renderImportError(w, "Failed to create feed: "+err.Error())
 		return
@@ -302,7 +475,7 @@ func (s *Server) handleImportURL(w http.ResponseWriter, r *http.Request) {
 			tags = strings.Join(feedExport.Tags, ", ")
 		}
 
-		feed, err := s.db.CreateFeedWithMetadata(feedExport.Name, feedExport.Description, feedExport.Author, tags)
+		feed, err := s.db.CreateFeedWithMetadata(s.currentUserID(r), feedExport.Name, feedExport.Description, feedExport.Author, tags)
 		if err != nil {
 			s.renderImportError(w, "Failed to create feed: "+err.Error())
 			return
@@ -344,7 +517,7 @@ func (s *Server) handleImportURL(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
-		feed, err := s.db.CreateFeed(feedName)
+		feed, err := s.db.CreateFeed(s.currentUserID(r), feedName)
 		if err != nil {
 			s.renderImportError(w, "Failed to create feed: "+err.Error())
 			return
@@ -395,7 +568,7 @@ func (s *Server) handleImportFile(w http.ResponseWriter, r *http.Request) {
 			tags = strings.Join(feedExport.Tags, ", ")
 		}
 
-		feed, err := s.db.CreateFeedWithMetadata(feedExport.Name, feedExport.Description, feedExport.Author, tags)
+		feed, err := s.db.CreateFeedWithMetadata(s.currentUserID(r), feedExport.Name, feedExport.Description, feedExport.Author, tags)
 		if err != nil {
 			s.renderImportError(w, "Failed to create feed: "+err.Error())
 			return
@@ -434,7 +607,7 @@ func (s *Server) handleImportFile(w http.ResponseWriter, r *http.Request) {
 			feedName = "Imported Feed"
 		}
 
-		feed, err := s.db.CreateFeed(feedName)
+		feed, err := s.db.CreateFeed(s.currentUserID(r), feedName)
 		if err != nil {
 			s.renderImportError(w, "Failed to create feed: "+err.Error())
 			return
@@ -461,7 +634,9 @@ func (s *Server) handleFeedPage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	feed, err := s.db.GetFeed(feedID)
+	userID := s.currentUserID(r)
+
+	feed, err := s.db.GetFeed(userID, feedID)
 	if err != nil {
 		http.Error(w, "Feed not found", http.StatusNotFound)
 		return
@@ -478,7 +653,7 @@ func (s *Server) handleFeedPage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	videos, _, err := s.db.GetVideosByFeed(feedID, 50, 0)
+	videos, _, err := s.db.GetVideosByFeed(userID, feedID, 50, 0)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -489,10 +664,10 @@ func (s *Server) handleFeedPage(w http.ResponseWriter, r *http.Request) {
 	for i, v := range videos {
 		videoIDs[i] = v.ID
 	}
-	progressMap, _ := s.db.GetWatchProgressMap(videoIDs)
+	progressMap, _ := s.db.GetWatchProgressMap(userID, videoIDs)
 
 	// Get all feeds for the move dropdown
-	allFeeds, err := s.db.GetFeeds()
+	allFeeds, err := s.db.GetFeeds(userID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -527,6 +702,11 @@ func (s *Server) handleRefreshFeed(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	includeShorts := true
+	if feed, err := s.db.GetFeedByID(feedID); err == nil && feed != nil {
+		includeShorts = !feed.HideShorts
+	}
+
 	log.Printf("Refreshing feed %d with %d channels", feedID, len(channels))
 
 	// Fetch videos via RSS - fast and no rate limiting
@@ -542,7 +722,7 @@ func (s *Server) handleRefreshFeed(w http.ResponseWriter, r *http.Request) {
 			semaphore <- struct{}{}        // Acquire
 			defer func() { <-semaphore }() // Release
 
-			videos, err := youtube.FetchLatestVideos(channel.URL, 5)
+			videos, err := youtube.FetchLatestVideos(channel.URL, 5, includeShorts)
 			if err != nil {
 				log.Printf("Failed to fetch videos for %s: %v", channel.Name, err)
 				return
@@ -550,9 +730,18 @@ func (s *Server) handleRefreshFeed(w http.ResponseWriter, r *http.Request) {
 
 			log.Printf("Fetched %d videos from %s", len(videos), channel.Name)
 
+			// Channels fetched through the UULF long-form playlist already
+			// had shorts filtered out by YouTube, so there's no need to
+			// classify them below - mark them known-not-short up front.
+			viaLongFormPlaylist := !includeShorts && strings.HasPrefix(youtube.ExtractChannelID(channel.URL), "UC")
+			notShort := false
+
 			mu.Lock()
 			for i := range videos {
 				videos[i].ChannelID = channel.ID
+				if viaLongFormPlaylist {
+					videos[i].IsShort = &notShort
+				}
 				allVideos = append(allVideos, videos[i])
 			}
 			mu.Unlock()
@@ -561,14 +750,20 @@ func (s *Server) handleRefreshFeed(w http.ResponseWriter, r *http.Request) {
 
 	wg.Wait()
 
-	// Check shorts status synchronously before saving
+	// Check shorts status synchronously before saving, skipping videos
+	// already classified via the long-form playlist above.
 	var totalVideos int
 	if len(allVideos) > 0 {
-		videoIDs := make([]string, len(allVideos))
-		for i, v := range allVideos {
-			videoIDs[i] = v.ID
+		var needsCheck []string
+		for _, v := range allVideos {
+			if v.IsShort == nil {
+				needsCheck = append(needsCheck, v.ID)
+			}
+		}
+		var shortsStatus map[string]bool
+		if len(needsCheck) > 0 {
+			shortsStatus = youtube.CheckShortsStatus(needsCheck)
 		}
-		shortsStatus := youtube.CheckShortsStatus(videoIDs)
 
 		for i := range allVideos {
 			if isShort, ok := shortsStatus[allVideos[i].ID]; ok {
@@ -583,14 +778,20 @@ func (s *Server) handleRefreshFeed(w http.ResponseWriter, r *http.Request) {
 	}
 	log.Printf("Refresh complete: %d total videos saved", totalVideos)
 
-	// Fetch durations in background (shorts status is checked synchronously now)
-	go s.fetchMissingDurations(feedID)
+	// Fetch durations on the job queue (shorts status is checked synchronously now)
+	if _, err := s.jobQueue.Enqueue(jobs.KindFetchDurations, map[string]any{"feed_id": feedID}); err != nil {
+		log.Printf("Failed to enqueue fetch_durations for feed %d: %v", feedID, err)
+	}
 
 	// Redirect back to feed page
 	http.Redirect(w, r, "/feeds/"+strconv.FormatInt(feedID, 10), http.StatusSeeOther)
 }
 
-// handleRefreshFeedStream provides SSE progress updates during feed refresh
+// handleRefreshFeedStream provides SSE progress updates during feed refresh.
+// The actual fetch/merge work happens in refreshFeedJob on the job queue;
+// this handler just enqueues it and relays its published progress as SSE,
+// so a refresh survives the request disconnecting and shows up in
+// GET /api/jobs like any other background job.
 func (s *Server) handleRefreshFeedStream(w http.ResponseWriter, r *http.Request) {
 	feedID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
@@ -598,13 +799,6 @@ func (s *Server) handleRefreshFeedStream(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	channels, err := s.db.GetChannelsByFeed(feedID)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	// Set SSE headers
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
@@ -616,147 +810,39 @@ func (s *Server) handleRefreshFeedStream(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	total := len(channels)
-	log.Printf("SSE refresh: feed %d with %d channels", feedID, total)
-
-	// Handle empty feed
-	if total == 0 {
-		complete := map[string]any{
-			"totalVideos": 0,
-			"feedID":      feedID,
-		}
-		data, _ := json.Marshal(complete)
-		fmt.Fprintf(w, "event: complete\ndata: %s\n\n", data)
-		flusher.Flush()
+	job, err := s.jobQueue.Enqueue(jobs.KindRefreshFeed, map[string]any{"feed_id": feedID})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Use worker pool for parallel fetching
-	const maxWorkers = 5
-
-	type result struct {
-		videos  []models.Video
-		err     error
-		chName  string
-		chID    int64
-	}
-
-	jobs := make(chan *models.Channel, len(channels))
-	results := make(chan result, len(channels))
+	events, unsubscribe := s.jobQueue.Subscribe(job.ID)
+	defer unsubscribe()
 
-	// Start workers
-	var wg sync.WaitGroup
-	for i := 0; i < maxWorkers; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for ch := range jobs {
-				videos, err := youtube.FetchLatestVideos(ch.URL, 5)
-				results <- result{videos: videos, err: err, chName: ch.Name, chID: ch.ID}
-			}
-		}()
-	}
+	log.Printf("SSE refresh: feed %d queued as job %d", feedID, job.ID)
 
-	// Send jobs
-	for i := range channels {
-		jobs <- &channels[i]
-	}
-	close(jobs)
-
-	// Wait for workers and close results
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
-
-	// Collect results and send progress
-	var totalVideos int
-	var completed int
-	var errors []string
-
-	for res := range results {
-		completed++
-
-		// Send progress event
-		progress := map[string]any{
-			"current": completed,
-			"total":   total,
-			"channel": res.chName,
-		}
-		data, _ := json.Marshal(progress)
-		fmt.Fprintf(w, "event: progress\ndata: %s\n\n", data)
-		flusher.Flush()
-
-		if res.err != nil {
-			errors = append(errors, res.chName+": "+res.err.Error())
-			log.Printf("Failed to fetch videos for %s: %v", res.chName, res.err)
+	for event := range events {
+		m, ok := event.(map[string]any)
+		if !ok {
 			continue
 		}
-
-		if len(res.videos) > 0 {
-			// Check shorts status only for videos that don't already have it
-			videoIDs := make([]string, len(res.videos))
-			for i, v := range res.videos {
-				videoIDs[i] = v.ID
-			}
-
-			// Get existing shorts status from DB
-			existingStatus, err := s.db.GetVideoShortsStatus(videoIDs)
-			if err != nil {
-				log.Printf("Failed to get existing shorts status: %v", err)
-				existingStatus = map[string]bool{}
-			}
-
-			// Only check shorts for videos that don't have status yet
-			var needsCheck []string
-			for _, id := range videoIDs {
-				if _, hasStatus := existingStatus[id]; !hasStatus {
-					needsCheck = append(needsCheck, id)
-				}
-			}
-
-			// Fetch shorts status only for new videos
-			var shortsStatus map[string]bool
-			if len(needsCheck) > 0 {
-				shortsStatus = youtube.CheckShortsStatus(needsCheck)
-			} else {
-				shortsStatus = map[string]bool{}
-			}
-
-			// Merge existing status into results
-			for id, isShort := range existingStatus {
-				shortsStatus[id] = isShort
-			}
-
-			for i := range res.videos {
-				res.videos[i].ChannelID = res.chID
-				if isShort, ok := shortsStatus[res.videos[i].ID]; ok {
-					res.videos[i].IsShort = &isShort
-				}
-				if _, err := s.db.UpsertVideo(&res.videos[i]); err != nil {
-					log.Printf("Failed to save video %s: %v", res.videos[i].ID, err)
-					continue
-				}
-				totalVideos++
-			}
+		data, _ := json.Marshal(event)
+		switch m["event"] {
+		case "progress":
+			fmt.Fprintf(w, "event: progress\ndata: %s\n\n", data)
+			flusher.Flush()
+		case "complete":
+			fmt.Fprintf(w, "event: complete\ndata: %s\n\n", data)
+			flusher.Flush()
+			return
+		case "error":
+			fmt.Fprintf(w, "event: complete\ndata: %s\n\n", data)
+			flusher.Flush()
+			return
+		case "done":
+			return
 		}
 	}
-
-	// Fetch durations for videos that don't have them (in background)
-	// Note: shorts status is checked synchronously now
-	go s.fetchMissingDurations(feedID)
-
-	// Send completion event
-	complete := map[string]any{
-		"totalVideos": totalVideos,
-		"feedID":      feedID,
-		"errors":      errors,
-	}
-	data, _ := json.Marshal(complete)
-	fmt.Fprintf(w, "event: complete\ndata: %s\n\n", data)
-	flusher.Flush()
-
-	log.Printf("SSE refresh complete: %d videos saved for feed %d", totalVideos, feedID)
 }
 
 // fetchMissingDurations fetches durations for videos that don't have them
@@ -840,7 +926,7 @@ func (s *Server) handleDeleteFeed(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.db.DeleteFeed(feedID); err != nil {
+	if err := s.db.DeleteFeed(s.currentUserID(r), feedID); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -855,9 +941,11 @@ func (s *Server) handleDeleteFeed(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleWatchPage(w http.ResponseWriter, r *http.Request) {
 	videoID := r.PathValue("id")
 
+	userID := s.currentUserID(r)
+
 	// Get watch progress for resume functionality
 	var startTime int
-	if wp, err := s.db.GetWatchProgress(videoID); err == nil {
+	if wp, err := s.db.GetWatchProgress(userID, videoID); err == nil {
 		// Only resume if not near the end (within 30 seconds)
 		if wp.DurationSeconds > 0 && wp.ProgressSeconds < wp.DurationSeconds-30 {
 			startTime = wp.ProgressSeconds
@@ -865,7 +953,7 @@ func (s *Server) handleWatchPage(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get all feeds for subscribe dropdown
-	feeds, _ := s.db.GetFeeds()
+	feeds, _ := s.db.GetFeeds(userID)
 
 	// Check query params for subscription status
 	subscribed := r.URL.Query().Get("subscribed")
@@ -896,7 +984,7 @@ func (s *Server) handleWatchInfo(w http.ResponseWriter, r *http.Request) {
 
 		// Get saved progress for resume
 		var resumeFrom int
-		if wp, err := s.db.GetWatchProgress(videoID); err == nil {
+		if wp, err := s.db.GetWatchProgress(s.currentUserID(r), videoID); err == nil {
 			resumeFrom = wp.ProgressSeconds
 		}
 
@@ -956,7 +1044,7 @@ func (s *Server) handleWatchInfo(w http.ResponseWriter, r *http.Request) {
 
 	// Get saved progress for resume
 	var resumeFrom int
-	if wp, err := s.db.GetWatchProgress(videoID); err == nil {
+	if wp, err := s.db.GetWatchProgress(s.currentUserID(r), videoID); err == nil {
 		resumeFrom = wp.ProgressSeconds
 	}
 
@@ -983,7 +1071,7 @@ type channelMembership struct {
 
 // channelInfo contains the channel ID (if known) and feed memberships
 type channelInfo struct {
-	ChannelID   *int64             `json:"channelId"` // nil if channel not yet in system
+	ChannelID   *int64              `json:"channelId"` // nil if channel not yet in system
 	Memberships []channelMembership `json:"memberships"`
 }
 
@@ -1055,13 +1143,15 @@ func (s *Server) handleAPINearbyVideos(w http.ResponseWriter, r *http.Request) {
 	for i, v := range videos {
 		videoIDs[i] = v.ID
 	}
-	progressMap, _ := s.db.GetWatchProgressMap(videoIDs)
+	progressMap, _ := s.db.GetWatchProgressMap(s.currentUserID(r), videoIDs)
+	chapters, _ := s.db.GetChaptersForVideo(videoID)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]any{
 		"videos":      videos,
 		"feedId":      feedID,
 		"progressMap": progressMap,
+		"chapters":    chapters,
 	})
 }
 
@@ -1100,7 +1190,7 @@ func (s *Server) handleAPIGetSegments(w http.ResponseWriter, r *http.Request) {
 				})
 			}
 			jsonResponse(w, map[string]any{
-				"segments": result,
+				"segments": s.withLocalSegments(s.currentUserID(r), videoID, result),
 				"cached":   true,
 			})
 			return
@@ -1108,12 +1198,12 @@ func (s *Server) handleAPIGetSegments(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Fetch from SponsorBlock API
-	apiSegments, err := s.sponsorblock.GetSegments(videoID, nil)
+	apiSegments, err := s.sponsorblock.GetSegments(videoID, sponsorblock.FetchCategories)
 	if err != nil {
 		log.Printf("Failed to fetch SponsorBlock segments for %s: %v", videoID, err)
-		// Return empty array, don't cache failure
+		// Return just the user's local overrides, don't cache failure
 		jsonResponse(w, map[string]any{
-			"segments": []any{},
+			"segments": s.withLocalSegments(s.currentUserID(r), videoID, nil),
 			"error":    "Failed to fetch segments",
 		})
 		return
@@ -1131,6 +1221,7 @@ func (s *Server) handleAPIGetSegments(w http.ResponseWriter, r *http.Request) {
 				Category:    seg.Category,
 				ActionType:  seg.ActionType,
 				Votes:       seg.Votes,
+				Description: seg.Description,
 			}
 		}
 		if err := s.db.SaveSponsorBlockSegments(videoID, dbSegments); err != nil {
@@ -1157,168 +1248,395 @@ func (s *Server) handleAPIGetSegments(w http.ResponseWriter, r *http.Request) {
 	}
 
 	jsonResponse(w, map[string]any{
-		"segments": result,
+		"segments": s.withLocalSegments(s.currentUserID(r), videoID, result),
 		"cached":   false,
 	})
 }
 
-func (s *Server) handleDownload(w http.ResponseWriter, r *http.Request) {
-	videoID := r.PathValue("id")
-	quality := r.URL.Query().Get("quality")
-	if quality == "" {
-		quality = "best"
+// withLocalSegments applies userID's category prefs to serverSegments (see
+// applySponsorBlockPrefs), then merges in userID's private local-only
+// overrides for videoID (see handleAPIAddLocalSegment): any server segment
+// whose range overlaps a local override is dropped in favor of it, since a
+// user who added their own override for a stretch of video presumably
+// disagrees with (or doesn't trust) the server's segment there.
+func (s *Server) withLocalSegments(userID int64, videoID string, serverSegments []map[string]any) []map[string]any {
+	prefsApplied := s.applySponsorBlockPrefs(userID, serverSegments)
+
+	local, err := s.db.GetSponsorBlockLocalSegments(userID, videoID)
+	if err != nil {
+		log.Printf("Failed to load local SponsorBlock overrides: %v", err)
+		return prefsApplied
+	}
+	if len(local) == 0 {
+		return prefsApplied
+	}
+
+	result := make([]map[string]any, 0, len(prefsApplied)+len(local))
+	for _, seg := range prefsApplied {
+		start, _ := seg["startTime"].(float64)
+		end, _ := seg["endTime"].(float64)
+		overridden := false
+		for _, l := range local {
+			if start < l.EndTime && end > l.StartTime {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			result = append(result, seg)
+		}
+	}
+	for _, l := range local {
+		result = append(result, map[string]any{
+			"uuid":       fmt.Sprintf("local-%d", l.ID),
+			"startTime":  l.StartTime,
+			"endTime":    l.EndTime,
+			"category":   l.Category,
+			"actionType": l.ActionType,
+			"votes":      0,
+			"action":     l.ActionType,
+			"local":      true,
+		})
 	}
+	return result
+}
 
-	videoURL := "https://www.youtube.com/watch?v=" + videoID
+// applySponsorBlockPrefs annotates each segment with userID's configured
+// action for its category (defaulting to ActionSkip for categories the user
+// hasn't set a preference for), and drops segments in categories the user
+// has disabled, so the player only has to read "action" instead of
+// re-merging prefs itself.
+func (s *Server) applySponsorBlockPrefs(userID int64, segments []map[string]any) []map[string]any {
+	prefs, err := s.db.GetSponsorBlockPrefs(userID)
+	if err != nil {
+		log.Printf("Failed to load SponsorBlock prefs: %v", err)
+	}
 
-	downloadURL, ext, err := s.ytdlp.GetDownloadURL(videoURL, quality)
+	var result []map[string]any
+	for _, seg := range segments {
+		action := sponsorblock.ActionSkip
+		if category, ok := seg["category"].(string); ok {
+			if p, ok := prefs[category]; ok {
+				action = p
+			}
+		}
+		if action == sponsorblock.ActionDisabled {
+			continue
+		}
+		seg["action"] = action
+		result = append(result, seg)
+	}
+	return result
+}
+
+// handleAPIGetSponsorBlockPrefs returns the caller's configured action per
+// SponsorBlock category. Categories with no entry fall back to ActionSkip
+// when merged onto segments by applySponsorBlockPrefs.
+func (s *Server) handleAPIGetSponsorBlockPrefs(w http.ResponseWriter, r *http.Request) {
+	prefs, err := s.db.GetSponsorBlockPrefs(s.currentUserID(r))
 	if err != nil {
-		log.Printf("Failed to get download URL: %v", err)
-		http.Error(w, "Failed to get download URL", http.StatusInternalServerError)
+		jsonError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	jsonResponse(w, map[string]any{
+		"categories": sponsorblock.AllCategories,
+		"prefs":      prefs,
+	})
+}
 
-	// Set headers to trigger download in browser
-	filename := videoID + "." + ext
-	w.Header().Set("Content-Disposition", "attachment; filename=\""+filename+"\"")
+// handleAPISetSponsorBlockPrefs sets the caller's action for a single
+// category.
+func (s *Server) handleAPISetSponsorBlockPrefs(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Category string `json:"category"`
+		Action   string `json:"action"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if !sponsorblock.IsValidCategory(req.Category) {
+		jsonError(w, "Unknown category", http.StatusBadRequest)
+		return
+	}
+	if !sponsorblock.IsValidAction(req.Action) {
+		jsonError(w, "Invalid action", http.StatusBadRequest)
+		return
+	}
 
-	// Redirect to the direct URL - browser will download
-	http.Redirect(w, r, downloadURL, http.StatusFound)
-}
+	userID := s.currentUserID(r)
+	if err := s.db.SetSponsorBlockPref(userID, req.Category, req.Action); err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
-// selectBestQuality returns the best quality to use for "auto" mode.
-// Defaults to 1080p as a good balance of quality and download speed.
-func selectBestQuality() string {
-	return "1080"
+	prefs, err := s.db.GetSponsorBlockPrefs(userID)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	jsonResponse(w, map[string]any{
+		"categories": sponsorblock.AllCategories,
+		"prefs":      prefs,
+	})
 }
 
-func (s *Server) handleStreamProxy(w http.ResponseWriter, r *http.Request) {
+// handleAPISubmitSegment submits a new SponsorBlock segment for a video,
+// credited to the caller's anonymous submitter ID (see
+// db.GetOrCreateSponsorBlockUserID). SponsorBlock moderates submissions
+// itself, so this doesn't write to our own cache - the segment shows up on
+// the next cache refresh once it's accepted.
+func (s *Server) handleAPISubmitSegment(w http.ResponseWriter, r *http.Request) {
 	videoID := r.PathValue("id")
-	quality := r.URL.Query().Get("quality")
-	if quality == "" || quality == "auto" {
-		quality = selectBestQuality()
-	}
 
-	// Check if already fully cached
-	cacheKey := CacheKey(videoID, quality)
-	if cachedPath, ok := s.videoCache.Get(cacheKey); ok {
-		log.Printf("Serving fully cached video: %s quality %s", videoID, quality)
-		http.ServeFile(w, r, cachedPath)
+	var req struct {
+		StartTime  float64 `json:"startTime"`
+		EndTime    float64 `json:"endTime"`
+		Category   string  `json:"category"`
+		ActionType string  `json:"actionType"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	if !sponsorblock.IsValidCategory(req.Category) {
+		jsonError(w, "Unknown category", http.StatusBadRequest)
+		return
+	}
+	if req.EndTime <= req.StartTime {
+		jsonError(w, "endTime must be after startTime", http.StatusBadRequest)
+		return
+	}
+	if req.ActionType == "" {
+		req.ActionType = sponsorblock.ActionSkip
+	}
 
-	// Start or get existing download
-	download := s.downloadManager.GetOrStartDownload(videoID, quality)
+	anonID, err := s.db.GetOrCreateSponsorBlockUserID(s.currentUserID(r))
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
-	// If already complete, serve the file
-	if download.Status == "complete" {
-		log.Printf("Serving completed download: %s quality %s", videoID, quality)
-		http.ServeFile(w, r, download.GetFilePath())
+	if err := s.sponsorblock.SubmitSegment(videoID, sponsorblock.HashUserID(anonID), req.StartTime, req.EndTime, req.Category, req.ActionType); err != nil {
+		jsonError(w, err.Error(), http.StatusBadGateway)
 		return
 	}
 
-	// Wait for buffer threshold
-	threshold := GetBufferThreshold(quality)
-	log.Printf("Waiting for buffer (%d bytes) for %s quality %s", threshold, videoID, quality)
+	jsonResponse(w, map[string]any{"ok": true})
+}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
-	defer cancel()
+// handleAPIVoteSegment casts the caller's upvote or downvote on an existing
+// SponsorBlock segment, credited to their anonymous submitter ID.
+func (s *Server) handleAPIVoteSegment(w http.ResponseWriter, r *http.Request) {
+	uuid := r.PathValue("uuid")
 
-	if err := download.WaitForBuffer(ctx, threshold); err != nil {
-		log.Printf("Buffer wait failed for %s: %v", videoID, err)
-		http.Error(w, "Buffering failed: "+err.Error(), http.StatusServiceUnavailable)
+	var req struct {
+		Upvote bool `json:"upvote"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	log.Printf("Buffer ready for %s quality %s, serving partial file", videoID, quality)
+	anonID, err := s.db.GetOrCreateSponsorBlockUserID(s.currentUserID(r))
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
-	// Serve the partial file
-	s.servePartialFile(w, r, download)
+	if err := s.sponsorblock.VoteOnSegment(uuid, sponsorblock.HashUserID(anonID), req.Upvote); err != nil {
+		jsonError(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	jsonResponse(w, map[string]any{"ok": true})
 }
 
-// servePartialFile serves a file that may still be downloading.
-// It handles range requests and serves available data.
-func (s *Server) servePartialFile(w http.ResponseWriter, r *http.Request, d *Download) {
-	filePath := d.GetFilePath()
+// handleAPIAddLocalSegment adds a private skip range for the caller only -
+// it's merged into handleAPIGetSegments' response (see withLocalSegments)
+// but never submitted to SponsorBlock, for ranges a user wants skipped on
+// their own player without asserting they belong to a public category.
+func (s *Server) handleAPIAddLocalSegment(w http.ResponseWriter, r *http.Request) {
+	videoID := r.PathValue("id")
 
-	file, err := os.Open(filePath)
+	var req struct {
+		StartTime  float64 `json:"startTime"`
+		EndTime    float64 `json:"endTime"`
+		Category   string  `json:"category"`
+		ActionType string  `json:"actionType"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if !sponsorblock.IsValidCategory(req.Category) {
+		jsonError(w, "Unknown category", http.StatusBadRequest)
+		return
+	}
+	if req.EndTime <= req.StartTime {
+		jsonError(w, "endTime must be after startTime", http.StatusBadRequest)
+		return
+	}
+	if req.ActionType == "" {
+		req.ActionType = sponsorblock.ActionSkip
+	}
+
+	id, err := s.db.AddSponsorBlockLocalSegment(s.currentUserID(r), videoID, req.StartTime, req.EndTime, req.Category, req.ActionType)
 	if err != nil {
-		http.Error(w, "Failed to open file", http.StatusInternalServerError)
+		jsonError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	defer file.Close()
 
-	// Get current file size
-	currentSize := d.GetFileSize()
-	if currentSize == 0 {
-		info, err := file.Stat()
-		if err != nil {
-			http.Error(w, "Failed to stat file", http.StatusInternalServerError)
-			return
-		}
-		currentSize = info.Size()
+	jsonResponse(w, map[string]any{"id": id})
+}
+
+func (s *Server) handleDownload(w http.ResponseWriter, r *http.Request) {
+	videoID := r.PathValue("id")
+	quality := r.URL.Query().Get("quality")
+	if quality == "" {
+		quality = "best"
 	}
 
-	// Set content type
-	w.Header().Set("Content-Type", "video/mp4")
-	w.Header().Set("Accept-Ranges", "bytes")
+	videoURL := "https://www.youtube.com/watch?v=" + videoID
 
-	// Handle range request
-	rangeHeader := r.Header.Get("Range")
-	if rangeHeader != "" {
-		s.servePartialFileRange(w, file, currentSize, rangeHeader)
+	downloadURL, ext, err := s.ytdlp.GetDownloadURL(videoURL, quality)
+	if err != nil {
+		log.Printf("Failed to get download URL: %v", err)
+		http.Error(w, "Failed to get download URL", http.StatusInternalServerError)
 		return
 	}
 
-	// No range request - serve from beginning
-	// Use current size as content length (client will handle incomplete)
-	w.Header().Set("Content-Length", strconv.FormatInt(currentSize, 10))
-	w.WriteHeader(http.StatusOK)
+	// Set headers to trigger download in browser
+	filename := videoID + "." + ext
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+filename+"\"")
 
-	// Copy available data
-	io.CopyN(w, file, currentSize)
+	// Redirect to the direct URL - browser will download
+	http.Redirect(w, r, downloadURL, http.StatusFound)
 }
 
-// servePartialFileRange handles range requests for partial files
-func (s *Server) servePartialFileRange(w http.ResponseWriter, file *os.File, fileSize int64, rangeHeader string) {
-	// Parse range header: "bytes=start-end" or "bytes=start-"
-	var start, end int64
-	_, err := fmt.Sscanf(rangeHeader, "bytes=%d-%d", &start, &end)
-	if err != nil {
-		// Try without end
-		_, err = fmt.Sscanf(rangeHeader, "bytes=%d-", &start)
-		if err != nil {
-			http.Error(w, "Invalid range", http.StatusBadRequest)
-			return
-		}
-		end = fileSize - 1
+// selectBestQuality returns the best quality to use for "auto" mode when
+// nothing is known yet about the client's throughput. Defaults to 1080p as
+// a good balance of quality and download speed.
+func selectBestQuality() string {
+	return "1080"
+}
+
+// qualityForThroughput maps a measured delivery rate (bytes/sec) to the
+// highest quality tier it can sustain, so DownloadManager.SelectQuality can
+// downgrade "auto" requests once a client's connection proves too slow for
+// the current tier instead of waiting indefinitely for the buffer to catch up.
+func qualityForThroughput(bytesPerSecond float64) string {
+	switch {
+	case bytesPerSecond >= 2*1024*1024:
+		return "1080"
+	case bytesPerSecond >= 1024*1024:
+		return "720"
+	case bytesPerSecond >= 512*1024:
+		return "480"
+	default:
+		return "360"
 	}
+}
 
-	// Validate range
-	if start < 0 || start >= fileSize {
-		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", fileSize))
-		http.Error(w, "Range not satisfiable", http.StatusRequestedRangeNotSatisfiable)
-		return
+// parseRangeHeader extracts the requested [start, end) byte range from a
+// Range header ("bytes=start-end" or "bytes=start-"), for gating a
+// VideoCache.GetRange call. It's deliberately lenient - a missing,
+// malformed, or unsatisfiable header just falls back to the whole file,
+// since the real range validation still happens in http.ServeFile once the
+// cache lookup succeeds.
+func parseRangeHeader(rangeHeader string, fileSize int64) (start, end int64) {
+	if fileSize <= 0 {
+		return 0, 0
+	}
+
+	var s, e int64
+	if _, err := fmt.Sscanf(rangeHeader, "bytes=%d-%d", &s, &e); err == nil {
+		e++
+	} else if _, err := fmt.Sscanf(rangeHeader, "bytes=%d-", &s); err == nil {
+		e = fileSize
+	} else {
+		return 0, fileSize
 	}
 
-	// Clamp end to available data
-	if end >= fileSize {
-		end = fileSize - 1
+	if s < 0 || s >= fileSize {
+		return 0, fileSize
+	}
+	if e > fileSize {
+		e = fileSize
 	}
+	return s, e
+}
 
-	// Seek to start position
-	if _, err := file.Seek(start, 0); err != nil {
-		http.Error(w, "Seek failed", http.StatusInternalServerError)
+// throughputWriter wraps an http.ResponseWriter to time and count a
+// streamed response's bytes, so handleStreamProxy can feed the observed
+// delivery rate into DownloadManager.RecordThroughput once it's done -
+// driving the quality tier the next "auto" request for this video picks.
+type throughputWriter struct {
+	http.ResponseWriter
+	written int64
+	start   time.Time
+}
+
+func newThroughputWriter(w http.ResponseWriter) *throughputWriter {
+	return &throughputWriter{ResponseWriter: w, start: time.Now()}
+}
+
+func (tw *throughputWriter) Write(p []byte) (int, error) {
+	n, err := tw.ResponseWriter.Write(p)
+	tw.written += int64(n)
+	return n, err
+}
+
+// bytesPerSecond reports the measured delivery rate, or 0 if too little was
+// written, or too little time passed, for the reading to be meaningful.
+func (tw *throughputWriter) bytesPerSecond() float64 {
+	elapsed := time.Since(tw.start).Seconds()
+	if elapsed < 0.5 || tw.written < fillBlockSize {
+		return 0
+	}
+	return float64(tw.written) / elapsed
+}
+
+func (s *Server) handleStreamProxy(w http.ResponseWriter, r *http.Request) {
+	videoID := r.PathValue("id")
+	quality := r.URL.Query().Get("quality")
+	if quality == "" || quality == "auto" {
+		quality = s.downloadManager.SelectQuality(videoID)
+	}
+
+	cacheKey := CacheKey(videoID, quality)
+	start, end := parseRangeHeader(r.Header.Get("Range"), GetFileSize(s.videoCache.CachePath(cacheKey)))
+
+	if _, err := s.downloadManager.StartDownload(videoID, quality, true); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	length := end - start + 1
+	// Nudge the download to prioritize this range if the client has seeked
+	// ahead of what's been muxed so far (see DownloadManager.EnsureRange).
+	s.downloadManager.EnsureRange(videoID, quality, start, end)
+
+	// GetRange blocks until every block covering [start, end) is present -
+	// whether because the file's already fully cached, or because an
+	// in-progress OpenForFill has just reached that range - so a seek past
+	// the download head is served as soon as that range lands instead of
+	// waiting for the whole file. It also pins the file against cleanup's
+	// TTL/size eviction for the duration of the response write, so a cache
+	// sweep landing mid-stream can't remove it out from under this request.
+	cachedPath, release, ok := s.videoCache.GetRange(cacheKey, start, end)
+	if !ok {
+		http.Error(w, "Stream unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	defer release()
 
-	w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, fileSize))
-	w.Header().Set("Content-Length", strconv.FormatInt(length, 10))
-	w.WriteHeader(http.StatusPartialContent)
+	log.Printf("Serving video: %s quality %s (range %d-%d)", videoID, quality, start, end)
 
-	io.CopyN(w, file, length)
+	tw := newThroughputWriter(w)
+	http.ServeFile(tw, r, cachedPath)
+	if bps := tw.bytesPerSecond(); bps > 0 {
+		s.downloadManager.RecordThroughput(videoID, bps)
+	}
 }
 
 func (s *Server) handleChannelPage(w http.ResponseWriter, r *http.Request) {
@@ -1353,7 +1671,7 @@ func (s *Server) handleChannelPage(w http.ResponseWriter, r *http.Request) {
 	for i, v := range videos {
 		videoIDs[i] = v.ID
 	}
-	progressMap, _ := s.db.GetWatchProgressMap(videoIDs)
+	progressMap, _ := s.db.GetWatchProgressMap(s.currentUserID(r), videoIDs)
 
 	data := map[string]any{
 		"Title":       channel.Name,
@@ -1372,6 +1690,14 @@ func (s *Server) handleAddChannel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if exists, err := s.db.FeedExists(s.currentUserID(r), feedID); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	} else if !exists {
+		http.Error(w, "Feed not found", http.StatusNotFound)
+		return
+	}
+
 	if err := r.ParseForm(); err != nil {
 		http.Error(w, "Failed to parse form", http.StatusBadRequest)
 		return
@@ -1454,7 +1780,7 @@ func (s *Server) handleConfirmOrganize(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 
-		feed, err := s.db.CreateFeed(g.Name)
+		feed, err := s.db.CreateFeed(s.currentUserID(r), g.Name)
 		if err != nil {
 			log.Printf("Failed to create feed %s: %v", g.Name, err)
 			continue
@@ -1470,8 +1796,15 @@ func (s *Server) handleConfirmOrganize(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
 
-// handleExportFeed exports a feed as JSON
-// Use ?format=newpipe for NewPipe-compatible format, otherwise uses Feeds format
+// newPipeExportAppVersion is the app_version NewPipe-format exports report.
+// NewPipe itself doesn't validate it on import; it's set purely so a
+// subscriptions file round-trips through other NewPipe-format consumers that
+// expect the field to be present.
+const newPipeExportAppVersion = "feeds-export"
+
+// handleExportFeed exports a feed as JSON, an OPML document, or a NewPipe
+// subscriptions file.
+// Use ?format=newpipe or ?format=opml; the default is the native Feeds format.
 func (s *Server) handleExportFeed(w http.ResponseWriter, r *http.Request) {
 	feedID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
@@ -1479,7 +1812,7 @@ func (s *Server) handleExportFeed(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	feed, err := s.db.GetFeed(feedID)
+	feed, err := s.db.GetFeed(s.currentUserID(r), feedID)
 	if err != nil {
 		http.Error(w, "Feed not found", http.StatusNotFound)
 		return
@@ -1493,11 +1826,24 @@ func (s *Server) handleExportFeed(w http.ResponseWriter, r *http.Request) {
 
 	format := r.URL.Query().Get("format")
 
+	if format == "opml" {
+		opmlChannels := make([]opmlChannel, 0, len(channels))
+		for _, ch := range channels {
+			opmlChannels = append(opmlChannels, opmlChannel{URL: ch.URL, Name: ch.Name})
+		}
+		filename := strings.ReplaceAll(feed.Name, " ", "-") + ".opml"
+		w.Header().Set("Content-Type", "text/x-opml")
+		w.Header().Set("Content-Disposition", "attachment; filename="+filename)
+		w.Write(buildOPML(feed.Name, opmlChannels))
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 
 	if format == "newpipe" {
 		// Build NewPipe-compatible export
 		export := models.NewPipeExport{
+			AppVersion:    newPipeExportAppVersion,
 			Subscriptions: make([]models.NewPipeSubscription, 0, len(channels)),
 		}
 		for _, ch := range channels {
@@ -1543,8 +1889,101 @@ func (s *Server) handleExportFeed(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(export)
 }
 
+// handleExportFeedOPML exports a feed's channels as an OPML document so
+// users can round-trip their subscriptions with other RSS readers.
+func (s *Server) handleExportFeedOPML(w http.ResponseWriter, r *http.Request) {
+	feedID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid feed ID", http.StatusBadRequest)
+		return
+	}
+
+	feed, err := s.db.GetFeed(s.currentUserID(r), feedID)
+	if err != nil {
+		http.Error(w, "Feed not found", http.StatusNotFound)
+		return
+	}
+
+	channels, err := s.db.GetChannelsByFeed(feedID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	opmlChannels := make([]opmlChannel, 0, len(channels))
+	for _, ch := range channels {
+		opmlChannels = append(opmlChannels, opmlChannel{URL: ch.URL, Name: ch.Name})
+	}
+
+	filename := strings.ReplaceAll(feed.Name, " ", "-") + ".opml"
+	w.Header().Set("Content-Type", "text/x-opml")
+	w.Header().Set("Content-Disposition", "attachment; filename="+filename)
+	w.Write(buildOPML(feed.Name, opmlChannels))
+}
+
+// handleExportFeedPodcast renders feed as an RSS 2.0 + itunes: podcast
+// document so it can be subscribed to directly in any podcast client. Unlike
+// handleExportFeed/handleExportFeedOPML, this always returns something -
+// feeds with PodcastFormat == "" still export, just without episode
+// enclosures, since those only appear once PodcastEpisodeWorker downloads
+// them.
+func (s *Server) handleExportFeedPodcast(w http.ResponseWriter, r *http.Request) {
+	feedID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid feed ID", http.StatusBadRequest)
+		return
+	}
+
+	userID := s.currentUserID(r)
+	feed, err := s.db.GetFeed(userID, feedID)
+	if err != nil {
+		http.Error(w, "Feed not found", http.StatusNotFound)
+		return
+	}
+
+	videos, err := s.db.GetVideosForPodcastExport(userID, feedID, podcastEpisodeLimit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	filename := strings.ReplaceAll(feed.Name, " ", "-") + ".rss"
+	w.Header().Set("Content-Type", "application/rss+xml")
+	w.Header().Set("Content-Disposition", "attachment; filename="+filename)
+	w.Write(buildPodcastRSS(feed, videos, requestBaseURL(r)))
+}
+
+// handleExportOPML exports every one of the caller's feeds as a single OPML
+// document, with each feed as a category outline, so the whole subscription
+// list (not just one feed) can migrate to or from another RSS reader.
+func (s *Server) handleExportOPML(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/x-opml")
+	w.Header().Set("Content-Disposition", "attachment; filename=feeds.opml")
+	if err := s.db.ExportOPML(s.currentUserID(r), w); err != nil {
+		log.Printf("Failed to export OPML: %v", err)
+		http.Error(w, "Failed to export OPML", http.StatusInternalServerError)
+	}
+}
+
+// handleImportOPML imports an OPML document's categories as feeds and its
+// channel outlines as subscriptions, skipping channels already subscribed
+// in their target feed.
+func (s *Server) handleImportOPML(w http.ResponseWriter, r *http.Request) {
+	added, skipped, err := s.db.ImportOPML(s.currentUserID(r), r.Body)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	jsonResponse(w, map[string]any{
+		"added":   added,
+		"skipped": skipped,
+	})
+}
+
 func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
-	videos, err := s.db.GetWatchHistory(100)
+	userID := s.currentUserID(r)
+
+	videos, err := s.db.GetWatchHistory(userID, 100)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -1555,7 +1994,7 @@ func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
 	for i, v := range videos {
 		videoIDs[i] = v.ID
 	}
-	progressMap, _ := s.db.GetWatchProgressMap(videoIDs)
+	progressMap, _ := s.db.GetWatchProgressMap(userID, videoIDs)
 
 	data := map[string]any{
 		"Title":       "History",
@@ -1577,7 +2016,7 @@ func (s *Server) handleUpdateWatchProgress(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	if err := s.db.UpdateWatchProgress(videoID, req.Progress, req.Duration); err != nil {
+	if err := s.db.UpdateWatchProgress(s.currentUserID(r), videoID, req.Progress, req.Duration); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -1594,7 +2033,7 @@ func (s *Server) handleMarkWatched(w http.ResponseWriter, r *http.Request) {
 	videoID := r.PathValue("id")
 
 	// Mark as 100% watched (use a placeholder duration if we don't know the real one)
-	if err := s.db.MarkAsWatched(videoID); err != nil {
+	if err := s.db.MarkAsWatched(s.currentUserID(r), videoID); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -1605,7 +2044,7 @@ func (s *Server) handleMarkWatched(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleMarkUnwatched(w http.ResponseWriter, r *http.Request) {
 	videoID := r.PathValue("id")
 
-	if err := s.db.DeleteWatchProgress(videoID); err != nil {
+	if err := s.db.DeleteWatchProgress(s.currentUserID(r), videoID); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -1663,13 +2102,19 @@ func (s *Server) handleSubscribeFromWatch(w http.ResponseWriter, r *http.Request
 
 	// Handle "Uncategorized" feed (feed_id=0)
 	if feedID == 0 {
-		feed, err := s.db.GetOrCreateFeed("Uncategorized")
+		feed, err := s.db.GetOrCreateFeed(s.currentUserID(r), "Uncategorized")
 		if err != nil {
 			log.Printf("Failed to create Uncategorized feed: %v", err)
 			http.Error(w, "Failed to create feed", http.StatusInternalServerError)
 			return
 		}
 		feedID = feed.ID
+	} else if exists, err := s.db.FeedExists(s.currentUserID(r), feedID); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	} else if !exists {
+		http.Error(w, "Feed not found", http.StatusNotFound)
+		return
 	}
 
 	// Add the channel
@@ -1683,79 +2128,236 @@ func (s *Server) handleSubscribeFromWatch(w http.ResponseWriter, r *http.Request
 	w.WriteHeader(http.StatusOK)
 }
 
-// handlePacksList returns a JSON list of available packs
-func (s *Server) handlePacksList(w http.ResponseWriter, r *http.Request) {
-	entries, err := fs.ReadDir(s.packs, "packs")
-	if err != nil {
-		http.Error(w, "Failed to read packs", http.StatusInternalServerError)
-		return
-	}
+// handleStartDownload starts a background download for a specific quality.
+// Quality "auto" instead picks the highest probed quality under the
+// request's max_height/max_bitrate budget - see autoSelectQuality.
+func (s *Server) handleStartDownload(w http.ResponseWriter, r *http.Request) {
+	videoID := r.PathValue("id")
 
-	type packInfo struct {
-		Name string `json:"name"`
-		URL  string `json:"url"`
+	var req struct {
+		Quality    string `json:"quality"`
+		MaxHeight  int    `json:"max_height"`
+		MaxBitrate int    `json:"max_bitrate"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
 	}
 
-	var packs []packInfo
-	for _, entry := range entries {
-		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
-			packs = append(packs, packInfo{
-				Name: strings.TrimSuffix(entry.Name(), ".json"),
-				URL:  "/packs/" + entry.Name(),
-			})
+	if req.Quality == "auto" {
+		quality, err := s.autoSelectQuality(videoID, req.MaxHeight, req.MaxBitrate)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
 		}
+		req.Quality = quality
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(packs)
-}
-
-// handlePackFile serves a specific pack file
-func (s *Server) handlePackFile(w http.ResponseWriter, r *http.Request) {
-	name := r.PathValue("name")
-	if !strings.HasSuffix(name, ".json") {
-		name += ".json"
+	if req.Quality == "" {
+		http.Error(w, `Quality must be specified (e.g., 720, 1080) or "auto" with max_height/max_bitrate`, http.StatusBadRequest)
+		return
 	}
 
-	data, err := fs.ReadFile(s.packs, "packs/"+name)
+	download, err := s.downloadManager.StartDownload(videoID, req.Quality, false)
 	if err != nil {
-		http.Error(w, "Pack not found", http.StatusNotFound)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(data)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  download.Status,
+		"quality": download.Quality,
+	})
 }
 
-// handleStartDownload starts a background download for a specific quality
-func (s *Server) handleStartDownload(w http.ResponseWriter, r *http.Request) {
+// handleDownloadPoolStats reports worker pool utilization (active/queued
+// downloads) for a status dashboard.
+func (s *Server) handleDownloadPoolStats(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(s.downloadManager.Stats())
+}
+
+// handleAdminCacheStats dumps every currently cached file's size, hit count,
+// and last-access time, plus the cache's aggregate hit rate and an estimate
+// of the bytes it's saved from being re-downloaded - for an admin dashboard
+// to inspect whether the GDSF eviction policy is keeping the right files warm.
+func (s *Server) handleAdminCacheStats(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(s.videoCache.StatsSummary())
+}
+
+// handleEnqueueDownload queues a video/quality for internal/downloader's
+// crash-resumable yt-dlp download queue. Unlike handleStartDownload (which
+// drives the segmented-HTTP-plus-mux flow for in-app streaming), this is for
+// callers that want a plain yt-dlp download they can walk away from.
+func (s *Server) handleEnqueueDownload(w http.ResponseWriter, r *http.Request) {
+	if s.downloadQueue == nil {
+		jsonError(w, "Download queue unavailable: requires the yt-dlp backend", http.StatusServiceUnavailable)
+		return
+	}
+
 	videoID := r.PathValue("id")
 
 	var req struct {
 		Quality string `json:"quality"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		jsonError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Quality == "" {
+		jsonError(w, "Quality must be specified (e.g., 720, 1080)", http.StatusBadRequest)
+		return
+	}
+
+	outputPath := s.videoCache.CachePath(CacheKey(videoID, req.Quality))
+	job, err := s.downloadQueue.Enqueue(videoID, req.Quality, outputPath)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(job)
+}
+
+// handleListDownloads lists every job in internal/downloader's queue,
+// most recently created first, with its current progress.
+func (s *Server) handleListDownloads(w http.ResponseWriter, r *http.Request) {
+	if s.downloadQueue == nil {
+		jsonError(w, "Download queue unavailable: requires the yt-dlp backend", http.StatusServiceUnavailable)
+		return
+	}
+
+	jobs, err := s.db.GetAllDownloadJobs()
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, map[string]any{"jobs": jobs})
+}
+
+// handleCancelDownload cancels a queued or in-flight download job, killing
+// its yt-dlp process if one is running, and removes its row and any partial
+// output it left behind.
+func (s *Server) handleCancelDownload(w http.ResponseWriter, r *http.Request) {
+	if s.downloadQueue == nil {
+		jsonError(w, "Download queue unavailable: requires the yt-dlp backend", http.StatusServiceUnavailable)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		jsonError(w, "Invalid download ID", http.StatusBadRequest)
 		return
 	}
 
-	if req.Quality == "" || req.Quality == "auto" {
-		http.Error(w, "Quality must be specified (e.g., 720, 1080)", http.StatusBadRequest)
+	if err := s.downloadQueue.Cancel(id); err != nil {
+		jsonError(w, err.Error(), http.StatusNotFound)
 		return
 	}
 
-	download, err := s.downloadManager.StartDownload(videoID, req.Quality)
+	jsonResponse(w, map[string]any{"ok": true})
+}
+
+// handleDownloadQueueStream emits SSE progress for every job in
+// internal/downloader's queue at once, so the SPA can render a combined
+// panel across simultaneous downloads.
+func (s *Server) handleDownloadQueueStream(w http.ResponseWriter, r *http.Request) {
+	if s.downloadQueue == nil {
+		http.Error(w, "Download queue unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "SSE not supported", http.StatusInternalServerError)
+		return
+	}
+
+	ch := s.downloadQueue.Subscribe()
+	defer s.downloadQueue.Unsubscribe(ch)
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case progress, ok := <-ch:
+			if !ok {
+				return
+			}
+			data, _ := json.Marshal(progress)
+			fmt.Fprintf(w, "event: progress\ndata: %s\n\n", data)
+			flusher.Flush()
+		}
+	}
+}
+
+// handleStartHLS begins on-the-fly HLS repackaging for a video/quality so
+// playback can start within seconds instead of waiting for a full download.
+func (s *Server) handleStartHLS(w http.ResponseWriter, r *http.Request) {
+	videoID := r.PathValue("id")
+	quality := r.PathValue("quality")
+
+	download, err := s.downloadManager.StartHLSDownload(videoID, quality)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	json.NewEncoder(w).Encode(map[string]any{
 		"status":  download.Status,
 		"quality": download.Quality,
 	})
 }
 
+// handleHLSPlaylist serves the master playlist for a video/quality's live
+// HLS repackage, referencing separate video and audio media playlists.
+func (s *Server) handleHLSPlaylist(w http.ResponseWriter, r *http.Request) {
+	videoID := r.PathValue("id")
+	quality := r.PathValue("quality")
+
+	http.ServeFile(w, r, filepath.Join(hlsDir(videoID, quality), "master.m3u8"))
+}
+
+// handleHLSFile serves media playlists (video.m3u8, audio.m3u8) and
+// .ts segments for a video/quality's live HLS repackage.
+func (s *Server) handleHLSFile(w http.ResponseWriter, r *http.Request) {
+	videoID := r.PathValue("id")
+	quality := r.PathValue("quality")
+	file := r.PathValue("file")
+
+	// Reject path traversal; we only ever serve flat filenames in this dir.
+	// ContainsAny alone lets a bare ".." through (it has neither '/' nor
+	// '\'), which Join then resolves to the parent of this video's HLS
+	// directory - so also reject any ".." component explicitly.
+	if strings.ContainsAny(file, "/\\") || strings.Contains(file, "..") {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	http.ServeFile(w, r, filepath.Join(hlsDir(videoID, quality), file))
+}
+
+// handleEpisodeFile serves downloaded podcast episode audio files from
+// episodesDir, referenced by Video.FileURL and buildPodcastRSS's enclosures.
+func (s *Server) handleEpisodeFile(w http.ResponseWriter, r *http.Request) {
+	file := r.PathValue("file")
+
+	// Reject path traversal; we only ever serve flat filenames in this dir.
+	// ContainsAny alone lets a bare ".." through (it has neither '/' nor
+	// '\'), which Join then resolves to the parent of episodesDir - so also
+	// reject any ".." component explicitly.
+	if strings.ContainsAny(file, "/\\") || strings.Contains(file, "..") {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	http.ServeFile(w, r, filepath.Join(s.episodesDir, file))
+}
+
 // handleDownloadStatus provides SSE progress updates for downloads
 func (s *Server) handleDownloadStatus(w http.ResponseWriter, r *http.Request) {
 	videoID := r.PathValue("id")
@@ -1805,27 +2407,49 @@ func (s *Server) handleDownloadStatus(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// handleGetQualities returns available, cached, and downloading qualities for a video
+// cachedQuality is one already-cached quality reported by handleGetQualities,
+// with the URL a client should fetch it from - see Server.resolveCachedURL.
+type cachedQuality struct {
+	Quality string `json:"quality"`
+	URL     string `json:"url"`
+}
+
+// handleGetQualities returns available, cached, and downloading qualities
+// for a video. Available qualities come from qualityProbe, which queries
+// the real itags/resolutions/fps/bitrate a video offers rather than
+// assuming a fixed rung list; a failed probe falls back to defaultQualities
+// so this endpoint still degrades gracefully.
 func (s *Server) handleGetQualities(w http.ResponseWriter, r *http.Request) {
 	videoID := r.PathValue("id")
 
-	// Available qualities (hardcoded for now, could query yt-dlp)
-	available := []string{"360", "480", "720", "1080", "1440", "2160"}
+	available, err := s.qualityProbe.Probe(videoID)
+	if err != nil {
+		log.Printf("Failed to probe qualities for %s: %v", videoID, err)
+		available = defaultQualities
+	}
 
-	// Check which are cached
-	var cached []string
+	// Check which are cached, resolving each one's fetch URL - presigned
+	// when the cache store is remote object storage, the local stream-proxy
+	// route otherwise.
+	var cached []cachedQuality
 	for _, q := range available {
-		cacheKey := CacheKey(videoID, q)
-		if _, ok := s.videoCache.Get(cacheKey); ok {
-			cached = append(cached, q)
+		cacheKey := CacheKey(videoID, q.Quality)
+		if _, ok := s.videoCache.Get(cacheKey); !ok {
+			continue
+		}
+		url, err := s.resolveCachedURL(videoID, q.Quality, cacheKey)
+		if err != nil {
+			log.Printf("Failed to resolve URL for %s: %v", cacheKey, err)
+			continue
 		}
+		cached = append(cached, cachedQuality{Quality: q.Quality, URL: url})
 	}
 
 	// Check which is downloading (only explicit downloads, not streaming)
 	var downloading *string
 	status := s.downloadManager.GetStatus(videoID)
 	for quality, d := range status {
-		if (d.Status == "downloading" || d.Status == "muxing") && !d.IsStreaming {
+		if (d.Status == "downloading" || d.Status == "muxing") && !d.TriggeredByStream {
 			downloading = &quality
 			break
 		}
@@ -1837,3 +2461,106 @@ func (s *Server) handleGetQualities(w http.ResponseWriter, r *http.Request) {
 		"downloading": downloading,
 	})
 }
+
+// resolveCachedURL returns how a client should fetch videoID's quality
+// (already confirmed cached under cacheKey): a time-limited presigned URL
+// when the cache store is remote object storage (see
+// DownloadManager.CachedURL), or the local stream-proxy route otherwise.
+func (s *Server) resolveCachedURL(videoID, quality, cacheKey string) (string, error) {
+	url, err := s.downloadManager.CachedURL(cacheKey)
+	if err != nil {
+		return "", err
+	}
+	if url == "" {
+		url = fmt.Sprintf("/api/stream/%s?quality=%s", videoID, quality)
+	}
+	return url, nil
+}
+
+// handleGetVideoURL mints a fresh fetch URL for videoID at quality - a
+// presigned object-storage URL when the cache store is remote, or the local
+// stream-proxy URL otherwise - for clients whose previously-minted presigned
+// URL has since expired. Returns 404 if that quality isn't cached yet;
+// callers should start a download via handleStartDownload first.
+func (s *Server) handleGetVideoURL(w http.ResponseWriter, r *http.Request) {
+	videoID := r.PathValue("id")
+	quality := r.PathValue("quality")
+
+	cacheKey := CacheKey(videoID, quality)
+	if _, ok := s.videoCache.Get(cacheKey); !ok {
+		jsonError(w, "Quality not cached", http.StatusNotFound)
+		return
+	}
+
+	url, err := s.resolveCachedURL(videoID, quality, cacheKey)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, map[string]any{"url": url})
+}
+
+// handleAdaptiveHLSMaster serves TranscodeManager's master playlist for a
+// video, listing every ladder rung that fits inside its source resolution.
+// Unlike handleHLSPlaylist's live single-quality repackage, this and its
+// sibling handlers below build a VOD ladder and transcode each chunk on
+// demand - see TranscodeManager.
+func (s *Server) handleAdaptiveHLSMaster(w http.ResponseWriter, r *http.Request) {
+	videoID := r.PathValue("id")
+
+	playlist, err := s.transcodeManager.MasterPlaylist(videoID)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+	w.Write([]byte(playlist))
+}
+
+// handleAdaptiveHLSMediaPlaylist serves a VOD media playlist for one rung of
+// a video's adaptive ladder.
+func (s *Server) handleAdaptiveHLSMediaPlaylist(w http.ResponseWriter, r *http.Request) {
+	videoID := r.PathValue("id")
+	quality := r.PathValue("quality")
+
+	playlist, err := s.transcodeManager.MediaPlaylist(videoID, quality)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+	w.Write([]byte(playlist))
+}
+
+// handleAdaptiveHLSChunk serves a single .ts chunk of one rung of a video's
+// adaptive ladder, transcoding it on demand if it isn't already memoized on
+// disk from a previous request.
+func (s *Server) handleAdaptiveHLSChunk(w http.ResponseWriter, r *http.Request) {
+	videoID := r.PathValue("id")
+	quality := r.PathValue("quality")
+	file := r.PathValue("file")
+
+	name, ok := strings.CutSuffix(file, ".ts")
+	indexStr, ok2 := strings.CutPrefix(name, "chunk-")
+	if !ok || !ok2 {
+		jsonError(w, "Invalid chunk name", http.StatusBadRequest)
+		return
+	}
+	index, err := strconv.Atoi(indexStr)
+	if err != nil {
+		jsonError(w, "Invalid chunk name", http.StatusBadRequest)
+		return
+	}
+
+	data, err := s.transcodeManager.Chunk(videoID, quality, index)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "video/mp2t")
+	w.Write(data)
+}
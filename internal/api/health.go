@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// handleMetrics exposes Prometheus metrics for scraping.
+func (s *Server) handleMetrics() http.Handler {
+	return promhttp.Handler()
+}
+
+// handleHealthz is a liveness check: it always returns 200 once the
+// process is up, independent of whether its dependencies are healthy.
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	jsonResponse(w, map[string]any{"status": "ok"})
+}
+
+// handleReadyz is a readiness check: it verifies the DB connection and
+// yt-dlp binary are both usable before reporting ready.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	checks := map[string]string{}
+	ready := true
+
+	if err := s.db.Ping(); err != nil {
+		checks["db"] = err.Error()
+		ready = false
+	} else {
+		checks["db"] = "ok"
+	}
+
+	if _, err := s.ytdlp.Version(); err != nil {
+		checks["ytdlp"] = err.Error()
+		ready = false
+	} else {
+		checks["ytdlp"] = "ok"
+	}
+
+	if !ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	jsonResponse(w, map[string]any{"ready": ready, "checks": checks})
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	hlsCacheDir                    = "/tmp/feeds-hls-cache"
+	hlsSegmentSeconds              = 4
+	clientMinSegmentsBeforePlaying = 2 // mirrors mediamtx's HLS client threshold
+)
+
+// hlsDir returns the directory a given video/quality's HLS output is
+// written to.
+func hlsDir(videoID, quality string) string {
+	return filepath.Join(hlsCacheDir, videoID+"_"+quality)
+}
+
+// StartHLSDownload begins repackaging the given video/quality as a live HLS
+// stream (segments + an event-mode .m3u8) instead of muxing to a single
+// fMP4, so playback can start within seconds instead of waiting for the
+// full download. Video and audio are kept in separate media playlists
+// referenced from a master playlist so adaptive muxing still works.
+func (dm *DownloadManager) StartHLSDownload(videoID, quality string) (*Download, error) {
+	key := downloadKey(videoID, quality) + ":hls"
+
+	dm.mu.Lock()
+	if d, exists := dm.active[key]; exists {
+		dm.mu.Unlock()
+		return d, nil
+	}
+
+	d := &Download{
+		VideoID:   videoID,
+		Quality:   quality,
+		Status:    "downloading",
+		StartedAt: time.Now(),
+	}
+	dm.active[key] = d
+	dm.mu.Unlock()
+
+	go dm.runHLSDownload(videoID, quality, key)
+
+	return d, nil
+}
+
+func (dm *DownloadManager) runHLSDownload(videoID, quality, key string) {
+	defer func() {
+		dm.mu.Lock()
+		delete(dm.active, key)
+		dm.mu.Unlock()
+	}()
+
+	videoURL := "https://www.youtube.com/watch?v=" + videoID
+
+	videoStreamURL, audioStreamURL, err := dm.ytdlp.GetAdaptiveStreamURLs(videoURL, quality)
+	if err != nil {
+		dm.setError(key, videoID, quality, fmt.Sprintf("Failed to get stream URLs: %v", err))
+		return
+	}
+
+	outDir := hlsDir(videoID, quality)
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		dm.setError(key, videoID, quality, fmt.Sprintf("Failed to create HLS dir: %v", err))
+		return
+	}
+
+	if err := writeMasterPlaylist(outDir); err != nil {
+		dm.setError(key, videoID, quality, fmt.Sprintf("Failed to write master playlist: %v", err))
+		return
+	}
+
+	var videoErr, audioErr error
+	done := make(chan struct{})
+
+	go func() {
+		videoErr = runHLSRepackage(videoStreamURL, outDir, "video")
+		close(done)
+	}()
+	if audioStreamURL != "" {
+		go func() {
+			audioErr = runHLSRepackage(audioStreamURL, outDir, "audio")
+		}()
+	}
+
+	go dm.watchHLSSegments(videoID, quality, key, outDir)
+
+	<-done
+	if videoErr != nil {
+		dm.setError(key, videoID, quality, fmt.Sprintf("HLS repackage failed: %v", videoErr))
+		return
+	}
+	if audioErr != nil {
+		log.Printf("HLS audio repackage failed for %s: %v", videoID, audioErr)
+	}
+
+	dm.broadcast(videoID, DownloadProgress{
+		Quality: quality,
+		Percent: 100,
+		Status:  "complete",
+	})
+}
+
+// runHLSRepackage runs ffmpeg in HLS mode for a single stream (video or
+// audio), writing segments and a live-updating event playlist named
+// <name>.m3u8 into outDir.
+func runHLSRepackage(streamURL, outDir, name string) error {
+	cmd := exec.Command(
+		"ffmpeg",
+		"-y",
+		"-i", streamURL,
+		"-c", "copy",
+		"-f", "hls",
+		"-hls_time", fmt.Sprintf("%d", hlsSegmentSeconds),
+		"-hls_playlist_type", "event",
+		"-hls_segment_filename", filepath.Join(outDir, name+"_%05d.ts"),
+		filepath.Join(outDir, name+".m3u8"),
+	)
+	return cmd.Run()
+}
+
+// watchHLSSegments polls outDir until the video media playlist has at
+// least clientMinSegmentsBeforePlaying segments, then sets PlaylistURL so
+// clients can start playback before the full stream has been repackaged.
+func (dm *DownloadManager) watchHLSSegments(videoID, quality, key, outDir string) {
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+
+	announced := false
+	for range ticker.C {
+		dm.mu.RLock()
+		_, exists := dm.active[key]
+		dm.mu.RUnlock()
+		if !exists {
+			return
+		}
+
+		if !announced && countSegments(filepath.Join(outDir, "video.m3u8")) >= clientMinSegmentsBeforePlaying {
+			announced = true
+			dm.broadcast(videoID, DownloadProgress{
+				Quality:     quality,
+				Status:      "downloading",
+				PlaylistURL: fmt.Sprintf("/api/videos/%s/%s/hls/index.m3u8", videoID, quality),
+			})
+		}
+	}
+}
+
+func countSegments(playlistPath string) int {
+	data, err := os.ReadFile(playlistPath)
+	if err != nil {
+		return 0
+	}
+	return strings.Count(string(data), "#EXTINF")
+}
+
+// writeMasterPlaylist writes a master playlist referencing the video and
+// audio media playlists (written once streams start producing segments),
+// so players can select/mux adaptive renditions.
+func writeMasterPlaylist(outDir string) error {
+	master := "#EXTM3U\n" +
+		"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"audio\",NAME=\"audio\",URI=\"audio.m3u8\"\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=2000000,AUDIO=\"audio\"\n" +
+		"video.m3u8\n"
+	return os.WriteFile(filepath.Join(outDir, "master.m3u8"), []byte(master), 0644)
+}
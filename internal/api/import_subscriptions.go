@@ -0,0 +1,198 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/erik/feeds/internal/models"
+	yt "github.com/erik/feeds/internal/youtube"
+)
+
+// subscriptionImportWorkers caps how many channels handleImportSubscriptions
+// resolves concurrently - the same bound classifyVideosViaScrape uses for
+// per-video lookups, for the same reason: enough parallelism to make a
+// large subscriptions file tractable without hammering the resolver's
+// upstream sources.
+const subscriptionImportWorkers = 5
+
+// resolvedSubscription is one row of handleImportSubscriptions' preview:
+// either a channel that resolved successfully, one already subscribed
+// (Existing), or the error that kept it from resolving - so the caller can
+// fix and retry just the failed rows instead of the whole file.
+type resolvedSubscription struct {
+	URL      string `json:"url"`
+	Name     string `json:"name"`
+	Error    string `json:"error,omitempty"`
+	Existing bool   `json:"existing"`
+}
+
+// parseSubscriptionsFile detects and parses a NewPipe subscriptions.json,
+// YouTube Takeout subscriptions.csv, or OPML subscriptions export into a
+// flat list of (url, name) entries. Returns nil if body matches none of
+// them.
+func parseSubscriptionsFile(body []byte) []opmlChannel {
+	var newPipeExport models.NewPipeExport
+	if err := json.Unmarshal(body, &newPipeExport); err == nil && len(newPipeExport.Subscriptions) > 0 {
+		channels := make([]opmlChannel, 0, len(newPipeExport.Subscriptions))
+		for _, sub := range newPipeExport.Subscriptions {
+			if sub.ServiceID != 0 {
+				continue
+			}
+			channels = append(channels, opmlChannel{URL: sub.URL, Name: sub.Name})
+		}
+		return channels
+	}
+
+	if channels := parseTakeoutCSV(body); len(channels) > 0 {
+		return channels
+	}
+
+	return parseOPMLChannels(body)
+}
+
+// parseTakeoutCSV parses YouTube Takeout's subscriptions.csv, whose header
+// row is "Channel Id,Channel Url,Channel Title". Returns nil if data isn't a
+// recognizable Takeout export.
+func parseTakeoutCSV(data []byte) []opmlChannel {
+	records, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+	if err != nil || len(records) < 2 {
+		return nil
+	}
+
+	header := records[0]
+	if len(header) < 3 || !strings.EqualFold(strings.TrimSpace(header[0]), "Channel Id") {
+		return nil
+	}
+
+	channels := make([]opmlChannel, 0, len(records)-1)
+	for _, row := range records[1:] {
+		if len(row) < 3 {
+			continue
+		}
+		url := strings.TrimSpace(row[1])
+		if url == "" {
+			continue
+		}
+		channels = append(channels, opmlChannel{URL: url, Name: strings.TrimSpace(row[2])})
+	}
+	return channels
+}
+
+// handleImportSubscriptions accepts a NewPipe subscriptions.json, YouTube
+// Takeout subscriptions.csv, or OPML upload and resolves each listed
+// channel, flagging ones already subscribed (see resolveSubscriptions). With
+// ?dryRun=true it only returns a preview - a single suggested group holding
+// every newly-resolvable channel, in importGroup's JSON shape - so the
+// client can let the user review it before POSTing the same shape to
+// /api/import/confirm (handleAPIConfirmOrganize). Without dryRun it commits
+// immediately via commitImportGroups.
+func (s *Server) handleImportSubscriptions(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(10 * 1024 * 1024); err != nil {
+		jsonError(w, "Failed to parse form: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		jsonError(w, "No file uploaded", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	body, err := io.ReadAll(io.LimitReader(file, 10*1024*1024))
+	if err != nil {
+		jsonError(w, "Failed to read file: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entries := parseSubscriptionsFile(body)
+	if len(entries) == 0 {
+		jsonError(w, "Unrecognized format - expected NewPipe, Takeout CSV, or OPML subscriptions", http.StatusBadRequest)
+		return
+	}
+
+	rows := s.resolveSubscriptions(entries)
+
+	groupName := "Imported"
+	if header.Filename != "" {
+		groupName = "Imported from " + header.Filename
+	}
+
+	var channelURLs []string
+	channelNames := make(map[string]string)
+	for _, row := range rows {
+		if row.Error != "" || row.Existing {
+			continue
+		}
+		channelURLs = append(channelURLs, row.URL)
+		channelNames[row.URL] = row.Name
+	}
+
+	if r.URL.Query().Get("dryRun") == "true" {
+		jsonResponse(w, map[string]any{
+			"groups":       []importGroup{{Name: groupName, Channels: channelURLs}},
+			"channelNames": channelNames,
+			"rows":         rows,
+		})
+		return
+	}
+
+	if len(channelURLs) == 0 {
+		jsonResponse(w, map[string]any{"feeds": []any{}, "rows": rows})
+		return
+	}
+
+	feeds, err := s.commitImportGroups(s.currentUserID(r), []importGroup{{Name: groupName, Channels: channelURLs}}, channelNames)
+	if err != nil {
+		jsonError(w, "Failed to create feed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, map[string]any{"feeds": feeds, "rows": rows})
+}
+
+// resolveSubscriptions resolves each entry's channel URL via
+// yt.DefaultChain.ResolveChannelURL, subscriptionImportWorkers at a time,
+// skipping resolution for ones already subscribed (per db.GetChannelByURL)
+// so a large re-import of a mostly-unchanged subscriptions file doesn't
+// pay for re-resolving channels it's just going to skip anyway. Resolution
+// failures are reported per-row instead of failing the whole batch.
+func (s *Server) resolveSubscriptions(entries []opmlChannel) []resolvedSubscription {
+	results := make([]resolvedSubscription, len(entries))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, subscriptionImportWorkers)
+
+	for i, entry := range entries {
+		wg.Add(1)
+		go func(i int, entry opmlChannel) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if existing, err := s.db.GetChannelByURL(entry.URL); err == nil && existing != nil {
+				results[i] = resolvedSubscription{URL: entry.URL, Name: existing.Name, Existing: true}
+				return
+			}
+
+			info, err := yt.DefaultChain.ResolveChannelURL(entry.URL)
+			if err != nil {
+				results[i] = resolvedSubscription{URL: entry.URL, Name: entry.Name, Error: err.Error()}
+				return
+			}
+
+			name := info.Name
+			if name == "" {
+				name = entry.Name
+			}
+			results[i] = resolvedSubscription{URL: info.URL, Name: name}
+		}(i, entry)
+	}
+
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,320 @@
+package api
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// takeoutPlaylistVideoCap bounds how many videos from a single Takeout
+// playlist CSV get resolved to channels - Takeout playlist files can run to
+// thousands of rows, and (like subscriptionImportWorkers) we'd rather give a
+// fast, good-enough Feed than block the import on an exhaustive resolve.
+const takeoutPlaylistVideoCap = 50
+
+// takeoutPlaylist is one playlists/*.csv file: its name (derived from the
+// filename, without extension) and the video IDs it lists.
+type takeoutPlaylist struct {
+	Name     string
+	VideoIDs []string
+}
+
+// TakeoutImportResult is everything ImportTakeoutZip/ImportTakeoutGZIP found
+// in a Google Takeout archive's YouTube subtree.
+type TakeoutImportResult struct {
+	Subscriptions []opmlChannel
+	Playlists     []takeoutPlaylist
+
+	// WatchHistoryJSON is watch-history.json's raw bytes, left unparsed here
+	// so the caller can reuse parseWatchHistory/clusterWatchHistory exactly
+	// as handleAPIImportWatchHistory does.
+	WatchHistoryJSON []byte
+
+	// Errors holds one message per archive member that looked like a
+	// relevant Takeout file but failed to parse, so a single bad CSV
+	// doesn't abort the whole import.
+	Errors []string
+}
+
+// ImportTakeoutZip reads a Google Takeout export in .zip form and extracts
+// subscriptions.csv, playlists/*.csv, and watch-history.json from wherever
+// they live under the archive's YouTube subtree (the exact path has changed
+// across Takeout format revisions, so members are matched by
+// basename/parent-dir instead of a fixed path).
+func ImportTakeoutZip(r io.Reader) (*TakeoutImportResult, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading archive: %w", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("not a valid zip archive: %w", err)
+	}
+
+	result := &TakeoutImportResult{}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", f.Name, err))
+			continue
+		}
+		body, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", f.Name, err))
+			continue
+		}
+		result.ingest(f.Name, body)
+	}
+	return result, nil
+}
+
+// ImportTakeoutGZIP reads a Google Takeout export in .tar.gz form, walking
+// its entries the same way ImportTakeoutZip walks a zip's.
+func ImportTakeoutGZIP(r io.Reader) (*TakeoutImportResult, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a valid gzip stream: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	result := &TakeoutImportResult{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading tar entries: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		body, err := io.ReadAll(tr)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", hdr.Name, err))
+			continue
+		}
+		result.ingest(hdr.Name, body)
+	}
+	return result, nil
+}
+
+// ingest classifies one archive member by its basename/parent directory and
+// parses it into the result, recording (rather than failing on) per-file
+// parse errors.
+func (result *TakeoutImportResult) ingest(name string, body []byte) {
+	base := filepath.Base(name)
+	switch {
+	case strings.EqualFold(base, "subscriptions.csv"):
+		channels := parseTakeoutCSV(body)
+		if len(channels) == 0 {
+			result.Errors = append(result.Errors, name+": not a recognizable subscriptions export")
+			return
+		}
+		result.Subscriptions = append(result.Subscriptions, channels...)
+
+	case strings.EqualFold(base, "watch-history.json"):
+		result.WatchHistoryJSON = body
+
+	case strings.EqualFold(filepath.Ext(base), ".csv") && strings.Contains(filepath.ToSlash(name), "/playlists/"):
+		videoIDs, err := parsePlaylistCSV(body)
+		if err != nil {
+			result.Errors = append(result.Errors, name+": "+err.Error())
+			return
+		}
+		if len(videoIDs) == 0 {
+			return
+		}
+		result.Playlists = append(result.Playlists, takeoutPlaylist{
+			Name:     strings.TrimSuffix(base, filepath.Ext(base)),
+			VideoIDs: videoIDs,
+		})
+	}
+}
+
+// parsePlaylistCSV parses one Takeout playlists/*.csv file, whose header row
+// is "Video Id,Playlist Video Creation Timestamp", returning just the video
+// IDs column.
+func parsePlaylistCSV(data []byte) ([]string, error) {
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	start := 0
+	if len(records[0]) > 0 && strings.EqualFold(strings.TrimSpace(records[0][0]), "Video Id") {
+		start = 1
+	}
+
+	ids := make([]string, 0, len(records)-start)
+	for _, row := range records[start:] {
+		if len(row) == 0 {
+			continue
+		}
+		if id := strings.TrimSpace(row[0]); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// resolvePlaylistChannels resolves up to takeoutPlaylistVideoCap of a
+// playlist's videos to their channel, deduplicating by channel URL -
+// subscriptionImportWorkers at a time, the same concurrency bound
+// resolveSubscriptions uses. A playlist CSV only lists video IDs, so this is
+// how a per-playlist Feed recovers which channels to subscribe to.
+func (s *Server) resolvePlaylistChannels(videoIDs []string) []opmlChannel {
+	if len(videoIDs) > takeoutPlaylistVideoCap {
+		videoIDs = videoIDs[:takeoutPlaylistVideoCap]
+	}
+
+	type resolved struct{ url, name string }
+	results := make([]resolved, len(videoIDs))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, subscriptionImportWorkers)
+	for i, videoID := range videoIDs {
+		wg.Add(1)
+		go func(i int, videoID string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			info, err := s.ytdlp.GetVideoInfo("https://www.youtube.com/watch?v=" + videoID)
+			if err != nil || info.ChannelURL == "" {
+				return
+			}
+			results[i] = resolved{url: info.ChannelURL, name: info.Channel}
+		}(i, videoID)
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool)
+	var channels []opmlChannel
+	for _, r := range results {
+		if r.url == "" || seen[r.url] {
+			continue
+		}
+		seen[r.url] = true
+		channels = append(channels, opmlChannel{URL: r.url, Name: r.name})
+	}
+	return channels
+}
+
+// handleImportTakeoutArchive accepts a full Google Takeout export (.zip or
+// .tar.gz/.tgz) and, in one pass, seeds subscriptions.csv's channels, creates
+// a Feed per playlists/*.csv (resolving each listed video's channel via
+// yt-dlp, see resolvePlaylistChannels), and runs watch-history.json through
+// the same weighted-recommendation clustering handleAPIImportWatchHistory
+// uses. Per-file parse errors are returned alongside whatever did succeed
+// instead of aborting the whole import.
+func (s *Server) handleImportTakeoutArchive(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(200 * 1024 * 1024); err != nil { // Takeout archives run large
+		jsonError(w, "Failed to parse form: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		jsonError(w, "No file uploaded", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	var result *TakeoutImportResult
+	lowerName := strings.ToLower(header.Filename)
+	switch {
+	case strings.HasSuffix(lowerName, ".zip"):
+		result, err = ImportTakeoutZip(file)
+	case strings.HasSuffix(lowerName, ".tar.gz"), strings.HasSuffix(lowerName, ".tgz"):
+		result, err = ImportTakeoutGZIP(file)
+	default:
+		jsonError(w, "Unrecognized archive format - expected .zip or .tar.gz", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userID := s.currentUserID(r)
+	var groups []importGroup
+	channelNames := make(map[string]string)
+
+	if len(result.Subscriptions) > 0 {
+		for _, row := range s.resolveSubscriptions(result.Subscriptions) {
+			if row.Error != "" || row.Existing {
+				continue
+			}
+			channelNames[row.URL] = row.Name
+			groups = appendImportGroupChannel(groups, "Subscriptions", row.URL)
+		}
+	}
+
+	for _, playlist := range result.Playlists {
+		for _, ch := range s.resolvePlaylistChannels(playlist.VideoIDs) {
+			channelNames[ch.URL] = ch.Name
+			groups = appendImportGroupChannel(groups, playlist.Name, ch.URL)
+		}
+	}
+
+	feeds, err := s.commitImportGroups(userID, groups, channelNames)
+	if err != nil {
+		jsonError(w, "Failed to create feeds: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]any{
+		"feeds":  feeds,
+		"errors": result.Errors,
+	}
+
+	if len(result.WatchHistoryJSON) > 0 {
+		channels, totalVideos, err := parseWatchHistory(result.WatchHistoryJSON)
+		if err != nil {
+			result.Errors = append(result.Errors, "watch-history.json: "+err.Error())
+		} else {
+			recommended, err := s.buildForYouFeed(userID, result.WatchHistoryJSON)
+			if err != nil {
+				result.Errors = append(result.Errors, "watch-history.json: failed to build For You feed: "+err.Error())
+			}
+			response["watchHistory"] = map[string]any{
+				"channels":    channels,
+				"totalVideos": totalVideos,
+				"groups":      clusterWatchHistory(result.WatchHistoryJSON, channels),
+				"recommended": recommended,
+			}
+		}
+	}
+	response["errors"] = result.Errors
+
+	jsonResponse(w, response)
+}
+
+// appendImportGroupChannel appends channelURL to the importGroup named name
+// within groups, creating that group if this is its first channel.
+func appendImportGroupChannel(groups []importGroup, name, channelURL string) []importGroup {
+	for i := range groups {
+		if groups[i].Name == name {
+			groups[i].Channels = append(groups[i].Channels, channelURL)
+			return groups
+		}
+	}
+	return append(groups, importGroup{Name: name, Channels: []string{channelURL}})
+}
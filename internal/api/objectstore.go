@@ -0,0 +1,255 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// CacheStore abstracts where cached, muxed MP4s are written and read from,
+// so deployments can back the video cache with local disk or cloud object
+// storage (S3, GCS) without changing DownloadManager's download/mux logic.
+type CacheStore interface {
+	// Put uploads/writes the content of r under key. size may be -1 if
+	// unknown (e.g. streaming directly from ffmpeg).
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	// Get opens the object at key for reading.
+	Get(key string) (io.ReadCloser, error)
+	// PresignedURL returns a time-limited URL clients can fetch key from
+	// directly, bypassing the app server. Local stores return "".
+	PresignedURL(key string, ttl time.Duration) (string, error)
+	// Exists reports whether key is already stored.
+	Exists(key string) (bool, error)
+}
+
+// localCacheStore implements CacheStore against the on-disk video cache
+// directory, publishing atomically via write-to-tmp-then-rename.
+type localCacheStore struct {
+	dir string
+}
+
+// NewLocalCacheStore creates a CacheStore backed by the local filesystem.
+func NewLocalCacheStore(dir string) CacheStore {
+	return &localCacheStore{dir: dir}
+}
+
+func (l *localCacheStore) path(key string) string {
+	return filepath.Join(l.dir, key+".mp4")
+}
+
+func (l *localCacheStore) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	dest := l.path(key)
+	tmp := dest + ".tmp"
+
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, dest)
+}
+
+func (l *localCacheStore) Get(key string) (io.ReadCloser, error) {
+	return os.Open(l.path(key))
+}
+
+func (l *localCacheStore) PresignedURL(key string, ttl time.Duration) (string, error) {
+	// Local disk has no notion of a presigned URL; callers fall back to
+	// proxying bytes through the app server.
+	return "", nil
+}
+
+func (l *localCacheStore) Exists(key string) (bool, error) {
+	info, err := os.Stat(l.path(key))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return info.Size() > 0, nil
+}
+
+// S3Client is the subset of the AWS SDK S3 client used by s3CacheStore,
+// narrowed to ease testing with a fake (mirrors the S3Client interface
+// pattern used for media fetches in the clipper project).
+type S3Client interface {
+	PutObject(ctx context.Context, bucket, key string, r io.Reader, size int64) error
+	GetObject(ctx context.Context, bucket, key string) (io.ReadCloser, error)
+	HeadObject(ctx context.Context, bucket, key string) (bool, error)
+	PresignGetObject(ctx context.Context, bucket, key string, ttl time.Duration) (string, error)
+}
+
+// s3CacheStore implements CacheStore against an S3-compatible bucket
+// (also usable for GCS via its S3-compatibility layer), using a
+// multipart upload under the hood so Put can stream from an io.Reader of
+// unknown length.
+type s3CacheStore struct {
+	client S3Client
+	bucket string
+	prefix string
+}
+
+// NewS3CacheStore creates a CacheStore backed by an S3-compatible bucket.
+func NewS3CacheStore(client S3Client, bucket, prefix string) CacheStore {
+	return &s3CacheStore{client: client, bucket: bucket, prefix: prefix}
+}
+
+func (s *s3CacheStore) key(key string) string {
+	if s.prefix == "" {
+		return key + ".mp4"
+	}
+	return s.prefix + "/" + key + ".mp4"
+}
+
+func (s *s3CacheStore) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	if err := s.client.PutObject(ctx, s.bucket, s.key(key), r, size); err != nil {
+		return fmt.Errorf("s3 put %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *s3CacheStore) Get(key string) (io.ReadCloser, error) {
+	return s.client.GetObject(context.Background(), s.bucket, s.key(key))
+}
+
+func (s *s3CacheStore) PresignedURL(key string, ttl time.Duration) (string, error) {
+	return s.client.PresignGetObject(context.Background(), s.bucket, s.key(key), ttl)
+}
+
+func (s *s3CacheStore) Exists(key string) (bool, error) {
+	return s.client.HeadObject(context.Background(), s.bucket, s.key(key))
+}
+
+// S3Config configures the S3-compatible bucket cached MP4s are offloaded to.
+// The zero value (Bucket == "") means object storage is disabled and
+// newCacheStoreFromConfig falls back to the local on-disk cache.
+type S3Config struct {
+	Bucket   string
+	Region   string
+	Prefix   string
+	Endpoint string // non-AWS S3-compatible endpoint (e.g. MinIO, Cloudflare R2); empty uses AWS's default resolution
+}
+
+// newCacheStoreFromConfig builds the CacheStore NewServer wires into
+// DownloadManager: local disk by default, or an S3-compatible bucket when
+// cfg.Bucket is set, so a fronted deployment can scale download bandwidth
+// away from the app server instead of proxying every byte through it - see
+// s3CacheStore.PresignedURL and handleGetVideoURL.
+func newCacheStoreFromConfig(cache *VideoCache, cfg S3Config) (CacheStore, error) {
+	if cfg.Bucket == "" {
+		return NewLocalCacheStore(cache.Dir()), nil
+	}
+
+	client, err := newAWSS3Client(context.Background(), cfg.Region, cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize S3 client: %w", err)
+	}
+	return NewS3CacheStore(client, cfg.Bucket, cfg.Prefix), nil
+}
+
+// awsS3Client implements S3Client against the real AWS SDK v2 S3 client.
+// PutObject goes through an s3manager.Uploader so it can stream from an
+// io.Reader of unknown length (muxIntoStore's ffmpeg stdout pipe) without
+// buffering the whole file in memory first.
+type awsS3Client struct {
+	client   *s3.Client
+	uploader *manager.Uploader
+	presign  *s3.PresignClient
+}
+
+// newAWSS3Client builds an S3Client backed by the AWS SDK v2, loading
+// credentials from the standard AWS chain (env vars, shared config file,
+// instance/task role). endpoint overrides AWS's default endpoint resolution
+// for S3-compatible services (MinIO, Cloudflare R2); leave it empty to talk
+// to AWS S3 directly.
+func newAWSS3Client(ctx context.Context, region, endpoint string) (S3Client, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if region != "" {
+		opts = append(opts, awsconfig.WithRegion(region))
+	}
+
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("loading AWS config: %w", err)
+	}
+
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+			o.UsePathStyle = true
+		}
+	})
+
+	return &awsS3Client{
+		client:   client,
+		uploader: manager.NewUploader(client),
+		presign:  s3.NewPresignClient(client),
+	}, nil
+}
+
+func (a *awsS3Client) PutObject(ctx context.Context, bucket, key string, r io.Reader, size int64) error {
+	_, err := a.uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   r,
+	})
+	return err
+}
+
+func (a *awsS3Client) GetObject(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	out, err := a.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Body, nil
+}
+
+func (a *awsS3Client) HeadObject(ctx context.Context, bucket, key string) (bool, error) {
+	_, err := a.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var notFound *types.NotFound
+		if errors.As(err, &notFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (a *awsS3Client) PresignGetObject(ctx context.Context, bucket, key string, ttl time.Duration) (string, error) {
+	req, err := a.presign.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", err
+	}
+	return req.URL, nil
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// opmlDocument is the subset of OPML 2.0 we care about: a flat or
+// nested list of <outline> entries, as exported by RSS readers and by
+// Google Takeout's YouTube-subscriptions-to-OPML conversion.
+type opmlDocument struct {
+	XMLName xml.Name `xml:"opml"`
+	Body    struct {
+		Outlines []opmlOutline `xml:"outline"`
+	} `xml:"body"`
+}
+
+type opmlOutline struct {
+	Text     string        `xml:"text,attr"`
+	Title    string        `xml:"title,attr"`
+	XMLURL   string        `xml:"xmlUrl,attr"`
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+type opmlChannel struct {
+	URL  string
+	Name string
+}
+
+// parseOPMLChannels extracts YouTube channels from an OPML document's
+// <outline xmlUrl="..."> entries, recursing into nested category
+// outlines. Entries whose xmlUrl isn't a YouTube channel feed URL (i.e.
+// has no channel_id query param) are skipped. Returns nil if data isn't a
+// recognizable OPML document.
+func parseOPMLChannels(data []byte) []opmlChannel {
+	var doc opmlDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil
+	}
+
+	var channels []opmlChannel
+	var walk func(outlines []opmlOutline)
+	walk = func(outlines []opmlOutline) {
+		for _, o := range outlines {
+			if o.XMLURL != "" {
+				if channelID := channelIDFromFeedURL(o.XMLURL); channelID != "" {
+					name := o.Title
+					if name == "" {
+						name = o.Text
+					}
+					channels = append(channels, opmlChannel{
+						URL:  fmt.Sprintf("https://www.youtube.com/channel/%s", channelID),
+						Name: name,
+					})
+				}
+			}
+			if len(o.Outlines) > 0 {
+				walk(o.Outlines)
+			}
+		}
+	}
+	walk(doc.Body.Outlines)
+	return channels
+}
+
+// channelIDFromFeedURL extracts the channel_id query param from a YouTube
+// channel RSS feed URL (https://www.youtube.com/feeds/videos.xml?channel_id=...).
+func channelIDFromFeedURL(feedURL string) string {
+	u, err := url.Parse(feedURL)
+	if err != nil {
+		return ""
+	}
+	return u.Query().Get("channel_id")
+}
+
+// buildOPML renders a feed's channels as an OPML 2.0 document so they can
+// be imported into other RSS readers.
+func buildOPML(feedName string, channels []opmlChannel) []byte {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString("<opml version=\"2.0\">\n")
+	b.WriteString("  <head>\n    <title>")
+	xml.EscapeText(&b, []byte(feedName))
+	b.WriteString("</title>\n  </head>\n  <body>\n")
+	for _, ch := range channels {
+		channelID := strings.TrimPrefix(ch.URL, "https://www.youtube.com/channel/")
+		feedURL := fmt.Sprintf("https://www.youtube.com/feeds/videos.xml?channel_id=%s", channelID)
+
+		b.WriteString("    <outline text=\"")
+		xml.EscapeText(&b, []byte(ch.Name))
+		b.WriteString("\" title=\"")
+		xml.EscapeText(&b, []byte(ch.Name))
+		b.WriteString("\" type=\"rss\" xmlUrl=\"")
+		xml.EscapeText(&b, []byte(feedURL))
+		b.WriteString("\" htmlUrl=\"")
+		xml.EscapeText(&b, []byte(ch.URL))
+		b.WriteString("\"/>\n")
+	}
+	b.WriteString("  </body>\n</opml>\n")
+	return []byte(b.String())
+}
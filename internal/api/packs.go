@@ -0,0 +1,386 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// pack is a curated list of channels a user can bulk-subscribe to in one
+// step - see handlePackSubscribe. Read-only packs ship embedded in the
+// binary (web.Packs); user-authored ones are persisted as JSON files under
+// packsDir, which overlays the embedded set by name.
+type pack struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Channels    []packChannel `json:"channels"`
+}
+
+type packChannel struct {
+	ChannelID  string `json:"channel_id"`
+	ChannelURL string `json:"channel_url"`
+	Title      string `json:"title"`
+}
+
+func (p pack) validate() error {
+	if p.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(p.Channels) == 0 {
+		return errors.New("channels must not be empty")
+	}
+	for i, c := range p.Channels {
+		if c.ChannelID == "" && c.ChannelURL == "" {
+			return fmt.Errorf("channel %d: channel_id or channel_url is required", i)
+		}
+	}
+	return nil
+}
+
+// packPath returns the on-disk path a user-authored pack named name is
+// persisted at, rejecting names that would escape packsDir.
+func (s *Server) packPath(name string) (string, error) {
+	clean := filepath.Base(name)
+	if clean != name || clean == "." || clean == ".." || clean == "" {
+		return "", errors.New("invalid pack name")
+	}
+	return filepath.Join(s.packsDir, clean+".json"), nil
+}
+
+// packETag computes a stable ETag for a pack file's current contents, used
+// for optimistic concurrency on handlePackUpdate.
+func packETag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:])[:16] + `"`
+}
+
+// loadPack reads and parses a pack by name, preferring a user-authored
+// version in packsDir over the embedded read-only copy.
+func (s *Server) loadPack(name string) (pack, error) {
+	var data []byte
+	if path, err := s.packPath(name); err == nil {
+		if d, rerr := os.ReadFile(path); rerr == nil {
+			data = d
+		}
+	}
+	if data == nil {
+		d, err := fs.ReadFile(s.packs, "packs/"+name+".json")
+		if err != nil {
+			return pack{}, fmt.Errorf("pack not found: %s", name)
+		}
+		data = d
+	}
+
+	var p pack
+	if err := json.Unmarshal(data, &p); err != nil {
+		return pack{}, fmt.Errorf("invalid pack file: %w", err)
+	}
+	return p, nil
+}
+
+// handlePacksList returns a JSON list of available packs, merging
+// user-authored packs in packsDir with the read-only embedded set -
+// user-authored packs take precedence over an embedded pack of the same
+// name.
+func (s *Server) handlePacksList(w http.ResponseWriter, r *http.Request) {
+	type packInfo struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	}
+
+	seen := make(map[string]bool)
+	var packs []packInfo
+
+	if entries, err := os.ReadDir(s.packsDir); err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+				continue
+			}
+			name := strings.TrimSuffix(entry.Name(), ".json")
+			seen[name] = true
+			packs = append(packs, packInfo{Name: name, URL: "/packs/" + entry.Name()})
+		}
+	}
+
+	if entries, err := fs.ReadDir(s.packs, "packs"); err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+				continue
+			}
+			name := strings.TrimSuffix(entry.Name(), ".json")
+			if seen[name] {
+				continue
+			}
+			packs = append(packs, packInfo{Name: name, URL: "/packs/" + entry.Name()})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(packs)
+}
+
+// handlePackFile serves a specific pack file, preferring a user-authored
+// version in packsDir over the embedded read-only copy - see
+// handlePackCreate/handlePackUpdate.
+func (s *Server) handlePackFile(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if strings.HasSuffix(name, ".json") {
+		name = strings.TrimSuffix(name, ".json")
+	}
+
+	if path, err := s.packPath(name); err == nil {
+		if data, rerr := os.ReadFile(path); rerr == nil {
+			w.Header().Set("ETag", packETag(data))
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(data)
+			return
+		}
+	}
+
+	data, err := fs.ReadFile(s.packs, "packs/"+name+".json")
+	if err != nil {
+		http.Error(w, "Pack not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// handlePackCreate persists a new user-authored pack to packsDir.
+//
+// POST /api/packs
+func (s *Server) handlePackCreate(w http.ResponseWriter, r *http.Request) {
+	var p pack
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		jsonError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := p.validate(); err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	path, err := s.packPath(p.Name)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, err := os.Stat(path); err == nil {
+		jsonError(w, "Pack already exists", http.StatusConflict)
+		return
+	}
+
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		jsonError(w, "Failed to encode pack", http.StatusInternalServerError)
+		return
+	}
+	if err := os.MkdirAll(s.packsDir, 0755); err != nil {
+		jsonError(w, "Failed to create packs directory", http.StatusInternalServerError)
+		return
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		jsonError(w, "Failed to save pack", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", packETag(data))
+	w.WriteHeader(http.StatusCreated)
+	jsonResponse(w, p)
+}
+
+// handlePackUpdate replaces a user-authored pack's contents, enforcing
+// optimistic concurrency via If-Match against the pack's current ETag (see
+// packETag) so two editors can't silently clobber each other's changes.
+// Embedded packs can't be edited - the first PUT for an embedded pack's name
+// creates a packsDir overlay rather than modifying the embedded copy.
+//
+// PUT /api/packs/{name}
+func (s *Server) handlePackUpdate(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if strings.HasSuffix(name, ".json") {
+		name = strings.TrimSuffix(name, ".json")
+	}
+
+	var p pack
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		jsonError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if p.Name == "" {
+		p.Name = name
+	}
+	if err := p.validate(); err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	path, err := s.packPath(name)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if existing, err := os.ReadFile(path); err == nil {
+		if ifMatch := r.Header.Get("If-Match"); ifMatch != "" && ifMatch != packETag(existing) {
+			jsonError(w, "Pack was modified by another request", http.StatusPreconditionFailed)
+			return
+		}
+	} else if r.Header.Get("If-Match") != "" {
+		jsonError(w, "Pack not found", http.StatusNotFound)
+		return
+	}
+
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		jsonError(w, "Failed to encode pack", http.StatusInternalServerError)
+		return
+	}
+	if err := os.MkdirAll(s.packsDir, 0755); err != nil {
+		jsonError(w, "Failed to create packs directory", http.StatusInternalServerError)
+		return
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		jsonError(w, "Failed to save pack", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", packETag(data))
+	jsonResponse(w, p)
+}
+
+// handlePackDelete removes a user-authored pack's packsDir overlay. Embedded
+// packs aren't affected - only overlays written by
+// handlePackCreate/handlePackUpdate live in packsDir.
+//
+// DELETE /api/packs/{name}
+func (s *Server) handlePackDelete(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if strings.HasSuffix(name, ".json") {
+		name = strings.TrimSuffix(name, ".json")
+	}
+
+	path, err := s.packPath(name)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			jsonError(w, "Pack not found", http.StatusNotFound)
+		} else {
+			jsonError(w, "Failed to delete pack", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlePackSubscribe bulk-subscribes feedID to every channel in the named
+// pack, streaming per-channel results via SSE so large packs can be watched
+// importing in real time - the per-channel subscribe logic mirrors
+// handleSubscribeFromWatch.
+//
+// POST /api/packs/{name}/subscribe?feed_id=N
+func (s *Server) handlePackSubscribe(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	feedID, err := strconv.ParseInt(r.URL.Query().Get("feed_id"), 10, 64)
+	if err != nil {
+		jsonError(w, "Invalid feed ID", http.StatusBadRequest)
+		return
+	}
+
+	// feed_id=0 means "create/reuse the Uncategorized feed" (below), which
+	// is already scoped to the caller - any other ID must be one of the
+	// caller's own feeds.
+	if feedID != 0 {
+		if exists, err := s.db.FeedExists(s.currentUserID(r), feedID); err != nil {
+			jsonError(w, err.Error(), http.StatusInternalServerError)
+			return
+		} else if !exists {
+			jsonError(w, "Feed not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	p, err := s.loadPack(name)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		jsonError(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	sendEvent := func(eventType string, data any) {
+		jsonData, _ := json.Marshal(data)
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", eventType, jsonData)
+		flusher.Flush()
+	}
+
+	// Handle "Uncategorized" feed (feed_id=0), same convention as
+	// handleSubscribeFromWatch.
+	if feedID == 0 {
+		feed, err := s.db.GetOrCreateFeed(s.currentUserID(r), "Uncategorized")
+		if err != nil {
+			sendEvent("error", map[string]any{"message": "failed to create Uncategorized feed"})
+			return
+		}
+		feedID = feed.ID
+	}
+
+	for _, ch := range p.Channels {
+		select {
+		case <-r.Context().Done():
+			return
+		default:
+		}
+
+		result := map[string]any{"channel": ch.Title, "url": ch.ChannelURL}
+
+		existing, err := s.db.GetChannelByURL(ch.ChannelURL)
+		if err != nil {
+			result["status"] = "error"
+			result["error"] = err.Error()
+			sendEvent("channel", result)
+			continue
+		}
+		if existing != nil {
+			result["status"] = "skipped"
+			sendEvent("channel", result)
+			continue
+		}
+
+		if _, err := s.db.AddChannel(feedID, ch.ChannelURL, ch.Title); err != nil {
+			result["status"] = "error"
+			result["error"] = err.Error()
+			sendEvent("channel", result)
+			continue
+		}
+
+		result["status"] = "subscribed"
+		sendEvent("channel", result)
+	}
+
+	sendEvent("complete", map[string]any{"pack": p.Name})
+}
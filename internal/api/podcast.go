@@ -0,0 +1,115 @@
+package api
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/erik/feeds/internal/db"
+	"github.com/erik/feeds/internal/models"
+)
+
+// podcastEpisodeLimit caps how many of a feed's most recent videos are
+// rendered as <item>s, mirroring handleAPIGetRecentVideos's default page
+// size - a podcast client re-polls the feed for anything older.
+const podcastEpisodeLimit = 100
+
+// requestBaseURL reconstructs the scheme+host a request arrived on, so
+// buildPodcastRSS can emit absolute <enclosure> URLs without a configured
+// public base URL. X-Forwarded-Proto is honored for the common case of
+// running behind a TLS-terminating reverse proxy.
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s", scheme, r.Host)
+}
+
+// buildPodcastRSS renders feed as an RSS 2.0 document with the itunes:
+// namespace, so any podcast client can subscribe to it directly. Only
+// videos with a downloaded episode file (EpisodeStatus == db.EpisodeStatusDownloaded)
+// get an <enclosure>; the rest are included without one so the episode
+// list stays complete while PodcastEpisodeWorker catches up.
+func buildPodcastRSS(feed *models.Feed, videos []models.Video, baseURL string) []byte {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<rss version="2.0" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd">` + "\n")
+	b.WriteString("  <channel>\n")
+	writeXMLElement(&b, "    ", "title", feed.Name)
+	if feed.Description != "" {
+		writeXMLElement(&b, "    ", "description", feed.Description)
+	}
+	if feed.Author != "" {
+		writeXMLElement(&b, "    ", "itunes:author", feed.Author)
+	}
+	if category := firstTag(feed.Tags); category != "" {
+		b.WriteString("    <itunes:category text=\"")
+		xml.EscapeText(&b, []byte(category))
+		b.WriteString("\"/>\n")
+	}
+	b.WriteString("    <itunes:explicit>no</itunes:explicit>\n")
+	b.WriteString("    <lastBuildDate>")
+	b.WriteString(feed.UpdatedAt.Format(time.RFC1123Z))
+	b.WriteString("</lastBuildDate>\n")
+
+	for i, v := range videos {
+		b.WriteString("    <item>\n")
+		writeXMLElement(&b, "      ", "title", v.Title)
+		writeXMLElement(&b, "      ", "itunes:summary", v.Title)
+		writeXMLElement(&b, "      ", "guid", v.ID)
+		b.WriteString("      <pubDate>")
+		b.WriteString(v.Published.Format(time.RFC1123Z))
+		b.WriteString("</pubDate>\n")
+		if v.Duration > 0 {
+			writeXMLElement(&b, "      ", "itunes:duration", formatItunesDuration(v.Duration))
+		}
+		fmt.Fprintf(&b, "      <itunes:episode>%d</itunes:episode>\n", i+1)
+		if v.Thumbnail != "" {
+			b.WriteString("      <itunes:image href=\"")
+			xml.EscapeText(&b, []byte(v.Thumbnail))
+			b.WriteString("\"/>\n")
+		}
+		if v.EpisodeStatus == db.EpisodeStatusDownloaded && v.FileURL != "" {
+			b.WriteString("      <enclosure url=\"")
+			xml.EscapeText(&b, []byte(baseURL+v.FileURL))
+			b.WriteString(fmt.Sprintf("\" length=\"%d\" type=\"%s\"/>\n", v.Bytes, mimeTypeOrDefault(v.MimeType)))
+		}
+		b.WriteString("    </item>\n")
+	}
+
+	b.WriteString("  </channel>\n</rss>\n")
+	return []byte(b.String())
+}
+
+func writeXMLElement(b *strings.Builder, indent, tag, value string) {
+	b.WriteString(indent + "<" + tag + ">")
+	xml.EscapeText(b, []byte(value))
+	b.WriteString("</" + tag + ">\n")
+}
+
+// firstTag returns feed.Tags' first comma-separated entry, trimmed, for use
+// as the single itunes:category RSS only allows one primary value for.
+func firstTag(tags string) string {
+	if tags == "" {
+		return ""
+	}
+	return strings.TrimSpace(strings.Split(tags, ",")[0])
+}
+
+// formatItunesDuration renders seconds as itunes:duration's HH:MM:SS form.
+func formatItunesDuration(seconds int) string {
+	h := seconds / 3600
+	m := (seconds % 3600) / 60
+	s := seconds % 60
+	return fmt.Sprintf("%02d:%02d:%02d", h, m, s)
+}
+
+func mimeTypeOrDefault(mimeType string) string {
+	if mimeType == "" {
+		return "audio/mpeg"
+	}
+	return mimeType
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/erik/feeds/internal/db"
+	"github.com/erik/feeds/internal/ytdlp"
+)
+
+const (
+	podcastWorkerPollInterval = 5 * time.Minute
+	podcastWorkerBatchSize    = 10
+)
+
+// PodcastEpisodeWorker downloads and transcodes audio for videos belonging
+// to a feed with podcast export enabled (see db.UpdateFeedPodcastSettings),
+// then sweeps expired episodes once their owning feed's retention policy has
+// passed - mirroring sponsorblock.Worker's poll-loop shape.
+type PodcastEpisodeWorker struct {
+	db          *db.DB
+	yt          ytdlp.Client
+	episodesDir string
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPodcastEpisodeWorker creates a PodcastEpisodeWorker. Call Start to begin
+// polling. episodesDir is created on demand the first time an episode is
+// downloaded, the same way packsDir is.
+func NewPodcastEpisodeWorker(database *db.DB, yt ytdlp.Client, episodesDir string) *PodcastEpisodeWorker {
+	return &PodcastEpisodeWorker{db: database, yt: yt, episodesDir: episodesDir}
+}
+
+// Start launches the poll loop in the background. It returns immediately;
+// call Stop, or cancel ctx, to stop it.
+func (w *PodcastEpisodeWorker) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	w.cancel = cancel
+
+	w.wg.Add(1)
+	go w.run(ctx)
+}
+
+// Stop cancels the poll loop and waits for the in-flight batch to finish.
+func (w *PodcastEpisodeWorker) Stop() {
+	if w.cancel != nil {
+		w.cancel()
+	}
+	w.wg.Wait()
+}
+
+func (w *PodcastEpisodeWorker) run(ctx context.Context) {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(podcastWorkerPollInterval)
+	defer ticker.Stop()
+
+	for {
+		w.processNewEpisodes(ctx)
+		w.cleanupExpiredEpisodes(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// processNewEpisodes downloads and transcodes audio for every video due for
+// one, recording the result with db.UpdateVideoEpisode. Every episode is
+// transcoded to 128k MP3 by downloadEpisode; the feed's high/low quality
+// setting is not consulted.
+func (w *PodcastEpisodeWorker) processNewEpisodes(ctx context.Context) {
+	videos, err := w.db.GetVideosNeedingEpisodeDownload(podcastWorkerBatchSize)
+	if err != nil {
+		log.Printf("podcast worker: failed to list videos needing episode download: %v", err)
+		return
+	}
+
+	for _, v := range videos {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		fileURL, mimeType, bytes, err := w.downloadEpisode(v.ID, v.URL)
+		if err != nil {
+			log.Printf("podcast worker: failed to download episode for %s: %v", v.ID, err)
+			if err := w.db.UpdateVideoEpisode(v.ID, "", "", 0, db.EpisodeStatusError); err != nil {
+				log.Printf("podcast worker: failed to mark episode error for %s: %v", v.ID, err)
+			}
+			continue
+		}
+
+		if err := w.db.UpdateVideoEpisode(v.ID, fileURL, mimeType, bytes, db.EpisodeStatusDownloaded); err != nil {
+			log.Printf("podcast worker: failed to record episode for %s: %v", v.ID, err)
+		}
+	}
+}
+
+// downloadEpisode fetches videoURL's best available audio via yt-dlp and
+// transcodes it to MP3 with ffmpeg, matching (*DownloadManager).muxIntoStore's
+// exec.Command shelling pattern. Quality (high/low bitrate) isn't threaded
+// through per-video here since it's a feed-level setting the caller already
+// filtered on; a fixed 128k bitrate keeps episode files modest either way.
+func (w *PodcastEpisodeWorker) downloadEpisode(videoID, videoURL string) (fileURL, mimeType string, bytes int64, err error) {
+	sourceURL, _, err := w.yt.GetDownloadURL(videoURL, "audio")
+	if err != nil {
+		return "", "", 0, err
+	}
+
+	if err := os.MkdirAll(w.episodesDir, 0755); err != nil {
+		return "", "", 0, err
+	}
+
+	outPath := filepath.Join(w.episodesDir, videoID+".mp3")
+	cmd := exec.Command("ffmpeg", "-y", "-i", sourceURL, "-vn", "-c:a", "libmp3lame", "-b:a", "128k", outPath)
+	if err := cmd.Run(); err != nil {
+		return "", "", 0, fmt.Errorf("ffmpeg transcode failed: %w", err)
+	}
+
+	info, err := os.Stat(outPath)
+	if err != nil {
+		return "", "", 0, err
+	}
+
+	return "/episodes/" + videoID + ".mp3", "audio/mpeg", info.Size(), nil
+}
+
+// cleanupExpiredEpisodes deletes downloaded episode files whose owning
+// feed's retention policy has passed, and marks them db.EpisodeStatusCleaned
+// so they're excluded from future podcast RSS enclosures.
+func (w *PodcastEpisodeWorker) cleanupExpiredEpisodes(ctx context.Context) {
+	videos, err := w.db.GetExpiredEpisodes(podcastWorkerBatchSize)
+	if err != nil {
+		log.Printf("podcast worker: failed to list expired episodes: %v", err)
+		return
+	}
+
+	for _, v := range videos {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		if v.FileURL != "" {
+			path := filepath.Join(w.episodesDir, filepath.Base(v.FileURL))
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				log.Printf("podcast worker: failed to delete expired episode file for %s: %v", v.ID, err)
+				continue
+			}
+		}
+
+		if err := w.db.UpdateVideoEpisode(v.ID, "", "", 0, db.EpisodeStatusCleaned); err != nil {
+			log.Printf("podcast worker: failed to mark episode cleaned for %s: %v", v.ID, err)
+		}
+	}
+}
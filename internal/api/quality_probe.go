@@ -0,0 +1,170 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/erik/feeds/internal/ytdlp"
+)
+
+// qualityProbeTTL is how long a video's probed quality list is cached
+// before a fresh yt-dlp/native lookup is made - short enough to pick up a
+// newly-finished live-to-VOD transcode, long enough that repeated
+// handleGetQualities polling from the SPA doesn't hammer the backend.
+const qualityProbeTTL = 30 * time.Minute
+
+// qualityInfo is one quality rung a video actually offers, as reported by
+// qualityProbe - replacing the hardcoded {360,480,720,1080,1440,2160} list
+// handleGetQualities used to return regardless of the source.
+type qualityInfo struct {
+	Quality          string `json:"quality"` // height as a string, e.g. "1080" - what StartDownload expects
+	Height           int    `json:"height"`
+	FPS              int    `json:"fps"`
+	Bitrate          int    `json:"bitrate"` // kbps
+	HDR              bool   `json:"hdr"`
+	Codec            string `json:"codec"`
+	FilesizeEstimate int64  `json:"filesize_estimate"`
+}
+
+type qualityProbeEntry struct {
+	qualities []qualityInfo
+	expiresAt time.Time
+}
+
+// qualityProbe enumerates a video's real available resolutions/fps/bitrate
+// via the ytdlp.Client backend in use, caching the result per videoID so
+// handleGetQualities and handleStartDownload's "auto" mode don't re-query
+// on every request.
+type qualityProbe struct {
+	yt ytdlp.Client
+
+	mu    sync.RWMutex
+	cache map[string]*qualityProbeEntry
+}
+
+func newQualityProbe(yt ytdlp.Client) *qualityProbe {
+	return &qualityProbe{
+		yt:    yt,
+		cache: make(map[string]*qualityProbeEntry),
+	}
+}
+
+// Probe returns videoID's available qualities, highest height first,
+// querying the backend and populating the cache on a miss or expiry.
+func (qp *qualityProbe) Probe(videoID string) ([]qualityInfo, error) {
+	qp.mu.RLock()
+	entry, ok := qp.cache[videoID]
+	qp.mu.RUnlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.qualities, nil
+	}
+
+	videoURL := "https://www.youtube.com/watch?v=" + videoID
+	formats, err := qp.yt.GetFormats(videoURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe formats for %s: %w", videoID, err)
+	}
+
+	qualities := formatsToQualities(formats)
+
+	qp.mu.Lock()
+	qp.cache[videoID] = &qualityProbeEntry{
+		qualities: qualities,
+		expiresAt: time.Now().Add(qualityProbeTTL),
+	}
+	qp.mu.Unlock()
+
+	return qualities, nil
+}
+
+// formatsToQualities collapses yt-dlp's raw per-itag format list down to
+// one entry per distinct height, keeping the highest-bitrate format at
+// each height (progressive and DASH variants of the same resolution both
+// show up, and the highest-bitrate one is the one worth offering).
+func formatsToQualities(formats []ytdlp.Format) []qualityInfo {
+	byHeight := make(map[int]qualityInfo)
+	for _, f := range formats {
+		if !f.IsVideo() || f.Height <= 0 {
+			continue
+		}
+
+		filesize := f.Filesize
+		if filesize == 0 {
+			filesize = f.FilesizeApprox
+		}
+
+		existing, ok := byHeight[f.Height]
+		if ok && existing.Bitrate >= int(f.TBR) {
+			continue
+		}
+
+		byHeight[f.Height] = qualityInfo{
+			Quality:          strconv.Itoa(f.Height),
+			Height:           f.Height,
+			FPS:              int(f.FPS),
+			Bitrate:          int(f.TBR),
+			HDR:              isHDR(f.DynamicRange),
+			Codec:            f.VCodec,
+			FilesizeEstimate: filesize,
+		}
+	}
+
+	qualities := make([]qualityInfo, 0, len(byHeight))
+	for _, q := range byHeight {
+		qualities = append(qualities, q)
+	}
+	sort.Slice(qualities, func(i, j int) bool {
+		return qualities[i].Height > qualities[j].Height
+	})
+	return qualities
+}
+
+// autoSelectQuality picks the highest probed quality for videoID no taller
+// than maxHeight and no more bitrate than maxBitrate kbps (either limit of
+// 0 means unbounded), so clients can request "best under 3000kbps" without
+// hardcoding a rung - see handleStartDownload's "auto" quality mode.
+func (s *Server) autoSelectQuality(videoID string, maxHeight, maxBitrate int) (string, error) {
+	qualities, err := s.qualityProbe.Probe(videoID)
+	if err != nil {
+		qualities = defaultQualities
+	}
+
+	var best *qualityInfo
+	for i := range qualities {
+		q := &qualities[i]
+		if maxHeight > 0 && q.Height > maxHeight {
+			continue
+		}
+		if maxBitrate > 0 && q.Bitrate > maxBitrate {
+			continue
+		}
+		if best == nil || q.Height > best.Height {
+			best = q
+		}
+	}
+	if best == nil {
+		return "", fmt.Errorf("no quality available under the requested budget")
+	}
+	return best.Quality, nil
+}
+
+func isHDR(dynamicRange string) bool {
+	return dynamicRange == "HDR" || dynamicRange == "HDR10" || dynamicRange == "DV"
+}
+
+// defaultQualities is what handleGetQualities and autoSelectQuality fall
+// back to when qualityProbe.Probe returns an error (Probe itself never
+// returns this list) because a backend's format probe failed (e.g. the video's gone private, or the
+// yt-dlp binary errors), preserving the pre-qualityProbe behavior rather
+// than returning nothing.
+var defaultQualities = []qualityInfo{
+	{Quality: "2160", Height: 2160},
+	{Quality: "1440", Height: 1440},
+	{Quality: "1080", Height: 1080},
+	{Quality: "720", Height: 720},
+	{Quality: "480", Height: 480},
+	{Quality: "360", Height: 360},
+}
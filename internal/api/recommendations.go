@@ -0,0 +1,143 @@
+package api
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/erik/feeds/internal/models"
+)
+
+const (
+	// recommendationHalfLifeDays is how many days of not watching a channel
+	// it takes for a single past watch's contribution to its score to halve.
+	recommendationHalfLifeDays = 30.0
+
+	// recommendationMinEvents is the minimum number of watches a channel
+	// needs before it's eligible for the "For You" Feed - one lucky click
+	// shouldn't outrank a channel genuinely watched often.
+	recommendationMinEvents = 3
+
+	// recommendationTopN caps how many channels the "For You" Feed holds.
+	recommendationTopN = 20
+)
+
+// scoreWatchHistoryChannels ranks channels by exponential time-decay
+// recency-weighted frequency instead of raw watch count: each watch
+// contributes exp(-ln(2)*Δdays/halfLife) to its channel's score (Δdays
+// measured from now), so its contribution is exactly halved every
+// recommendationHalfLifeDays and a channel binged once last year scores
+// lower than one watched a little most weeks. Channels in ignored, or
+// under recommendationMinEvents watches, are excluded.
+func scoreWatchHistoryChannels(entries []models.WatchHistoryEntry, ignored map[string]bool, now time.Time) []models.WatchHistoryChannel {
+	type accum struct {
+		name        string
+		score       float64
+		events      int
+		lastWatched time.Time
+	}
+	byURL := make(map[string]*accum)
+
+	for _, entry := range entries {
+		if entry.Header != "YouTube" || len(entry.Subtitles) == 0 {
+			continue
+		}
+		url := entry.Subtitles[0].URL
+		if url == "" || ignored[url] {
+			continue
+		}
+
+		deltaDays := now.Sub(entry.Time).Hours() / 24
+		if deltaDays < 0 {
+			deltaDays = 0
+		}
+
+		a, ok := byURL[url]
+		if !ok {
+			a = &accum{name: entry.Subtitles[0].Name}
+			byURL[url] = a
+		}
+		a.score += math.Exp(-math.Ln2 * deltaDays / recommendationHalfLifeDays)
+		a.events++
+		if entry.Time.After(a.lastWatched) {
+			a.lastWatched = entry.Time
+		}
+	}
+
+	channels := make([]models.WatchHistoryChannel, 0, len(byURL))
+	for url, a := range byURL {
+		if a.events < recommendationMinEvents {
+			continue
+		}
+		lastWatched := a.lastWatched
+		channels = append(channels, models.WatchHistoryChannel{
+			URL:         url,
+			Name:        a.name,
+			WatchCount:  a.events,
+			Score:       a.score,
+			LastWatched: &lastWatched,
+		})
+	}
+
+	sort.Slice(channels, func(i, j int) bool { return channels[i].Score > channels[j].Score })
+	if len(channels) > recommendationTopN {
+		channels = channels[:recommendationTopN]
+	}
+	return channels
+}
+
+// buildForYouFeed re-ranks userID's "For You" system Feed from historyJSON
+// (watch-history.json's raw bytes), called after every watch-history import
+// so the recommendation stays current. Returns the channels it ranked in, so
+// the caller can surface the score/last-watched explanation alongside the
+// import result.
+func (s *Server) buildForYouFeed(userID int64, historyJSON []byte) ([]models.WatchHistoryChannel, error) {
+	var entries []models.WatchHistoryEntry
+	if err := json.Unmarshal(historyJSON, &entries); err != nil {
+		return nil, err
+	}
+
+	ignored, err := s.db.GetIgnoredRecommendationChannels(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	channels := scoreWatchHistoryChannels(entries, ignored, time.Now())
+
+	feed, err := s.db.EnsureForYouFeedExists(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.ReplaceFeedChannels(feed.ID, channels); err != nil {
+		return nil, err
+	}
+
+	return channels, nil
+}
+
+// handleAPIIgnoreRecommendedChannel records that the caller explicitly
+// doesn't want the channel suggested again, so the next buildForYouFeed call
+// excludes it even if it's still heavily represented in watch history.
+func (s *Server) handleAPIIgnoreRecommendedChannel(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		jsonError(w, "Invalid channel ID", http.StatusBadRequest)
+		return
+	}
+
+	channel, err := s.db.GetChannel(id)
+	if err != nil {
+		jsonError(w, "Channel not found", http.StatusNotFound)
+		return
+	}
+
+	if err := s.db.IgnoreChannelForRecommendations(s.currentUserID(r), channel.URL); err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -10,9 +10,24 @@ import (
 
 const (
 	refreshInterval = 4 * time.Hour
+
+	// streamsTabRefreshInterval and shortsTabRefreshInterval refresh their
+	// tabs independently of refreshInterval and of each other: livestreams
+	// need to be caught going live quickly, shorts churn faster than
+	// regular uploads but far slower than a stream's status, and regular
+	// videos change the least once published.
+	streamsTabRefreshInterval = 15 * time.Minute
+	shortsTabRefreshInterval  = 1 * time.Hour
+
+	// tabRefreshLimit bounds how many of a tab's newest videos are fetched
+	// per channel per refresh. The full "videos" refresh in
+	// refreshAllChannels asks FetchLatestVideos for 5 instead.
+	tabRefreshLimit = 10
 )
 
-// VideoRefreshScheduler handles periodic video refresh for all channels
+// VideoRefreshScheduler handles periodic video refresh for all channels,
+// split into the classic "videos" refresh plus independent cadences for
+// the shorts and streams channel tabs (see youtube.FetchChannelTab).
 type VideoRefreshScheduler struct {
 	db *db.DB
 }
@@ -21,6 +36,8 @@ type VideoRefreshScheduler struct {
 func NewVideoRefreshScheduler(database *db.DB) *VideoRefreshScheduler {
 	s := &VideoRefreshScheduler{db: database}
 	go s.run()
+	go s.runTab(youtube.TabStreams, streamsTabRefreshInterval)
+	go s.runTab(youtube.TabShorts, shortsTabRefreshInterval)
 	return s
 }
 
@@ -36,6 +53,50 @@ func (s *VideoRefreshScheduler) run() {
 	}
 }
 
+// runTab refreshes a single channel-page tab for every channel on its own
+// ticker, independent of the full "videos" refresh in run.
+func (s *VideoRefreshScheduler) runTab(tab string, interval time.Duration) {
+	s.refreshTab(tab)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.refreshTab(tab)
+	}
+}
+
+// refreshTab fetches tab's newest videos for every channel and upserts
+// them, tagging each with its tab so the frontend can query per tab via
+// db.GetVideosByChannelTab.
+func (s *VideoRefreshScheduler) refreshTab(tab string) {
+	channels, err := s.db.GetAllChannels()
+	if err != nil {
+		log.Printf("[scheduler] tab %s: failed to get channels: %v", tab, err)
+		return
+	}
+
+	for _, ch := range channels {
+		channelID := youtube.ExtractChannelID(ch.URL)
+		if channelID == "" {
+			continue
+		}
+
+		videos, err := youtube.FetchChannelTab(channelID, tab, tabRefreshLimit)
+		if err != nil {
+			log.Printf("[scheduler] tab %s: failed to fetch %s: %v", tab, ch.Name, err)
+			continue
+		}
+
+		for i := range videos {
+			videos[i].ChannelID = ch.ID
+			if _, err := s.db.UpsertVideo(&videos[i]); err != nil {
+				log.Printf("[scheduler] tab %s: failed to save video %s: %v", tab, videos[i].ID, err)
+			}
+		}
+	}
+}
+
 func (s *VideoRefreshScheduler) refreshAllChannels() {
 	channels, err := s.db.GetAllChannels()
 	if err != nil {
@@ -57,7 +118,7 @@ func (s *VideoRefreshScheduler) refreshAllChannels() {
 	for i, ch := range channels {
 		log.Printf("[scheduler] Refreshing channel %d/%d: %s", i+1, len(channels), ch.Name)
 
-		videos, err := youtube.FetchLatestVideos(ch.URL, 5)
+		videos, err := youtube.FetchLatestVideos(ch.URL, 5, true)
 		if err != nil {
 			log.Printf("[scheduler] Failed to fetch videos for %s: %v", ch.Name, err)
 			errorCount++
@@ -68,49 +129,61 @@ func (s *VideoRefreshScheduler) refreshAllChannels() {
 			continue
 		}
 
-		// Check shorts status for new videos
+		// Classify duration, shorts, and livestream status for new videos
+		// in one pass; skip any video that already has a shorts verdict, so
+		// a refresh doesn't re-fetch its metadata (its duration is not
+		// checked).
 		videoIDs := make([]string, len(videos))
 		for j, v := range videos {
 			videoIDs[j] = v.ID
 		}
 
-		// Get existing shorts status to avoid re-checking
 		existingStatus, err := s.db.GetVideoShortsStatus(videoIDs)
 		if err != nil {
 			log.Printf("[scheduler] Failed to get existing shorts status: %v", err)
 			existingStatus = map[string]bool{}
 		}
 
-		// Only check shorts for videos without status
-		var needsCheck []string
+		var needsClassify []string
 		for _, id := range videoIDs {
 			if _, hasStatus := existingStatus[id]; !hasStatus {
-				needsCheck = append(needsCheck, id)
+				needsClassify = append(needsClassify, id)
 			}
 		}
 
-		var shortsStatus map[string]bool
-		if len(needsCheck) > 0 {
-			shortsStatus = youtube.CheckShortsStatus(needsCheck)
+		var meta map[string]youtube.VideoMeta
+		if len(needsClassify) > 0 {
+			meta = youtube.ClassifyVideos(needsClassify)
 		} else {
-			shortsStatus = map[string]bool{}
-		}
-
-		// Merge existing status
-		for id, isShort := range existingStatus {
-			shortsStatus[id] = isShort
+			meta = map[string]youtube.VideoMeta{}
 		}
 
 		// Save videos
 		for j := range videos {
 			videos[j].ChannelID = ch.ID
-			if isShort, ok := shortsStatus[videos[j].ID]; ok {
+			if isShort, ok := existingStatus[videos[j].ID]; ok {
+				videos[j].IsShort = &isShort
+			} else if m, ok := meta[videos[j].ID]; ok {
+				videos[j].Duration = m.Duration
+				isShort := m.IsShort
 				videos[j].IsShort = &isShort
 			}
-			if err := s.db.UpsertVideo(&videos[j]); err != nil {
+			if _, err := s.db.UpsertVideo(&videos[j]); err != nil {
 				log.Printf("[scheduler] Failed to save video %s: %v", videos[j].ID, err)
 				continue
 			}
+			if m, ok := meta[videos[j].ID]; ok {
+				switch {
+				case m.IsLive:
+					if _, _, err := s.db.UpsertLiveStatus(videos[j].ID, db.LiveStatusLive, m.PremiereAt, nil); err != nil {
+						log.Printf("[scheduler] Failed to mark %s live: %v", videos[j].ID, err)
+					}
+				case m.PremiereAt != nil:
+					if _, _, err := s.db.UpsertLiveStatus(videos[j].ID, db.LiveStatusUpcoming, m.PremiereAt, nil); err != nil {
+						log.Printf("[scheduler] Failed to mark %s upcoming: %v", videos[j].ID, err)
+					}
+				}
+			}
 			totalVideos++
 		}
 	}
@@ -0,0 +1,280 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	segmentChunkSize   = 8 * 1024 * 1024 // 8MB per chunk
+	segmentConcurrency = 4               // concurrent range requests per stream
+	segmentMaxRetries  = 5
+	segmentBaseBackoff = 500 * time.Millisecond
+)
+
+// chunkManifest is persisted alongside a partial download so it can resume
+// after a crash or restart without re-fetching completed chunks.
+type chunkManifest struct {
+	URL       string `json:"url"`
+	Size      int64  `json:"size"`
+	ChunkSize int64  `json:"chunk_size"`
+	Completed []bool `json:"completed"`
+}
+
+func manifestPath(destPath string) string {
+	return destPath + ".part.json"
+}
+
+func loadChunkManifest(destPath, url string, size, chunkSize int64) *chunkManifest {
+	numChunks := int((size + chunkSize - 1) / chunkSize)
+
+	data, err := os.ReadFile(manifestPath(destPath))
+	if err == nil {
+		var m chunkManifest
+		if err := json.Unmarshal(data, &m); err == nil {
+			if m.URL == url && m.Size == size && m.ChunkSize == chunkSize && len(m.Completed) == numChunks {
+				return &m
+			}
+		}
+	}
+
+	return &chunkManifest{
+		URL:       url,
+		Size:      size,
+		ChunkSize: chunkSize,
+		Completed: make([]bool, numChunks),
+	}
+}
+
+func (m *chunkManifest) save(destPath string) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(manifestPath(destPath), data, 0644)
+}
+
+func chunkLen(index int, chunkSize, totalSize int64) int64 {
+	offset := int64(index) * chunkSize
+	if offset+chunkSize > totalSize {
+		return totalSize - offset
+	}
+	return chunkSize
+}
+
+// downloadFileSegmented downloads url into destPath using parallel HTTP
+// Range requests into a sparse file, resuming from a .part.json manifest of
+// completed chunks on restart. Falls back to a plain sequential GET if the
+// server doesn't report a Content-Length (and therefore can't be chunked).
+func (dm *DownloadManager) downloadFileSegmented(url, destPath string, state *streamState) (int64, error) {
+	size, err := probeContentLength(url)
+	if err != nil || size <= 0 {
+		return dm.downloadFile(url, destPath, state)
+	}
+	state.setTotal(size)
+
+	manifest := loadChunkManifest(destPath, url, size, segmentChunkSize)
+
+	f, err := os.OpenFile(destPath, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	if err := f.Truncate(size); err != nil {
+		return 0, err
+	}
+
+	// Account for chunks already completed from a prior run.
+	for i, c := range manifest.Completed {
+		if c {
+			state.addBytes(chunkLen(i, manifest.ChunkSize, size))
+		}
+	}
+
+	var pending []int
+	for i, c := range manifest.Completed {
+		if !c {
+			pending = append(pending, i)
+		}
+	}
+
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for _, i := range pending {
+			jobs <- i
+		}
+	}()
+
+	var wg sync.WaitGroup
+	var manifestMu sync.Mutex
+	errCh := make(chan error, len(pending))
+
+	concurrency := segmentConcurrency
+	if concurrency > len(pending) {
+		concurrency = len(pending)
+	}
+
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				offset := int64(index) * manifest.ChunkSize
+				length := chunkLen(index, manifest.ChunkSize, size)
+
+				if err := fetchChunkWithRetry(dm.pool, url, f, offset, length, state); err != nil {
+					errCh <- fmt.Errorf("chunk %d: %w", index, err)
+					continue
+				}
+
+				manifestMu.Lock()
+				manifest.Completed[index] = true
+				manifest.save(destPath)
+				manifestMu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errCh)
+
+	if err := <-errCh; err != nil {
+		return 0, err
+	}
+
+	os.Remove(manifestPath(destPath))
+	return size, nil
+}
+
+// fetchChunkWithRetry fetches a single byte range, retrying on 5xx/429 and
+// network errors with exponential backoff (honoring Retry-After when set).
+func fetchChunkWithRetry(pool *WorkerPool, url string, f *os.File, offset, length int64, state *streamState) error {
+	var lastErr error
+
+	for attempt := 0; attempt < segmentMaxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoffDelay(attempt, lastErr))
+		}
+
+		release := pool.AcquireHost(url)
+		n, err := fetchChunk(url, f, offset, length)
+		release()
+		if err == nil {
+			state.addBytes(n)
+			return nil
+		}
+		lastErr = err
+
+		if !isRetryableChunkError(err) {
+			return err
+		}
+	}
+
+	return fmt.Errorf("exceeded %d retries: %w", segmentMaxRetries, lastErr)
+}
+
+// retryableHTTPError carries the status code and any Retry-After hint so
+// backoffDelay can honor the server's preferred wait.
+type retryableHTTPError struct {
+	statusCode int
+	retryAfter time.Duration
+}
+
+func (e *retryableHTTPError) Error() string {
+	return fmt.Sprintf("HTTP %d", e.statusCode)
+}
+
+func isRetryableChunkError(err error) bool {
+	if httpErr, ok := err.(*retryableHTTPError); ok {
+		return httpErr.statusCode == http.StatusTooManyRequests || httpErr.statusCode >= 500
+	}
+	// Network errors (timeouts, connection resets) are also retried.
+	return true
+}
+
+func backoffDelay(attempt int, lastErr error) time.Duration {
+	if httpErr, ok := lastErr.(*retryableHTTPError); ok && httpErr.retryAfter > 0 {
+		return httpErr.retryAfter
+	}
+	delay := segmentBaseBackoff * time.Duration(int64(1)<<uint(attempt-1))
+	jitter := time.Duration(rand.Int63n(int64(delay)/2 + 1))
+	return delay + jitter
+}
+
+// fetchChunk issues a single Range request and writes the response directly
+// into f at offset, returning the number of bytes written.
+func fetchChunk(url string, f *os.File, offset, length int64) (int64, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPartialContent && resp.StatusCode != http.StatusOK {
+		return 0, &retryableHTTPError{statusCode: resp.StatusCode, retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
+	}
+
+	buf := make([]byte, length)
+	var total int64
+	for total < length {
+		n, err := resp.Body.Read(buf[total:])
+		if n > 0 {
+			total += int64(n)
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return total, err
+		}
+	}
+
+	if _, err := f.WriteAt(buf[:total], offset); err != nil {
+		return total, err
+	}
+	return total, nil
+}
+
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		return time.Until(when)
+	}
+	return 0
+}
+
+// probeContentLength issues a HEAD request to determine the total size of a
+// download without fetching the body.
+func probeContentLength(url string) (int64, error) {
+	resp, err := http.Head(url)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("HEAD returned status %d", resp.StatusCode)
+	}
+	return resp.ContentLength, nil
+}
@@ -1,71 +1,529 @@
 package api
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"io"
 	"io/fs"
 	"net/http"
 	"path"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// Options configures compression and caching behavior for an SPAHandler.
+type Options struct {
+	// Precompressed serves a <file>.br or <file>.gz sibling directly when
+	// the client's Accept-Encoding allows it and the sibling exists in the
+	// embedded fs.FS (as emitted by build tools like Vite/SvelteKit).
+	Precompressed bool
+	// DynamicCompress gzips compressible responses on the fly when no
+	// precompressed sibling is available.
+	DynamicCompress bool
+	// MinSize is the smallest file, in bytes, worth dynamically compressing.
+	MinSize int
+	// CachePolicy decides the Cache-Control header for each served path.
+	CachePolicy CachePolicy
+	// BasePath mounts the SPA under a path prefix (e.g. "/feeds") instead
+	// of at the web root. It is stripped from incoming requests before
+	// file lookup, and injected into index.html as a <base href> so the
+	// client-side router resolves relative URLs against it.
+	BasePath string
+	// AssetPrefixes lists path prefixes that hold build-emitted assets
+	// (e.g. "_app/"). A missing file under one of these returns 404
+	// instead of falling back to index.html, so a broken asset URL fails
+	// loudly instead of the browser trying to parse HTML as JS.
+	AssetPrefixes []string
+}
+
+var defaultOptions = Options{
+	Precompressed:   true,
+	DynamicCompress: true,
+	MinSize:         1024,
+	CachePolicy:     defaultCachePolicy,
+	AssetPrefixes:   []string{"_app/"},
+}
+
+// CachePolicy declares which paths are immutable (long max-age), which get
+// a short max-age, and which should never be cached by a shared proxy.
+// Immutable/ShortMaxAge/NoCache entries are either a literal path, a
+// "prefix/*" glob, or a path.Match pattern.
+type CachePolicy struct {
+	Immutable           []string
+	ShortMaxAge         []string
+	ShortMaxAgeDuration time.Duration
+	NoCache             []string
+}
+
+var defaultCachePolicy = CachePolicy{
+	Immutable:           []string{"_app/*"},
+	NoCache:             []string{"index.html"},
+	ShortMaxAgeDuration: 5 * time.Minute,
+}
+
+// assetHashPattern matches fingerprinted filenames emitted by React/Vite/
+// webpack build tooling (e.g. "app.3f9a1c2d.js"), so those are treated as
+// immutable even when no configured prefix matches.
+var assetHashPattern = regexp.MustCompile(`.*\.[0-9a-f]{8,}\.(js|css|woff2)$`)
+
+// cacheControl returns the Cache-Control header value for filePath, or ""
+// if the policy has no opinion and the caller should omit the header.
+func (p CachePolicy) cacheControl(filePath string) string {
+	if matchesAny(p.NoCache, filePath) {
+		return "no-cache"
+	}
+	if matchesAny(p.Immutable, filePath) || assetHashPattern.MatchString(filePath) {
+		return "public, max-age=31536000, immutable"
+	}
+	if matchesAny(p.ShortMaxAge, filePath) {
+		d := p.ShortMaxAgeDuration
+		if d <= 0 {
+			d = 5 * time.Minute
+		}
+		return fmt.Sprintf("public, max-age=%d", int(d.Seconds()))
+	}
+	return ""
+}
+
+func matchesAny(patterns []string, filePath string) bool {
+	for _, pat := range patterns {
+		if prefix, ok := strings.CutSuffix(pat, "/*"); ok {
+			if strings.HasPrefix(filePath, prefix+"/") {
+				return true
+			}
+			continue
+		}
+		if ok, err := path.Match(pat, filePath); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// precompressedEncodings lists the sibling extensions SPAHandler looks for,
+// in preference order (brotli compresses better than gzip, so try it first).
+var precompressedEncodings = []struct {
+	ext      string
+	encoding string
+}{
+	{".br", "br"},
+	{".gz", "gzip"},
+}
+
+var gzipWriterPool = sync.Pool{
+	New: func() any { return gzip.NewWriter(io.Discard) },
+}
+
+// basePathPlaceholder is an opt-in token build tooling can emit in
+// index.html (e.g. "%BASE_PATH%") in place of hand-rolling a <base> tag;
+// SPAHandler substitutes it with BasePath when present.
+const basePathPlaceholder = "%BASE_PATH%"
+
+// rewriteIndexHTML points index.html's relative URLs at basePath, either by
+// substituting basePathPlaceholder or, failing that, injecting a <base
+// href> tag right after <head>.
+func rewriteIndexHTML(data []byte, basePath string) []byte {
+	if basePath == "" {
+		return data
+	}
+	if bytes.Contains(data, []byte(basePathPlaceholder)) {
+		return bytes.ReplaceAll(data, []byte(basePathPlaceholder), []byte(basePath+"/"))
+	}
+
+	idx := bytes.Index(data, []byte("<head>"))
+	if idx == -1 {
+		return data
+	}
+	insertAt := idx + len("<head>")
+	baseTag := []byte(fmt.Sprintf(`<base href="%s/">`, basePath))
+
+	out := make([]byte, 0, len(data)+len(baseTag))
+	out = append(out, data[:insertAt]...)
+	out = append(out, baseTag...)
+	out = append(out, data[insertAt:]...)
+	return out
+}
+
 // SPAHandler serves a single-page application from an embedded filesystem.
 // It serves static files when they exist, and falls back to index.html for
 // client-side routing.
 type SPAHandler struct {
-	fs fs.FS
+	fs   fs.FS
+	opts Options
+
+	// startedAt stands in for the binary's build time as the Last-Modified
+	// value: embedded assets are immutable for the life of the process, so
+	// anything from process start through now is an equally valid "last
+	// changed" timestamp for conditional-GET purposes.
+	startedAt time.Time
+
+	// etags caches each path's ETag after the first request, since hashing
+	// an embedded file's contents always produces the same result.
+	etags sync.Map // cache key (string) -> etag (string)
+
+	// gzipped caches the on-the-fly gzip output for a path so repeated
+	// requests don't recompress immutable content.
+	gzipped sync.Map // path (string) -> compressed bytes ([]byte)
+
+	// rewrittenIndex caches index.html's BasePath-rewritten contents,
+	// computed once since BasePath never changes after construction.
+	rewrittenIndex atomic.Pointer[[]byte]
+
+	// transformer, if set, rewrites index.html's bytes for each request.
+	transformer atomic.Pointer[IndexTransformer]
+}
+
+// IndexTransformer customizes index.html for a single request - e.g. to
+// inject per-request OpenGraph <meta> tags, preload hints, a CSP nonce, or
+// a <script>window.__INITIAL_STATE__=...</script> hydration block. It
+// receives the cached, BasePath-rewritten template on every call. If it
+// returns an error, SPAHandler serves that template unmodified.
+type IndexTransformer func(ctx context.Context, r *http.Request, html []byte) ([]byte, error)
+
+// SetIndexTransformer installs a hook invoked whenever index.html is
+// served, whether as the root document or the SPA routing fallback. This
+// turns the otherwise-static handler into a lightweight SSR shell without
+// a JS runtime.
+func (h *SPAHandler) SetIndexTransformer(fn IndexTransformer) {
+	h.transformer.Store(&fn)
 }
 
 // NewSPAHandler creates a handler for serving an embedded SPA.
 // The fsys should contain the built SPA files (index.html, assets, etc.)
 func NewSPAHandler(fsys fs.FS) *SPAHandler {
-	return &SPAHandler{fs: fsys}
+	return NewSPAHandlerWithOptions(fsys, defaultOptions)
+}
+
+// NewSPAHandlerWithOptions creates a handler for serving an embedded SPA
+// with explicit control over compression, caching, and mount path.
+func NewSPAHandlerWithOptions(fsys fs.FS, opts Options) *SPAHandler {
+	if opts.BasePath != "" {
+		opts.BasePath = "/" + strings.Trim(opts.BasePath, "/")
+	}
+	return &SPAHandler{fs: fsys, opts: opts, startedAt: time.Now()}
 }
 
 func (h *SPAHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// Clean the path
 	urlPath := r.URL.Path
 	if urlPath == "" {
 		urlPath = "/"
 	}
+	if h.opts.BasePath != "" {
+		urlPath = strings.TrimPrefix(urlPath, h.opts.BasePath)
+		if urlPath == "" {
+			urlPath = "/"
+		}
+	}
+
+	// Mirror net/http's static file server: /foo/index.html canonicalizes
+	// to /foo/.
+	if strings.HasSuffix(urlPath, "/index.html") {
+		localRedirect(w, r, "./")
+		return
+	}
+
+	filePath, ok := sanitizePath(urlPath)
+	if !ok {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	// A directory URL without a trailing slash is redirected so
+	// document-relative asset URLs in the served HTML resolve correctly.
+	if isDir, err := h.isDir(filePath); err == nil && isDir && !strings.HasSuffix(urlPath, "/") {
+		localRedirect(w, r, path.Base(urlPath)+"/")
+		return
+	}
 
 	// Try to serve the actual file first
-	filePath := strings.TrimPrefix(urlPath, "/")
-	if filePath == "" {
-		filePath = "index.html"
+	if h.serveFile(w, r, filePath) {
+		return
 	}
 
-	// Check if file exists
+	// A missing file under a known asset prefix is a broken build
+	// reference, not a client-side route - 404 instead of handing back
+	// index.html with a 200, which breaks browser module loading.
+	if isAssetPath(filePath, h.opts.AssetPrefixes) {
+		http.NotFound(w, r)
+		return
+	}
+
+	// File doesn't exist - serve index.html for client-side routing
+	if !h.serveFile(w, r, "index.html") {
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// sanitizePath cleans urlPath into a safe, relative fs.FS lookup path. It
+// rejects NUL bytes, backslashes, and any path that still escapes the root
+// after cleaning - defense in depth for callers who back SPAHandler with a
+// real filesystem (os.DirFS) rather than embed.FS.
+func sanitizePath(urlPath string) (string, bool) {
+	if strings.ContainsRune(urlPath, 0) || strings.ContainsRune(urlPath, '\\') {
+		return "", false
+	}
+
+	cleaned := path.Clean(urlPath)
+	cleaned = strings.TrimPrefix(cleaned, "/")
+	if cleaned == "." {
+		return "index.html", true
+	}
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", false
+	}
+	return cleaned, true
+}
+
+// isAssetPath reports whether filePath falls under a configured asset
+// prefix such as "_app/".
+func isAssetPath(filePath string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(filePath, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDir reports whether filePath names a directory in h.fs.
+func (h *SPAHandler) isDir(filePath string) (bool, error) {
 	f, err := h.fs.Open(filePath)
-	if err == nil {
-		defer f.Close()
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
 
-		// Check if it's a directory
-		stat, err := f.Stat()
-		if err == nil && !stat.IsDir() {
-			// Serve the file with appropriate content type
-			contentType := getContentType(filePath)
-			w.Header().Set("Content-Type", contentType)
-
-			// Cache static assets (not index.html)
-			if strings.HasPrefix(filePath, "_app/") {
-				w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	stat, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	return stat.IsDir(), nil
+}
+
+// localRedirect mirrors net/http's unexported localRedirect: it redirects
+// to newPath relative to the current URL, preserving the query string,
+// instead of echoing back a caller-controlled absolute URL.
+func localRedirect(w http.ResponseWriter, r *http.Request, newPath string) {
+	if q := r.URL.RawQuery; q != "" {
+		newPath += "?" + q
+	}
+	w.Header().Set("Location", newPath)
+	w.WriteHeader(http.StatusMovedPermanently)
+}
+
+// serveFile serves a single file from h.fs via http.ServeContent, which
+// handles If-None-Match/If-Modified-Since and Range requests once ETag is
+// set. It returns false when filePath doesn't exist or is a directory, so
+// the caller can fall back to index.html.
+func (h *SPAHandler) serveFile(w http.ResponseWriter, r *http.Request, filePath string) bool {
+	f, err := h.fs.Open(filePath)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil || stat.IsDir() {
+		return false
+	}
+
+	// Proxies and CDNs must not serve a gzip response to a client that
+	// didn't ask for one, regardless of which branch below fires.
+	w.Header().Set("Vary", "Accept-Encoding")
+	w.Header().Set("Content-Type", getContentType(filePath))
+	if cacheControl := h.opts.CachePolicy.cacheControl(filePath); cacheControl != "" {
+		w.Header().Set("Cache-Control", cacheControl)
+	}
+
+	// index.html's content can depend on BasePath and on a per-request
+	// transformer, so it bypasses the precompressed/gzip paths below (which
+	// serve immutable file bytes as-is) in favor of its own handling.
+	if filePath == "index.html" {
+		return h.serveIndex(w, r, f)
+	}
+
+	acceptEncoding := r.Header.Get("Accept-Encoding")
+
+	if h.opts.Precompressed {
+		if pf, encoding, ok := h.openPrecompressed(filePath, acceptEncoding); ok {
+			defer pf.Close()
+			if reader, err := readSeeker(pf); err == nil {
+				w.Header().Set("Content-Encoding", encoding)
+				if etag := h.etag(filePath+"."+encoding, reader); etag != "" {
+					w.Header().Set("ETag", etag)
+				}
+				http.ServeContent(w, r, filePath, h.startedAt, reader)
+				return true
 			}
+		}
+	}
+
+	reader, err := readSeeker(f)
+	if err != nil {
+		http.Error(w, "Failed to read file", http.StatusInternalServerError)
+		return true
+	}
 
-			io.Copy(w, f)
-			return
+	if h.opts.DynamicCompress && stat.Size() >= int64(h.opts.MinSize) &&
+		isCompressible(filePath) && strings.Contains(acceptEncoding, "gzip") {
+		if gzReader, err := h.gzip(filePath, reader); err == nil {
+			w.Header().Set("Content-Encoding", "gzip")
+			if etag := h.etag(filePath+".gzip", gzReader); etag != "" {
+				w.Header().Set("ETag", etag)
+			}
+			http.ServeContent(w, r, filePath, h.startedAt, gzReader)
+			return true
+		}
+		if _, err := reader.Seek(0, io.SeekStart); err != nil {
+			http.Error(w, "Failed to read file", http.StatusInternalServerError)
+			return true
 		}
 	}
 
-	// File doesn't exist - serve index.html for client-side routing
-	indexFile, err := h.fs.Open("index.html")
+	if etag := h.etag(filePath, reader); etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	http.ServeContent(w, r, filePath, h.startedAt, reader)
+	return true
+}
+
+// openPrecompressed opens the first <filePath>.br/.gz sibling the client's
+// Accept-Encoding header allows, in brotli-then-gzip preference order.
+func (h *SPAHandler) openPrecompressed(filePath, acceptEncoding string) (fs.File, string, bool) {
+	for _, enc := range precompressedEncodings {
+		if !strings.Contains(acceptEncoding, enc.encoding) {
+			continue
+		}
+		f, err := h.fs.Open(filePath + enc.ext)
+		if err != nil {
+			continue
+		}
+		stat, err := f.Stat()
+		if err != nil || stat.IsDir() {
+			f.Close()
+			continue
+		}
+		return f, enc.encoding, true
+	}
+	return nil, "", false
+}
+
+// gzip returns (and caches) the gzip-compressed contents of r under filePath.
+func (h *SPAHandler) gzip(filePath string, r io.ReadSeeker) (io.ReadSeeker, error) {
+	if cached, ok := h.gzipped.Load(filePath); ok {
+		return bytes.NewReader(cached.([]byte)), nil
+	}
+
+	var buf bytes.Buffer
+	gw := gzipWriterPool.Get().(*gzip.Writer)
+	gw.Reset(&buf)
+	defer gzipWriterPool.Put(gw)
+
+	if _, err := io.Copy(gw, r); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+
+	data := buf.Bytes()
+	h.gzipped.Store(filePath, data)
+	return bytes.NewReader(data), nil
+}
+
+// serveIndex serves index.html, running it through the installed
+// IndexTransformer (if any) before writing the response.
+func (h *SPAHandler) serveIndex(w http.ResponseWriter, r *http.Request, f fs.File) bool {
+	base, err := h.indexBytes(f)
 	if err != nil {
-		http.Error(w, "Not found", http.StatusNotFound)
-		return
+		http.Error(w, "Failed to read file", http.StatusInternalServerError)
+		return true
+	}
+
+	if transformer := h.transformer.Load(); transformer != nil {
+		if out, err := (*transformer)(r.Context(), r, base); err == nil {
+			w.Header().Set("Content-Length", strconv.Itoa(len(out)))
+			w.Write(out)
+			return true
+		}
+		// Transformer failed - fall back to the raw template below.
+	}
+
+	reader := bytes.NewReader(base)
+	if etag := h.etag("index.html.rendered", reader); etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	http.ServeContent(w, r, "index.html", h.startedAt, reader)
+	return true
+}
+
+// indexBytes returns (and caches) index.html rewritten for h.opts.BasePath.
+func (h *SPAHandler) indexBytes(f fs.File) ([]byte, error) {
+	if cached := h.rewrittenIndex.Load(); cached != nil {
+		return *cached, nil
+	}
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	rewritten := rewriteIndexHTML(data, h.opts.BasePath)
+	h.rewrittenIndex.Store(&rewritten)
+	return rewritten, nil
+}
+
+// isCompressible reports whether filePath's content type is worth gzipping.
+func isCompressible(filePath string) bool {
+	switch path.Ext(filePath) {
+	case ".js", ".css", ".html", ".json", ".svg":
+		return true
+	default:
+		return false
+	}
+}
+
+// readSeeker returns f as an io.ReadSeeker, buffering it into a
+// bytes.Reader if it doesn't already satisfy io.Seeker. embed.FS's files
+// do, but fs.FS in general makes no such guarantee.
+func readSeeker(f fs.File) (io.ReadSeeker, error) {
+	if rs, ok := f.(io.ReadSeeker); ok {
+		return rs, nil
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(data), nil
+}
+
+// etag computes (and caches) a stable ETag for a cache key from the
+// SHA-256 of r's contents. Embedded assets never change within a
+// process's lifetime, so the hash only needs to be computed once per key.
+func (h *SPAHandler) etag(cacheKey string, r io.ReadSeeker) string {
+	if cached, ok := h.etags.Load(cacheKey); ok {
+		return cached.(string)
+	}
+
+	sum := sha256.New()
+	if _, err := io.Copy(sum, r); err != nil {
+		return ""
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return ""
 	}
-	defer indexFile.Close()
 
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	io.Copy(w, indexFile)
+	tag := `"` + hex.EncodeToString(sum.Sum(nil))[:16] + `"`
+	h.etags.Store(cacheKey, tag)
+	return tag
 }
 
 func getContentType(filePath string) string {
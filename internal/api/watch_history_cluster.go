@@ -0,0 +1,166 @@
+package api
+
+import (
+	"encoding/json"
+	"math/rand"
+	"strings"
+
+	"github.com/erik/feeds/internal/models"
+)
+
+// SuggestedGroup is a community-detected cluster of channels from watch
+// history co-occurrence, returned by handleAPIImportWatchHistory. Its JSON
+// shape (suggestedName, channel objects) is not importGroup's (name, channel
+// URLs), so handleAPIConfirmOrganize does not accept it as-is.
+type SuggestedGroup struct {
+	SuggestedName string                       `json:"suggestedName"`
+	Channels      []models.WatchHistoryChannel `json:"channels"`
+}
+
+// clusterWatchHistory groups channels into suggested feeds based on how
+// often they're watched within the same day. It builds a weighted
+// similarity graph -- an edge between two channels per day both were
+// watched, weighted by co-occurrence count -- then runs label propagation:
+// each channel starts in its own cluster and repeatedly adopts whichever
+// label is heaviest among its neighbors, until labels stop changing or a
+// fixed number of passes is reached.
+func clusterWatchHistory(data []byte, channels []models.WatchHistoryChannel) []SuggestedGroup {
+	if len(channels) == 0 {
+		return nil
+	}
+
+	var entries []models.WatchHistoryEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil
+	}
+
+	channelByURL := make(map[string]bool, len(channels))
+	for _, ch := range channels {
+		channelByURL[ch.URL] = true
+	}
+
+	// Collect the distinct set of known channels watched on each day.
+	dayChannels := make(map[string]map[string]bool)
+	for _, entry := range entries {
+		if entry.Header != "YouTube" || len(entry.Subtitles) == 0 {
+			continue
+		}
+		url := entry.Subtitles[0].URL
+		if url == "" || !channelByURL[url] {
+			continue
+		}
+		day := entry.Time.Format("2006-01-02")
+		if dayChannels[day] == nil {
+			dayChannels[day] = make(map[string]bool)
+		}
+		dayChannels[day][url] = true
+	}
+
+	weights := make(map[string]map[string]int)
+	addEdge := func(a, b string) {
+		if weights[a] == nil {
+			weights[a] = make(map[string]int)
+		}
+		weights[a][b]++
+	}
+	for _, urls := range dayChannels {
+		list := make([]string, 0, len(urls))
+		for u := range urls {
+			list = append(list, u)
+		}
+		for i := 0; i < len(list); i++ {
+			for j := i + 1; j < len(list); j++ {
+				addEdge(list[i], list[j])
+				addEdge(list[j], list[i])
+			}
+		}
+	}
+
+	labels := make(map[string]string, len(channels))
+	nodes := make([]string, 0, len(channels))
+	for _, ch := range channels {
+		labels[ch.URL] = ch.URL
+		nodes = append(nodes, ch.URL)
+	}
+
+	const maxPasses = 20
+	for pass := 0; pass < maxPasses; pass++ {
+		rand.Shuffle(len(nodes), func(i, j int) { nodes[i], nodes[j] = nodes[j], nodes[i] })
+
+		changed := false
+		for _, node := range nodes {
+			neighbors := weights[node]
+			if len(neighbors) == 0 {
+				continue
+			}
+			labelWeight := make(map[string]int)
+			for neighbor, w := range neighbors {
+				labelWeight[labels[neighbor]] += w
+			}
+			best, bestWeight := labels[node], -1
+			for label, w := range labelWeight {
+				if w > bestWeight {
+					best, bestWeight = label, w
+				}
+			}
+			if best != labels[node] {
+				labels[node] = best
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	groupsByLabel := make(map[string][]models.WatchHistoryChannel)
+	for _, ch := range channels {
+		label := labels[ch.URL]
+		groupsByLabel[label] = append(groupsByLabel[label], ch)
+	}
+
+	groups := make([]SuggestedGroup, 0, len(groupsByLabel))
+	for _, members := range groupsByLabel {
+		groups = append(groups, SuggestedGroup{
+			SuggestedName: suggestGroupName(members),
+			Channels:      members,
+		})
+	}
+	return groups
+}
+
+// suggestGroupName picks the top-watched channel's name as the suggested
+// feed name, falling back to the most common word across the cluster's
+// channel names when the top channel has no usable name.
+func suggestGroupName(members []models.WatchHistoryChannel) string {
+	if len(members) == 0 {
+		return "Suggested Feed"
+	}
+
+	top := members[0]
+	for _, ch := range members[1:] {
+		if ch.WatchCount > top.WatchCount {
+			top = ch
+		}
+	}
+	if top.Name != "" {
+		return top.Name
+	}
+
+	wordCounts := make(map[string]int)
+	for _, ch := range members {
+		for _, word := range strings.Fields(ch.Name) {
+			wordCounts[strings.ToLower(word)]++
+		}
+	}
+	best, bestCount := "", 0
+	for word, count := range wordCounts {
+		if count > bestCount {
+			best, bestCount = word, count
+		}
+	}
+	if best == "" {
+		return "Suggested Feed"
+	}
+	return best
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"net/url"
+	"sync"
+)
+
+const (
+	defaultMaxConcurrentDownloads = 4
+	defaultMaxChunksPerHost       = 6
+)
+
+// WorkerPool bounds how many downloads run at once and how many HTTP chunk
+// fetches hit any single googlevideo host concurrently, so a burst of
+// requests can't exhaust file descriptors or saturate the network.
+type WorkerPool struct {
+	downloads chan struct{}
+
+	maxPerHost int
+	hostsMu    sync.Mutex
+	hosts      map[string]chan struct{}
+}
+
+// NewWorkerPool creates a pool allowing maxDownloads concurrent downloads
+// and maxPerHost concurrent chunk fetches against any one host.
+func NewWorkerPool(maxDownloads, maxPerHost int) *WorkerPool {
+	if maxDownloads <= 0 {
+		maxDownloads = defaultMaxConcurrentDownloads
+	}
+	if maxPerHost <= 0 {
+		maxPerHost = defaultMaxChunksPerHost
+	}
+	return &WorkerPool{
+		downloads:  make(chan struct{}, maxDownloads),
+		maxPerHost: maxPerHost,
+		hosts:      make(map[string]chan struct{}),
+	}
+}
+
+// AcquireDownload blocks until a download slot is free, then returns a
+// release func the caller must call when the download finishes.
+func (p *WorkerPool) AcquireDownload() func() {
+	p.downloads <- struct{}{}
+	return func() { <-p.downloads }
+}
+
+// QueueDepth returns the number of download slots currently in use, out of
+// the pool's total capacity.
+func (p *WorkerPool) QueueDepth() (active, capacity int) {
+	return len(p.downloads), cap(p.downloads)
+}
+
+// AcquireHost blocks until a chunk-fetch slot is free for the given URL's
+// host, then returns a release func the caller must call when done.
+func (p *WorkerPool) AcquireHost(rawURL string) func() {
+	host := hostOf(rawURL)
+
+	p.hostsMu.Lock()
+	sem, ok := p.hosts[host]
+	if !ok {
+		sem = make(chan struct{}, p.maxPerHost)
+		p.hosts[host] = sem
+	}
+	p.hostsMu.Unlock()
+
+	sem <- struct{}{}
+	return func() { <-sem }
+}
+
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return u.Host
+}
+
+// PoolStats summarizes current worker pool utilization for a status endpoint.
+type PoolStats struct {
+	ActiveDownloads int `json:"activeDownloads"`
+	QueuedDownloads int `json:"queuedDownloads"`
+	MaxDownloads    int `json:"maxDownloads"`
+}
+
+// Stats returns the current download queue depth and active-download count.
+func (dm *DownloadManager) Stats() PoolStats {
+	active, capacity := dm.pool.QueueDepth()
+
+	dm.mu.RLock()
+	queued := 0
+	for _, d := range dm.active {
+		if d.Status == "queued" {
+			queued++
+		}
+	}
+	dm.mu.RUnlock()
+
+	return PoolStats{
+		ActiveDownloads: active,
+		QueuedDownloads: queued,
+		MaxDownloads:    capacity,
+	}
+}
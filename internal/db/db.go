@@ -1,10 +1,15 @@
 package db
 
 import (
+	"crypto/rand"
 	"database/sql"
 	"embed"
+	"encoding/hex"
+	"encoding/xml"
 	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"strings"
 	"time"
 
@@ -19,6 +24,10 @@ var embedMigrations embed.FS
 
 var ErrSystemFeed = errors.New("cannot delete system feed")
 
+// ErrAlreadySubscribed is returned by SubscribeUserToFeed when the user is
+// already subscribed to the feed.
+var ErrAlreadySubscribed = errors.New("already subscribed to feed")
+
 type DB struct {
 	conn *sql.DB
 }
@@ -64,17 +73,167 @@ func (db *DB) Close() error {
 	return db.conn.Close()
 }
 
+// Ping verifies the database connection is alive, for health checks.
+func (db *DB) Ping() error {
+	return db.conn.Ping()
+}
+
+// defaultRefreshIntervalSeconds mirrors the feeds.refresh_interval_seconds
+// column default, for feeds constructed in Go before they hit the DB.
+const defaultRefreshIntervalSeconds = 1800
+
+// DefaultUserID is the account that existing single-user installs are
+// migrated onto (see migrations/0007_users.sql), and the account used for
+// requests that arrive without a session or API token.
+const DefaultUserID int64 = 1
+
+// feedColumns is the column list shared by every query that returns a full
+// feeds row, so scanFeedRow always lines up with what was selected.
+const feedColumns = "id, user_id, name, description, author, tags, is_system, sort_order, new_video_count, " +
+	"refresh_interval_seconds, next_refresh_at, last_refresh_at, last_refresh_error, paused, hide_shorts, created_at, updated_at, " +
+	"parent_id, kind, auto_download_quality, podcast_format, podcast_quality, retention_days"
+
+// feedRowScanner is satisfied by both *sql.Row and *sql.Rows.
+type feedRowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanFeedRow scans a row selected with feedColumns into a models.Feed,
+// translating the nullable schedule timestamps.
+func scanFeedRow(row feedRowScanner) (*models.Feed, error) {
+	var f models.Feed
+	var nextRefreshAt, lastRefreshAt sql.NullTime
+	var parentID sql.NullInt64
+	err := row.Scan(&f.ID, &f.UserID, &f.Name, &f.Description, &f.Author, &f.Tags, &f.IsSystem, &f.SortOrder, &f.NewVideoCount,
+		&f.RefreshIntervalSeconds, &nextRefreshAt, &lastRefreshAt, &f.LastRefreshError, &f.Paused, &f.HideShorts, &f.CreatedAt, &f.UpdatedAt,
+		&parentID, &f.Kind, &f.AutoDownloadQuality, &f.PodcastFormat, &f.PodcastQuality, &f.RetentionDays)
+	if err != nil {
+		return nil, err
+	}
+	if nextRefreshAt.Valid {
+		f.NextRefreshAt = &nextRefreshAt.Time
+	}
+	if lastRefreshAt.Valid {
+		f.LastRefreshAt = &lastRefreshAt.Time
+	}
+	if parentID.Valid {
+		f.ParentID = &parentID.Int64
+	}
+	return &f, nil
+}
+
+// User operations
+
+// CreateUser inserts a new account with an already-hashed password.
+func (db *DB) CreateUser(username, passwordHash string) (*models.User, error) {
+	now := time.Now()
+	result, err := db.conn.Exec(
+		"INSERT INTO users (username, password_hash, created_at) VALUES (?, ?, ?)",
+		username, passwordHash, now,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.User{ID: id, Username: username, PasswordHash: passwordHash, CreatedAt: now}, nil
+}
+
+// SubscribeUserToFeed subscribes userID to feedID, letting multiple users
+// share the same feed with independent watched/unwatched lists (watch_progress
+// is keyed by (user_id, video_id), not feed ownership). Returns
+// ErrAlreadySubscribed if the subscription already exists.
+func (db *DB) SubscribeUserToFeed(userID, feedID int64) error {
+	_, err := db.conn.Exec(
+		"INSERT INTO feed_subscriptions (user_id, feed_id, created_at) VALUES (?, ?, ?)",
+		userID, feedID, time.Now(),
+	)
+	if err != nil && strings.Contains(err.Error(), "UNIQUE constraint") {
+		return ErrAlreadySubscribed
+	}
+	return err
+}
+
+// UnsubscribeUserFromFeed removes userID's subscription to feedID. It is not
+// an error to unsubscribe from a feed the user wasn't subscribed to.
+func (db *DB) UnsubscribeUserFromFeed(userID, feedID int64) error {
+	_, err := db.conn.Exec(
+		"DELETE FROM feed_subscriptions WHERE user_id = ? AND feed_id = ?",
+		userID, feedID,
+	)
+	return err
+}
+
+func scanUserRow(row feedRowScanner) (*models.User, error) {
+	var u models.User
+	var apiToken sql.NullString
+	if err := row.Scan(&u.ID, &u.Username, &u.PasswordHash, &apiToken, &u.CreatedAt); err != nil {
+		return nil, err
+	}
+	u.APIToken = apiToken.String
+	return &u, nil
+}
+
+// GetUserByID returns an account by its ID, or nil if not found.
+func (db *DB) GetUserByID(id int64) (*models.User, error) {
+	u, err := scanUserRow(db.conn.QueryRow(
+		"SELECT id, username, password_hash, api_token, created_at FROM users WHERE id = ?", id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return u, err
+}
+
+// GetUserByUsername returns an account by username, or nil if not found.
+func (db *DB) GetUserByUsername(username string) (*models.User, error) {
+	u, err := scanUserRow(db.conn.QueryRow(
+		"SELECT id, username, password_hash, api_token, created_at FROM users WHERE username = ?", username,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return u, err
+}
+
+// GetUserByAPIToken returns the account owning token, or nil if no account does.
+func (db *DB) GetUserByAPIToken(token string) (*models.User, error) {
+	u, err := scanUserRow(db.conn.QueryRow(
+		"SELECT id, username, password_hash, api_token, created_at FROM users WHERE api_token = ?", token,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return u, err
+}
+
+// SetUserAPIToken issues (or clears, if token is "") a user's API token.
+func (db *DB) SetUserAPIToken(userID int64, token string) error {
+	_, err := db.conn.Exec("UPDATE users SET api_token = ? WHERE id = ?", nullIfEmpty(token), userID)
+	return err
+}
+
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
 // Inbox operations
 
-// EnsureInboxExists creates the Inbox system feed if it doesn't exist
-func (db *DB) EnsureInboxExists() (*models.Feed, error) {
+// EnsureInboxExists creates userID's Inbox system feed if it doesn't exist.
+func (db *DB) EnsureInboxExists(userID int64) (*models.Feed, error) {
 	// Check if Inbox already exists
-	var f models.Feed
-	err := db.conn.QueryRow(
-		"SELECT id, name, description, author, tags, is_system, sort_order, new_video_count, created_at, updated_at FROM feeds WHERE is_system = TRUE AND name = 'Inbox'",
-	).Scan(&f.ID, &f.Name, &f.Description, &f.Author, &f.Tags, &f.IsSystem, &f.SortOrder, &f.NewVideoCount, &f.CreatedAt, &f.UpdatedAt)
+	f, err := scanFeedRow(db.conn.QueryRow(
+		"SELECT "+feedColumns+" FROM feeds WHERE user_id = ? AND is_system = TRUE AND name = 'Inbox'", userID,
+	))
 	if err == nil {
-		return &f, nil
+		return f, nil
 	}
 	if err != sql.ErrNoRows {
 		return nil, err
@@ -82,14 +241,14 @@ func (db *DB) EnsureInboxExists() (*models.Feed, error) {
 
 	// Create Inbox - get max sort_order first
 	var maxOrder int
-	if err := db.conn.QueryRow("SELECT COALESCE(MAX(sort_order), -1) FROM feeds").Scan(&maxOrder); err != nil {
+	if err := db.conn.QueryRow("SELECT COALESCE(MAX(sort_order), -1) FROM feeds WHERE user_id = ?", userID).Scan(&maxOrder); err != nil {
 		return nil, err
 	}
 
 	now := time.Now()
 	result, err := db.conn.Exec(
-		"INSERT INTO feeds (name, description, author, tags, is_system, sort_order, created_at, updated_at) VALUES ('Inbox', '', '', '', TRUE, ?, ?, ?)",
-		maxOrder+1, now, now,
+		"INSERT INTO feeds (user_id, name, description, author, tags, is_system, sort_order, created_at, updated_at) VALUES (?, 'Inbox', '', '', '', TRUE, ?, ?, ?)",
+		userID, maxOrder+1, now, now,
 	)
 	if err != nil {
 		return nil, err
@@ -101,44 +260,204 @@ func (db *DB) EnsureInboxExists() (*models.Feed, error) {
 	}
 
 	return &models.Feed{
-		ID:        id,
-		Name:      "Inbox",
-		IsSystem:  true,
-		SortOrder: maxOrder + 1,
-		CreatedAt: now,
-		UpdatedAt: now,
+		ID:                     id,
+		UserID:                 userID,
+		Name:                   "Inbox",
+		IsSystem:               true,
+		SortOrder:              maxOrder + 1,
+		RefreshIntervalSeconds: defaultRefreshIntervalSeconds,
+		CreatedAt:              now,
+		UpdatedAt:              now,
+		Kind:                   "feed",
 	}, nil
 }
 
-// GetInbox returns the Inbox system feed
-func (db *DB) GetInbox() (*models.Feed, error) {
-	var f models.Feed
-	err := db.conn.QueryRow(
-		"SELECT id, name, description, author, tags, is_system, sort_order, new_video_count, created_at, updated_at FROM feeds WHERE is_system = TRUE AND name = 'Inbox'",
-	).Scan(&f.ID, &f.Name, &f.Description, &f.Author, &f.Tags, &f.IsSystem, &f.SortOrder, &f.NewVideoCount, &f.CreatedAt, &f.UpdatedAt)
+// GetInbox returns userID's Inbox system feed
+func (db *DB) GetInbox(userID int64) (*models.Feed, error) {
+	return scanFeedRow(db.conn.QueryRow(
+		"SELECT "+feedColumns+" FROM feeds WHERE user_id = ? AND is_system = TRUE AND name = 'Inbox'", userID,
+	))
+}
+
+// EnsureForYouFeedExists creates userID's "For You" recommendation system
+// feed if it doesn't exist, mirroring EnsureInboxExists. Its channel
+// membership is populated/re-ranked separately by ReplaceFeedChannels - see
+// (*api.Server).buildForYouFeed.
+func (db *DB) EnsureForYouFeedExists(userID int64) (*models.Feed, error) {
+	f, err := scanFeedRow(db.conn.QueryRow(
+		"SELECT "+feedColumns+" FROM feeds WHERE user_id = ? AND is_system = TRUE AND name = 'For You'", userID,
+	))
+	if err == nil {
+		return f, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	var maxOrder int
+	if err := db.conn.QueryRow("SELECT COALESCE(MAX(sort_order), -1) FROM feeds WHERE user_id = ?", userID).Scan(&maxOrder); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	description := "Channels you watch often, ranked by recency-weighted frequency"
+	result, err := db.conn.Exec(
+		"INSERT INTO feeds (user_id, name, description, author, tags, is_system, sort_order, created_at, updated_at) VALUES (?, 'For You', ?, '', '', TRUE, ?, ?, ?)",
+		userID, description, maxOrder+1, now, now,
+	)
 	if err != nil {
 		return nil, err
 	}
-	return &f, nil
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Feed{
+		ID:                     id,
+		UserID:                 userID,
+		Name:                   "For You",
+		Description:            description,
+		IsSystem:               true,
+		SortOrder:              maxOrder + 1,
+		RefreshIntervalSeconds: defaultRefreshIntervalSeconds,
+		CreatedAt:              now,
+		UpdatedAt:              now,
+		Kind:                   "feed",
+	}, nil
+}
+
+// ReplaceFeedChannels clears feedID's channel membership and re-adds exactly
+// the given channels (creating any that don't already exist by URL), used by
+// (*api.Server).buildForYouFeed to re-rank the "For You" Feed from scratch on
+// every watch-history import rather than diffing the old membership against
+// the new ranking.
+func (db *DB) ReplaceFeedChannels(feedID int64, channels []models.WatchHistoryChannel) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM feed_channels WHERE feed_id = ?", feedID); err != nil {
+		return err
+	}
+
+	for _, ch := range channels {
+		var channelID int64
+		err := tx.QueryRow("SELECT id FROM channels WHERE url = ?", ch.URL).Scan(&channelID)
+		if err == sql.ErrNoRows {
+			result, err := tx.Exec(
+				"INSERT INTO channels (url, name, source, source_type) VALUES (?, ?, 'youtube', 'channel')",
+				ch.URL, ch.Name,
+			)
+			if err != nil {
+				return err
+			}
+			channelID, _ = result.LastInsertId()
+		} else if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec("INSERT OR IGNORE INTO feed_channels (feed_id, channel_id) VALUES (?, ?)", feedID, channelID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// IgnoreChannelForRecommendations records that userID explicitly
+// unsubscribed from channelURL, so scoreWatchHistoryChannels excludes it
+// from future "For You" Feed rankings instead of letting it resurface.
+func (db *DB) IgnoreChannelForRecommendations(userID int64, channelURL string) error {
+	_, err := db.conn.Exec(
+		"INSERT OR IGNORE INTO ignored_recommendation_channels (user_id, channel_url, created_at) VALUES (?, ?, ?)",
+		userID, channelURL, time.Now(),
+	)
+	return err
+}
+
+// GetIgnoredRecommendationChannels returns the set of channel URLs userID
+// has unsubscribed from, for scoreWatchHistoryChannels to exclude.
+func (db *DB) GetIgnoredRecommendationChannels(userID int64) (map[string]bool, error) {
+	rows, err := db.conn.Query("SELECT channel_url FROM ignored_recommendation_channels WHERE user_id = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ignored := make(map[string]bool)
+	for rows.Next() {
+		var url string
+		if err := rows.Scan(&url); err != nil {
+			return nil, err
+		}
+		ignored[url] = true
+	}
+	return ignored, rows.Err()
 }
 
 // Feed operations
 
-func (db *DB) CreateFeed(name string) (*models.Feed, error) {
-	return db.CreateFeedWithMetadata(name, "", "", "")
+func (db *DB) CreateFeed(userID int64, name string) (*models.Feed, error) {
+	return db.CreateFeedWithMetadata(userID, name, "", "", "")
 }
 
-func (db *DB) CreateFeedWithMetadata(name, description, author, tags string) (*models.Feed, error) {
+func (db *DB) CreateFeedWithMetadata(userID int64, name, description, author, tags string) (*models.Feed, error) {
 	// Get max sort_order to put new feed at end
 	var maxOrder int
-	if err := db.conn.QueryRow("SELECT COALESCE(MAX(sort_order), -1) FROM feeds").Scan(&maxOrder); err != nil {
+	if err := db.conn.QueryRow("SELECT COALESCE(MAX(sort_order), -1) FROM feeds WHERE user_id = ?", userID).Scan(&maxOrder); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result, err := db.conn.Exec(
+		"INSERT INTO feeds (user_id, name, description, author, tags, sort_order, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		userID, name, description, author, tags, maxOrder+1, now, now,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Feed{
+		ID:                     id,
+		UserID:                 userID,
+		Name:                   name,
+		Description:            description,
+		Author:                 author,
+		Tags:                   tags,
+		SortOrder:              maxOrder + 1,
+		RefreshIntervalSeconds: defaultRefreshIntervalSeconds,
+		CreatedAt:              now,
+		UpdatedAt:              now,
+		Kind:                   "feed",
+	}, nil
+}
+
+// CreateFolder creates a folder node for grouping other feeds/folders under
+// userID, optionally nested under parentID (nil for a top-level folder). A
+// folder has no channels of its own; GetVideosByFeed aggregates across its
+// descendant feeds when called on one.
+func (db *DB) CreateFolder(userID int64, name string, parentID *int64) (*models.Feed, error) {
+	var maxOrder int
+	err := db.conn.QueryRow(
+		"SELECT COALESCE(MAX(sort_order), -1) FROM feeds WHERE user_id = ? AND parent_id IS ?", userID, parentID,
+	).Scan(&maxOrder)
+	if err != nil {
 		return nil, err
 	}
 
 	now := time.Now()
 	result, err := db.conn.Exec(
-		"INSERT INTO feeds (name, description, author, tags, sort_order, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
-		name, description, author, tags, maxOrder+1, now, now,
+		"INSERT INTO feeds (user_id, name, sort_order, kind, parent_id, created_at, updated_at) VALUES (?, ?, ?, 'folder', ?, ?, ?)",
+		userID, name, maxOrder+1, parentID, now, now,
 	)
 	if err != nil {
 		return nil, err
@@ -150,19 +469,157 @@ func (db *DB) CreateFeedWithMetadata(name, description, author, tags string) (*m
 	}
 
 	return &models.Feed{
-		ID:          id,
-		Name:        name,
-		Description: description,
-		Author:      author,
-		Tags:        tags,
-		SortOrder:   maxOrder + 1,
-		CreatedAt:   now,
-		UpdatedAt:   now,
+		ID:                     id,
+		UserID:                 userID,
+		Name:                   name,
+		SortOrder:              maxOrder + 1,
+		RefreshIntervalSeconds: defaultRefreshIntervalSeconds,
+		CreatedAt:              now,
+		UpdatedAt:              now,
+		Kind:                   "folder",
+		ParentID:               parentID,
 	}, nil
 }
 
-func (db *DB) GetFeeds() ([]models.Feed, error) {
-	rows, err := db.conn.Query("SELECT id, name, description, author, tags, is_system, sort_order, new_video_count, created_at, updated_at FROM feeds ORDER BY sort_order ASC, name ASC")
+// GetFeedTree returns userID's feeds and folders nested into a tree, each
+// node's children ordered by sort_order, built with a single recursive CTE
+// rather than N+1 queries per level.
+func (db *DB) GetFeedTree(userID int64) ([]models.FeedNode, error) {
+	rows, err := db.conn.Query(`
+		WITH RECURSIVE tree AS (
+			SELECT `+feedColumns+`, 0 AS depth
+			FROM feeds
+			WHERE user_id = ? AND parent_id IS NULL
+			UNION ALL
+			SELECT f.id, f.user_id, f.name, f.description, f.author, f.tags, f.is_system, f.sort_order, f.new_video_count,
+			       f.refresh_interval_seconds, f.next_refresh_at, f.last_refresh_at, f.last_refresh_error, f.paused, f.hide_shorts,
+			       f.created_at, f.updated_at, f.parent_id, f.kind, f.auto_download_quality,
+			       f.podcast_format, f.podcast_quality, f.retention_days, tree.depth + 1
+			FROM feeds f
+			JOIN tree ON f.parent_id = tree.id
+		)
+		SELECT `+feedColumns+` FROM tree
+		ORDER BY depth, sort_order, name
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var flat []models.Feed
+	for rows.Next() {
+		f, err := scanFeedRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		flat = append(flat, *f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	nodesByID := make(map[int64]*models.FeedNode, len(flat))
+	for _, f := range flat {
+		nodesByID[f.ID] = &models.FeedNode{Feed: f}
+	}
+
+	// flat is ordered shallowest-first (depth, sort_order, name), so walk it
+	// back to front: by the time a node is attached to its parent, every one
+	// of that node's own children (strictly deeper, so visited earlier in
+	// this reverse pass) is already in place.
+	var roots []models.FeedNode
+	for i := len(flat) - 1; i >= 0; i-- {
+		f := flat[i]
+		node := nodesByID[f.ID]
+		if f.ParentID == nil {
+			roots = append([]models.FeedNode{*node}, roots...)
+			continue
+		}
+		parent, ok := nodesByID[*f.ParentID]
+		if !ok {
+			roots = append([]models.FeedNode{*node}, roots...)
+			continue
+		}
+		parent.Children = append([]models.FeedNode{*node}, parent.Children...)
+	}
+
+	return roots, nil
+}
+
+// MoveFeed reparents id under newParent (nil to move it to the top level),
+// walking newParent's ancestors first to reject a move that would create a
+// cycle (moving a folder under its own descendant).
+func (db *DB) MoveFeed(id int64, newParent *int64) error {
+	if newParent != nil {
+		cur := *newParent
+		for {
+			if cur == id {
+				return fmt.Errorf("cannot move feed %d under its own descendant %d", id, *newParent)
+			}
+			var parentID sql.NullInt64
+			err := db.conn.QueryRow("SELECT parent_id FROM feeds WHERE id = ?", cur).Scan(&parentID)
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("parent feed %d does not exist", cur)
+			}
+			if err != nil {
+				return err
+			}
+			if !parentID.Valid {
+				break
+			}
+			cur = parentID.Int64
+		}
+	}
+
+	_, err := db.conn.Exec("UPDATE feeds SET parent_id = ?, updated_at = ? WHERE id = ?", newParent, time.Now(), id)
+	return err
+}
+
+// CreateCategory creates a top-level category for organizing feeds.
+// Categories are folders (see migrations/0011_feed_tree.sql) with no
+// parent; it's a thin alias over CreateFolder for callers that think in
+// terms of "categories" rather than the folder tree.
+func (db *DB) CreateCategory(userID int64, name string) (*models.Feed, error) {
+	return db.CreateFolder(userID, name, nil)
+}
+
+// ListCategories returns userID's top-level categories (folders).
+func (db *DB) ListCategories(userID int64) ([]models.Feed, error) {
+	rows, err := db.conn.Query(
+		"SELECT "+feedColumns+" FROM feeds WHERE user_id = ? AND kind = 'folder' AND parent_id IS NULL ORDER BY sort_order ASC, name ASC",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []models.Feed
+	for rows.Next() {
+		f, err := scanFeedRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, *f)
+	}
+	return categories, rows.Err()
+}
+
+// AssignFeedToCategory moves feedID into categoryID (or out of any category
+// when categoryID is nil). It's a thin alias over MoveFeed.
+func (db *DB) AssignFeedToCategory(feedID int64, categoryID *int64) error {
+	return db.MoveFeed(feedID, categoryID)
+}
+
+// GetVideosByCategory returns videos across every feed in categoryID,
+// aggregating across descendants the same way GetVideosByFeed does when
+// called on a folder - a category IS a folder, so it's a thin alias.
+func (db *DB) GetVideosByCategory(userID, categoryID int64, limit, offset int) ([]models.Video, int, error) {
+	return db.GetVideosByFeed(userID, categoryID, limit, offset)
+}
+
+func (db *DB) GetFeeds(userID int64) ([]models.Feed, error) {
+	rows, err := db.conn.Query("SELECT "+feedColumns+" FROM feeds WHERE user_id = ? ORDER BY sort_order ASC, name ASC", userID)
 	if err != nil {
 		return nil, err
 	}
@@ -170,24 +627,66 @@ func (db *DB) GetFeeds() ([]models.Feed, error) {
 
 	var feeds []models.Feed
 	for rows.Next() {
-		var f models.Feed
-		if err := rows.Scan(&f.ID, &f.Name, &f.Description, &f.Author, &f.Tags, &f.IsSystem, &f.SortOrder, &f.NewVideoCount, &f.CreatedAt, &f.UpdatedAt); err != nil {
+		f, err := scanFeedRow(rows)
+		if err != nil {
 			return nil, err
 		}
-		feeds = append(feeds, f)
+		feeds = append(feeds, *f)
 	}
 	return feeds, rows.Err()
 }
 
-func (db *DB) GetFeed(id int64) (*models.Feed, error) {
-	var f models.Feed
+func (db *DB) GetFeed(userID, id int64) (*models.Feed, error) {
+	return scanFeedRow(db.conn.QueryRow(
+		"SELECT "+feedColumns+" FROM feeds WHERE id = ? AND user_id = ?", id, userID,
+	))
+}
+
+// GetFeedByID looks up a feed without an ownership check, for internal
+// system components (the scheduler, backfill workers) that operate on a
+// feed ID directly rather than on behalf of a specific HTTP request.
+func (db *DB) GetFeedByID(id int64) (*models.Feed, error) {
+	return scanFeedRow(db.conn.QueryRow(
+		"SELECT "+feedColumns+" FROM feeds WHERE id = ?", id,
+	))
+}
+
+// FeedExists reports whether feedID exists and belongs to userID, so
+// handlers can cheaply reject cross-account access before doing real work.
+func (db *DB) FeedExists(userID, feedID int64) (bool, error) {
+	var exists bool
 	err := db.conn.QueryRow(
-		"SELECT id, name, description, author, tags, is_system, sort_order, new_video_count, created_at, updated_at FROM feeds WHERE id = ?", id,
-	).Scan(&f.ID, &f.Name, &f.Description, &f.Author, &f.Tags, &f.IsSystem, &f.SortOrder, &f.NewVideoCount, &f.CreatedAt, &f.UpdatedAt)
+		"SELECT 1 FROM feeds WHERE id = ? AND user_id = ?", feedID, userID,
+	).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
 	if err != nil {
-		return nil, err
+		return false, err
 	}
-	return &f, nil
+	return exists, nil
+}
+
+// ChannelURLExistsForUser reports whether url is already subscribed to in
+// any of userID's feeds, so callers can reject duplicate subscriptions
+// without two accounts subscribing to the same channel colliding.
+func (db *DB) ChannelURLExistsForUser(userID int64, url string) (bool, error) {
+	var exists bool
+	err := db.conn.QueryRow(`
+		SELECT 1
+		FROM channels c
+		JOIN feed_channels fc ON fc.channel_id = c.id
+		JOIN feeds f ON f.id = fc.feed_id
+		WHERE c.url = ? AND f.user_id = ?
+		LIMIT 1
+	`, url, userID).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return exists, nil
 }
 
 func (db *DB) UpdateFeed(id int64, name string) error {
@@ -206,34 +705,33 @@ func (db *DB) UpdateFeedMetadata(id int64, name, description, author, tags strin
 	return err
 }
 
-func (db *DB) DeleteFeed(id int64) error {
+func (db *DB) DeleteFeed(userID, id int64) error {
 	// Check if this is a system feed
 	var isSystem bool
-	err := db.conn.QueryRow("SELECT is_system FROM feeds WHERE id = ?", id).Scan(&isSystem)
+	err := db.conn.QueryRow("SELECT is_system FROM feeds WHERE id = ? AND user_id = ?", id, userID).Scan(&isSystem)
 	if err != nil {
 		return err
 	}
 	if isSystem {
 		return ErrSystemFeed
 	}
-	_, err = db.conn.Exec("DELETE FROM feeds WHERE id = ?", id)
+	_, err = db.conn.Exec("DELETE FROM feeds WHERE id = ? AND user_id = ?", id, userID)
 	return err
 }
 
-// GetOrCreateFeed returns an existing feed by name or creates it if it doesn't exist
-func (db *DB) GetOrCreateFeed(name string) (*models.Feed, error) {
-	var f models.Feed
-	err := db.conn.QueryRow(
-		"SELECT id, name, description, author, tags, is_system, sort_order, new_video_count, created_at, updated_at FROM feeds WHERE name = ?", name,
-	).Scan(&f.ID, &f.Name, &f.Description, &f.Author, &f.Tags, &f.IsSystem, &f.SortOrder, &f.NewVideoCount, &f.CreatedAt, &f.UpdatedAt)
+// GetOrCreateFeed returns userID's existing feed by name or creates it if it doesn't exist
+func (db *DB) GetOrCreateFeed(userID int64, name string) (*models.Feed, error) {
+	f, err := scanFeedRow(db.conn.QueryRow(
+		"SELECT "+feedColumns+" FROM feeds WHERE user_id = ? AND name = ?", userID, name,
+	))
 	if err == nil {
-		return &f, nil
+		return f, nil
 	}
 	if err != sql.ErrNoRows {
 		return nil, err
 	}
 	// Feed doesn't exist, create it
-	return db.CreateFeed(name)
+	return db.CreateFeed(userID, name)
 }
 
 // Channel operations
@@ -241,6 +739,20 @@ func (db *DB) GetOrCreateFeed(name string) (*models.Feed, error) {
 // AddChannelToFeed adds a channel to a feed. If the channel URL doesn't exist,
 // creates it first. Returns the channel and whether it was newly created.
 func (db *DB) AddChannelToFeed(feedID int64, url, name string) (*models.Channel, bool, error) {
+	return db.AddChannelToFeedWithSource(feedID, url, name, "youtube")
+}
+
+// AddChannelToFeedWithSource is like AddChannelToFeed but records which
+// source backend (youtube, peertube, rss, ...) resolved the channel.
+func (db *DB) AddChannelToFeedWithSource(feedID int64, url, name, source string) (*models.Channel, bool, error) {
+	return db.AddChannelToFeedWithSourceType(feedID, url, name, source, "channel")
+}
+
+// AddChannelToFeedWithSourceType is like AddChannelToFeedWithSource but also
+// records sourceType ("channel", "playlist", "user", "group" - see
+// models.Channel.SourceType), as reported by the sources.Source that
+// resolved the channel.
+func (db *DB) AddChannelToFeedWithSourceType(feedID int64, url, name, source, sourceType string) (*models.Channel, bool, error) {
 	tx, err := db.conn.Begin()
 	if err != nil {
 		return nil, false, err
@@ -250,15 +762,21 @@ func (db *DB) AddChannelToFeed(feedID int64, url, name string) (*models.Channel,
 	// Check if channel exists
 	var channel models.Channel
 	err = tx.QueryRow(
-		"SELECT id, url, name FROM channels WHERE url = ?", url,
-	).Scan(&channel.ID, &channel.URL, &channel.Name)
+		"SELECT id, url, name, source, source_type, language_override FROM channels WHERE url = ?", url,
+	).Scan(&channel.ID, &channel.URL, &channel.Name, &channel.Source, &channel.SourceType, &channel.LanguageOverride)
 
 	isNew := false
 	if err == sql.ErrNoRows {
+		if source == "" {
+			source = "youtube"
+		}
+		if sourceType == "" {
+			sourceType = "channel"
+		}
 		// Create new channel
 		result, err := tx.Exec(
-			"INSERT INTO channels (url, name) VALUES (?, ?)",
-			url, name,
+			"INSERT INTO channels (url, name, source, source_type) VALUES (?, ?, ?, ?)",
+			url, name, source, sourceType,
 		)
 		if err != nil {
 			return nil, false, err
@@ -266,6 +784,8 @@ func (db *DB) AddChannelToFeed(feedID int64, url, name string) (*models.Channel,
 		channel.ID, _ = result.LastInsertId()
 		channel.URL = url
 		channel.Name = name
+		channel.Source = source
+		channel.SourceType = sourceType
 		isNew = true
 	} else if err != nil {
 		return nil, false, err
@@ -295,7 +815,7 @@ func (db *DB) AddChannel(feedID int64, url, name string) (*models.Channel, error
 
 func (db *DB) GetChannelsByFeed(feedID int64) ([]models.Channel, error) {
 	rows, err := db.conn.Query(`
-		SELECT c.id, c.url, c.name
+		SELECT c.id, c.url, c.name, c.source, c.source_type, c.language_override
 		FROM channels c
 		JOIN feed_channels fc ON c.id = fc.channel_id
 		WHERE fc.feed_id = ?
@@ -309,7 +829,7 @@ func (db *DB) GetChannelsByFeed(feedID int64) ([]models.Channel, error) {
 	var channels []models.Channel
 	for rows.Next() {
 		var c models.Channel
-		if err := rows.Scan(&c.ID, &c.URL, &c.Name); err != nil {
+		if err := rows.Scan(&c.ID, &c.URL, &c.Name, &c.Source, &c.SourceType, &c.LanguageOverride); err != nil {
 			return nil, err
 		}
 		channels = append(channels, c)
@@ -320,7 +840,7 @@ func (db *DB) GetChannelsByFeed(feedID int64) ([]models.Channel, error) {
 // GetAllChannels returns all channels in the database
 func (db *DB) GetAllChannels() ([]models.Channel, error) {
 	rows, err := db.conn.Query(`
-		SELECT id, url, name FROM channels ORDER BY name
+		SELECT id, url, name, source, source_type, language_override FROM channels ORDER BY name
 	`)
 	if err != nil {
 		return nil, err
@@ -330,7 +850,7 @@ func (db *DB) GetAllChannels() ([]models.Channel, error) {
 	var channels []models.Channel
 	for rows.Next() {
 		var c models.Channel
-		if err := rows.Scan(&c.ID, &c.URL, &c.Name); err != nil {
+		if err := rows.Scan(&c.ID, &c.URL, &c.Name, &c.Source, &c.SourceType, &c.LanguageOverride); err != nil {
 			return nil, err
 		}
 		channels = append(channels, c)
@@ -338,30 +858,438 @@ func (db *DB) GetAllChannels() ([]models.Channel, error) {
 	return channels, rows.Err()
 }
 
-// DeleteChannel removes a channel completely (from all feeds)
-func (db *DB) DeleteChannel(channelID int64) error {
-	// CASCADE will handle feed_channels and videos
-	_, err := db.conn.Exec("DELETE FROM channels WHERE id = ?", channelID)
-	return err
+// OPML import/export
+//
+// Feeds become top-level outline categories; channels become nested
+// type="rss" outlines, matching how miniflux and other readers structure
+// OPML subscription exports.
+
+type opmlDocument struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    opmlHead `xml:"head"`
+	Body    opmlBody `xml:"body"`
 }
 
-func (db *DB) GetChannel(channelID int64) (*models.Channel, error) {
-	var c models.Channel
-	err := db.conn.QueryRow(
-		"SELECT id, url, name FROM channels WHERE id = ?", channelID,
-	).Scan(&c.ID, &c.URL, &c.Name)
+type opmlHead struct {
+	Title string `xml:"title"`
+}
+
+type opmlBody struct {
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+type opmlOutline struct {
+	Text     string        `xml:"text,attr"`
+	Title    string        `xml:"title,attr,omitempty"`
+	Type     string        `xml:"type,attr,omitempty"`
+	XMLURL   string        `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string        `xml:"htmlUrl,attr,omitempty"`
+	Outlines []opmlOutline `xml:"outline,omitempty"`
+
+	// Description, Author, and Tags are custom attributes (ignored by other
+	// OPML readers, which skip attributes they don't recognize) carrying a
+	// feed-level outline's models.Feed.Description/Author/Tags through
+	// export/import so re-importing our own export fully round-trips a
+	// feed rather than just its name and channel list.
+	Description string `xml:"description,attr,omitempty"`
+	Author      string `xml:"author,attr,omitempty"`
+	Tags        string `xml:"tags,attr,omitempty"`
+}
+
+// youtubeFeedURL returns the YouTube channel RSS feed URL for a channel
+// page URL like https://www.youtube.com/channel/UCxxxx, or "" if
+// channelURL isn't in that form.
+func youtubeFeedURL(channelURL string) string {
+	const prefix = "https://www.youtube.com/channel/"
+	if !strings.HasPrefix(channelURL, prefix) {
+		return ""
+	}
+	return "https://www.youtube.com/feeds/videos.xml?channel_id=" + strings.TrimPrefix(channelURL, prefix)
+}
+
+// youtubeChannelIDFromFeedURL extracts the channel_id query param from a
+// YouTube channel RSS feed URL, or "" if xmlURL isn't one.
+func youtubeChannelIDFromFeedURL(xmlURL string) string {
+	u, err := url.Parse(xmlURL)
 	if err != nil {
-		return nil, err
+		return ""
 	}
-	return &c, nil
+	return u.Query().Get("channel_id")
 }
 
-// GetChannelByURL returns a channel by its URL, or nil if not found
-func (db *DB) GetChannelByURL(url string) (*models.Channel, error) {
+// buildOPMLOutline turns one feed into its OPML outline: a folder (category,
+// see migrations/0011_feed_tree.sql) recurses into byParent's children as
+// nested category outlines, while a regular feed's channels become nested
+// type="rss" outlines carrying both the RSS feed (xmlUrl) and the channel's
+// own page (htmlUrl), so the subscription list round-trips through other
+// RSS readers. A regular feed's own Description/Author/Tags are carried on
+// its outline as custom attributes (see opmlOutline) so re-importing our own
+// export round-trips the feed, not just its channels.
+func (db *DB) buildOPMLOutline(feed models.Feed, byParent map[int64][]models.Feed) (opmlOutline, error) {
+	outline := opmlOutline{Text: feed.Name, Title: feed.Name}
+
+	if feed.Kind == "folder" {
+		for _, child := range byParent[feed.ID] {
+			childOutline, err := db.buildOPMLOutline(child, byParent)
+			if err != nil {
+				return opmlOutline{}, err
+			}
+			outline.Outlines = append(outline.Outlines, childOutline)
+		}
+		return outline, nil
+	}
+
+	outline.Description = feed.Description
+	outline.Author = feed.Author
+	outline.Tags = feed.Tags
+
+	channels, err := db.GetChannelsByFeed(feed.ID)
+	if err != nil {
+		return opmlOutline{}, err
+	}
+	for _, ch := range channels {
+		xmlURL := youtubeFeedURL(ch.URL)
+		if xmlURL == "" {
+			// Non-YouTube sources (generic RSS, PeerTube, ...) store
+			// the feed URL itself as the channel URL.
+			xmlURL = ch.URL
+		}
+		outline.Outlines = append(outline.Outlines, opmlOutline{
+			Text:    ch.Name,
+			Title:   ch.Name,
+			Type:    "rss",
+			XMLURL:  xmlURL,
+			HTMLURL: ch.URL,
+		})
+	}
+	return outline, nil
+}
+
+// ExportOPML writes all of userID's feeds as an OPML 2.0 document: each
+// top-level feed is an outline category, folders recurse into their child
+// feeds as nested category outlines (see buildOPMLOutline), and channels
+// are nested inside their feed's outline as type="rss" outlines carrying
+// both the RSS feed (xmlUrl) and the channel's own page (htmlUrl), so the
+// subscription list round-trips through other RSS readers. A feed's
+// Description/Author/Tags also round-trip, through custom outline
+// attributes ImportOPML understands but other readers simply ignore.
+func (db *DB) ExportOPML(userID int64, w io.Writer) error {
+	feeds, err := db.GetFeeds(userID)
+	if err != nil {
+		return err
+	}
+
+	byParent := make(map[int64][]models.Feed)
+	var roots []models.Feed
+	for _, feed := range feeds {
+		if feed.ParentID != nil {
+			byParent[*feed.ParentID] = append(byParent[*feed.ParentID], feed)
+		} else {
+			roots = append(roots, feed)
+		}
+	}
+
+	doc := opmlDocument{
+		Version: "2.0",
+		Head:    opmlHead{Title: "Feeds subscriptions"},
+	}
+
+	for _, feed := range roots {
+		outline, err := db.buildOPMLOutline(feed, byParent)
+		if err != nil {
+			return err
+		}
+		doc.Body.Outlines = append(doc.Body.Outlines, outline)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// ImportOPML reads an OPML document and recreates its feeds/channels for
+// userID in a single transaction: top-level outlines become feeds (or are
+// merged into an existing feed of the same name), in document order, and
+// nested type="rss" outlines become channels added to that feed. A top-level
+// outline that groups other feeds (see isOPMLCategoryOutline) becomes a
+// folder instead, and its child outlines become feeds inside it. Channels
+// already subscribed (matched by URL, within the target feed) are skipped
+// rather than erroring, mirroring miniflux's "ignore duplicate entry"
+// import behavior.
+func (db *DB) ImportOPML(userID int64, r io.Reader) (added, skipped int, err error) {
+	var doc opmlDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return 0, 0, fmt.Errorf("failed to parse OPML: %w", err)
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+
+	for _, outline := range doc.Body.Outlines {
+		var parentID *int64
+		feedOutlines := []opmlOutline{outline}
+
+		if isOPMLCategoryOutline(outline) {
+			name := outline.Title
+			if name == "" {
+				name = outline.Text
+			}
+			if name == "" {
+				name = "Imported"
+			}
+
+			var folderID int64
+			err := tx.QueryRow(
+				"SELECT id FROM feeds WHERE user_id = ? AND name = ? AND kind = 'folder' AND parent_id IS NULL",
+				userID, name,
+			).Scan(&folderID)
+			if err == sql.ErrNoRows {
+				var maxOrder int
+				if err := tx.QueryRow("SELECT COALESCE(MAX(sort_order), -1) FROM feeds WHERE user_id = ? AND parent_id IS NULL", userID).Scan(&maxOrder); err != nil {
+					return 0, 0, err
+				}
+				result, err := tx.Exec(
+					"INSERT INTO feeds (user_id, name, sort_order, kind, created_at, updated_at) VALUES (?, ?, ?, 'folder', ?, ?)",
+					userID, name, maxOrder+1, now, now,
+				)
+				if err != nil {
+					return 0, 0, err
+				}
+				folderID, err = result.LastInsertId()
+				if err != nil {
+					return 0, 0, err
+				}
+			} else if err != nil {
+				return 0, 0, err
+			}
+
+			parentID = &folderID
+			feedOutlines = outline.Outlines
+		}
+
+		for _, feedOutline := range feedOutlines {
+			a, s, err := db.importOPMLFeedOutline(tx, userID, feedOutline, parentID, now)
+			if err != nil {
+				return 0, 0, err
+			}
+			added += a
+			skipped += s
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, err
+	}
+	return added, skipped, nil
+}
+
+// isOPMLCategoryOutline reports whether outline groups other feeds (a
+// folder, see migrations/0011_feed_tree.sql) rather than being itself a
+// feed whose children are leaf channel outlines: it has no xmlUrl of its
+// own, and at least one child is itself non-leaf (no xmlUrl).
+func isOPMLCategoryOutline(outline opmlOutline) bool {
+	if outline.XMLURL != "" || len(outline.Outlines) == 0 {
+		return false
+	}
+	for _, child := range outline.Outlines {
+		if child.XMLURL == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// importOPMLFeedOutline finds or creates (by name, under parentID) the feed
+// for a top-level or folder-nested outline, then imports its nested
+// type="rss" outlines as channels. A newly created feed's
+// Description/Author/Tags are seeded from the outline's custom attributes
+// (see opmlOutline), if present. Channels already subscribed (matched by
+// URL, within the target feed) are skipped rather than erroring, mirroring
+// miniflux's "ignore duplicate entry" import behavior.
+func (db *DB) importOPMLFeedOutline(tx *sql.Tx, userID int64, outline opmlOutline, parentID *int64, now time.Time) (added, skipped int, err error) {
+	channelOutlines := outline.Outlines
+	name := outline.Title
+	if name == "" {
+		name = outline.Text
+	}
+
+	// A flat (non-category) outline is itself a channel; treat it as a
+	// single-entry feed under its own name rather than dropping it.
+	if outline.XMLURL != "" && len(channelOutlines) == 0 {
+		channelOutlines = []opmlOutline{outline}
+	}
+	if name == "" {
+		name = "Imported"
+	}
+
+	var feedID int64
+	err = tx.QueryRow("SELECT id FROM feeds WHERE user_id = ? AND name = ? AND parent_id IS ?", userID, name, parentID).Scan(&feedID)
+	if err == sql.ErrNoRows {
+		var maxOrder int
+		if err := tx.QueryRow("SELECT COALESCE(MAX(sort_order), -1) FROM feeds WHERE user_id = ? AND parent_id IS ?", userID, parentID).Scan(&maxOrder); err != nil {
+			return 0, 0, err
+		}
+		result, err := tx.Exec(
+			"INSERT INTO feeds (user_id, name, description, author, tags, sort_order, parent_id, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			userID, name, outline.Description, outline.Author, outline.Tags, maxOrder+1, parentID, now, now,
+		)
+		if err != nil {
+			return 0, 0, err
+		}
+		feedID, err = result.LastInsertId()
+		if err != nil {
+			return 0, 0, err
+		}
+	} else if err != nil {
+		return 0, 0, err
+	}
+
+	for _, channelOutline := range channelOutlines {
+		if channelOutline.XMLURL == "" {
+			continue
+		}
+
+		channelURL := channelOutline.HTMLURL
+		source := "rss"
+		if channelID := youtubeChannelIDFromFeedURL(channelOutline.XMLURL); channelID != "" {
+			source = "youtube"
+			if channelURL == "" {
+				channelURL = "https://www.youtube.com/channel/" + channelID
+			}
+		}
+		if channelURL == "" {
+			channelURL = channelOutline.XMLURL
+		}
+
+		channelName := channelOutline.Title
+		if channelName == "" {
+			channelName = channelOutline.Text
+		}
+
+		var channelID int64
+		err := tx.QueryRow("SELECT id FROM channels WHERE url = ?", channelURL).Scan(&channelID)
+		if err == sql.ErrNoRows {
+			result, err := tx.Exec(
+				"INSERT INTO channels (url, name, source) VALUES (?, ?, ?)",
+				channelURL, channelName, source,
+			)
+			if err != nil {
+				return 0, 0, err
+			}
+			channelID, err = result.LastInsertId()
+			if err != nil {
+				return 0, 0, err
+			}
+		} else if err != nil {
+			return 0, 0, err
+		}
+
+		result, err := tx.Exec(
+			"INSERT OR IGNORE INTO feed_channels (feed_id, channel_id) VALUES (?, ?)",
+			feedID, channelID,
+		)
+		if err != nil {
+			return 0, 0, err
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return 0, 0, err
+		}
+		if rowsAffected > 0 {
+			added++
+		} else {
+			skipped++
+		}
+	}
+	return added, skipped, nil
+}
+
+// DeleteChannel removes a channel completely (from all feeds)
+func (db *DB) DeleteChannel(channelID int64) error {
+	// CASCADE will handle feed_channels and videos
+	_, err := db.conn.Exec("DELETE FROM channels WHERE id = ?", channelID)
+	return err
+}
+
+func (db *DB) GetChannel(channelID int64) (*models.Channel, error) {
+	var c models.Channel
+	err := db.conn.QueryRow(
+		"SELECT id, url, name, source, source_type, language_override FROM channels WHERE id = ?", channelID,
+	).Scan(&c.ID, &c.URL, &c.Name, &c.Source, &c.SourceType, &c.LanguageOverride)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// GetChannelBackfillCursor returns a channel's saved Data-API pagination
+// cursor, so a ChannelBackfiller can resume after a restart instead of
+// re-paging from the start of the channel's history. done reports whether a
+// prior run already paged through the channel's entire upload history.
+func (db *DB) GetChannelBackfillCursor(channelID int64) (pageToken string, done bool, err error) {
+	err = db.conn.QueryRow(
+		"SELECT next_page_token, backfill_done FROM channels WHERE id = ?", channelID,
+	).Scan(&pageToken, &done)
+	if err != nil {
+		return "", false, err
+	}
+	return pageToken, done, nil
+}
+
+// SaveChannelBackfillCursor persists a channel's Data-API pagination cursor
+// after each page, so a crash mid-backfill resumes from the last page
+// fetched rather than the beginning. done should be true once pageToken is
+// exhausted, so GetChannelsNeedingBackfill stops returning the channel.
+func (db *DB) SaveChannelBackfillCursor(channelID int64, pageToken string, done bool) error {
+	_, err := db.conn.Exec(
+		"UPDATE channels SET next_page_token = ?, backfill_done = ?, last_backfilled_at = ? WHERE id = ?",
+		pageToken, done, time.Now(), channelID,
+	)
+	return err
+}
+
+// GetChannelsNeedingBackfill returns up to limit channels whose upload
+// history hasn't been fully paged through yet, for a scheduler to drive
+// ChannelBackfillManager without an explicit per-channel trigger.
+func (db *DB) GetChannelsNeedingBackfill(limit int) ([]models.Channel, error) {
+	rows, err := db.conn.Query(
+		"SELECT id, url, name, source, source_type, language_override FROM channels WHERE backfill_done = 0 LIMIT ?", limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var channels []models.Channel
+	for rows.Next() {
+		var c models.Channel
+		if err := rows.Scan(&c.ID, &c.URL, &c.Name, &c.Source, &c.SourceType, &c.LanguageOverride); err != nil {
+			return nil, err
+		}
+		channels = append(channels, c)
+	}
+	return channels, rows.Err()
+}
+
+// GetChannelByURL returns a channel by its URL, or nil if not found
+func (db *DB) GetChannelByURL(url string) (*models.Channel, error) {
 	var c models.Channel
 	err := db.conn.QueryRow(
-		"SELECT id, url, name FROM channels WHERE url = ?", url,
-	).Scan(&c.ID, &c.URL, &c.Name)
+		"SELECT id, url, name, source, source_type, language_override FROM channels WHERE url = ?", url,
+	).Scan(&c.ID, &c.URL, &c.Name, &c.Source, &c.SourceType, &c.LanguageOverride)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -383,7 +1311,7 @@ func (db *DB) GetChannelsByURL(url string) ([]models.Channel, error) {
 // GetFeedsByChannel returns all feeds that contain a channel
 func (db *DB) GetFeedsByChannel(channelID int64) ([]models.Feed, error) {
 	rows, err := db.conn.Query(`
-		SELECT f.id, f.name, f.description, f.author, f.tags, f.is_system, f.sort_order, f.new_video_count, f.created_at, f.updated_at
+		SELECT f.id, f.user_id, f.name, f.description, f.author, f.tags, f.is_system, f.sort_order, f.new_video_count, f.created_at, f.updated_at
 		FROM feeds f
 		JOIN feed_channels fc ON f.id = fc.feed_id
 		WHERE fc.channel_id = ?
@@ -397,7 +1325,7 @@ func (db *DB) GetFeedsByChannel(channelID int64) ([]models.Feed, error) {
 	var feeds []models.Feed
 	for rows.Next() {
 		var f models.Feed
-		if err := rows.Scan(&f.ID, &f.Name, &f.Description, &f.Author, &f.Tags, &f.IsSystem, &f.SortOrder, &f.NewVideoCount, &f.CreatedAt, &f.UpdatedAt); err != nil {
+		if err := rows.Scan(&f.ID, &f.UserID, &f.Name, &f.Description, &f.Author, &f.Tags, &f.IsSystem, &f.SortOrder, &f.NewVideoCount, &f.CreatedAt, &f.UpdatedAt); err != nil {
 			return nil, err
 		}
 		feeds = append(feeds, f)
@@ -405,23 +1333,29 @@ func (db *DB) GetFeedsByChannel(channelID int64) ([]models.Feed, error) {
 	return feeds, rows.Err()
 }
 
-// ReorderFeeds updates sort_order for feeds based on the provided order.
-// feedIDs should contain all feed IDs in the desired display order.
-func (db *DB) ReorderFeeds(feedIDs []int64) error {
+// ReorderFeeds sets sort_order for feedIDs to their position in the slice,
+// scoped to parentID (0 for the top level) so drag-and-drop within one
+// folder can't disturb sibling order in another.
+func (db *DB) ReorderFeeds(parentID int64, feedIDs []int64) error {
 	tx, err := db.conn.Begin()
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	stmt, err := tx.Prepare("UPDATE feeds SET sort_order = ? WHERE id = ?")
+	var parent any
+	if parentID != 0 {
+		parent = parentID
+	}
+
+	stmt, err := tx.Prepare("UPDATE feeds SET sort_order = ? WHERE id = ? AND parent_id IS ?")
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
 	for i, id := range feedIDs {
-		if _, err := stmt.Exec(i, id); err != nil {
+		if _, err := stmt.Exec(i, id, parent); err != nil {
 			return err
 		}
 	}
@@ -435,6 +1369,192 @@ func (db *DB) UpdateNewVideoCount(feedID int64, count int) error {
 	return err
 }
 
+// UpdateFeedSchedule sets a feed's refresh interval and paused flag. Pausing
+// clears next_refresh_at so the scheduler leaves it alone; unpausing schedules
+// the next run `intervalSeconds` out.
+func (db *DB) UpdateFeedSchedule(feedID int64, intervalSeconds int, paused bool) error {
+	var nextRefreshAt any
+	if !paused {
+		nextRefreshAt = time.Now().Add(time.Duration(intervalSeconds) * time.Second)
+	}
+	_, err := db.conn.Exec(
+		"UPDATE feeds SET refresh_interval_seconds = ?, paused = ?, next_refresh_at = ?, updated_at = ? WHERE id = ?",
+		intervalSeconds, paused, nextRefreshAt, time.Now(), feedID,
+	)
+	return err
+}
+
+// UpdateFeedHideShorts sets whether a feed's refresh should exclude YouTube
+// Shorts. See youtube.FetchLatestVideos's includeShorts parameter for how
+// this is used to skip the per-video shorts probe entirely for channels
+// that support it.
+func (db *DB) UpdateFeedHideShorts(feedID int64, hideShorts bool) error {
+	_, err := db.conn.Exec(
+		"UPDATE feeds SET hide_shorts = ?, updated_at = ? WHERE id = ?",
+		hideShorts, time.Now(), feedID,
+	)
+	return err
+}
+
+// UpdateFeedAutoDownload sets the quality (e.g. "720") that the feed's new
+// videos should be auto-queued into internal/downloader's download queue at,
+// or "" to turn auto-download off - see (*api.Server).refreshFeedCore.
+func (db *DB) UpdateFeedAutoDownload(feedID int64, quality string) error {
+	_, err := db.conn.Exec(
+		"UPDATE feeds SET auto_download_quality = ?, updated_at = ? WHERE id = ?",
+		quality, time.Now(), feedID,
+	)
+	return err
+}
+
+// UpdateFeedPodcastSettings configures feedID's podcast export/episode
+// download - format is "audio", "video", "custom", or "" to disable;
+// quality is "high" or "low"; retentionDays is how long a downloaded
+// episode is kept before PodcastEpisodeWorker cleans it up (0 = forever).
+// See (*api.Server).handleAPISetFeedPodcast.
+func (db *DB) UpdateFeedPodcastSettings(feedID int64, format, quality string, retentionDays int) error {
+	_, err := db.conn.Exec(
+		"UPDATE feeds SET podcast_format = ?, podcast_quality = ?, retention_days = ?, updated_at = ? WHERE id = ?",
+		format, quality, retentionDays, time.Now(), feedID,
+	)
+	return err
+}
+
+// ClaimDueFeeds atomically claims every unpaused, due feed whose lease is
+// unclaimed or expired for hostname, so multiple feeds instances sharing
+// this database cooperatively refresh channels without duplicate work - see
+// scheduler.Scheduler's poll loop. A claimed feed stays leased to hostname
+// until leaseUntil; HeartbeatFeedClaim extends it while a refresh is still
+// in flight, and ReleaseFeedClaim clears it on completion.
+func (db *DB) ClaimDueFeeds(hostname string, now, leaseUntil time.Time) ([]int64, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		"UPDATE feeds SET claimed_by = ?, claimed_until = ? "+
+			"WHERE paused = FALSE AND (next_refresh_at IS NULL OR next_refresh_at <= ?) "+
+			"AND (claimed_until IS NULL OR claimed_until <= ?)",
+		hostname, leaseUntil, now, now,
+	); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query("SELECT id FROM feeds WHERE claimed_by = ? AND claimed_until = ?", hostname, leaseUntil)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, tx.Commit()
+}
+
+// HeartbeatFeedClaim extends hostname's lease on feedID while its refresh is
+// still running, so another instance's ClaimDueFeeds doesn't steal it out
+// from under a slow refresh. A no-op if hostname no longer holds the claim.
+func (db *DB) HeartbeatFeedClaim(feedID int64, hostname string, leaseUntil time.Time) error {
+	_, err := db.conn.Exec(
+		"UPDATE feeds SET claimed_until = ? WHERE id = ? AND claimed_by = ?",
+		leaseUntil, feedID, hostname,
+	)
+	return err
+}
+
+// ReleaseFeedClaim clears hostname's lease on feedID once its refresh has
+// finished, freeing it for the next instance's ClaimDueFeeds to pick up once
+// it's next due. A no-op if hostname no longer holds the claim.
+func (db *DB) ReleaseFeedClaim(feedID int64, hostname string) error {
+	_, err := db.conn.Exec(
+		"UPDATE feeds SET claimed_by = '', claimed_until = NULL WHERE id = ? AND claimed_by = ?",
+		feedID, hostname,
+	)
+	return err
+}
+
+// ReassignFeed force-clears feedID's claim regardless of which host holds
+// it, for POST /api/cluster/reassign's manual rebalancing - the next poll
+// tick on any instance is then free to claim it.
+func (db *DB) ReassignFeed(feedID int64) error {
+	_, err := db.conn.Exec("UPDATE feeds SET claimed_by = '', claimed_until = NULL WHERE id = ?", feedID)
+	return err
+}
+
+// ReassignHostClaims force-clears every claim held by hostname, for
+// decommissioning an instance via POST /api/cluster/reassign. Returns how
+// many feeds were released.
+func (db *DB) ReassignHostClaims(hostname string) (int64, error) {
+	res, err := db.conn.Exec("UPDATE feeds SET claimed_by = '', claimed_until = NULL WHERE claimed_by = ?", hostname)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// UpsertSyncWorkerHeartbeat records that hostname is alive right now, for
+// GET /api/cluster/workers's observability view - see scheduler.Scheduler's
+// heartbeat loop.
+func (db *DB) UpsertSyncWorkerHeartbeat(hostname string) error {
+	_, err := db.conn.Exec(
+		"INSERT INTO sync_workers (hostname, last_heartbeat) VALUES (?, ?) "+
+			"ON CONFLICT(hostname) DO UPDATE SET last_heartbeat = excluded.last_heartbeat",
+		hostname, time.Now(),
+	)
+	return err
+}
+
+// GetSyncWorkers lists every feeds instance that has heartbeated, most
+// recently alive first, for GET /api/cluster/workers.
+func (db *DB) GetSyncWorkers() ([]models.SyncWorker, error) {
+	rows, err := db.conn.Query("SELECT hostname, last_heartbeat FROM sync_workers ORDER BY last_heartbeat DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var workers []models.SyncWorker
+	for rows.Next() {
+		var w models.SyncWorker
+		if err := rows.Scan(&w.Hostname, &w.LastHeartbeat); err != nil {
+			return nil, err
+		}
+		workers = append(workers, w)
+	}
+	return workers, rows.Err()
+}
+
+// RecordFeedRefreshSuccess clears a feed's error state and schedules its next run.
+func (db *DB) RecordFeedRefreshSuccess(feedID int64, nextRefreshAt time.Time) error {
+	_, err := db.conn.Exec(
+		"UPDATE feeds SET last_refresh_at = ?, last_refresh_error = '', next_refresh_at = ? WHERE id = ?",
+		time.Now(), nextRefreshAt, feedID,
+	)
+	return err
+}
+
+// RecordFeedRefreshFailure records a feed's refresh error and reschedules its
+// next attempt, typically after a backoff delay longer than its normal interval.
+func (db *DB) RecordFeedRefreshFailure(feedID int64, errMsg string, nextRefreshAt time.Time) error {
+	_, err := db.conn.Exec(
+		"UPDATE feeds SET last_refresh_at = ?, last_refresh_error = ?, next_refresh_at = ? WHERE id = ?",
+		time.Now(), errMsg, nextRefreshAt, feedID,
+	)
+	return err
+}
+
 // RemoveChannelFromFeed removes a channel from a feed.
 // If the channel has no more feeds, it and its videos are deleted.
 // Returns true if the channel was completely deleted.
@@ -499,9 +1619,25 @@ func (db *DB) UpsertVideo(v *models.Video) (bool, error) {
 	err := db.conn.QueryRow("SELECT 1 FROM videos WHERE id = ?", v.ID).Scan(&exists)
 	isInsert := err == sql.ErrNoRows
 
+	// A channel's language override, if set, beats whatever language the
+	// video itself was tagged with - it exists specifically for channels
+	// where automatic detection misfires.
+	language := v.Language
+	if override, err := db.channelLanguageOverride(v.ChannelID); err == nil && override != "" {
+		language = override
+	}
+
+	// An empty Tab means "caller doesn't know/care which tab this came
+	// from" - default it to the ordinary videos tab rather than writing an
+	// empty string, matching the column's own default.
+	tab := v.Tab
+	if tab == "" {
+		tab = "videos"
+	}
+
 	_, err = db.conn.Exec(`
-		INSERT INTO videos (id, channel_id, title, channel_name, thumbnail, duration, is_short, published, url)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO videos (id, channel_id, title, channel_name, thumbnail, duration, is_short, published, url, language, tab)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(id) DO UPDATE SET
 			channel_id = excluded.channel_id,
 			title = excluded.title,
@@ -513,36 +1649,259 @@ func (db *DB) UpsertVideo(v *models.Video) (bool, error) {
 			is_short = CASE
 				WHEN excluded.is_short IS NOT NULL THEN excluded.is_short
 				ELSE videos.is_short
-			END
-	`, v.ID, v.ChannelID, v.Title, v.ChannelName, v.Thumbnail, v.Duration, isShort, v.Published, v.URL)
+			END,
+			language = CASE
+				WHEN excluded.language != '' THEN excluded.language
+				ELSE videos.language
+			END,
+			tab = excluded.tab
+	`, v.ID, v.ChannelID, v.Title, v.ChannelName, v.Thumbnail, v.Duration, isShort, v.Published, v.URL, language, tab)
 	return isInsert, err
 }
 
-func (db *DB) GetVideosByFeed(feedID int64, limit, offset int) ([]models.Video, int, error) {
-	// Get total count first
-	var total int
-	err := db.conn.QueryRow(`
-		SELECT COUNT(*)
-		FROM videos v
-		JOIN channels c ON v.channel_id = c.id
-		JOIN feed_channels fc ON c.id = fc.channel_id
-		WHERE fc.feed_id = ?
-	`, feedID).Scan(&total)
-	if err != nil {
-		return nil, 0, err
+// channelLanguageOverride returns channelID's language_override, or "" if
+// unset or the channel doesn't exist.
+func (db *DB) channelLanguageOverride(channelID int64) (string, error) {
+	var override string
+	err := db.conn.QueryRow("SELECT language_override FROM channels WHERE id = ?", channelID).Scan(&override)
+	if err == sql.ErrNoRows {
+		return "", nil
 	}
+	return override, err
+}
 
-	rows, err := db.conn.Query(`
-		SELECT v.id, v.channel_id, v.title, v.channel_name, v.thumbnail, v.duration, v.is_short, v.published, v.url
-		FROM videos v
-		JOIN channels c ON v.channel_id = c.id
-		JOIN feed_channels fc ON c.id = fc.channel_id
-		WHERE fc.feed_id = ?
-		ORDER BY v.published DESC
-		LIMIT ? OFFSET ?
-	`, feedID, limit, offset)
+// SetChannelLanguageOverride pins channelID's videos to language (an
+// ISO-639-1 code), or clears the override back to automatic detection when
+// language is "".
+func (db *DB) SetChannelLanguageOverride(channelID int64, language string) error {
+	_, err := db.conn.Exec("UPDATE channels SET language_override = ? WHERE id = ?", language, channelID)
+	return err
+}
+
+// Live-stream status values for videos.live_status (see
+// migrations/0012_live_status.sql). "none" is an ordinary, never-live video;
+// a stream moves upcoming -> live -> ended as a poller (see
+// internal/livestream) observes it.
+const (
+	LiveStatusNone     = "none"
+	LiveStatusUpcoming = "upcoming"
+	LiveStatusLive     = "live"
+	LiveStatusEnded    = "ended"
+)
+
+// Episode download status values for videos.episode_status (see
+// migrations/0025_podcast_episodes.sql), driven by api.PodcastEpisodeWorker.
+// A video starts "new", moves to "downloaded" or "error" once the worker
+// attempts it, and finally to "cleaned" once its file is removed by the
+// owning feed's retention policy.
+const (
+	EpisodeStatusNew        = "new"
+	EpisodeStatusDownloaded = "downloaded"
+	EpisodeStatusError      = "error"
+	EpisodeStatusCleaned    = "cleaned"
+)
+
+// liveVideoColumns extends the plain video column list with the live-stream
+// columns, for queries that need to report status/schedule/viewer data.
+const liveVideoColumns = "v.id, v.channel_id, v.title, v.channel_name, v.thumbnail, v.duration, v.is_short, v.published, v.url, " +
+	"v.live_status, v.scheduled_start_time, v.actual_start_time, v.concurrent_viewers"
+
+// scanLiveVideoRow scans a row selected with liveVideoColumns into a
+// models.Video.
+func scanLiveVideoRow(row feedRowScanner) (models.Video, error) {
+	var v models.Video
+	var isShort sql.NullBool
+	var scheduledStart, actualStart sql.NullTime
+	var concurrentViewers sql.NullInt64
+	err := row.Scan(&v.ID, &v.ChannelID, &v.Title, &v.ChannelName, &v.Thumbnail, &v.Duration, &isShort, &v.Published, &v.URL,
+		&v.LiveStatus, &scheduledStart, &actualStart, &concurrentViewers)
 	if err != nil {
-		return nil, 0, err
+		return v, err
+	}
+	if isShort.Valid {
+		v.IsShort = &isShort.Bool
+	}
+	if scheduledStart.Valid {
+		v.ScheduledStartTime = &scheduledStart.Time
+	}
+	if actualStart.Valid {
+		v.ActualStartTime = &actualStart.Time
+	}
+	if concurrentViewers.Valid {
+		v.ConcurrentViewers = &concurrentViewers.Int64
+	}
+	return v, nil
+}
+
+// UpsertLiveStatus updates a video's live-stream state, recording a
+// stream_events row whenever the status actually changes so "went live" /
+// "ended" transitions can be reconstructed later (or alerted on live by
+// the caller, via the returned previous status). scheduledStart and
+// actualStart may be nil when not yet known.
+func (db *DB) UpsertLiveStatus(videoID, status string, scheduledStart, actualStart *time.Time) (changed bool, previous string, err error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return false, "", err
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRow("SELECT live_status FROM videos WHERE id = ?", videoID).Scan(&previous); err != nil {
+		return false, "", err
+	}
+
+	if _, err := tx.Exec(
+		"UPDATE videos SET live_status = ?, scheduled_start_time = ?, actual_start_time = ? WHERE id = ?",
+		status, scheduledStart, actualStart, videoID,
+	); err != nil {
+		return false, "", err
+	}
+
+	if previous != status {
+		if _, err := tx.Exec(
+			"INSERT INTO stream_events (video_id, from_status, to_status, occurred_at) VALUES (?, ?, ?, ?)",
+			videoID, previous, status, time.Now(),
+		); err != nil {
+			return false, "", err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, "", err
+	}
+	return previous != status, previous, nil
+}
+
+// UpdateConcurrentViewers records a live video's current viewer count
+// without touching its status or stream_events, for the poller's
+// short-interval refresh of videos already known to be live.
+func (db *DB) UpdateConcurrentViewers(videoID string, count int64) error {
+	_, err := db.conn.Exec("UPDATE videos SET concurrent_viewers = ? WHERE id = ?", count, videoID)
+	return err
+}
+
+// LiveCheckCandidate is a video the live-stream poller should re-check on
+// its shorter cadence, returned by GetVideosNeedingLiveStatusPoll.
+type LiveCheckCandidate struct {
+	VideoID string
+	URL     string
+}
+
+// GetVideosNeedingLiveStatusPoll returns up to limit videos currently marked
+// upcoming or live, soonest/most-recently-scheduled first, for the poller's
+// batch.
+func (db *DB) GetVideosNeedingLiveStatusPoll(limit int) ([]LiveCheckCandidate, error) {
+	rows, err := db.conn.Query(
+		"SELECT id, url FROM videos WHERE live_status IN (?, ?) ORDER BY COALESCE(scheduled_start_time, actual_start_time) ASC LIMIT ?",
+		LiveStatusUpcoming, LiveStatusLive, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candidates []LiveCheckCandidate
+	for rows.Next() {
+		var c LiveCheckCandidate
+		if err := rows.Scan(&c.VideoID, &c.URL); err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, rows.Err()
+}
+
+// GetUpcomingStreams returns feedID's upcoming premieres/streams scheduled
+// to start within the next `within` duration, soonest first.
+func (db *DB) GetUpcomingStreams(feedID int64, within time.Duration) ([]models.Video, error) {
+	rows, err := db.conn.Query(`
+		SELECT `+liveVideoColumns+`
+		FROM videos v
+		JOIN channels c ON v.channel_id = c.id
+		JOIN feed_channels fc ON c.id = fc.channel_id
+		WHERE fc.feed_id = ? AND v.live_status = ?
+		  AND v.scheduled_start_time IS NOT NULL AND v.scheduled_start_time <= ?
+		ORDER BY v.scheduled_start_time ASC
+	`, feedID, LiveStatusUpcoming, time.Now().Add(within))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var videos []models.Video
+	for rows.Next() {
+		v, err := scanLiveVideoRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		videos = append(videos, v)
+	}
+	return videos, rows.Err()
+}
+
+// GetLiveNow returns feedID's videos currently live, most recently started
+// first.
+func (db *DB) GetLiveNow(feedID int64) ([]models.Video, error) {
+	rows, err := db.conn.Query(`
+		SELECT `+liveVideoColumns+`
+		FROM videos v
+		JOIN channels c ON v.channel_id = c.id
+		JOIN feed_channels fc ON c.id = fc.channel_id
+		WHERE fc.feed_id = ? AND v.live_status = ?
+		ORDER BY v.actual_start_time DESC
+	`, feedID, LiveStatusLive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var videos []models.Video
+	for rows.Next() {
+		v, err := scanLiveVideoRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		videos = append(videos, v)
+	}
+	return videos, rows.Err()
+}
+
+// descendantFeedsCTE resolves to feedID itself plus, when feedID is a
+// folder, every feed/folder nested under it - so a query joining against it
+// transparently aggregates a folder's descendant feeds without needing to
+// know in advance whether feedID is a leaf feed or a folder.
+const descendantFeedsCTE = `
+	WITH RECURSIVE descendant_feeds(id) AS (
+		SELECT id FROM feeds WHERE id = ? AND user_id = ?
+		UNION ALL
+		SELECT f.id FROM feeds f JOIN descendant_feeds df ON f.parent_id = df.id
+	)
+`
+
+func (db *DB) GetVideosByFeed(userID, feedID int64, limit, offset int) ([]models.Video, int, error) {
+	// Get total count first
+	var total int
+	err := db.conn.QueryRow(descendantFeedsCTE+`
+		SELECT COUNT(*)
+		FROM videos v
+		JOIN channels c ON v.channel_id = c.id
+		JOIN feed_channels fc ON c.id = fc.channel_id
+		WHERE fc.feed_id IN (SELECT id FROM descendant_feeds)
+	`, feedID, userID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := db.conn.Query(descendantFeedsCTE+`
+		SELECT v.id, v.channel_id, v.title, v.channel_name, v.thumbnail, v.duration, v.is_short, v.published, v.url
+		FROM videos v
+		JOIN channels c ON v.channel_id = c.id
+		JOIN feed_channels fc ON c.id = fc.channel_id
+		WHERE fc.feed_id IN (SELECT id FROM descendant_feeds)
+		ORDER BY v.published DESC
+		LIMIT ? OFFSET ?
+	`, feedID, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
 	}
 	defer rows.Close()
 
@@ -561,6 +1920,35 @@ func (db *DB) GetVideosByFeed(feedID int64, limit, offset int) ([]models.Video,
 	return videos, total, rows.Err()
 }
 
+// GetVideosForPodcastExport returns feedID's (and, if it's a folder, its
+// descendants') most recent videos with episode fields populated, for
+// buildPodcastRSS to render as <item> enclosures.
+func (db *DB) GetVideosForPodcastExport(userID, feedID int64, limit int) ([]models.Video, error) {
+	rows, err := db.conn.Query(descendantFeedsCTE+`
+		SELECT `+episodeVideoColumns+`
+		FROM videos v
+		JOIN channels c ON v.channel_id = c.id
+		JOIN feed_channels fc ON c.id = fc.channel_id
+		WHERE fc.feed_id IN (SELECT id FROM descendant_feeds)
+		ORDER BY v.published DESC
+		LIMIT ?
+	`, feedID, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var videos []models.Video
+	for rows.Next() {
+		v, err := scanEpisodeVideoRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		videos = append(videos, v)
+	}
+	return videos, rows.Err()
+}
+
 func (db *DB) GetVideosByChannel(channelID int64, limit, offset int) ([]models.Video, error) {
 	rows, err := db.conn.Query(`
 		SELECT id, channel_id, title, channel_name, thumbnail, duration, is_short, published, url
@@ -589,6 +1977,37 @@ func (db *DB) GetVideosByChannel(channelID int64, limit, offset int) ([]models.V
 	return videos, rows.Err()
 }
 
+// GetVideosByChannelTab returns a channel's videos from a single tab
+// ("videos", "shorts", "streams", or "playlists"; see
+// migrations/0018_video_tabs.sql), newest first.
+func (db *DB) GetVideosByChannelTab(channelID int64, tab string, limit, offset int) ([]models.Video, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, channel_id, title, channel_name, thumbnail, duration, is_short, published, url, tab
+		FROM videos
+		WHERE channel_id = ? AND tab = ?
+		ORDER BY published DESC
+		LIMIT ? OFFSET ?
+	`, channelID, tab, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var videos []models.Video
+	for rows.Next() {
+		var v models.Video
+		var isShort sql.NullBool
+		if err := rows.Scan(&v.ID, &v.ChannelID, &v.Title, &v.ChannelName, &v.Thumbnail, &v.Duration, &isShort, &v.Published, &v.URL, &v.Tab); err != nil {
+			return nil, err
+		}
+		if isShort.Valid {
+			v.IsShort = &isShort.Bool
+		}
+		videos = append(videos, v)
+	}
+	return videos, rows.Err()
+}
+
 // GetVideoCountByChannel returns the total number of videos for a channel
 func (db *DB) GetVideoCountByChannel(channelID int64) (int, error) {
 	var count int
@@ -628,6 +2047,99 @@ func (db *DB) UpdateVideoDuration(videoID string, duration int) error {
 	return err
 }
 
+// UpdateVideoEpisode records the result of PodcastEpisodeWorker's download
+// attempt for videoID - fileURL/mimeType/bytes are ignored (left as-is)
+// when status is EpisodeStatusError.
+func (db *DB) UpdateVideoEpisode(videoID, fileURL, mimeType string, bytes int64, status string) error {
+	if status == EpisodeStatusError {
+		_, err := db.conn.Exec(`UPDATE videos SET episode_status = ? WHERE id = ?`, status, videoID)
+		return err
+	}
+	_, err := db.conn.Exec(
+		`UPDATE videos SET file_url = ?, mime_type = ?, bytes = ?, episode_status = ? WHERE id = ?`,
+		fileURL, mimeType, bytes, status, videoID,
+	)
+	return err
+}
+
+// episodeVideoColumns extends the plain video column list with the podcast
+// episode columns, for PodcastEpisodeWorker and the podcast RSS export.
+const episodeVideoColumns = "v.id, v.channel_id, v.title, v.channel_name, v.thumbnail, v.duration, v.is_short, v.published, v.url, " +
+	"v.file_url, v.bytes, v.mime_type, v.episode_status"
+
+func scanEpisodeVideoRow(row feedRowScanner) (models.Video, error) {
+	var v models.Video
+	var isShort sql.NullBool
+	err := row.Scan(&v.ID, &v.ChannelID, &v.Title, &v.ChannelName, &v.Thumbnail, &v.Duration, &isShort, &v.Published, &v.URL,
+		&v.FileURL, &v.Bytes, &v.MimeType, &v.EpisodeStatus)
+	if err != nil {
+		return v, err
+	}
+	if isShort.Valid {
+		v.IsShort = &isShort.Bool
+	}
+	return v, nil
+}
+
+// GetVideosNeedingEpisodeDownload returns up to limit videos belonging to a
+// feed with podcast export enabled (non-empty podcast_format) whose episode
+// hasn't been downloaded yet, for PodcastEpisodeWorker to pick up.
+func (db *DB) GetVideosNeedingEpisodeDownload(limit int) ([]models.Video, error) {
+	rows, err := db.conn.Query(`
+		SELECT DISTINCT `+episodeVideoColumns+`
+		FROM videos v
+		JOIN feed_channels fc ON fc.channel_id = v.channel_id
+		JOIN feeds f ON f.id = fc.feed_id
+		WHERE f.podcast_format != '' AND v.episode_status = ?
+		ORDER BY v.published DESC
+		LIMIT ?
+	`, EpisodeStatusNew, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var videos []models.Video
+	for rows.Next() {
+		v, err := scanEpisodeVideoRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		videos = append(videos, v)
+	}
+	return videos, rows.Err()
+}
+
+// GetExpiredEpisodes returns downloaded episodes whose owning feed has a
+// retention policy (retention_days > 0) and whose video was published more
+// than that many days ago, for PodcastEpisodeWorker's cleanup sweep to
+// delete the file and mark EpisodeStatusCleaned.
+func (db *DB) GetExpiredEpisodes(limit int) ([]models.Video, error) {
+	rows, err := db.conn.Query(`
+		SELECT DISTINCT `+episodeVideoColumns+`
+		FROM videos v
+		JOIN feed_channels fc ON fc.channel_id = v.channel_id
+		JOIN feeds f ON f.id = fc.feed_id
+		WHERE v.episode_status = ? AND f.retention_days > 0
+			AND v.published < datetime('now', '-' || f.retention_days || ' days')
+		LIMIT ?
+	`, EpisodeStatusDownloaded, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var videos []models.Video
+	for rows.Next() {
+		v, err := scanEpisodeVideoRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		videos = append(videos, v)
+	}
+	return videos, rows.Err()
+}
+
 func (db *DB) DeleteVideosByFeed(feedID int64) error {
 	_, err := db.conn.Exec(`
 		DELETE FROM videos WHERE channel_id IN (
@@ -731,15 +2243,15 @@ type WatchProgress struct {
 	WatchedAt       time.Time `json:"watched_at"`
 }
 
-func (db *DB) UpdateWatchProgress(videoID string, progressSeconds, durationSeconds int) error {
+func (db *DB) UpdateWatchProgress(userID int64, videoID string, progressSeconds, durationSeconds int) error {
 	// Only update if:
 	// 1. No existing record, OR
 	// 2. New progress is higher than existing, OR
 	// 3. New progress is at least 10 seconds (to allow restarting from beginning intentionally)
 	_, err := db.conn.Exec(`
-		INSERT INTO watch_progress (video_id, progress_seconds, duration_seconds, watched_at)
-		VALUES (?, ?, ?, ?)
-		ON CONFLICT(video_id) DO UPDATE SET
+		INSERT INTO watch_progress (user_id, video_id, progress_seconds, duration_seconds, watched_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(user_id, video_id) DO UPDATE SET
 			progress_seconds = CASE
 				WHEN excluded.progress_seconds > watch_progress.progress_seconds THEN excluded.progress_seconds
 				WHEN excluded.progress_seconds >= 10 THEN excluded.progress_seconds
@@ -750,14 +2262,14 @@ func (db *DB) UpdateWatchProgress(videoID string, progressSeconds, durationSecon
 				ELSE watch_progress.duration_seconds
 			END,
 			watched_at = excluded.watched_at
-	`, videoID, progressSeconds, durationSeconds, time.Now())
+	`, userID, videoID, progressSeconds, durationSeconds, time.Now())
 	return err
 }
 
-func (db *DB) GetWatchProgress(videoID string) (*WatchProgress, error) {
+func (db *DB) GetWatchProgress(userID int64, videoID string) (*WatchProgress, error) {
 	var wp WatchProgress
 	err := db.conn.QueryRow(
-		"SELECT video_id, progress_seconds, duration_seconds, watched_at FROM watch_progress WHERE video_id = ?", videoID,
+		"SELECT video_id, progress_seconds, duration_seconds, watched_at FROM watch_progress WHERE user_id = ? AND video_id = ?", userID, videoID,
 	).Scan(&wp.VideoID, &wp.ProgressSeconds, &wp.DurationSeconds, &wp.WatchedAt)
 	if err != nil {
 		return nil, err
@@ -765,20 +2277,21 @@ func (db *DB) GetWatchProgress(videoID string) (*WatchProgress, error) {
 	return &wp, nil
 }
 
-func (db *DB) GetWatchProgressMap(videoIDs []string) (map[string]*WatchProgress, error) {
+func (db *DB) GetWatchProgressMap(userID int64, videoIDs []string) (map[string]*WatchProgress, error) {
 	if len(videoIDs) == 0 {
 		return make(map[string]*WatchProgress), nil
 	}
 
 	// Build placeholders for IN clause
 	placeholders := make([]string, len(videoIDs))
-	args := make([]any, len(videoIDs))
+	args := make([]any, len(videoIDs)+1)
+	args[0] = userID
 	for i, id := range videoIDs {
 		placeholders[i] = "?"
-		args[i] = id
+		args[i+1] = id
 	}
 
-	query := "SELECT video_id, progress_seconds, duration_seconds, watched_at FROM watch_progress WHERE video_id IN (" + strings.Join(placeholders, ",") + ")"
+	query := "SELECT video_id, progress_seconds, duration_seconds, watched_at FROM watch_progress WHERE user_id = ? AND video_id IN (" + strings.Join(placeholders, ",") + ")"
 	rows, err := db.conn.Query(query, args...)
 	if err != nil {
 		return nil, err
@@ -796,14 +2309,15 @@ func (db *DB) GetWatchProgressMap(videoIDs []string) (map[string]*WatchProgress,
 	return result, rows.Err()
 }
 
-func (db *DB) GetWatchHistory(limit int) ([]models.Video, error) {
+func (db *DB) GetWatchHistory(userID int64, limit int) ([]models.Video, error) {
 	rows, err := db.conn.Query(`
 		SELECT v.id, v.channel_id, v.title, v.channel_name, v.thumbnail, v.duration, v.is_short, v.published, v.url
 		FROM videos v
 		JOIN watch_progress wp ON v.id = wp.video_id
+		WHERE wp.user_id = ?
 		ORDER BY wp.watched_at DESC
 		LIMIT ?
-	`, limit)
+	`, userID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -824,42 +2338,48 @@ func (db *DB) GetWatchHistory(limit int) ([]models.Video, error) {
 	return videos, rows.Err()
 }
 
-func (db *DB) MarkAsWatched(videoID string) error {
+func (db *DB) MarkAsWatched(userID int64, videoID string) error {
 	// Mark as fully watched (100% = progress equals duration)
 	// Use 100/100 as a marker for "manually marked watched"
 	_, err := db.conn.Exec(`
-		INSERT INTO watch_progress (video_id, progress_seconds, duration_seconds, watched_at)
-		VALUES (?, 100, 100, ?)
-		ON CONFLICT(video_id) DO UPDATE SET
+		INSERT INTO watch_progress (user_id, video_id, progress_seconds, duration_seconds, watched_at)
+		VALUES (?, ?, 100, 100, ?)
+		ON CONFLICT(user_id, video_id) DO UPDATE SET
 			progress_seconds = 100,
 			duration_seconds = 100,
 			watched_at = excluded.watched_at
-	`, videoID, time.Now())
+	`, userID, videoID, time.Now())
 	return err
 }
 
-func (db *DB) DeleteWatchProgress(videoID string) error {
-	_, err := db.conn.Exec("DELETE FROM watch_progress WHERE video_id = ?", videoID)
+func (db *DB) DeleteWatchProgress(userID int64, videoID string) error {
+	_, err := db.conn.Exec("DELETE FROM watch_progress WHERE user_id = ? AND video_id = ?", userID, videoID)
 	return err
 }
 
 // SponsorBlock segment operations
 
+// chapterCategory is sponsorblock.CategoryChapter's value, duplicated here
+// (rather than imported) to avoid an import cycle with internal/sponsorblock,
+// which already imports internal/db.
+const chapterCategory = "chapter"
+
 type SponsorBlockSegment struct {
-	VideoID    string    `json:"video_id"`
-	SegmentUUID string   `json:"segment_uuid"`
-	StartTime  float64   `json:"start_time"`
-	EndTime    float64   `json:"end_time"`
-	Category   string    `json:"category"`
-	ActionType string    `json:"action_type"`
-	Votes      int       `json:"votes"`
-	FetchedAt  time.Time `json:"fetched_at"`
+	VideoID     string    `json:"video_id"`
+	SegmentUUID string    `json:"segment_uuid"`
+	StartTime   float64   `json:"start_time"`
+	EndTime     float64   `json:"end_time"`
+	Category    string    `json:"category"`
+	ActionType  string    `json:"action_type"`
+	Votes       int       `json:"votes"`
+	FetchedAt   time.Time `json:"fetched_at"`
+	Description string    `json:"description"`
 }
 
 // GetSponsorBlockSegments returns cached segments for a video
 func (db *DB) GetSponsorBlockSegments(videoID string) ([]SponsorBlockSegment, error) {
 	rows, err := db.conn.Query(`
-		SELECT video_id, segment_uuid, start_time, end_time, category, action_type, votes, fetched_at
+		SELECT video_id, segment_uuid, start_time, end_time, category, action_type, votes, fetched_at, description
 		FROM sponsorblock_segments
 		WHERE video_id = ?
 		ORDER BY start_time
@@ -872,7 +2392,7 @@ func (db *DB) GetSponsorBlockSegments(videoID string) ([]SponsorBlockSegment, er
 	var segments []SponsorBlockSegment
 	for rows.Next() {
 		var s SponsorBlockSegment
-		if err := rows.Scan(&s.VideoID, &s.SegmentUUID, &s.StartTime, &s.EndTime, &s.Category, &s.ActionType, &s.Votes, &s.FetchedAt); err != nil {
+		if err := rows.Scan(&s.VideoID, &s.SegmentUUID, &s.StartTime, &s.EndTime, &s.Category, &s.ActionType, &s.Votes, &s.FetchedAt, &s.Description); err != nil {
 			return nil, err
 		}
 		segments = append(segments, s)
@@ -880,6 +2400,72 @@ func (db *DB) GetSponsorBlockSegments(videoID string) ([]SponsorBlockSegment, er
 	return segments, rows.Err()
 }
 
+// Chapter is a named chapter marker derived from a cached category="chapter"
+// SponsorBlock segment (see migrations/0015_sponsorblock_chapters.sql).
+type Chapter struct {
+	Name      string  `json:"name"`
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"`
+}
+
+// GetChaptersForVideo returns videoID's cached chapter markers, in order,
+// for the player to use as navigation points rather than skip targets.
+func (db *DB) GetChaptersForVideo(videoID string) ([]Chapter, error) {
+	rows, err := db.conn.Query(`
+		SELECT description, start_time, end_time
+		FROM sponsorblock_segments
+		WHERE video_id = ? AND category = ? AND action_type = ?
+		ORDER BY start_time
+	`, videoID, chapterCategory, chapterCategory)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var chapters []Chapter
+	for rows.Next() {
+		var c Chapter
+		if err := rows.Scan(&c.Name, &c.StartTime, &c.EndTime); err != nil {
+			return nil, err
+		}
+		chapters = append(chapters, c)
+	}
+	return chapters, rows.Err()
+}
+
+// GetVideosWithChapter returns feedID's videos that have a cached chapter
+// matching chapterName (case-insensitive), so users can jump to every
+// video in a feed containing, say, a "Q&A" or "Outro" chapter.
+func (db *DB) GetVideosWithChapter(feedID int64, chapterName string) ([]models.Video, error) {
+	rows, err := db.conn.Query(`
+		SELECT DISTINCT v.id, v.channel_id, v.title, v.channel_name, v.thumbnail, v.duration, v.is_short, v.published, v.url
+		FROM videos v
+		JOIN channels c ON v.channel_id = c.id
+		JOIN feed_channels fc ON c.id = fc.channel_id
+		JOIN sponsorblock_segments s ON s.video_id = v.id
+		WHERE fc.feed_id = ? AND s.category = ? AND s.action_type = ? AND s.description = ? COLLATE NOCASE
+		ORDER BY v.published DESC
+	`, feedID, chapterCategory, chapterCategory, chapterName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var videos []models.Video
+	for rows.Next() {
+		var v models.Video
+		var isShort sql.NullBool
+		if err := rows.Scan(&v.ID, &v.ChannelID, &v.Title, &v.ChannelName, &v.Thumbnail, &v.Duration, &isShort, &v.Published, &v.URL); err != nil {
+			return nil, err
+		}
+		if isShort.Valid {
+			v.IsShort = &isShort.Bool
+		}
+		videos = append(videos, v)
+	}
+	return videos, rows.Err()
+}
+
 // HasSponsorBlockSegments checks if we have cached segments for a video (even if empty)
 func (db *DB) HasSponsorBlockSegments(videoID string) (bool, time.Time, error) {
 	var fetchedAt time.Time
@@ -895,7 +2481,10 @@ func (db *DB) HasSponsorBlockSegments(videoID string) (bool, time.Time, error) {
 	return true, fetchedAt, nil
 }
 
-// SaveSponsorBlockSegments saves segments for a video (replaces existing)
+// SaveSponsorBlockSegments upserts segments for a video, keyed on
+// (video_id, segment_uuid), so a re-fetch picks up revised vote counts
+// without a delete pass first. A segment missing from the re-fetch (e.g. a
+// since-rejected one) keeps its cached row.
 func (db *DB) SaveSponsorBlockSegments(videoID string, segments []SponsorBlockSegment) error {
 	tx, err := db.conn.Begin()
 	if err != nil {
@@ -903,15 +2492,17 @@ func (db *DB) SaveSponsorBlockSegments(videoID string, segments []SponsorBlockSe
 	}
 	defer tx.Rollback()
 
-	// Delete existing segments for this video
-	if _, err := tx.Exec("DELETE FROM sponsorblock_segments WHERE video_id = ?", videoID); err != nil {
-		return err
-	}
-
-	// Insert new segments
 	stmt, err := tx.Prepare(`
-		INSERT INTO sponsorblock_segments (video_id, segment_uuid, start_time, end_time, category, action_type, votes, fetched_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO sponsorblock_segments (video_id, segment_uuid, start_time, end_time, category, action_type, votes, fetched_at, description)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(video_id, segment_uuid) DO UPDATE SET
+			start_time = excluded.start_time,
+			end_time = excluded.end_time,
+			category = excluded.category,
+			action_type = excluded.action_type,
+			votes = excluded.votes,
+			fetched_at = excluded.fetched_at,
+			description = excluded.description
 	`)
 	if err != nil {
 		return err
@@ -920,7 +2511,7 @@ func (db *DB) SaveSponsorBlockSegments(videoID string, segments []SponsorBlockSe
 
 	now := time.Now()
 	for _, s := range segments {
-		if _, err := stmt.Exec(videoID, s.SegmentUUID, s.StartTime, s.EndTime, s.Category, s.ActionType, s.Votes, now); err != nil {
+		if _, err := stmt.Exec(videoID, s.SegmentUUID, s.StartTime, s.EndTime, s.Category, s.ActionType, s.Votes, now, s.Description); err != nil {
 			return err
 		}
 	}
@@ -938,36 +2529,397 @@ func (db *DB) MarkSponsorBlockFetched(videoID string) error {
 	return err
 }
 
-// GetShuffledVideosByFeed returns unwatched, non-short videos in random order
-func (db *DB) GetShuffledVideosByFeed(feedID int64, limit, offset int) ([]models.Video, int, error) {
-	// Get total count of unwatched, non-short videos
-	var total int
-	err := db.conn.QueryRow(`
-		SELECT COUNT(*)
-		FROM videos v
-		JOIN channels c ON v.channel_id = c.id
-		JOIN feed_channels fc ON c.id = fc.channel_id
-		WHERE fc.feed_id = ?
-		  AND (v.is_short IS NULL OR v.is_short = 0)
-		  AND v.id NOT IN (SELECT video_id FROM watch_progress)
-	`, feedID).Scan(&total)
-	if err != nil {
-		return nil, 0, err
+// GetSponsorBlockSegmentsForCategories returns cached segments for a video,
+// filtered to the given categories and votes >= minVotes, for callers (like
+// the player applying per-category skip preferences) that only want a
+// subset of what's cached.
+func (db *DB) GetSponsorBlockSegmentsForCategories(videoID string, categories []string, minVotes int) ([]SponsorBlockSegment, error) {
+	if len(categories) == 0 {
+		return nil, nil
+	}
+
+	placeholders := make([]string, len(categories))
+	args := make([]any, 0, len(categories)+2)
+	args = append(args, videoID)
+	for i, c := range categories {
+		placeholders[i] = "?"
+		args = append(args, c)
+	}
+	args = append(args, minVotes)
+
+	query := fmt.Sprintf(`
+		SELECT video_id, segment_uuid, start_time, end_time, category, action_type, votes, fetched_at, description
+		FROM sponsorblock_segments
+		WHERE video_id = ? AND category IN (%s) AND votes >= ?
+		ORDER BY start_time
+	`, strings.Join(placeholders, ", "))
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var segments []SponsorBlockSegment
+	for rows.Next() {
+		var s SponsorBlockSegment
+		if err := rows.Scan(&s.VideoID, &s.SegmentUUID, &s.StartTime, &s.EndTime, &s.Category, &s.ActionType, &s.Votes, &s.FetchedAt, &s.Description); err != nil {
+			return nil, err
+		}
+		segments = append(segments, s)
 	}
+	return segments, rows.Err()
+}
 
+// GetVideosNeedingSponsorBlockFetch returns up to limit video IDs that have
+// never been looked up, or whose cached lookup is older than maxAge, so the
+// background worker can batch through them instead of only fetching on
+// first playback.
+func (db *DB) GetVideosNeedingSponsorBlockFetch(limit int, maxAge time.Duration) ([]string, error) {
 	rows, err := db.conn.Query(`
-		SELECT v.id, v.channel_id, v.title, v.channel_name, v.thumbnail, v.duration, v.is_short, v.published, v.url
+		SELECT v.id
+		FROM videos v
+		LEFT JOIN sponsorblock_segments s ON s.video_id = v.id
+		GROUP BY v.id
+		HAVING MAX(s.fetched_at) IS NULL OR MAX(s.fetched_at) < ?
+		ORDER BY v.published DESC
+		LIMIT ?
+	`, time.Now().Add(-maxAge), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var videoIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		videoIDs = append(videoIDs, id)
+	}
+	return videoIDs, rows.Err()
+}
+
+// SponsorBlockPref is a user's configured action ("skip", "mute",
+// "showonly", or "disabled") for a SponsorBlock category.
+type SponsorBlockPref struct {
+	Category string `json:"category"`
+	Action   string `json:"action"`
+}
+
+// GetSponsorBlockPrefs returns userID's configured action per category.
+// Categories with no row here should fall back to the player's own default.
+func (db *DB) GetSponsorBlockPrefs(userID int64) (map[string]string, error) {
+	rows, err := db.conn.Query(`SELECT category, action FROM sponsorblock_prefs WHERE user_id = ?`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prefs := make(map[string]string)
+	for rows.Next() {
+		var category, action string
+		if err := rows.Scan(&category, &action); err != nil {
+			return nil, err
+		}
+		prefs[category] = action
+	}
+	return prefs, rows.Err()
+}
+
+// SetSponsorBlockPref sets userID's action for category, overwriting any
+// existing preference.
+func (db *DB) SetSponsorBlockPref(userID int64, category, action string) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO sponsorblock_prefs (user_id, category, action)
+		VALUES (?, ?, ?)
+		ON CONFLICT(user_id, category) DO UPDATE SET action = excluded.action
+	`, userID, category, action)
+	return err
+}
+
+// GetOrCreateSponsorBlockUserID returns userID's anonymous SponsorBlock
+// submitter ID, generating and persisting a random one on first use. This ID
+// is never sent to SponsorBlock directly - submissions and votes hash it
+// first (see sponsorblock.HashUserID) - but keeping it stable across requests
+// lets SponsorBlock attribute a user's own submissions and votes to the same
+// anonymous identity rather than a fresh one each time.
+func (db *DB) GetOrCreateSponsorBlockUserID(userID int64) (string, error) {
+	var anonID string
+	err := db.conn.QueryRow(`SELECT anon_id FROM sponsorblock_user_ids WHERE user_id = ?`, userID).Scan(&anonID)
+	if err == nil {
+		return anonID, nil
+	}
+	if err != sql.ErrNoRows {
+		return "", err
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	anonID = hex.EncodeToString(buf)
+
+	if _, err := db.conn.Exec(
+		`INSERT INTO sponsorblock_user_ids (user_id, anon_id) VALUES (?, ?)`,
+		userID, anonID,
+	); err != nil {
+		return "", err
+	}
+	return anonID, nil
+}
+
+// SponsorBlockLocalSegment is a user's private skip range for a video that's
+// never uploaded to SponsorBlock - see AddSponsorBlockLocalSegment.
+type SponsorBlockLocalSegment struct {
+	ID         int64     `json:"id"`
+	VideoID    string    `json:"video_id"`
+	StartTime  float64   `json:"start_time"`
+	EndTime    float64   `json:"end_time"`
+	Category   string    `json:"category"`
+	ActionType string    `json:"action_type"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// AddSponsorBlockLocalSegment records userID's private skip range for
+// videoID and returns its ID.
+func (db *DB) AddSponsorBlockLocalSegment(userID int64, videoID string, startTime, endTime float64, category, actionType string) (int64, error) {
+	result, err := db.conn.Exec(`
+		INSERT INTO sponsorblock_local_segments (user_id, video_id, start_time, end_time, category, action_type, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, userID, videoID, startTime, endTime, category, actionType, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// GetSponsorBlockLocalSegments returns userID's private skip ranges for
+// videoID, for merging into the cached server response - see
+// (*api.Server).handleAPIGetSegments.
+func (db *DB) GetSponsorBlockLocalSegments(userID int64, videoID string) ([]SponsorBlockLocalSegment, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, video_id, start_time, end_time, category, action_type, created_at
+		FROM sponsorblock_local_segments
+		WHERE user_id = ? AND video_id = ?
+		ORDER BY start_time
+	`, userID, videoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var segments []SponsorBlockLocalSegment
+	for rows.Next() {
+		var s SponsorBlockLocalSegment
+		if err := rows.Scan(&s.ID, &s.VideoID, &s.StartTime, &s.EndTime, &s.Category, &s.ActionType, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		segments = append(segments, s)
+	}
+	return segments, rows.Err()
+}
+
+// VideoQueryBuilder composes the videos/channels/feed_channels join shared
+// by GetShuffledVideosByFeed, GetNearbyVideos, and GetVideosWithoutShortStatus,
+// which otherwise hand-roll nearly identical queries with slightly different
+// WHERE clauses. Modeled on miniflux's EntryQueryBuilder: each With* method
+// appends a filter and its argument, and Fetch/Count render the final
+// parameterized query. Callers can combine filters without adding a new DB
+// method for every combination.
+type VideoQueryBuilder struct {
+	db     *DB
+	userID int64
+
+	feedID          *int64
+	excludeVideoID  string
+	unwatched       bool
+	excludeShorts   bool
+	unknownShorts   bool
+	search          string
+	languages       []string
+	publishedBefore *time.Time
+	publishedAfter  *time.Time
+	sortColumn      string
+	sortDir         string
+	limit           *int
+	offset          *int
+}
+
+// NewVideoQueryBuilder starts a video query scoped to userID, which is only
+// consulted by filters - like WithUnwatched - that need to know whose
+// watch_progress to check.
+func (db *DB) NewVideoQueryBuilder(userID int64) *VideoQueryBuilder {
+	return &VideoQueryBuilder{db: db, userID: userID, sortColumn: "v.published", sortDir: "DESC"}
+}
+
+// WithFeedID restricts results to videos in feedID.
+func (q *VideoQueryBuilder) WithFeedID(feedID int64) *VideoQueryBuilder {
+	q.feedID = &feedID
+	return q
+}
+
+// WithExcludeVideoID omits videoID from the results.
+func (q *VideoQueryBuilder) WithExcludeVideoID(videoID string) *VideoQueryBuilder {
+	q.excludeVideoID = videoID
+	return q
+}
+
+// WithUnwatched restricts results to videos the builder's userID has no
+// watch_progress row for.
+func (q *VideoQueryBuilder) WithUnwatched() *VideoQueryBuilder {
+	q.unwatched = true
+	return q
+}
+
+// WithoutShorts excludes videos known to be shorts. Videos whose shorts
+// status hasn't been determined yet (is_short IS NULL) are kept.
+func (q *VideoQueryBuilder) WithoutShorts() *VideoQueryBuilder {
+	q.excludeShorts = true
+	return q
+}
+
+// WithUnknownShortStatus restricts results to videos whose is_short flag
+// hasn't been determined yet, for the worker that classifies them.
+func (q *VideoQueryBuilder) WithUnknownShortStatus() *VideoQueryBuilder {
+	q.unknownShorts = true
+	return q
+}
+
+// WithSearch restricts results to videos whose title or channel name
+// contains query. This is a plain substring filter for combining with other
+// builder filters - SearchVideos' FTS5/BM25 ranking is the dedicated search
+// path when relevance ranking is what's needed.
+func (q *VideoQueryBuilder) WithSearch(query string) *VideoQueryBuilder {
+	q.search = query
+	return q
+}
+
+// WithLanguages restricts results to videos whose detected (or
+// channel-overridden) language is one of codes, e.g. ["en", "es"].
+func (q *VideoQueryBuilder) WithLanguages(codes []string) *VideoQueryBuilder {
+	q.languages = codes
+	return q
+}
+
+// WithPublishedBefore restricts results to videos published at or before t.
+func (q *VideoQueryBuilder) WithPublishedBefore(t time.Time) *VideoQueryBuilder {
+	q.publishedBefore = &t
+	return q
+}
+
+// WithPublishedAfter restricts results to videos published at or after t.
+func (q *VideoQueryBuilder) WithPublishedAfter(t time.Time) *VideoQueryBuilder {
+	q.publishedAfter = &t
+	return q
+}
+
+// WithSorting sets the ORDER BY column and direction. Pass "RANDOM()" as
+// column to shuffle results; direction is ignored in that case.
+func (q *VideoQueryBuilder) WithSorting(column, direction string) *VideoQueryBuilder {
+	q.sortColumn = column
+	q.sortDir = direction
+	return q
+}
+
+// WithLimit caps how many videos Fetch returns.
+func (q *VideoQueryBuilder) WithLimit(limit int) *VideoQueryBuilder {
+	q.limit = &limit
+	return q
+}
+
+// WithOffset skips the first offset matching videos.
+func (q *VideoQueryBuilder) WithOffset(offset int) *VideoQueryBuilder {
+	q.offset = &offset
+	return q
+}
+
+func (q *VideoQueryBuilder) buildQuery() (from, where string, args []any) {
+	from = `
 		FROM videos v
 		JOIN channels c ON v.channel_id = c.id
-		JOIN feed_channels fc ON c.id = fc.channel_id
-		WHERE fc.feed_id = ?
-		  AND (v.is_short IS NULL OR v.is_short = 0)
-		  AND v.id NOT IN (SELECT video_id FROM watch_progress)
-		ORDER BY RANDOM()
-		LIMIT ? OFFSET ?
-	`, feedID, limit, offset)
+		JOIN feed_channels fc ON c.id = fc.channel_id`
+	where = " WHERE 1=1"
+
+	if q.feedID != nil {
+		where += " AND fc.feed_id = ?"
+		args = append(args, *q.feedID)
+	}
+	if q.excludeVideoID != "" {
+		where += " AND v.id != ?"
+		args = append(args, q.excludeVideoID)
+	}
+	if q.excludeShorts {
+		where += " AND (v.is_short IS NULL OR v.is_short = 0)"
+	}
+	if q.unknownShorts {
+		where += " AND v.is_short IS NULL"
+	}
+	if q.publishedBefore != nil {
+		where += " AND v.published <= ?"
+		args = append(args, *q.publishedBefore)
+	}
+	if q.publishedAfter != nil {
+		where += " AND v.published >= ?"
+		args = append(args, *q.publishedAfter)
+	}
+	if q.search != "" {
+		where += " AND (v.title LIKE ? OR v.channel_name LIKE ?)"
+		like := "%" + q.search + "%"
+		args = append(args, like, like)
+	}
+	if len(q.languages) > 0 {
+		placeholders := strings.Repeat("?,", len(q.languages))
+		placeholders = placeholders[:len(placeholders)-1]
+		where += " AND v.language IN (" + placeholders + ")"
+		for _, lang := range q.languages {
+			args = append(args, lang)
+		}
+	}
+	if q.unwatched {
+		from += `
+		LEFT JOIN watch_progress wp ON wp.video_id = v.id AND wp.user_id = ?`
+		args = append(args, q.userID)
+		where += " AND wp.video_id IS NULL"
+	}
+	return from, where, args
+}
+
+// Count returns how many videos match the builder's filters.
+func (q *VideoQueryBuilder) Count() (int, error) {
+	from, where, args := q.buildQuery()
+	var total int
+	err := q.db.conn.QueryRow("SELECT COUNT(*)"+from+where, args...).Scan(&total)
+	return total, err
+}
+
+// Fetch runs the built query and returns the matching videos.
+func (q *VideoQueryBuilder) Fetch() ([]models.Video, error) {
+	from, where, args := q.buildQuery()
+
+	order := q.sortColumn
+	if order != "RANDOM()" {
+		order += " " + q.sortDir
+	}
+
+	query := `
+		SELECT v.id, v.channel_id, v.title, v.channel_name, v.thumbnail, v.duration, v.is_short, v.published, v.url, v.language
+		` + from + where + `
+		ORDER BY ` + order
+
+	if q.limit != nil {
+		query += " LIMIT ?"
+		args = append(args, *q.limit)
+	}
+	if q.offset != nil {
+		query += " OFFSET ?"
+		args = append(args, *q.offset)
+	}
+
+	rows, err := q.db.conn.Query(query, args...)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 	defer rows.Close()
 
@@ -975,15 +2927,31 @@ func (db *DB) GetShuffledVideosByFeed(feedID int64, limit, offset int) ([]models
 	for rows.Next() {
 		var v models.Video
 		var isShort sql.NullBool
-		if err := rows.Scan(&v.ID, &v.ChannelID, &v.Title, &v.ChannelName, &v.Thumbnail, &v.Duration, &isShort, &v.Published, &v.URL); err != nil {
-			return nil, 0, err
+		if err := rows.Scan(&v.ID, &v.ChannelID, &v.Title, &v.ChannelName, &v.Thumbnail, &v.Duration, &isShort, &v.Published, &v.URL, &v.Language); err != nil {
+			return nil, err
 		}
 		if isShort.Valid {
 			v.IsShort = &isShort.Bool
 		}
 		videos = append(videos, v)
 	}
-	return videos, total, rows.Err()
+	return videos, rows.Err()
+}
+
+// GetShuffledVideosByFeed returns unwatched, non-short videos in random order
+func (db *DB) GetShuffledVideosByFeed(userID, feedID int64, limit, offset int) ([]models.Video, int, error) {
+	q := db.NewVideoQueryBuilder(userID).WithFeedID(feedID).WithoutShorts().WithUnwatched()
+
+	total, err := q.Count()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	videos, err := q.WithSorting("RANDOM()", "").WithLimit(limit).WithOffset(offset).Fetch()
+	if err != nil {
+		return nil, 0, err
+	}
+	return videos, total, nil
 }
 
 // GetNearbyVideos returns videos from the same feed as the given video,
@@ -1008,35 +2976,19 @@ func (db *DB) GetNearbyVideos(videoID string, limit int, offset int) ([]models.V
 		return nil, 0, err
 	}
 
-	// Get videos from the same feed that are older than (or same as) the current video
-	// excluding the current video itself and shorts, ordered by newest first
-	rows, err := db.conn.Query(`
-		SELECT v.id, v.channel_id, v.title, v.channel_name, v.thumbnail, v.duration, v.is_short, v.published, v.url
-		FROM videos v
-		JOIN channels c ON v.channel_id = c.id
-		JOIN feed_channels fc ON c.id = fc.channel_id
-		WHERE fc.feed_id = ? AND v.published <= ? AND v.id != ? AND (v.is_short IS NULL OR v.is_short = 0)
-		ORDER BY v.published DESC
-		LIMIT ? OFFSET ?
-	`, feedID, published, videoID, limit, offset)
+	videos, err := db.NewVideoQueryBuilder(0).
+		WithFeedID(feedID).
+		WithPublishedBefore(published).
+		WithExcludeVideoID(videoID).
+		WithoutShorts().
+		WithSorting("v.published", "DESC").
+		WithLimit(limit).
+		WithOffset(offset).
+		Fetch()
 	if err != nil {
 		return nil, 0, err
 	}
-	defer rows.Close()
-
-	var videos []models.Video
-	for rows.Next() {
-		var v models.Video
-		var isShort sql.NullBool
-		if err := rows.Scan(&v.ID, &v.ChannelID, &v.Title, &v.ChannelName, &v.Thumbnail, &v.Duration, &isShort, &v.Published, &v.URL); err != nil {
-			return nil, 0, err
-		}
-		if isShort.Valid {
-			v.IsShort = &isShort.Bool
-		}
-		videos = append(videos, v)
-	}
-	return videos, feedID, rows.Err()
+	return videos, feedID, nil
 }
 
 // UpdateVideoIsShort updates the is_short flag for a video
@@ -1051,28 +3003,21 @@ func (db *DB) UpdateVideoIsShort(videoID string, isShort bool) error {
 
 // GetVideosWithoutShortStatus returns video IDs that have is_short = NULL
 func (db *DB) GetVideosWithoutShortStatus(feedID int64, limit int) ([]string, error) {
-	rows, err := db.conn.Query(`
-		SELECT v.id FROM videos v
-		JOIN channels c ON v.channel_id = c.id
-		JOIN feed_channels fc ON c.id = fc.channel_id
-		WHERE fc.feed_id = ? AND v.is_short IS NULL
-		ORDER BY v.published DESC
-		LIMIT ?
-	`, feedID, limit)
+	videos, err := db.NewVideoQueryBuilder(0).
+		WithFeedID(feedID).
+		WithUnknownShortStatus().
+		WithSorting("v.published", "DESC").
+		WithLimit(limit).
+		Fetch()
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
 	var ids []string
-	for rows.Next() {
-		var id string
-		if err := rows.Scan(&id); err != nil {
-			return nil, err
-		}
-		ids = append(ids, id)
+	for _, v := range videos {
+		ids = append(ids, v.ID)
 	}
-	return ids, rows.Err()
+	return ids, nil
 }
 
 // GetVideoShortsStatus returns existing shorts status for given video IDs.
@@ -1112,3 +3057,791 @@ func (db *DB) GetVideoShortsStatus(videoIDs []string) (map[string]bool, error) {
 	}
 	return result, rows.Err()
 }
+
+// Download state operations
+
+// DownloadState is the persisted record of an in-flight or terminal
+// download, used to recover in-flight jobs after a crash/restart.
+type DownloadState struct {
+	VideoID         string    `json:"video_id"`
+	Quality         string    `json:"quality"`
+	Status          string    `json:"status"`
+	BytesDownloaded int64     `json:"bytes_downloaded"`
+	TotalBytes      int64     `json:"total_bytes"`
+	Error           string    `json:"error,omitempty"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// SaveDownloadState upserts the current status of a download.
+func (db *DB) SaveDownloadState(state *DownloadState) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO downloads (video_id, quality, status, bytes_downloaded, total_bytes, error, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(video_id, quality) DO UPDATE SET
+			status = excluded.status,
+			bytes_downloaded = excluded.bytes_downloaded,
+			total_bytes = excluded.total_bytes,
+			error = excluded.error,
+			updated_at = excluded.updated_at
+	`, state.VideoID, state.Quality, state.Status, state.BytesDownloaded, state.TotalBytes, state.Error, time.Now())
+	return err
+}
+
+// GetInFlightDownloads returns downloads left in a non-terminal status,
+// e.g. from a server crash mid-download.
+func (db *DB) GetInFlightDownloads() ([]DownloadState, error) {
+	rows, err := db.conn.Query(`
+		SELECT video_id, quality, status, bytes_downloaded, total_bytes, error, updated_at
+		FROM downloads
+		WHERE status NOT IN ('complete', 'error')
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var states []DownloadState
+	for rows.Next() {
+		var s DownloadState
+		if err := rows.Scan(&s.VideoID, &s.Quality, &s.Status, &s.BytesDownloaded, &s.TotalBytes, &s.Error, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		states = append(states, s)
+	}
+	return states, rows.Err()
+}
+
+// DeleteDownloadState removes a download's persisted state once it's no
+// longer useful for crash recovery (e.g. after the cached file is confirmed).
+func (db *DB) DeleteDownloadState(videoID, quality string) error {
+	_, err := db.conn.Exec("DELETE FROM downloads WHERE video_id = ? AND quality = ?", videoID, quality)
+	return err
+}
+
+// Download job queue (internal/downloader)
+//
+// download_jobs is distinct from the "downloads" table above: downloads
+// tracks the older single-shot segmented-download-and-mux flow's in-flight
+// state, while download_jobs backs internal/downloader's persistent,
+// worker-pool-driven queue of plain yt-dlp downloads.
+
+// DownloadJobStatus values for download_jobs.status (see
+// migrations/0017_download_jobs.sql).
+const (
+	DownloadJobQueued    = "queued"
+	DownloadJobRunning   = "running"
+	DownloadJobDone      = "done"
+	DownloadJobFailed    = "failed"
+	DownloadJobCancelled = "cancelled"
+)
+
+// DownloadJob is a persisted unit of work for internal/downloader's queue.
+type DownloadJob struct {
+	ID              int64     `json:"id"`
+	VideoID         string    `json:"video_id"`
+	Quality         string    `json:"quality"`
+	OutputPath      string    `json:"output_path"`
+	Status          string    `json:"status"`
+	RetryCount      int       `json:"retry_count"`
+	BytesDownloaded int64     `json:"bytes_downloaded"`
+	TotalBytes      int64     `json:"total_bytes"`
+	Error           string    `json:"error,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// EnqueueDownloadJob inserts a new queued job and returns it with its ID.
+func (db *DB) EnqueueDownloadJob(videoID, quality, outputPath string) (*DownloadJob, error) {
+	now := time.Now()
+	result, err := db.conn.Exec(`
+		INSERT INTO download_jobs (video_id, quality, output_path, status, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, videoID, quality, outputPath, DownloadJobQueued, now, now)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return &DownloadJob{
+		ID: id, VideoID: videoID, Quality: quality, OutputPath: outputPath,
+		Status: DownloadJobQueued, CreatedAt: now, UpdatedAt: now,
+	}, nil
+}
+
+// GetQueuedDownloadJobs returns jobs waiting for a worker, oldest first.
+func (db *DB) GetQueuedDownloadJobs(limit int) ([]DownloadJob, error) {
+	return db.queryDownloadJobs("SELECT "+downloadJobColumns+" FROM download_jobs WHERE status = ? ORDER BY created_at LIMIT ?",
+		DownloadJobQueued, limit)
+}
+
+// GetRunningDownloadJobs returns jobs a crashed process left in "running",
+// for NewQueue to requeue on startup.
+func (db *DB) GetRunningDownloadJobs() ([]DownloadJob, error) {
+	return db.queryDownloadJobs("SELECT " + downloadJobColumns + " FROM download_jobs WHERE status = '" + DownloadJobRunning + "'")
+}
+
+const downloadJobColumns = "id, video_id, quality, output_path, status, retry_count, bytes_downloaded, total_bytes, error, created_at, updated_at"
+
+func (db *DB) queryDownloadJobs(query string, args ...any) ([]DownloadJob, error) {
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []DownloadJob
+	for rows.Next() {
+		var j DownloadJob
+		if err := rows.Scan(&j.ID, &j.VideoID, &j.Quality, &j.OutputPath, &j.Status, &j.RetryCount,
+			&j.BytesDownloaded, &j.TotalBytes, &j.Error, &j.CreatedAt, &j.UpdatedAt); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+// SetDownloadJobStatus transitions a job to status, resetting its error on
+// success paths and requeuing (status = queued) bumps retry_count.
+func (db *DB) SetDownloadJobStatus(id int64, status string, errMsg string) error {
+	if status == DownloadJobQueued {
+		_, err := db.conn.Exec(`
+			UPDATE download_jobs SET status = ?, retry_count = retry_count + 1, error = ?, updated_at = ? WHERE id = ?
+		`, status, errMsg, time.Now(), id)
+		return err
+	}
+	_, err := db.conn.Exec(`
+		UPDATE download_jobs SET status = ?, error = ?, updated_at = ? WHERE id = ?
+	`, status, errMsg, time.Now(), id)
+	return err
+}
+
+// UpdateDownloadJobProgress records the job's per-phase byte counts without
+// touching its status.
+func (db *DB) UpdateDownloadJobProgress(id int64, downloaded, total int64) error {
+	_, err := db.conn.Exec(`
+		UPDATE download_jobs SET bytes_downloaded = ?, total_bytes = ?, updated_at = ? WHERE id = ?
+	`, downloaded, total, time.Now(), id)
+	return err
+}
+
+// GetDownloadJob returns a single job by ID.
+func (db *DB) GetDownloadJob(id int64) (*DownloadJob, error) {
+	jobs, err := db.queryDownloadJobs("SELECT "+downloadJobColumns+" FROM download_jobs WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &jobs[0], nil
+}
+
+// GetAllDownloadJobs returns every job in the queue, most recently created
+// first, for the GET /api/downloads listing.
+func (db *DB) GetAllDownloadJobs() ([]DownloadJob, error) {
+	return db.queryDownloadJobs("SELECT " + downloadJobColumns + " FROM download_jobs ORDER BY created_at DESC")
+}
+
+// DeleteDownloadJob removes a job's row once it's been cancelled and its
+// output cleaned up - see (*downloader.Queue).Cancel.
+func (db *DB) DeleteDownloadJob(id int64) error {
+	_, err := db.conn.Exec("DELETE FROM download_jobs WHERE id = ?", id)
+	return err
+}
+
+// DownloadedJobWatchInfo is one row of ListDownloadJobsForGC's report: a
+// finished download job plus whether any user has fully watched its video.
+type DownloadedJobWatchInfo struct {
+	DownloadJob
+	FullyWatched bool
+}
+
+// ListDownloadJobsForGC returns every "done" download job ordered for a
+// disk-quota GC pass to walk through: fully-watched videos (by any user)
+// oldest-first, then not-yet-watched videos oldest-first, so the GC frees
+// space from things people are done with before touching anything else.
+func (db *DB) ListDownloadJobsForGC() ([]DownloadedJobWatchInfo, error) {
+	rows, err := db.conn.Query(`
+		SELECT ` + downloadJobColumnsPrefixed("j") + `,
+		       EXISTS (
+		           SELECT 1 FROM watch_progress wp
+		           WHERE wp.video_id = j.video_id AND wp.progress_seconds >= wp.duration_seconds AND wp.duration_seconds > 0
+		       ) AS fully_watched
+		FROM download_jobs j
+		WHERE j.status = '` + DownloadJobDone + `'
+		ORDER BY fully_watched DESC, j.updated_at ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []DownloadedJobWatchInfo
+	for rows.Next() {
+		var j DownloadedJobWatchInfo
+		if err := rows.Scan(&j.ID, &j.VideoID, &j.Quality, &j.OutputPath, &j.Status, &j.RetryCount,
+			&j.BytesDownloaded, &j.TotalBytes, &j.Error, &j.CreatedAt, &j.UpdatedAt, &j.FullyWatched); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+// downloadJobColumnsPrefixed is downloadJobColumns with each column
+// qualified by alias, for queries that join download_jobs against another
+// table.
+func downloadJobColumnsPrefixed(alias string) string {
+	cols := strings.Split(downloadJobColumns, ", ")
+	for i, c := range cols {
+		cols[i] = alias + "." + c
+	}
+	return strings.Join(cols, ", ")
+}
+
+// Backfill job operations
+
+// BackfillJob tracks a resumable, unbounded sync of a channel's entire
+// upload history, so progress survives a server crash/restart.
+type BackfillJob struct {
+	ID            int64     `json:"id"`
+	ChannelID     int64     `json:"channel_id"`
+	Status        string    `json:"status"` // "queued", "running", "paused", "done", "failed"
+	LastPosition  int       `json:"last_position"`
+	TotalExpected int       `json:"total_expected"`
+	VideosSaved   int       `json:"videos_saved"`
+	LastError     string    `json:"last_error,omitempty"`
+	StartedAt     time.Time `json:"started_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// CreateBackfillJob queues a new backfill job for a channel.
+func (db *DB) CreateBackfillJob(channelID int64) (*BackfillJob, error) {
+	now := time.Now()
+	res, err := db.conn.Exec(`
+		INSERT INTO backfill_jobs (channel_id, status, last_position, total_expected, videos_saved, last_error, started_at, updated_at)
+		VALUES (?, 'queued', 0, 0, 0, '', ?, ?)
+	`, channelID, now, now)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return db.GetBackfillJob(id)
+}
+
+// GetBackfillJob fetches a single backfill job by ID.
+func (db *DB) GetBackfillJob(id int64) (*BackfillJob, error) {
+	var j BackfillJob
+	err := db.conn.QueryRow(`
+		SELECT id, channel_id, status, last_position, total_expected, videos_saved, last_error, started_at, updated_at
+		FROM backfill_jobs WHERE id = ?
+	`, id).Scan(&j.ID, &j.ChannelID, &j.Status, &j.LastPosition, &j.TotalExpected, &j.VideosSaved, &j.LastError, &j.StartedAt, &j.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// ListBackfillJobs returns all backfill jobs, most recently updated first.
+func (db *DB) ListBackfillJobs() ([]BackfillJob, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, channel_id, status, last_position, total_expected, videos_saved, last_error, started_at, updated_at
+		FROM backfill_jobs ORDER BY updated_at DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []BackfillJob
+	for rows.Next() {
+		var j BackfillJob
+		if err := rows.Scan(&j.ID, &j.ChannelID, &j.Status, &j.LastPosition, &j.TotalExpected, &j.VideosSaved, &j.LastError, &j.StartedAt, &j.UpdatedAt); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+// GetQueuedBackfillJobs returns jobs waiting to be picked up by a worker.
+func (db *DB) GetQueuedBackfillJobs() ([]BackfillJob, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, channel_id, status, last_position, total_expected, videos_saved, last_error, started_at, updated_at
+		FROM backfill_jobs WHERE status = 'queued' ORDER BY started_at ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []BackfillJob
+	for rows.Next() {
+		var j BackfillJob
+		if err := rows.Scan(&j.ID, &j.ChannelID, &j.Status, &j.LastPosition, &j.TotalExpected, &j.VideosSaved, &j.LastError, &j.StartedAt, &j.UpdatedAt); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+// UpdateBackfillJobProgress persists last_position/videos_saved after a
+// batch, so a crash/restart resumes exactly where the job left off.
+func (db *DB) UpdateBackfillJobProgress(id int64, lastPosition, videosSaved, totalExpected int) error {
+	_, err := db.conn.Exec(`
+		UPDATE backfill_jobs SET last_position = ?, videos_saved = ?, total_expected = ?, updated_at = ?
+		WHERE id = ?
+	`, lastPosition, videosSaved, totalExpected, time.Now(), id)
+	return err
+}
+
+// SetBackfillJobStatus transitions a job's status, optionally recording an error.
+func (db *DB) SetBackfillJobStatus(id int64, status, lastError string) error {
+	_, err := db.conn.Exec(`
+		UPDATE backfill_jobs SET status = ?, last_error = ?, updated_at = ? WHERE id = ?
+	`, status, lastError, time.Now(), id)
+	return err
+}
+
+// Generic job queue (internal/jobs)
+//
+// jobs is distinct from backfill_jobs and download_jobs above: those each
+// back one specific subsystem's own worker loop, while jobs is a single
+// polymorphic queue internal/jobs.Queue dispatches through by job.Kind, for
+// work (refresh_feed, backfill_channel, fetch_durations, check_shorts,
+// download_video) that used to run as an ad-hoc goroutine that died with
+// the process and dropped silently on error.
+
+// Job status values for jobs.status (see migrations/0020_jobs_queue.sql).
+const (
+	JobStatusPending = "pending"
+	JobStatusRunning = "running"
+	JobStatusDone    = "done"
+	JobStatusFailed  = "failed"
+)
+
+// Job is one unit of work on the generic queue.
+type Job struct {
+	ID          int64     `json:"id"`
+	Kind        string    `json:"kind"`
+	PayloadJSON string    `json:"payload_json"`
+	Status      string    `json:"status"`
+	Attempts    int       `json:"attempts"`
+	NextRunAt   time.Time `json:"next_run_at"`
+	LastError   string    `json:"last_error,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+const jobColumns = "id, kind, payload_json, status, attempts, next_run_at, last_error, created_at, updated_at"
+
+// jobRowScanner is satisfied by both *sql.Row and *sql.Rows.
+type jobRowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanJobRow(row jobRowScanner) (*Job, error) {
+	var j Job
+	if err := row.Scan(&j.ID, &j.Kind, &j.PayloadJSON, &j.Status, &j.Attempts, &j.NextRunAt, &j.LastError, &j.CreatedAt, &j.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// EnqueueJob inserts a new pending job, runnable immediately, and returns it.
+func (db *DB) EnqueueJob(kind string, payloadJSON []byte) (*Job, error) {
+	now := time.Now()
+	res, err := db.conn.Exec(
+		"INSERT INTO jobs (kind, payload_json, status, attempts, next_run_at, last_error, created_at, updated_at) VALUES (?, ?, ?, 0, ?, '', ?, ?)",
+		kind, string(payloadJSON), JobStatusPending, now, now, now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return db.GetJob(id)
+}
+
+// GetJob fetches a single job by ID.
+func (db *DB) GetJob(id int64) (*Job, error) {
+	row := db.conn.QueryRow("SELECT "+jobColumns+" FROM jobs WHERE id = ?", id)
+	return scanJobRow(row)
+}
+
+// ListJobs returns the most recently updated jobs, for the GET /api/jobs
+// activity view.
+func (db *DB) ListJobs(limit int) ([]Job, error) {
+	rows, err := db.conn.Query("SELECT "+jobColumns+" FROM jobs ORDER BY updated_at DESC LIMIT ?", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []Job
+	for rows.Next() {
+		j, err := scanJobRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *j)
+	}
+	return jobs, rows.Err()
+}
+
+// GetDueJobs returns pending jobs whose next_run_at has passed, oldest
+// first, for a worker to claim.
+func (db *DB) GetDueJobs(limit int) ([]Job, error) {
+	rows, err := db.conn.Query(
+		"SELECT "+jobColumns+" FROM jobs WHERE status = ? AND next_run_at <= ? ORDER BY next_run_at ASC LIMIT ?",
+		JobStatusPending, time.Now(), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []Job
+	for rows.Next() {
+		j, err := scanJobRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *j)
+	}
+	return jobs, rows.Err()
+}
+
+// SetJobRunning marks a job as claimed by a worker.
+func (db *DB) SetJobRunning(id int64) error {
+	_, err := db.conn.Exec("UPDATE jobs SET status = ?, updated_at = ? WHERE id = ?", JobStatusRunning, time.Now(), id)
+	return err
+}
+
+// CompleteJob marks a job done.
+func (db *DB) CompleteJob(id int64) error {
+	_, err := db.conn.Exec("UPDATE jobs SET status = ?, last_error = '', updated_at = ? WHERE id = ?", JobStatusDone, time.Now(), id)
+	return err
+}
+
+// RetryOrFailJob records a failed attempt: if attempts is still under
+// maxAttempts the job goes back to pending with next_run_at pushed out by
+// backoff (the caller's exponential-backoff duration), otherwise it's left
+// failed for good.
+func (db *DB) RetryOrFailJob(id int64, attempts, maxAttempts int, backoff time.Duration, lastError string) error {
+	now := time.Now()
+	status := JobStatusPending
+	nextRunAt := now.Add(backoff)
+	if attempts >= maxAttempts {
+		status = JobStatusFailed
+		nextRunAt = now
+	}
+	_, err := db.conn.Exec(
+		"UPDATE jobs SET status = ?, attempts = ?, next_run_at = ?, last_error = ?, updated_at = ? WHERE id = ?",
+		status, attempts, nextRunAt, lastError, now, id,
+	)
+	return err
+}
+
+// Full-text search (videos_fts/channels_fts, see migrations/0006_fts_search.sql)
+
+// SearchFacet is a count of matching rows grouped by feed or channel, so
+// the search UI can render filter chips alongside results.
+type SearchFacet struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// VideoSearchResult is a video search hit with its BM25-ranked snippet.
+type VideoSearchResult struct {
+	models.Video
+	Snippet string `json:"snippet"`
+}
+
+// ChannelSearchResult is a channel search hit with its BM25-ranked snippet.
+type ChannelSearchResult struct {
+	models.Channel
+	Snippet string `json:"snippet"`
+}
+
+// sanitizeFTSQuery rewrites a raw user search string into an FTS5 MATCH
+// expression: quoted phrases are preserved (with embedded quotes escaped by
+// doubling, per FTS5 string-literal rules), bare tokens are individually
+// quoted so stray FTS5 syntax characters in user input (colons, parens,
+// carets) are treated as literal text rather than query operators, and a
+// leading "-" or trailing "*" on a token is preserved outside the quotes so
+// exclusion and prefix-match keep working.
+func sanitizeFTSQuery(query string) string {
+	runes := []rune(strings.TrimSpace(query))
+	n := len(runes)
+
+	var b strings.Builder
+	first := true
+	for i := 0; i < n; {
+		for i < n && runes[i] == ' ' {
+			i++
+		}
+		if i >= n {
+			break
+		}
+		if !first {
+			b.WriteString(" ")
+		}
+		first = false
+
+		if runes[i] == '-' {
+			b.WriteString("-")
+			i++
+		}
+
+		if i < n && runes[i] == '"' {
+			i++
+			start := i
+			for i < n && runes[i] != '"' {
+				i++
+			}
+			phrase := string(runes[start:i])
+			if i < n {
+				i++ // skip closing quote
+			}
+			b.WriteString("\"")
+			b.WriteString(strings.ReplaceAll(phrase, "\"", "\"\""))
+			b.WriteString("\"")
+			continue
+		}
+
+		start := i
+		for i < n && runes[i] != ' ' {
+			i++
+		}
+		token := string(runes[start:i])
+		prefix := strings.HasSuffix(token, "*")
+		token = strings.TrimSuffix(token, "*")
+
+		b.WriteString("\"")
+		b.WriteString(strings.ReplaceAll(token, "\"", "\"\""))
+		b.WriteString("\"")
+		if prefix {
+			b.WriteString("*")
+		}
+	}
+
+	if b.Len() == 0 {
+		return "\"\""
+	}
+	return b.String()
+}
+
+// SearchVideos runs a sanitized FTS5 query (phrase and "-" exclusion syntax
+// are supported) against video titles, channel names, and each video's
+// channel's cached video_titles blob (see migrations/0010_search_titles.sql),
+// returning BM25-ranked hits with a highlighted title snippet. feedID, if
+// non-nil, scopes results to videos whose channel is in that feed.
+// searchRankExpr orders hits by BM25 with a small recency-decay term added
+// on top, so that among comparably relevant matches a fresh video outranks
+// an old one instead of ties breaking arbitrarily (bm25() returns more
+// negative values for better matches, so age is added as a positive penalty
+// that grows with staleness).
+const searchRankExpr = "bm25(videos_fts) + (strftime('%s', 'now') - strftime('%s', v.published)) * 0.0000001"
+
+// SearchVideos runs a sanitized FTS5 query over indexed video titles,
+// channel names and each channel's cached video_titles blob (video
+// descriptions are not indexed), ranked by searchRankExpr. feedID scopes
+// results to one feed; excludeShorts and unwatchedOnly (resolved against
+// userID's own watch_progress) are optional filters for the search UI's
+// filter chips. The returned count is the total match count for pagination,
+// not just len(results).
+func (db *DB) SearchVideos(userID int64, query string, feedID *int64, excludeShorts, unwatchedOnly bool, limit, offset int) ([]VideoSearchResult, int, error) {
+	ftsQuery := sanitizeFTSQuery(query)
+
+	from := `
+		FROM videos_fts
+		JOIN videos v ON v.rowid = videos_fts.rowid`
+	where := " WHERE videos_fts MATCH ?"
+	args := []any{ftsQuery}
+	if feedID != nil {
+		from += `
+		JOIN feed_channels fc ON fc.channel_id = v.channel_id`
+		where += " AND fc.feed_id = ?"
+		args = append(args, *feedID)
+	}
+	if excludeShorts {
+		where += " AND (v.is_short IS NULL OR v.is_short = 0)"
+	}
+	if unwatchedOnly {
+		from += `
+		LEFT JOIN watch_progress wp ON wp.video_id = v.id AND wp.user_id = ?`
+		args = append(args, userID)
+		where += " AND wp.video_id IS NULL"
+	}
+
+	var total int
+	if err := db.conn.QueryRow("SELECT COUNT(*)"+from+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT v.id, v.channel_id, v.title, v.channel_name, v.thumbnail, v.duration, v.is_short, v.published, v.url,
+		       snippet(videos_fts, 0, '<mark>', '</mark>', '...', 12)
+		`+from+where+`
+		ORDER BY `+searchRankExpr+`
+		LIMIT ? OFFSET ?
+	`, append(append([]any{}, args...), limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var results []VideoSearchResult
+	for rows.Next() {
+		var r VideoSearchResult
+		var isShort sql.NullBool
+		if err := rows.Scan(&r.ID, &r.ChannelID, &r.Title, &r.ChannelName, &r.Thumbnail, &r.Duration, &isShort, &r.Published, &r.URL, &r.Snippet); err != nil {
+			return nil, 0, err
+		}
+		if isShort.Valid {
+			r.IsShort = &isShort.Bool
+		}
+		results = append(results, r)
+	}
+	return results, total, rows.Err()
+}
+
+// searchChannelsLimit caps how many channel hits SearchChannels returns;
+// the channel list is for a filter/typeahead UI, not a paginated result
+// set, so unlike SearchVideos it doesn't take a caller-supplied limit.
+const searchChannelsLimit = 20
+
+// SearchChannels runs a sanitized FTS5 query against channel names,
+// returning BM25-ranked hits with a highlighted snippet of the matching
+// name.
+func (db *DB) SearchChannels(query string) ([]ChannelSearchResult, error) {
+	rows, err := db.conn.Query(`
+		SELECT c.id, c.url, c.name, c.source,
+		       snippet(channels_fts, 0, '<mark>', '</mark>', '...', 12)
+		FROM channels_fts
+		JOIN channels c ON c.rowid = channels_fts.rowid
+		WHERE channels_fts MATCH ?
+		ORDER BY bm25(channels_fts)
+		LIMIT ?
+	`, sanitizeFTSQuery(query), searchChannelsLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []ChannelSearchResult
+	for rows.Next() {
+		var r ChannelSearchResult
+		if err := rows.Scan(&r.ID, &r.URL, &r.Name, &r.Source, &r.Snippet); err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+// RebuildSearchIndex fully repopulates videos_fts and channels_fts from
+// their source tables. Triggers on videos, channels and channel_metadata
+// (see migrations/0006_fts_search.sql and migrations/0010_search_titles.sql)
+// already keep both indexes in sync row by row, including a channel's
+// cached video_titles blob; this rebuilds them wholesale.
+func (db *DB) RebuildSearchIndex() error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM videos_fts"); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO videos_fts(rowid, title, channel_name, titles)
+		SELECT v.rowid, v.title, v.channel_name, COALESCE(cm.video_titles, '')
+		FROM videos v
+		LEFT JOIN channels c ON c.id = v.channel_id
+		LEFT JOIN channel_metadata cm ON cm.url = c.url
+	`); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM channels_fts"); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO channels_fts(rowid, name)
+		SELECT rowid, name FROM channels
+	`); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SearchFeedFacets counts matching videos grouped by feed, for filter
+// chips in the search UI.
+func (db *DB) SearchFeedFacets(query string) ([]SearchFacet, error) {
+	rows, err := db.conn.Query(`
+		SELECT f.id, f.name, COUNT(*) AS cnt
+		FROM videos_fts
+		JOIN videos v ON v.rowid = videos_fts.rowid
+		JOIN feed_channels fc ON fc.channel_id = v.channel_id
+		JOIN feeds f ON f.id = fc.feed_id
+		WHERE videos_fts MATCH ?
+		GROUP BY f.id
+		ORDER BY cnt DESC
+	`, sanitizeFTSQuery(query))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var facets []SearchFacet
+	for rows.Next() {
+		var f SearchFacet
+		if err := rows.Scan(&f.ID, &f.Name, &f.Count); err != nil {
+			return nil, err
+		}
+		facets = append(facets, f)
+	}
+	return facets, rows.Err()
+}
+
+// SearchChannelFacets counts matching videos grouped by channel, for
+// filter chips in the search UI.
+func (db *DB) SearchChannelFacets(query string) ([]SearchFacet, error) {
+	rows, err := db.conn.Query(`
+		SELECT c.id, c.name, COUNT(*) AS cnt
+		FROM videos_fts
+		JOIN videos v ON v.rowid = videos_fts.rowid
+		JOIN channels c ON c.id = v.channel_id
+		WHERE videos_fts MATCH ?
+		GROUP BY c.id
+		ORDER BY cnt DESC
+	`, sanitizeFTSQuery(query))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var facets []SearchFacet
+	for rows.Next() {
+		var f SearchFacet
+		if err := rows.Scan(&f.ID, &f.Name, &f.Count); err != nil {
+			return nil, err
+		}
+		facets = append(facets, f)
+	}
+	return facets, rows.Err()
+}
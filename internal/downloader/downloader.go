@@ -0,0 +1,321 @@
+// Package downloader provides a persistent, crash-resumable queue for
+// plain yt-dlp downloads. It is distinct from internal/api's DownloadManager,
+// which drives direct segmented HTTP fetches of adaptive streams followed by
+// an ffmpeg mux; Queue instead wraps ytdlp.YTDLP.DownloadVideoWithProgress
+// behind a bounded worker pool, backed by the download_jobs table so queued
+// and in-flight work survives a restart.
+package downloader
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/erik/feeds/internal/db"
+	"github.com/erik/feeds/internal/ytdlp"
+)
+
+// DefaultConcurrency is how many downloads Queue runs at once when the
+// caller doesn't specify one (e.g. FEEDS_DL_CONCURRENCY is unset).
+const DefaultConcurrency = 2
+
+// gcInterval is how often Queue checks the downloaded library's total size
+// against its configured quota when one is set.
+const gcInterval = 10 * time.Minute
+
+// Progress is broadcast to subscribers as a job moves through the queue.
+type Progress struct {
+	JobID           int64   `json:"job_id"`
+	VideoID         string  `json:"video_id"`
+	Quality         string  `json:"quality"`
+	Status          string  `json:"status"`
+	BytesDownloaded int64   `json:"bytes_downloaded"`
+	TotalBytes      int64   `json:"total_bytes"`
+	Percent         float64 `json:"percent"`
+	Error           string  `json:"error,omitempty"`
+}
+
+// Queue runs queued download_jobs through yt-dlp with at most concurrency
+// downloads in flight at once, persisting status transitions so a crashed
+// process can resume where it left off.
+type Queue struct {
+	db    *db.DB
+	ytdlp *ytdlp.YTDLP
+	sem   chan struct{}
+
+	// maxLibraryBytes bounds the total size of "done" download_jobs output
+	// files; <= 0 disables the GC pass entirely. See RunGC.
+	maxLibraryBytes int64
+
+	mu        sync.Mutex
+	listeners []chan Progress
+	cancels   map[int64]context.CancelFunc
+}
+
+// NewQueue creates a Queue and requeues any jobs a prior process left
+// running. concurrency <= 0 falls back to DefaultConcurrency. maxLibraryBytes
+// <= 0 leaves the downloaded library's disk-quota GC disabled.
+func NewQueue(database *db.DB, yt *ytdlp.YTDLP, concurrency int, maxLibraryBytes int64) *Queue {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	q := &Queue{
+		db:              database,
+		ytdlp:           yt,
+		sem:             make(chan struct{}, concurrency),
+		maxLibraryBytes: maxLibraryBytes,
+		cancels:         make(map[int64]context.CancelFunc),
+	}
+	q.recover()
+	if maxLibraryBytes > 0 {
+		go q.gcLoop()
+	}
+	return q
+}
+
+// recover requeues jobs left in "running" by a process that crashed or was
+// killed mid-download, so Start picks them back up. yt-dlp's --continue plus
+// its own .part file convention means these resume rather than restart.
+func (q *Queue) recover() {
+	jobs, err := q.db.GetRunningDownloadJobs()
+	if err != nil {
+		log.Printf("downloader: failed to list running jobs for recovery: %v", err)
+		return
+	}
+	for _, j := range jobs {
+		if err := q.db.SetDownloadJobStatus(j.ID, db.DownloadJobQueued, ""); err != nil {
+			log.Printf("downloader: failed to requeue job %d: %v", j.ID, err)
+		}
+	}
+}
+
+// Enqueue persists a new download job and schedules it to run.
+func (q *Queue) Enqueue(videoID, quality, outputPath string) (*db.DownloadJob, error) {
+	job, err := q.db.EnqueueDownloadJob(videoID, quality, outputPath)
+	if err != nil {
+		return nil, err
+	}
+	go q.run(job)
+	return job, nil
+}
+
+// Start schedules every job left "queued" from a previous run (including
+// ones recover requeued) to run.
+func (q *Queue) Start() {
+	// A negative LIMIT means no limit in SQLite; LIMIT 0 would return no rows.
+	jobs, err := q.db.GetQueuedDownloadJobs(-1)
+	if err != nil {
+		log.Printf("downloader: failed to list queued jobs: %v", err)
+		return
+	}
+	for i := range jobs {
+		job := jobs[i]
+		go q.run(&job)
+	}
+}
+
+// run blocks until a worker slot is free, then drives job through yt-dlp to
+// completion, persisting and broadcasting status along the way.
+func (q *Queue) run(job *db.DownloadJob) {
+	q.sem <- struct{}{}
+	defer func() { <-q.sem }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q.mu.Lock()
+	q.cancels[job.ID] = cancel
+	q.mu.Unlock()
+	defer func() {
+		q.mu.Lock()
+		delete(q.cancels, job.ID)
+		q.mu.Unlock()
+		cancel()
+	}()
+
+	// Cancel deletes a still-queued job's row outright, so by the time this
+	// goroutine gets a worker slot there may be nothing left to run.
+	if _, err := q.db.GetDownloadJob(job.ID); err != nil {
+		return
+	}
+
+	if err := q.db.SetDownloadJobStatus(job.ID, db.DownloadJobRunning, ""); err != nil {
+		log.Printf("downloader: failed to mark job %d running: %v", job.ID, err)
+	}
+	q.broadcast(job, db.DownloadJobRunning, 0, 0, "")
+
+	videoURL := "https://www.youtube.com/watch?v=" + job.VideoID
+	_, err := q.ytdlp.DownloadVideoWithProgress(ctx, videoURL, job.Quality, job.OutputPath, 0,
+		func(downloaded, total int64, percent float64) {
+			if err := q.db.UpdateDownloadJobProgress(job.ID, downloaded, total); err != nil {
+				log.Printf("downloader: failed to record progress for job %d: %v", job.ID, err)
+			}
+			q.broadcast(job, db.DownloadJobRunning, downloaded, total, "")
+		})
+
+	if err != nil {
+		if ctx.Err() != nil {
+			// Cancel already deleted the job row and cleaned up its output.
+			return
+		}
+		log.Printf("downloader: job %d (%s/%s) failed: %v", job.ID, job.VideoID, job.Quality, err)
+		if statusErr := q.db.SetDownloadJobStatus(job.ID, db.DownloadJobFailed, err.Error()); statusErr != nil {
+			log.Printf("downloader: failed to mark job %d failed: %v", job.ID, statusErr)
+		}
+		q.broadcast(job, db.DownloadJobFailed, 0, 0, err.Error())
+		return
+	}
+
+	if err := q.db.SetDownloadJobStatus(job.ID, db.DownloadJobDone, ""); err != nil {
+		log.Printf("downloader: failed to mark job %d done: %v", job.ID, err)
+	}
+	q.broadcast(job, db.DownloadJobDone, 0, 0, "")
+}
+
+// Cancel stops job id - killing its in-flight yt-dlp process if it's already
+// running, or letting run's queued-job check short-circuit it otherwise -
+// then deletes its row and any output it had written so far, including
+// yt-dlp's ".part" files.
+func (q *Queue) Cancel(id int64) error {
+	job, err := q.db.GetDownloadJob(id)
+	if err != nil {
+		return err
+	}
+
+	if err := q.db.DeleteDownloadJob(id); err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	cancel, running := q.cancels[id]
+	q.mu.Unlock()
+	if running {
+		cancel()
+	}
+
+	removeOutputAndParts(job.OutputPath)
+	q.broadcast(job, db.DownloadJobCancelled, 0, 0, "")
+	return nil
+}
+
+// removeOutputAndParts deletes outputPath and any in-progress artifacts
+// yt-dlp may have left alongside it (outputTemplate+".<ext>.part" while a
+// download is running). Best-effort: a job cancelled before yt-dlp wrote
+// anything leaves nothing to remove.
+func removeOutputAndParts(outputPath string) {
+	base := strings.TrimSuffix(outputPath, filepath.Ext(outputPath))
+	matches, _ := filepath.Glob(base + "*")
+	for _, m := range matches {
+		if err := os.Remove(m); err != nil && !os.IsNotExist(err) {
+			log.Printf("downloader: failed to remove %s during cleanup: %v", m, err)
+		}
+	}
+}
+
+// gcLoop periodically runs RunGC against the configured library quota.
+func (q *Queue) gcLoop() {
+	ticker := time.NewTicker(gcInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := q.RunGC(q.maxLibraryBytes); err != nil {
+			log.Printf("downloader: GC pass failed: %v", err)
+		}
+	}
+}
+
+// RunGC deletes downloaded files - oldest fully-watched first, per
+// db.ListDownloadJobsForGC's ordering - until the library's total footprint
+// is back under maxBytes. maxBytes <= 0 disables the pass.
+func (q *Queue) RunGC(maxBytes int64) error {
+	if maxBytes <= 0 {
+		return nil
+	}
+
+	jobs, err := q.db.ListDownloadJobsForGC()
+	if err != nil {
+		return err
+	}
+
+	sizes := make([]int64, len(jobs))
+	var total int64
+	for i, j := range jobs {
+		size := j.TotalBytes
+		if size == 0 {
+			if info, err := os.Stat(j.OutputPath); err == nil {
+				size = info.Size()
+			}
+		}
+		sizes[i] = size
+		total += size
+	}
+
+	for i, j := range jobs {
+		if total <= maxBytes {
+			break
+		}
+		if err := os.Remove(j.OutputPath); err != nil && !os.IsNotExist(err) {
+			log.Printf("downloader: GC failed to remove %s: %v", j.OutputPath, err)
+			continue
+		}
+		if err := q.db.DeleteDownloadJob(j.ID); err != nil {
+			log.Printf("downloader: GC failed to delete job %d row: %v", j.ID, err)
+		}
+		total -= sizes[i]
+	}
+	return nil
+}
+
+// Subscribe returns a channel that receives progress for every job in the
+// queue, not just one - the SPA's panel renders all simultaneous downloads
+// at once. The caller must call Unsubscribe when done listening.
+func (q *Queue) Subscribe() chan Progress {
+	ch := make(chan Progress, 16)
+	q.mu.Lock()
+	q.listeners = append(q.listeners, ch)
+	q.mu.Unlock()
+	return ch
+}
+
+// Unsubscribe removes and closes a channel returned by Subscribe.
+func (q *Queue) Unsubscribe(ch chan Progress) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, l := range q.listeners {
+		if l == ch {
+			q.listeners = append(q.listeners[:i], q.listeners[i+1:]...)
+			close(ch)
+			return
+		}
+	}
+}
+
+// broadcast sends progress to every subscriber, waiting up to 100ms on any
+// listener whose buffer is full and dropping the update for it after that.
+func (q *Queue) broadcast(job *db.DownloadJob, status string, downloaded, total int64, errMsg string) {
+	var percent float64
+	if total > 0 {
+		percent = float64(downloaded) / float64(total) * 100
+	}
+	progress := Progress{
+		JobID:           job.ID,
+		VideoID:         job.VideoID,
+		Quality:         job.Quality,
+		Status:          status,
+		BytesDownloaded: downloaded,
+		TotalBytes:      total,
+		Percent:         percent,
+		Error:           errMsg,
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, ch := range q.listeners {
+		select {
+		case ch <- progress:
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,339 @@
+// Package invidious provides a fallback path for fetching video metadata
+// and shorts status from a pool of Invidious instances, used once the
+// Piped pool (internal/piped) is exhausted. It mirrors that package's
+// race-across-healthy-instances design against Invidious's own API shape.
+package invidious
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/erik/feeds/internal/metrics"
+	"github.com/erik/feeds/internal/models"
+)
+
+const (
+	initialBackoff = 12 * time.Hour
+	minBackoff     = 1 * time.Minute
+	requestTimeout = 8 * time.Second
+	shortsMaxSecs  = 60
+)
+
+// DefaultInstances is the out-of-the-box pool of public Invidious hosts.
+var DefaultInstances = []string{
+	"https://invidious.io.lol",
+	"https://yewtu.be",
+}
+
+type instanceState struct {
+	disabledUntil time.Time
+	backoff       time.Duration
+}
+
+// Client races requests across a pool of Invidious instances, temporarily
+// disabling any instance that errors, times out, or rate-limits, and
+// halving that penalty on each success until it drops below minBackoff.
+type Client struct {
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	instances []string
+	state     map[string]*instanceState
+}
+
+// NewClient builds a client over the given instance pool, or
+// DefaultInstances if empty.
+func NewClient(instances []string) *Client {
+	if len(instances) == 0 {
+		instances = DefaultInstances
+	}
+	return &Client{
+		httpClient: &http.Client{Timeout: requestTimeout},
+		instances:  instances,
+		state:      make(map[string]*instanceState),
+	}
+}
+
+// SetInstances replaces the configured instance pool, clearing any
+// disabled/backoff state for instances no longer in the list.
+func (c *Client) SetInstances(instances []string) {
+	if len(instances) == 0 {
+		instances = DefaultInstances
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.instances = instances
+	kept := make(map[string]*instanceState, len(instances))
+	for _, inst := range instances {
+		if st, ok := c.state[inst]; ok {
+			kept[inst] = st
+		}
+	}
+	c.state = kept
+}
+
+// Instances returns the configured instance pool and each one's current
+// disabled-until time (zero if healthy), for a health/status endpoint.
+func (c *Client) Instances() map[string]time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[string]time.Time, len(c.instances))
+	for _, inst := range c.instances {
+		if st, ok := c.state[inst]; ok {
+			out[inst] = st.disabledUntil
+		} else {
+			out[inst] = time.Time{}
+		}
+	}
+	return out
+}
+
+func (c *Client) healthyInstances() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	var healthy []string
+	for _, inst := range c.instances {
+		st, ok := c.state[inst]
+		if !ok || now.After(st.disabledUntil) {
+			healthy = append(healthy, inst)
+		}
+	}
+	return healthy
+}
+
+// markFailure disables an instance for an exponentially growing window,
+// starting at minBackoff and capping at initialBackoff.
+func (c *Client) markFailure(instance string) {
+	metrics.InstanceRequests.WithLabelValues("invidious", instance, "failure").Inc()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st, ok := c.state[instance]
+	if !ok {
+		st = &instanceState{backoff: minBackoff}
+		c.state[instance] = st
+	} else {
+		st.backoff *= 2
+		if st.backoff > initialBackoff {
+			st.backoff = initialBackoff
+		}
+	}
+	st.disabledUntil = time.Now().Add(st.backoff)
+}
+
+// markSuccess decays an instance's backoff back toward zero instead of
+// resetting it outright, so a single lucky request doesn't immediately
+// re-expose a flaky instance to the full pool.
+func (c *Client) markSuccess(instance string) {
+	metrics.InstanceRequests.WithLabelValues("invidious", instance, "success").Inc()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st, ok := c.state[instance]
+	if !ok {
+		return
+	}
+	st.backoff /= 2
+	st.disabledUntil = time.Time{}
+	if st.backoff < minBackoff {
+		delete(c.state, instance)
+	}
+}
+
+type raceResult struct {
+	body     []byte
+	instance string
+	err      error
+}
+
+// raceGet fires the given path at every currently-healthy instance and
+// returns the body of whichever responds 2xx first; the rest are canceled
+// once it returns.
+func (c *Client) raceGet(ctx context.Context, path string) ([]byte, error) {
+	instances := c.healthyInstances()
+	if len(instances) == 0 {
+		return nil, errors.New("invidious: no healthy instances available")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ch := make(chan raceResult, len(instances))
+	for _, inst := range instances {
+		go func(inst string) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, inst+path, nil)
+			if err != nil {
+				ch <- raceResult{instance: inst, err: err}
+				return
+			}
+			resp, err := c.httpClient.Do(req)
+			if err != nil {
+				c.markFailure(inst)
+				ch <- raceResult{instance: inst, err: err}
+				return
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+				c.markFailure(inst)
+				ch <- raceResult{instance: inst, err: fmt.Errorf("%s returned status %d", inst, resp.StatusCode)}
+				return
+			}
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				c.markFailure(inst)
+				ch <- raceResult{instance: inst, err: err}
+				return
+			}
+			ch <- raceResult{body: body, instance: inst}
+		}(inst)
+	}
+
+	var lastErr error
+	for range instances {
+		r := <-ch
+		if r.err == nil {
+			c.markSuccess(r.instance)
+			return r.body, nil
+		}
+		lastErr = r.err
+	}
+	return nil, fmt.Errorf("invidious: all instances failed: %w", lastErr)
+}
+
+type channelResponse struct {
+	Author string           `json:"author"`
+	Videos []videoListEntry `json:"latestVideos"`
+}
+
+type videoListEntry struct {
+	VideoID     string `json:"videoId"`
+	Title       string `json:"title"`
+	Author      string `json:"author"`
+	Published   int64  `json:"published"` // unix seconds
+	LengthSecs  int64  `json:"lengthSeconds"`
+	VideoThumbs []struct {
+		URL string `json:"url"`
+	} `json:"videoThumbnails"`
+}
+
+type videoResponse struct {
+	LengthSeconds int64  `json:"lengthSeconds"`
+	AuthorID      string `json:"authorId"`
+	Author        string `json:"author"`
+}
+
+// ResolveChannel fetches a channel's display name via /api/v1/channels/{id}.
+func (c *Client) ResolveChannel(channelID string) (name string, err error) {
+	body, err := c.raceGet(context.Background(), "/api/v1/channels/"+channelID)
+	if err != nil {
+		return "", err
+	}
+	var resp channelResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", err
+	}
+	return resp.Author, nil
+}
+
+// ResolveVideoToChannel fetches the uploading channel's ID and name for a
+// video via /api/v1/videos/{id}.
+func (c *Client) ResolveVideoToChannel(videoID string) (channelID, channelName string, err error) {
+	body, err := c.raceGet(context.Background(), "/api/v1/videos/"+videoID)
+	if err != nil {
+		return "", "", err
+	}
+	var v videoResponse
+	if err := json.Unmarshal(body, &v); err != nil {
+		return "", "", err
+	}
+	if v.AuthorID == "" {
+		return "", "", fmt.Errorf("invidious: no author for video %s", videoID)
+	}
+	return v.AuthorID, v.Author, nil
+}
+
+// FetchLatestVideos fetches a channel's most recent uploads via
+// /api/v1/channels/{id}.
+func (c *Client) FetchLatestVideos(channelID string, limit int) ([]models.Video, error) {
+	body, err := c.raceGet(context.Background(), "/api/v1/channels/"+channelID)
+	if err != nil {
+		return nil, err
+	}
+	var resp channelResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+
+	videos := make([]models.Video, 0, limit)
+	for _, v := range resp.Videos {
+		if len(videos) >= limit {
+			break
+		}
+		thumb := ""
+		if len(v.VideoThumbs) > 0 {
+			thumb = v.VideoThumbs[0].URL
+		}
+		videos = append(videos, models.Video{
+			ID:          v.VideoID,
+			Title:       v.Title,
+			ChannelName: resp.Author,
+			Thumbnail:   thumb,
+			Published:   time.Unix(v.Published, 0),
+			URL:         "https://www.youtube.com/watch?v=" + v.VideoID,
+			Duration:    int(v.LengthSecs),
+			IsShort:     boolPtr(isShortDuration(v.LengthSecs)),
+		})
+	}
+	return videos, nil
+}
+
+// CheckShortsStatus classifies each video ID by duration via
+// /api/v1/videos/{id}.
+func (c *Client) CheckShortsStatus(videoIDs []string) map[string]bool {
+	results := make(map[string]bool)
+	if len(videoIDs) == 0 {
+		return results
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 5)
+
+	for _, id := range videoIDs {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			body, err := c.raceGet(context.Background(), "/api/v1/videos/"+id)
+			if err != nil {
+				return // leave unset; caller falls back for missing IDs
+			}
+			var v videoResponse
+			if err := json.Unmarshal(body, &v); err != nil {
+				return
+			}
+
+			mu.Lock()
+			results[id] = isShortDuration(v.LengthSeconds)
+			mu.Unlock()
+		}(id)
+	}
+
+	wg.Wait()
+	return results
+}
+
+func isShortDuration(durationSeconds int64) bool {
+	return durationSeconds > 0 && durationSeconds <= shortsMaxSecs
+}
+
+func boolPtr(b bool) *bool { return &b }
@@ -0,0 +1,112 @@
+// Package ippool manages a pool of outbound source IPs and/or HTTP/SOCKS
+// proxy URLs that yt-dlp invocations rotate through, so a single rate limit
+// or IP ban from YouTube doesn't take down every channel refresh. Modeled on
+// ytsync's ip_manager, but treats a 429 as a cooldown-and-retry condition
+// for the caller to act on rather than a fatal error.
+package ippool
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCooldown is how long a lease that triggers a rate limit is taken
+// out of rotation before being offered again.
+const DefaultCooldown = 30 * time.Minute
+
+type entry struct {
+	value         string // source IP or proxy URL
+	proxy         bool   // true if value is a proxy URL, false if a source IP
+	cooldownUntil time.Time
+}
+
+// Pool hands out leases over a fixed set of source IPs and/or proxy URLs,
+// round-robining across whichever aren't currently cooling down.
+type Pool struct {
+	cooldown time.Duration
+
+	mu      sync.Mutex
+	entries []*entry
+	next    int
+}
+
+// New builds a pool from source IPs and proxy URLs (either may be empty).
+// cooldown <= 0 uses DefaultCooldown. A pool with no entries is valid and
+// harmless: Acquire always returns false for it, so callers fall back to
+// running yt-dlp without an injected address.
+func New(ips, proxies []string, cooldown time.Duration) *Pool {
+	if cooldown <= 0 {
+		cooldown = DefaultCooldown
+	}
+	p := &Pool{cooldown: cooldown}
+	for _, ip := range ips {
+		if ip = strings.TrimSpace(ip); ip != "" {
+			p.entries = append(p.entries, &entry{value: ip})
+		}
+	}
+	for _, proxy := range proxies {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			p.entries = append(p.entries, &entry{value: proxy, proxy: true})
+		}
+	}
+	return p
+}
+
+// Size returns the number of configured entries, healthy or not. Safe to
+// call on a nil Pool.
+func (p *Pool) Size() int {
+	if p == nil {
+		return 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.entries)
+}
+
+// Lease is one outbound IP or proxy handed out by Acquire. Args returns the
+// yt-dlp flags that route a request through it; Cooldown takes it out of
+// rotation after a rate-limit response.
+type Lease struct {
+	pool  *Pool
+	entry *entry
+}
+
+// Acquire returns the next entry not currently cooling down, round-robin
+// over the pool, or (nil, false) if the pool is nil, has no entries, or
+// every entry is currently cooling down. Safe to call on a nil Pool.
+func (p *Pool) Acquire() (*Lease, bool) {
+	if p == nil {
+		return nil, false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.entries) == 0 {
+		return nil, false
+	}
+	now := time.Now()
+	for i := 0; i < len(p.entries); i++ {
+		idx := (p.next + i) % len(p.entries)
+		e := p.entries[idx]
+		if now.After(e.cooldownUntil) {
+			p.next = idx + 1
+			return &Lease{pool: p, entry: e}, true
+		}
+	}
+	return nil, false
+}
+
+// Args returns the yt-dlp flags that route a request through this lease.
+func (l *Lease) Args() []string {
+	if l.entry.proxy {
+		return []string{"--proxy", l.entry.value}
+	}
+	return []string{"--source-address", l.entry.value}
+}
+
+// Cooldown takes this lease out of rotation for the pool's cooldown window.
+func (l *Lease) Cooldown() {
+	l.pool.mu.Lock()
+	defer l.pool.mu.Unlock()
+	l.entry.cooldownUntil = time.Now().Add(l.pool.cooldown)
+}
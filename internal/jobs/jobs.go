@@ -0,0 +1,187 @@
+// Package jobs is a generic, SQLite-backed work queue for the handful of
+// background tasks that used to run as ad-hoc goroutines spawned straight
+// from an HTTP handler - they died with the process and silently dropped
+// work on error. A Queue persists each unit of work as a row (see
+// db.Job/migrations/0020_jobs_queue.sql) so a crash/restart just leaves it
+// pending for the next poll, retries failed attempts with exponential
+// backoff up to MaxAttempts, and lets callers watch a job's progress via
+// Subscribe instead of doing the work inline.
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/erik/feeds/internal/db"
+)
+
+// Job kinds. Each must have a Handler registered via Register before any
+// job of that kind is enqueued.
+const (
+	KindRefreshFeed     = "refresh_feed"
+	KindBackfillChannel = "backfill_channel"
+	KindFetchDurations  = "fetch_durations"
+	KindCheckShorts     = "check_shorts"
+	KindDownloadVideo   = "download_video"
+)
+
+// MaxAttempts caps how many times a failed job is retried before it's left
+// in the "failed" status for good.
+const MaxAttempts = 5
+
+// pollInterval is how often each worker checks for due jobs.
+const pollInterval = 2 * time.Second
+
+// Handler runs one job's payload. publish sends an incremental progress
+// event to anyone watching the job via Subscribe; handlers that don't have
+// meaningful incremental progress can ignore it and just return an error or
+// nil at the end.
+type Handler func(payload json.RawMessage, publish func(event any)) error
+
+// Queue is a SQLite-backed job queue: Enqueue persists a job row, a pool of
+// worker goroutines polls for due jobs and runs them through the Handler
+// registered for their kind, and Subscribe lets callers (e.g. an SSE
+// handler) observe a running job's progress without doing the work inline
+// themselves.
+type Queue struct {
+	db       *db.DB
+	handlers map[string]Handler
+
+	subsMu sync.Mutex
+	subs   map[int64][]chan any
+}
+
+// NewQueue creates a queue; Start launches the workers that poll for due
+// jobs. Register every kind's Handler before Start.
+func NewQueue(database *db.DB) *Queue {
+	return &Queue{
+		db:       database,
+		handlers: make(map[string]Handler),
+		subs:     make(map[int64][]chan any),
+	}
+}
+
+// Register associates kind with the handler that processes its jobs. Call
+// before Start.
+func (q *Queue) Register(kind string, handler Handler) {
+	q.handlers[kind] = handler
+}
+
+// Start launches workers background-polling goroutines that claim and run
+// due jobs.
+func (q *Queue) Start(workers int) {
+	for i := 0; i < workers; i++ {
+		go q.worker()
+	}
+}
+
+// Enqueue persists a new pending job of kind, runnable immediately, and
+// returns it.
+func (q *Queue) Enqueue(kind string, payload any) (*db.Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return q.db.EnqueueJob(kind, data)
+}
+
+func (q *Queue) worker() {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		due, err := q.db.GetDueJobs(1)
+		if err != nil {
+			log.Printf("jobs: failed to list due jobs: %v", err)
+			continue
+		}
+		for _, job := range due {
+			q.run(job)
+		}
+	}
+}
+
+func (q *Queue) run(job db.Job) {
+	if err := q.db.SetJobRunning(job.ID); err != nil {
+		log.Printf("jobs: job %d: failed to mark running: %v", job.ID, err)
+		return
+	}
+
+	handler, ok := q.handlers[job.Kind]
+	if !ok {
+		q.fail(job, fmt.Errorf("no handler registered for kind %q", job.Kind))
+		return
+	}
+
+	publish := func(event any) { q.Publish(job.ID, event) }
+
+	if err := handler(json.RawMessage(job.PayloadJSON), publish); err != nil {
+		q.fail(job, err)
+		return
+	}
+
+	if err := q.db.CompleteJob(job.ID); err != nil {
+		log.Printf("jobs: job %d: failed to mark done: %v", job.ID, err)
+	}
+	q.Publish(job.ID, map[string]any{"event": "done"})
+}
+
+func (q *Queue) fail(job db.Job, jobErr error) {
+	attempts := job.Attempts + 1
+	wait := backoff(attempts)
+	if err := q.db.RetryOrFailJob(job.ID, attempts, MaxAttempts, wait, jobErr.Error()); err != nil {
+		log.Printf("jobs: job %d: failed to record failure: %v", job.ID, err)
+	}
+	log.Printf("jobs: job %d (%s) attempt %d failed: %v", job.ID, job.Kind, attempts, jobErr)
+	q.Publish(job.ID, map[string]any{"event": "error", "error": jobErr.Error(), "attempts": attempts})
+}
+
+// backoff returns min(2^attempts, 3600) seconds, so retries spread out
+// quickly without needing a max-delay config knob.
+func backoff(attempts int) time.Duration {
+	seconds := math.Min(math.Pow(2, float64(attempts)), 3600)
+	return time.Duration(seconds) * time.Second
+}
+
+// Subscribe registers a channel to receive progress events published for
+// jobID (see Publish) until unsubscribe is called. The channel is buffered
+// so a slow reader doesn't block the worker; events are dropped rather than
+// blocking if the buffer fills.
+func (q *Queue) Subscribe(jobID int64) (events <-chan any, unsubscribe func()) {
+	ch := make(chan any, 16)
+
+	q.subsMu.Lock()
+	q.subs[jobID] = append(q.subs[jobID], ch)
+	q.subsMu.Unlock()
+
+	return ch, func() {
+		q.subsMu.Lock()
+		defer q.subsMu.Unlock()
+		chans := q.subs[jobID]
+		for i, c := range chans {
+			if c == ch {
+				q.subs[jobID] = append(chans[:i], chans[i+1:]...)
+				break
+			}
+		}
+		if len(q.subs[jobID]) == 0 {
+			delete(q.subs, jobID)
+		}
+		close(ch)
+	}
+}
+
+// Publish fans event out to every subscriber currently watching jobID.
+func (q *Queue) Publish(jobID int64, event any) {
+	q.subsMu.Lock()
+	defer q.subsMu.Unlock()
+	for _, ch := range q.subs[jobID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
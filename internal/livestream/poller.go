@@ -0,0 +1,179 @@
+// Package livestream re-checks upcoming and live YouTube videos on a
+// shorter cadence than the normal RSS refresh, so a stream's
+// upcoming/live/ended transitions (see db.LiveStatus*) are caught promptly
+// instead of waiting for the next feed refresh to notice.
+package livestream
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/erik/feeds/internal/db"
+	"github.com/erik/feeds/internal/ytdlp"
+)
+
+const (
+	pollInterval = 30 * time.Second
+	batchSize    = 25
+)
+
+// Event is emitted whenever a polled video's live status changes, for the
+// notification/UI layer to subscribe to via Poller.Subscribe.
+type Event struct {
+	VideoID  string
+	Title    string
+	From     string
+	To       string
+	Occurred time.Time
+}
+
+// Poller periodically re-fetches db.GetVideosNeedingLiveStatusPoll's
+// candidates via yt-dlp and records any status change, fanning each
+// transition out to subscribers (mirroring the channel-based listener
+// pattern DownloadManager uses for download progress).
+type Poller struct {
+	db    *db.DB
+	ytdlp ytdlp.Client
+
+	mu        sync.Mutex
+	listeners []chan Event
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPoller creates a Poller. Call Start to begin polling.
+func NewPoller(database *db.DB, yt ytdlp.Client) *Poller {
+	return &Poller{db: database, ytdlp: yt}
+}
+
+// Subscribe returns a channel that receives every future status transition,
+// and a func to unsubscribe and release it. The channel is buffered; a
+// subscriber whose buffer is full has new events dropped rather than
+// blocking the poll loop.
+func (p *Poller) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, 16)
+
+	p.mu.Lock()
+	p.listeners = append(p.listeners, ch)
+	p.mu.Unlock()
+
+	unsubscribe := func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		for i, l := range p.listeners {
+			if l == ch {
+				p.listeners = append(p.listeners[:i], p.listeners[i+1:]...)
+				break
+			}
+		}
+		close(ch)
+	}
+	return ch, unsubscribe
+}
+
+func (p *Poller) emit(e Event) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, l := range p.listeners {
+		select {
+		case l <- e:
+		default:
+			log.Printf("livestream poller: subscriber channel full, dropping event for %s", e.VideoID)
+		}
+	}
+}
+
+// Start launches the poll loop in the background. It returns immediately;
+// call Stop, or cancel ctx, to stop it.
+func (p *Poller) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	p.cancel = cancel
+
+	p.wg.Add(1)
+	go p.run(ctx)
+}
+
+// Stop cancels the poll loop and waits for the in-flight batch to finish.
+func (p *Poller) Stop() {
+	if p.cancel != nil {
+		p.cancel()
+	}
+	p.wg.Wait()
+}
+
+func (p *Poller) run(ctx context.Context) {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		p.pollBatch()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Poller) pollBatch() {
+	candidates, err := p.db.GetVideosNeedingLiveStatusPoll(batchSize)
+	if err != nil {
+		log.Printf("livestream poller: failed to list candidates: %v", err)
+		return
+	}
+
+	for _, c := range candidates {
+		info, err := p.ytdlp.GetVideoInfo(c.URL)
+		if err != nil {
+			log.Printf("livestream poller: failed to fetch %s: %v", c.VideoID, err)
+			continue
+		}
+
+		status := normalizeLiveStatus(info.LiveStatus)
+
+		var scheduledStart, actualStart *time.Time
+		if info.ReleaseTimestamp > 0 {
+			t := time.Unix(info.ReleaseTimestamp, 0)
+			scheduledStart = &t
+		}
+		if status == db.LiveStatusLive {
+			now := time.Now()
+			actualStart = &now
+		}
+
+		changed, previous, err := p.db.UpsertLiveStatus(c.VideoID, status, scheduledStart, actualStart)
+		if err != nil {
+			log.Printf("livestream poller: failed to update status for %s: %v", c.VideoID, err)
+			continue
+		}
+		if changed {
+			p.emit(Event{VideoID: c.VideoID, Title: info.Title, From: previous, To: status, Occurred: time.Now()})
+		}
+
+		if info.ConcurrentViewCount > 0 {
+			if err := p.db.UpdateConcurrentViewers(c.VideoID, info.ConcurrentViewCount); err != nil {
+				log.Printf("livestream poller: failed to update viewer count for %s: %v", c.VideoID, err)
+			}
+		}
+	}
+}
+
+// normalizeLiveStatus maps yt-dlp's live_status vocabulary onto our own
+// none/upcoming/live/ended states.
+func normalizeLiveStatus(s string) string {
+	switch s {
+	case "is_upcoming":
+		return db.LiveStatusUpcoming
+	case "is_live":
+		return db.LiveStatusLive
+	case "was_live", "post_live":
+		return db.LiveStatusEnded
+	default:
+		return db.LiveStatusNone
+	}
+}
@@ -0,0 +1,64 @@
+// Package metrics exposes Prometheus counters and histograms for feed and
+// channel refresh activity, yt-dlp invocations, and backfill job state, so
+// operators can see what the worker pool is actually doing under load.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// VideosFetched counts videos returned per channel refresh, labeled by
+	// the source backend that produced them (yt-dlp, piped, rss).
+	VideosFetched = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "feeds_videos_fetched_total",
+		Help: "Videos fetched per channel refresh.",
+	}, []string{"channel_id", "source"})
+
+	// RefreshDuration times a feed or channel refresh end-to-end.
+	RefreshDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "feeds_refresh_duration_seconds",
+		Help:    "Time to refresh a feed or channel.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"feed_id", "channel_id"})
+
+	// ShortsCheckDuration times a shorts-classification batch.
+	ShortsCheckDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "feeds_shorts_check_duration_seconds",
+		Help:    "Time to classify a batch of videos as shorts or not.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"source"})
+
+	// YTDLPInvocations counts yt-dlp CLI runs by outcome.
+	YTDLPInvocations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "feeds_ytdlp_invocations_total",
+		Help: "yt-dlp CLI invocations by outcome.",
+	}, []string{"outcome"}) // "success", "failure", "timeout"
+
+	// YTDLPCookiesConfigured counts yt-dlp runs by whether cookies were configured.
+	YTDLPCookiesConfigured = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "feeds_ytdlp_cookies_configured_total",
+		Help: "yt-dlp invocations split by whether cookies were configured.",
+	}, []string{"configured"}) // "true", "false"
+
+	// BackfillJobTransitions counts backfill job status transitions.
+	BackfillJobTransitions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "feeds_backfill_job_transitions_total",
+		Help: "Backfill job state transitions.",
+	}, []string{"status"}) // "queued", "running", "paused", "done", "failed"
+
+	// SourceErrors counts fetch errors per video source backend.
+	SourceErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "feeds_source_errors_total",
+		Help: "Fetch errors per video source backend.",
+	}, []string{"source"}) // "ytdlp", "piped", "rss"
+
+	// InstanceRequests counts requests per third-party instance (Piped,
+	// Invidious) by outcome, so operators can spot and prune bad instances
+	// from their configured pool.
+	InstanceRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "feeds_instance_requests_total",
+		Help: "Requests per third-party API instance by outcome.",
+	}, []string{"pool", "instance", "outcome"}) // pool: "piped", "invidious"; outcome: "success", "failure"
+)
@@ -2,8 +2,17 @@ package models
 
 import "time"
 
+type User struct {
+	ID           int64     `json:"id"`
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"-"`
+	APIToken     string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
 type Feed struct {
 	ID          int64     `json:"id"`
+	UserID      int64     `json:"user_id"`
 	Name        string    `json:"name"`
 	Description string    `json:"description,omitempty"`
 	Author      string    `json:"author,omitempty"`
@@ -11,12 +20,73 @@ type Feed struct {
 	IsSystem    bool      `json:"is_system"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	// SortOrder positions this feed/folder among its siblings in the tree
+	// returned by GetFeedTree; lower sorts first.
+	SortOrder int `json:"sort_order"`
+
+	// NewVideoCount is the number of this feed's videos not yet marked
+	// watched, kept in sync by UpdateNewVideoCount.
+	NewVideoCount int `json:"new_video_count"`
+
+	RefreshIntervalSeconds int        `json:"refresh_interval_seconds"`
+	NextRefreshAt          *time.Time `json:"next_refresh_at,omitempty"`
+	LastRefreshAt          *time.Time `json:"last_refresh_at,omitempty"`
+	LastRefreshError       string     `json:"last_refresh_error,omitempty"`
+	Paused                 bool       `json:"paused"`
+	HideShorts             bool       `json:"hide_shorts"`
+
+	// AutoDownloadQuality is the quality (e.g. "720") that new videos in this
+	// feed are automatically queued for in internal/downloader's crash-resumable
+	// download queue, or "" if auto-download is off - see
+	// (*api.Server).refreshFeedCore.
+	AutoDownloadQuality string `json:"auto_download_quality,omitempty"`
+
+	// PodcastFormat is "audio", "video", or "custom" - when non-empty, this
+	// feed's videos are queued for download by the podcast episode worker
+	// (see api.PodcastEpisodeWorker) and the feed becomes subscribable as a
+	// podcast RSS document (see api.buildPodcastRSS). "" means the feed has
+	// no podcast export/episode download enabled.
+	PodcastFormat string `json:"podcast_format,omitempty"`
+
+	// PodcastQuality is "high" or "low". Defaults to "high". The episode
+	// worker does not read it; every episode is transcoded to 128k MP3.
+	PodcastQuality string `json:"podcast_quality,omitempty"`
+
+	// RetentionDays is how long a downloaded episode's file is kept before
+	// the episode worker deletes it and marks the video "cleaned" (see
+	// db.EpisodeStatusCleaned). 0 means keep forever.
+	RetentionDays int `json:"retention_days,omitempty"`
+
+	ParentID *int64 `json:"parent_id,omitempty"`
+	Kind     string `json:"kind"` // "feed" or "folder", see migrations/0011_feed_tree.sql
+}
+
+// FeedNode is a models.Feed positioned in the folder tree returned by
+// (*db.DB).GetFeedTree, with its immediate children attached.
+type FeedNode struct {
+	Feed
+	Children []FeedNode `json:"children,omitempty"`
 }
 
 type Channel struct {
-	ID   int64  `json:"id"`
-	URL  string `json:"url"`
-	Name string `json:"name"`
+	ID     int64  `json:"id"`
+	URL    string `json:"url"`
+	Name   string `json:"name"`
+	Source string `json:"source"` // e.g. "youtube", "peertube", "rss"
+
+	// SourceType classifies what kind of thing URL actually points at within
+	// Source - "channel", "playlist", "user", or "group" - so a Feed can mix
+	// e.g. a YouTube channel with a YouTube playlist without either being
+	// misreported as the other. Set by the sources.Source that resolved the
+	// channel (see sources.ChannelInfo.SourceType); "" is treated as
+	// "channel", the overwhelmingly common case.
+	SourceType string `json:"source_type"`
+
+	// LanguageOverride pins every video ingested from this channel to a
+	// given ISO-639-1 language code, bypassing the automatic detection in
+	// ytdlp.VideoInfo.ToModel. Empty means "trust detection".
+	LanguageOverride string `json:"language_override"`
 }
 
 type Video struct {
@@ -29,10 +99,44 @@ type Video struct {
 	IsShort     *bool     `json:"is_short"` // nil = unknown, true = short, false = not short
 	Published   time.Time `json:"published"`
 	URL         string    `json:"url"`
+
+	// Language is an ISO-639-1 code detected from the video's title and
+	// description (see ytdlp.detectLanguage), or the owning channel's
+	// LanguageOverride when one is set. Empty means detection found no
+	// reliable language.
+	Language string `json:"language"`
+
+	// LiveStatus is one of "none"/"upcoming"/"live"/"ended", see
+	// migrations/0012_live_status.sql and db.LiveStatus* constants.
+	LiveStatus         string     `json:"live_status"`
+	ScheduledStartTime *time.Time `json:"scheduled_start_time,omitempty"`
+	ActualStartTime    *time.Time `json:"actual_start_time,omitempty"`
+	ConcurrentViewers  *int64     `json:"concurrent_viewers,omitempty"`
+
+	// Tab is which channel-page tab this video came from - "videos",
+	// "shorts", "streams", or "playlists" (see youtube.FetchChannelTab) -
+	// so the frontend can offer the same per-tab split YouTube and
+	// Invidious do instead of one all-or-nothing list. Empty is treated as
+	// "videos"; see migrations/0018_video_tabs.sql.
+	Tab string `json:"tab"`
+
+	// FileURL, Bytes, and MimeType describe a downloaded podcast episode
+	// file once api.PodcastEpisodeWorker has fetched one for this video -
+	// all empty/zero until then. EpisodeStatus is "new" (not yet
+	// attempted), "downloaded", "error", or "cleaned" (deleted by the
+	// worker's retention sweep) - see db.EpisodeStatus* constants and
+	// migrations/0025_podcast_episodes.sql.
+	FileURL       string `json:"file_url,omitempty"`
+	Bytes         int64  `json:"bytes,omitempty"`
+	MimeType      string `json:"mime_type,omitempty"`
+	EpisodeStatus string `json:"episode_status,omitempty"`
 }
 
 // NewPipe import format
 type NewPipeExport struct {
+	// AppVersion is only ever set on export; the importer ignores it since
+	// NewPipe itself doesn't require it to round-trip a subscriptions file.
+	AppVersion    string                `json:"app_version,omitempty"`
 	Subscriptions []NewPipeSubscription `json:"subscriptions"`
 }
 
@@ -44,13 +148,13 @@ type NewPipeSubscription struct {
 
 // FeedExport is the shareable feed format
 type FeedExport struct {
-	Version     int              `json:"version"`
-	Name        string           `json:"name"`
-	Description string           `json:"description,omitempty"`
-	Author      string           `json:"author,omitempty"`
-	Tags        []string         `json:"tags,omitempty"`
-	Updated     time.Time        `json:"updated,omitempty"`
-	Channels    []ExportChannel  `json:"channels"`
+	Version     int             `json:"version"`
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Author      string          `json:"author,omitempty"`
+	Tags        []string        `json:"tags,omitempty"`
+	Updated     time.Time       `json:"updated,omitempty"`
+	Channels    []ExportChannel `json:"channels"`
 }
 
 type ExportChannel struct {
@@ -77,4 +181,21 @@ type WatchHistoryChannel struct {
 	URL        string `json:"url"`
 	Name       string `json:"name"`
 	WatchCount int    `json:"watch_count"`
+
+	// Score and LastWatched are set by api.scoreWatchHistoryChannels when
+	// this record ranks a candidate for the "For You" recommendation Feed -
+	// Score is the exponential time-decay recency-weighted watch frequency
+	// (see api.buildForYouFeed), not just WatchCount, so the UI can explain
+	// why a channel was suggested. Both are zero/nil for plain import-preview
+	// rows (see parseWatchHistory).
+	Score       float64    `json:"score,omitempty"`
+	LastWatched *time.Time `json:"last_watched,omitempty"`
+}
+
+// SyncWorker is one feeds instance's row in sync_workers: a heartbeat other
+// instances sharing the same database can see, for GET /api/cluster/workers's
+// observability view - see scheduler.Scheduler's work-stealing refresh claim.
+type SyncWorker struct {
+	Hostname      string    `json:"hostname"`
+	LastHeartbeat time.Time `json:"last_heartbeat"`
 }
@@ -0,0 +1,565 @@
+// Package piped provides a fast, no-yt-dlp path for fetching video
+// metadata and shorts status by racing requests across a pool of
+// Piped API instances, falling back to slower paths (RSS,
+// yt-dlp) only once every instance in the pool has failed.
+package piped
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/erik/feeds/internal/metrics"
+	"github.com/erik/feeds/internal/models"
+)
+
+const (
+	initialBackoff = 12 * time.Hour
+	minBackoff     = 1 * time.Minute
+	requestTimeout = 8 * time.Second
+	shortsMaxSecs  = 60
+)
+
+// DefaultInstances is the out-of-the-box pool of public Piped API hosts.
+var DefaultInstances = []string{
+	"https://pipedapi.kavin.rocks",
+	"https://pipedapi.moomoo.me",
+	"https://api.piped.yt",
+}
+
+type instanceState struct {
+	disabledUntil time.Time
+	backoff       time.Duration
+}
+
+// Client races requests across a pool of Piped instances,
+// temporarily disabling any instance that errors or times out and
+// halving that penalty on each success until it drops below minBackoff.
+type Client struct {
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	instances []string
+	state     map[string]*instanceState
+}
+
+// NewClient builds a client over the given instance pool, or
+// DefaultInstances if empty.
+func NewClient(instances []string) *Client {
+	if len(instances) == 0 {
+		instances = DefaultInstances
+	}
+	return &Client{
+		httpClient: &http.Client{Timeout: requestTimeout},
+		instances:  instances,
+		state:      make(map[string]*instanceState),
+	}
+}
+
+// SetInstances replaces the configured instance pool, clearing any
+// disabled/backoff state for instances no longer in the list.
+func (c *Client) SetInstances(instances []string) {
+	if len(instances) == 0 {
+		instances = DefaultInstances
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.instances = instances
+	kept := make(map[string]*instanceState, len(instances))
+	for _, inst := range instances {
+		if st, ok := c.state[inst]; ok {
+			kept[inst] = st
+		}
+	}
+	c.state = kept
+}
+
+// Instances returns the configured instance pool and each one's current
+// disabled-until time (zero if healthy), for a config/status endpoint.
+func (c *Client) Instances() map[string]time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[string]time.Time, len(c.instances))
+	for _, inst := range c.instances {
+		if st, ok := c.state[inst]; ok {
+			out[inst] = st.disabledUntil
+		} else {
+			out[inst] = time.Time{}
+		}
+	}
+	return out
+}
+
+func (c *Client) healthyInstances() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	var healthy []string
+	for _, inst := range c.instances {
+		st, ok := c.state[inst]
+		if !ok || now.After(st.disabledUntil) {
+			healthy = append(healthy, inst)
+		}
+	}
+	return healthy
+}
+
+// markFailure disables an instance for an exponentially growing window,
+// starting at minBackoff and capping at initialBackoff.
+func (c *Client) markFailure(instance string) {
+	metrics.InstanceRequests.WithLabelValues("piped", instance, "failure").Inc()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st, ok := c.state[instance]
+	if !ok {
+		st = &instanceState{backoff: minBackoff}
+		c.state[instance] = st
+	} else {
+		st.backoff *= 2
+		if st.backoff > initialBackoff {
+			st.backoff = initialBackoff
+		}
+	}
+	st.disabledUntil = time.Now().Add(st.backoff)
+}
+
+// markSuccess decays an instance's backoff back toward zero instead of
+// resetting it outright, so a single lucky request doesn't immediately
+// re-expose a flaky instance to the full pool.
+func (c *Client) markSuccess(instance string) {
+	metrics.InstanceRequests.WithLabelValues("piped", instance, "success").Inc()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st, ok := c.state[instance]
+	if !ok {
+		return
+	}
+	st.backoff /= 2
+	st.disabledUntil = time.Time{}
+	if st.backoff < minBackoff {
+		delete(c.state, instance)
+	}
+}
+
+type raceResult struct {
+	body     []byte
+	instance string
+	err      error
+}
+
+// raceGet fires the given path at every currently-healthy instance and
+// returns the body of whichever responds 2xx first; the rest are canceled
+// once a winner is found.
+func (c *Client) raceGet(ctx context.Context, path string) ([]byte, error) {
+	instances := c.healthyInstances()
+	if len(instances) == 0 {
+		return nil, errors.New("piped: no healthy instances available")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ch := make(chan raceResult, len(instances))
+	for _, inst := range instances {
+		go func(inst string) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, inst+path, nil)
+			if err != nil {
+				ch <- raceResult{instance: inst, err: err}
+				return
+			}
+			resp, err := c.httpClient.Do(req)
+			if err != nil {
+				c.markFailure(inst)
+				ch <- raceResult{instance: inst, err: err}
+				return
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+				c.markFailure(inst)
+				ch <- raceResult{instance: inst, err: fmt.Errorf("%s returned status %d", inst, resp.StatusCode)}
+				return
+			}
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				c.markFailure(inst)
+				ch <- raceResult{instance: inst, err: err}
+				return
+			}
+			ch <- raceResult{body: body, instance: inst}
+		}(inst)
+	}
+
+	var lastErr error
+	for range instances {
+		r := <-ch
+		if r.err == nil {
+			c.markSuccess(r.instance)
+			return r.body, nil
+		}
+		lastErr = r.err
+	}
+	return nil, fmt.Errorf("piped: all instances failed: %w", lastErr)
+}
+
+type channelResponse struct {
+	Name           string   `json:"name"`
+	RelatedStreams []stream `json:"relatedStreams"`
+	NextPage       string   `json:"nextpage"`
+}
+
+type stream struct {
+	URL          string `json:"url"`
+	Title        string `json:"title"`
+	Thumbnail    string `json:"thumbnail"`
+	UploaderName string `json:"uploaderName"`
+	Uploaded     int64  `json:"uploaded"` // unix millis
+	Duration     int64  `json:"duration"` // seconds
+	IsShort      bool   `json:"isShort"`
+}
+
+type videoResponse struct {
+	Title        string      `json:"title"`
+	Duration     int64       `json:"duration"`
+	UploaderURL  string      `json:"uploaderUrl"`
+	UploaderName string      `json:"uploaderName"`
+	Thumbnail    string      `json:"thumbnailUrl"`
+	UploadDate   string      `json:"uploadDate"`
+	Livestream   bool        `json:"livestream"`
+	VideoStreams []rawFormat `json:"videoStreams"`
+	AudioStreams []rawFormat `json:"audioStreams"`
+}
+
+type rawFormat struct {
+	URL      string `json:"url"`
+	Quality  string `json:"quality"`
+	MimeType string `json:"mimeType"`
+	Bitrate  int    `json:"bitrate"`
+}
+
+// Format is one adaptive video-only or audio-only stream from a Piped
+// /streams response.
+type Format struct {
+	URL      string
+	Quality  string // e.g. "1080p60" or "128kbps"
+	MimeType string
+	Bitrate  int
+}
+
+// Streams is the subset of a Piped /streams/{id} response ytnative needs
+// to serve adaptive playback without a yt-dlp binary: Piped instances
+// already perform YouTube's signature deciphering server-side, so these
+// URLs play directly.
+type Streams struct {
+	Title        string
+	Duration     int64
+	Channel      string
+	ChannelURL   string
+	Thumbnail    string
+	UploadDate   string
+	VideoStreams []Format
+	AudioStreams []Format
+}
+
+// GetStreams fetches full stream metadata for a video via /streams/{id}.
+func (c *Client) GetStreams(videoID string) (*Streams, error) {
+	body, err := c.raceGet(context.Background(), "/streams/"+videoID)
+	if err != nil {
+		return nil, err
+	}
+	var v videoResponse
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil, err
+	}
+
+	streams := &Streams{
+		Title:      v.Title,
+		Duration:   v.Duration,
+		Channel:    v.UploaderName,
+		ChannelURL: v.UploaderURL,
+		Thumbnail:  v.Thumbnail,
+		UploadDate: v.UploadDate,
+	}
+	for _, f := range v.VideoStreams {
+		streams.VideoStreams = append(streams.VideoStreams, Format{URL: f.URL, Quality: f.Quality, MimeType: f.MimeType, Bitrate: f.Bitrate})
+	}
+	for _, f := range v.AudioStreams {
+		streams.AudioStreams = append(streams.AudioStreams, Format{URL: f.URL, Quality: f.Quality, MimeType: f.MimeType, Bitrate: f.Bitrate})
+	}
+	return streams, nil
+}
+
+// ResolveChannel fetches a channel's display name via /channel/{channelID}.
+func (c *Client) ResolveChannel(channelID string) (name string, err error) {
+	body, err := c.raceGet(context.Background(), "/channel/"+channelID)
+	if err != nil {
+		return "", err
+	}
+	var resp channelResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", err
+	}
+	return resp.Name, nil
+}
+
+// ResolveVideoToChannel fetches the uploading channel's ID and name for a
+// video via /streams/{id}, so a video URL can be imported the same way a
+// channel URL can.
+func (c *Client) ResolveVideoToChannel(videoID string) (channelID, channelName string, err error) {
+	body, err := c.raceGet(context.Background(), "/streams/"+videoID)
+	if err != nil {
+		return "", "", err
+	}
+	var v videoResponse
+	if err := json.Unmarshal(body, &v); err != nil {
+		return "", "", err
+	}
+	channelID = strings.TrimPrefix(v.UploaderURL, "/channel/")
+	if channelID == "" {
+		return "", "", fmt.Errorf("piped: no uploader channel for video %s", videoID)
+	}
+	return channelID, v.UploaderName, nil
+}
+
+// FetchLatestVideos fetches a channel's most recent uploads via
+// /channel/{channelID}.
+func (c *Client) FetchLatestVideos(channelID string, limit int) ([]models.Video, error) {
+	body, err := c.raceGet(context.Background(), "/channel/"+channelID)
+	if err != nil {
+		return nil, err
+	}
+	var resp channelResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+
+	videos := make([]models.Video, 0, limit)
+	for _, s := range resp.RelatedStreams {
+		if len(videos) >= limit {
+			break
+		}
+		id := extractVideoID(s.URL)
+		if id == "" {
+			continue
+		}
+		videos = append(videos, models.Video{
+			ID:          id,
+			Title:       s.Title,
+			ChannelName: resp.Name,
+			Thumbnail:   s.Thumbnail,
+			Published:   time.UnixMilli(s.Uploaded),
+			URL:         "https://www.youtube.com/watch?v=" + id,
+			Duration:    int(s.Duration),
+			IsShort:     boolPtr(s.IsShort || isShortDuration(s.Duration)),
+		})
+	}
+	return videos, nil
+}
+
+// FetchChannelPage fetches one page of a channel's uploads, oldest-paging
+// forward via Piped's continuation-token endpoint: an empty nextpage fetches
+// /channel/{channelID} (the first, newest-first page), and a non-empty one
+// fetches /nextpage/channel/{channelID}?nextpage=... to continue from where
+// the previous page left off. It's the fallback BackfillChannel uses when no
+// YouTube Data API key is configured, since playlistItems.list isn't
+// available without one.
+func (c *Client) FetchChannelPage(channelID, nextpage string) (videos []models.Video, nextNextpage string, err error) {
+	path := "/channel/" + channelID
+	if nextpage != "" {
+		path = "/nextpage/channel/" + channelID + "?nextpage=" + url.QueryEscape(nextpage)
+	}
+
+	body, err := c.raceGet(context.Background(), path)
+	if err != nil {
+		return nil, "", err
+	}
+	var resp channelResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, "", err
+	}
+
+	for _, s := range resp.RelatedStreams {
+		id := extractVideoID(s.URL)
+		if id == "" {
+			continue
+		}
+		videos = append(videos, models.Video{
+			ID:          id,
+			Title:       s.Title,
+			ChannelName: resp.Name,
+			Thumbnail:   s.Thumbnail,
+			Published:   time.UnixMilli(s.Uploaded),
+			URL:         "https://www.youtube.com/watch?v=" + id,
+			Duration:    int(s.Duration),
+			IsShort:     boolPtr(s.IsShort || isShortDuration(s.Duration)),
+		})
+	}
+	return videos, resp.NextPage, nil
+}
+
+type channelTabResponse struct {
+	Content  []stream `json:"content"`
+	Nextpage string   `json:"nextpage"`
+}
+
+// FetchChannelTab fetches one page of a single channel-page tab ("videos",
+// "shorts", "streams", or "playlists") via Piped's /channels/tabs
+// continuation endpoint. continuation is the opaque "data" token from a
+// previous call's returned nextContinuation; pass "" to fetch tab's first
+// page, which this builds itself by base64-encoding the channel/tab
+// selector the same way Piped's own frontend does when it requests a tab
+// for the first time.
+func (c *Client) FetchChannelTab(channelID, tab, continuation string) (videos []models.Video, nextContinuation string, err error) {
+	data := continuation
+	if data == "" {
+		data = encodeChannelTabSelector(channelID, tab)
+	}
+
+	body, err := c.raceGet(context.Background(), "/channels/tabs?data="+url.QueryEscape(data))
+	if err != nil {
+		return nil, "", err
+	}
+	var resp channelTabResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, "", err
+	}
+
+	for _, s := range resp.Content {
+		id := extractVideoID(s.URL)
+		if id == "" {
+			continue
+		}
+		videos = append(videos, models.Video{
+			ID:          id,
+			Title:       s.Title,
+			ChannelName: s.UploaderName,
+			Thumbnail:   s.Thumbnail,
+			Published:   time.UnixMilli(s.Uploaded),
+			URL:         "https://www.youtube.com/watch?v=" + id,
+			Duration:    int(s.Duration),
+			IsShort:     boolPtr(s.IsShort || isShortDuration(s.Duration)),
+		})
+	}
+	return videos, resp.Nextpage, nil
+}
+
+// encodeChannelTabSelector builds the base64 "data" payload Piped expects to
+// select a channel's tab on the first request, before it has a server-
+// issued continuation token to page with.
+func encodeChannelTabSelector(channelID, tab string) string {
+	payload := fmt.Sprintf(`{"channelId":%q,"tab":%q}`, channelID, tab)
+	return base64.StdEncoding.EncodeToString([]byte(payload))
+}
+
+// CheckShortsStatus classifies each video ID by duration via
+// /streams/{id}, avoiding the HEAD-request scrape of /shorts/{id}.
+func (c *Client) CheckShortsStatus(videoIDs []string) map[string]bool {
+	results := make(map[string]bool)
+	if len(videoIDs) == 0 {
+		return results
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 5)
+
+	for _, id := range videoIDs {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			body, err := c.raceGet(context.Background(), "/streams/"+id)
+			if err != nil {
+				return // leave unset; caller falls back for missing IDs
+			}
+			var v videoResponse
+			if err := json.Unmarshal(body, &v); err != nil {
+				return
+			}
+
+			mu.Lock()
+			results[id] = isShortDuration(v.Duration)
+			mu.Unlock()
+		}(id)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// Classification is a video's duration and shorts/livestream status from a
+// single /streams/{id} fetch, so a caller wanting all three doesn't need a
+// separate request per property.
+type Classification struct {
+	Duration int64
+	IsShort  bool
+	IsLive   bool
+}
+
+// ClassifyVideos fetches duration and livestream status for a batch of
+// video IDs via /streams/{id}, same concurrency-limited shape as
+// CheckShortsStatus.
+func (c *Client) ClassifyVideos(videoIDs []string) map[string]Classification {
+	results := make(map[string]Classification)
+	if len(videoIDs) == 0 {
+		return results
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 5)
+
+	for _, id := range videoIDs {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			body, err := c.raceGet(context.Background(), "/streams/"+id)
+			if err != nil {
+				return // leave unset; caller falls back for missing IDs
+			}
+			var v videoResponse
+			if err := json.Unmarshal(body, &v); err != nil {
+				return
+			}
+
+			mu.Lock()
+			results[id] = Classification{
+				Duration: v.Duration,
+				IsShort:  isShortDuration(v.Duration),
+				IsLive:   v.Livestream,
+			}
+			mu.Unlock()
+		}(id)
+	}
+
+	wg.Wait()
+	return results
+}
+
+func isShortDuration(durationSeconds int64) bool {
+	return durationSeconds > 0 && durationSeconds <= shortsMaxSecs
+}
+
+func boolPtr(b bool) *bool { return &b }
+
+func extractVideoID(streamURL string) string {
+	const marker = "v="
+	if idx := strings.Index(streamURL, marker); idx >= 0 {
+		return streamURL[idx+len(marker):]
+	}
+	return ""
+}
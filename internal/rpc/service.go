@@ -0,0 +1,85 @@
+// Package rpc implements feeds' gRPC API, mirroring the SSE/JSON endpoints
+// internal/api exposes over HTTP for non-browser clients (CLIs, other
+// services) that would rather consume a typed stream than parse
+// text/event-stream. It's registered on its own port alongside the HTTP mux
+// - see Server.RegisterRoutes and cmd/server/main.go.
+package rpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/erik/feeds/internal/rpc/downloadpb"
+)
+
+// Progress is one update on a video/quality download, translated from
+// DownloadManager's own progress type by the caller's DownloadManager
+// implementation - see internal/api's adapter.
+type Progress struct {
+	Quality         string
+	Percent         float64
+	BytesDownloaded int64
+	TotalBytes      int64
+	Status          string
+	Error           string
+}
+
+// DownloadManager is the subset of internal/api's DownloadManager this
+// service needs, narrowed to an interface so this package doesn't import
+// internal/api (which embeds this service to run its gRPC server alongside
+// the HTTP mux, and would otherwise create an import cycle).
+type DownloadManager interface {
+	// StartDownload kicks off (or attaches to an already-running) background
+	// download for videoID at quality.
+	StartDownload(videoID, quality string) error
+
+	// Subscribe streams videoID's download progress until ctx is canceled or
+	// a terminal update (Status "complete" or "error") is delivered, then
+	// closes the returned channel - unsubscribing internally either way.
+	Subscribe(ctx context.Context, videoID string) <-chan Progress
+}
+
+// DownloadService implements downloadpb.DownloadServiceServer.
+type DownloadService struct {
+	downloadpb.UnimplementedDownloadServiceServer
+	dm DownloadManager
+}
+
+// NewDownloadService creates a DownloadService backed by dm.
+func NewDownloadService(dm DownloadManager) *DownloadService {
+	return &DownloadService{dm: dm}
+}
+
+// StartDownload implements the StartDownload RPC: it starts (or attaches to)
+// videoID's download at the requested quality, then streams progress until
+// it reaches a terminal state, mirroring internal/api's
+// handleStartDownload+handleDownloadStatus pair over a single gRPC call.
+func (s *DownloadService) StartDownload(req *downloadpb.StartDownloadRequest, stream downloadpb.DownloadService_StartDownloadServer) error {
+	videoID := req.GetVideoId()
+	quality := req.GetQuality()
+	if videoID == "" || quality == "" {
+		return status.Error(codes.InvalidArgument, "video_id and quality are required")
+	}
+
+	if err := s.dm.StartDownload(videoID, quality); err != nil {
+		return status.Errorf(codes.Internal, "failed to start download: %v", err)
+	}
+
+	ctx := stream.Context()
+	for progress := range s.dm.Subscribe(ctx, videoID) {
+		if err := stream.Send(&downloadpb.DownloadProgress{
+			Quality:         progress.Quality,
+			Percent:         progress.Percent,
+			BytesDownloaded: progress.BytesDownloaded,
+			TotalBytes:      progress.TotalBytes,
+			Status:          progress.Status,
+			Error:           progress.Error,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return ctx.Err()
+}
@@ -0,0 +1,57 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a simple per-key leaky bucket: a key may only be drawn from
+// once per interval, regardless of how many goroutines are waiting on it.
+// Keys are source backend names ("youtube", "piped", "peertube", ...), so a
+// slow or rate-limited backend can't starve refreshes of channels on other
+// backends.
+type RateLimiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next map[string]time.Time
+}
+
+// NewRateLimiter creates a limiter that allows one draw per key every interval.
+func NewRateLimiter(interval time.Duration) *RateLimiter {
+	return &RateLimiter{
+		interval: interval,
+		next:     make(map[string]time.Time),
+	}
+}
+
+// Wait blocks until a token for key is available or ctx is canceled.
+func (r *RateLimiter) Wait(ctx context.Context, key string) error {
+	for {
+		wait := r.reserve(key)
+		if wait <= 0 {
+			return nil
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// reserve returns how long the caller must still wait, claiming the next slot
+// for key if none is owed.
+func (r *RateLimiter) reserve(key string) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	next, ok := r.next[key]
+	if !ok || !now.Before(next) {
+		r.next[key] = now.Add(r.interval)
+		return 0
+	}
+	return next.Sub(now)
+}
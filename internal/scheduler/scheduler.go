@@ -0,0 +1,301 @@
+// Package scheduler runs feed refreshes on a per-feed schedule instead of
+// waiting for a user to click refresh, reusing the same worker-pool and
+// rate-limiting conventions as the manual refresh endpoints.
+package scheduler
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/erik/feeds/internal/db"
+)
+
+const (
+	pollInterval = 30 * time.Second
+	maxWorkers   = 5
+	jobQueueSize = 256
+
+	defaultInterval = 30 * time.Minute
+	minBackoff      = 1 * time.Minute
+	maxBackoff      = 6 * time.Hour
+
+	// sourceLimiterInterval caps how often any single source backend
+	// (youtube/piped/peertube) can be hit by scheduled or manual refreshes.
+	sourceLimiterInterval = 2 * time.Second
+
+	// leaseDuration bounds how long this instance holds a claimed feed
+	// before another instance sharing the database is free to steal it,
+	// should this instance die mid-refresh without releasing it. Refreshes
+	// running longer than half of this get their claim heartbeated so a
+	// slow (but alive) refresh doesn't lose its own lease.
+	leaseDuration = 10 * time.Minute
+
+	// workerHeartbeatInterval is how often this instance records itself as
+	// alive in sync_workers for GET /api/cluster/workers.
+	workerHeartbeatInterval = 1 * time.Minute
+)
+
+// RefreshFunc performs a single feed's refresh, returning an error if any
+// part of it failed. It's implemented by the api package, which already owns
+// the channel-fetching logic that both the scheduler and the manual refresh
+// endpoints share.
+type RefreshFunc func(ctx context.Context, feedID int64) error
+
+// Scheduler periodically refreshes feeds according to their own
+// refresh_interval_seconds, backing off exponentially on repeated failure.
+// Multiple Scheduler instances can share the same database - see
+// db.ClaimDueFeeds - to cooperatively refresh channels without duplicate
+// work, each identified by its own hostname.
+type Scheduler struct {
+	db       *db.DB
+	refresh  RefreshFunc
+	limiter  *RateLimiter
+	hostname string
+
+	jobs chan int64
+
+	backoffMu sync.Mutex
+	backoff   map[int64]time.Duration
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New creates a Scheduler. hostname identifies this instance's claims to
+// others sharing the same database; if empty, os.Hostname() is used,
+// falling back to "unknown" if that fails too. Call Start to begin polling.
+func New(database *db.DB, refresh RefreshFunc, hostname string) *Scheduler {
+	if hostname == "" {
+		var err error
+		hostname, err = os.Hostname()
+		if err != nil {
+			hostname = "unknown"
+		}
+	}
+	return &Scheduler{
+		db:       database,
+		refresh:  refresh,
+		limiter:  NewRateLimiter(sourceLimiterInterval),
+		jobs:     make(chan int64, jobQueueSize),
+		backoff:  make(map[int64]time.Duration),
+		hostname: hostname,
+	}
+}
+
+// Hostname returns the identity this instance claims feed leases under.
+func (s *Scheduler) Hostname() string {
+	return s.hostname
+}
+
+// Limiter returns the scheduler's per-source-backend rate limiter, shared
+// with manual refresh handlers so both draw from the same budget.
+func (s *Scheduler) Limiter() *RateLimiter {
+	return s.limiter
+}
+
+// Start launches the poll loop and its worker pool in the background. It
+// returns immediately; call Stop, or cancel ctx, to drain and shut down.
+func (s *Scheduler) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	s.cancel = cancel
+
+	for i := 0; i < maxWorkers; i++ {
+		s.wg.Add(1)
+		go s.worker(ctx)
+	}
+
+	s.wg.Add(1)
+	go s.pollLoop(ctx)
+
+	s.wg.Add(1)
+	go s.workerHeartbeatLoop(ctx)
+}
+
+// Stop cancels the poll loop and workers and waits for in-flight refreshes to
+// return, then releases every claim this instance held so peers don't wait
+// out the full lease before picking them back up.
+func (s *Scheduler) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+	if _, err := s.db.ReassignHostClaims(s.hostname); err != nil {
+		log.Printf("scheduler: failed to release claims on shutdown: %v", err)
+	}
+}
+
+// EnqueueNow schedules an immediate refresh for a feed, bypassing the poll
+// loop. Used by the manual refresh endpoints so they run through the same
+// worker pool and rate limiter as scheduled runs instead of fetching inline.
+func (s *Scheduler) EnqueueNow(feedID int64) {
+	select {
+	case s.jobs <- feedID:
+	default:
+		log.Printf("scheduler: job queue full, dropping manual refresh for feed %d", feedID)
+	}
+}
+
+func (s *Scheduler) pollLoop(ctx context.Context) {
+	defer s.wg.Done()
+
+	// Jitter the first tick so a fleet of instances restarting together
+	// doesn't all hit the same source backends at once.
+	jitter := time.Duration(rand.Int63n(int64(pollInterval)))
+	select {
+	case <-time.After(jitter):
+	case <-ctx.Done():
+		return
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.enqueueDue()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// enqueueDue claims every due, unclaimed-or-expired-lease feed under this
+// instance's hostname and schedules it - see db.ClaimDueFeeds. When no other
+// instance shares this database, every due feed is simply unclaimed and this
+// behaves the same as before clustering.
+func (s *Scheduler) enqueueDue() {
+	now := time.Now()
+	ids, err := s.db.ClaimDueFeeds(s.hostname, now, now.Add(leaseDuration))
+	if err != nil {
+		log.Printf("scheduler: failed to claim due feeds: %v", err)
+		return
+	}
+	for _, id := range ids {
+		s.EnqueueNow(id)
+	}
+}
+
+// workerHeartbeatLoop records this instance as alive in sync_workers on a
+// steady cadence, for GET /api/cluster/workers's observability view.
+func (s *Scheduler) workerHeartbeatLoop(ctx context.Context) {
+	defer s.wg.Done()
+
+	s.heartbeat()
+
+	ticker := time.NewTicker(workerHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.heartbeat()
+		}
+	}
+}
+
+func (s *Scheduler) heartbeat() {
+	if err := s.db.UpsertSyncWorkerHeartbeat(s.hostname); err != nil {
+		log.Printf("scheduler: failed to record heartbeat for %s: %v", s.hostname, err)
+	}
+}
+
+func (s *Scheduler) worker(ctx context.Context) {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case feedID := <-s.jobs:
+			s.runOne(ctx, feedID)
+		}
+	}
+}
+
+func (s *Scheduler) runOne(ctx context.Context, feedID int64) {
+	feed, err := s.db.GetFeedByID(feedID)
+	if err != nil {
+		log.Printf("scheduler: feed %d vanished before refresh: %v", feedID, err)
+		return
+	}
+
+	interval := time.Duration(feed.RefreshIntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	heartbeatCtx, stopHeartbeat := context.WithCancel(ctx)
+	go s.heartbeatClaim(heartbeatCtx, feedID)
+
+	err = s.refresh(ctx, feedID)
+	stopHeartbeat()
+
+	if err != nil {
+		backoff := s.nextBackoff(feedID)
+		log.Printf("scheduler: feed %d refresh failed, retrying in %s: %v", feedID, backoff, err)
+		if rErr := s.db.RecordFeedRefreshFailure(feedID, err.Error(), time.Now().Add(backoff)); rErr != nil {
+			log.Printf("scheduler: failed to record failure for feed %d: %v", feedID, rErr)
+		}
+	} else {
+		s.clearBackoff(feedID)
+		if rErr := s.db.RecordFeedRefreshSuccess(feedID, time.Now().Add(interval)); rErr != nil {
+			log.Printf("scheduler: failed to record success for feed %d: %v", feedID, rErr)
+		}
+	}
+
+	if rErr := s.db.ReleaseFeedClaim(feedID, s.hostname); rErr != nil {
+		log.Printf("scheduler: failed to release claim for feed %d: %v", feedID, rErr)
+	}
+}
+
+// heartbeatClaim periodically extends this instance's lease on feedID while
+// its refresh is still running, so a slow (but alive) refresh doesn't lose
+// its claim to another instance's ClaimDueFeeds mid-run. Stopped by runOne
+// the moment the refresh returns, via ctx.
+func (s *Scheduler) heartbeatClaim(ctx context.Context, feedID int64) {
+	ticker := time.NewTicker(leaseDuration / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.db.HeartbeatFeedClaim(feedID, s.hostname, time.Now().Add(leaseDuration)); err != nil {
+				log.Printf("scheduler: failed to heartbeat claim for feed %d: %v", feedID, err)
+			}
+		}
+	}
+}
+
+// nextBackoff doubles a feed's backoff on each consecutive failure, starting
+// at minBackoff and capping at maxBackoff, so a persistently broken feed
+// backs off instead of being retried every poll tick.
+func (s *Scheduler) nextBackoff(feedID int64) time.Duration {
+	s.backoffMu.Lock()
+	defer s.backoffMu.Unlock()
+
+	current, ok := s.backoff[feedID]
+	if !ok || current <= 0 {
+		current = minBackoff
+	} else {
+		current *= 2
+		if current > maxBackoff {
+			current = maxBackoff
+		}
+	}
+	s.backoff[feedID] = current
+	return current
+}
+
+func (s *Scheduler) clearBackoff(feedID int64) {
+	s.backoffMu.Lock()
+	delete(s.backoff, feedID)
+	s.backoffMu.Unlock()
+}
@@ -0,0 +1,115 @@
+package sources
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/erik/feeds/internal/models"
+)
+
+var peertubeChannelURL = regexp.MustCompile(`^https?://([^/]+)/(?:video-channels|c)/([^/?]+)`)
+
+// PeerTubeSource resolves and fetches videos from a PeerTube instance's
+// public REST API, so a Feed isn't limited to YouTube channels.
+type PeerTubeSource struct {
+	client *http.Client
+}
+
+func NewPeerTubeSource() *PeerTubeSource {
+	return &PeerTubeSource{client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (s *PeerTubeSource) Name() string { return "peertube" }
+
+func (s *PeerTubeSource) CanResolve(inputURL string) bool {
+	return peertubeChannelURL.MatchString(inputURL)
+}
+
+type peertubeChannelResponse struct {
+	DisplayName string `json:"displayName"`
+	Name        string `json:"name"`
+}
+
+func (s *PeerTubeSource) ResolveChannelURL(inputURL string) (*ChannelInfo, error) {
+	instance, handle, err := splitPeerTubeURL(inputURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var meta peertubeChannelResponse
+	apiURL := fmt.Sprintf("https://%s/api/v1/video-channels/%s", instance, handle)
+	if err := s.getJSON(apiURL, &meta); err != nil {
+		return nil, err
+	}
+
+	name := meta.DisplayName
+	if name == "" {
+		name = meta.Name
+	}
+
+	return &ChannelInfo{
+		ID:   instance + "/" + handle,
+		Name: name,
+		URL:  fmt.Sprintf("https://%s/video-channels/%s", instance, handle),
+	}, nil
+}
+
+type peertubeVideosResponse struct {
+	Data []struct {
+		UUID          string    `json:"uuid"`
+		Name          string    `json:"name"`
+		ThumbnailPath string    `json:"thumbnailPath"`
+		PublishedAt   time.Time `json:"publishedAt"`
+		Duration      int       `json:"duration"`
+	} `json:"data"`
+}
+
+func (s *PeerTubeSource) FetchLatestVideos(channelURL string, limit int) ([]models.Video, error) {
+	instance, handle, err := splitPeerTubeURL(channelURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var page peertubeVideosResponse
+	apiURL := fmt.Sprintf("https://%s/api/v1/video-channels/%s/videos?count=%d&sort=-publishedAt", instance, handle, limit)
+	if err := s.getJSON(apiURL, &page); err != nil {
+		return nil, err
+	}
+
+	videos := make([]models.Video, 0, len(page.Data))
+	for _, v := range page.Data {
+		videos = append(videos, models.Video{
+			ID:        v.UUID,
+			Title:     v.Name,
+			Thumbnail: fmt.Sprintf("https://%s%s", instance, v.ThumbnailPath),
+			Published: v.PublishedAt,
+			URL:       fmt.Sprintf("https://%s/videos/watch/%s", instance, v.UUID),
+			Duration:  v.Duration,
+		})
+	}
+	return videos, nil
+}
+
+func (s *PeerTubeSource) getJSON(url string, out any) error {
+	resp, err := s.client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("PeerTube API returned status %d for %s", resp.StatusCode, url)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func splitPeerTubeURL(inputURL string) (instance, handle string, err error) {
+	m := peertubeChannelURL.FindStringSubmatch(inputURL)
+	if m == nil {
+		return "", "", fmt.Errorf("not a PeerTube channel URL: %s", inputURL)
+	}
+	return m[1], m[2], nil
+}
@@ -0,0 +1,128 @@
+package sources
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/erik/feeds/internal/models"
+)
+
+// RSSSource is the generic catch-all backend: any Atom or RSS 2.0 feed
+// URL, used for arbitrary channels that aren't YouTube or PeerTube (e.g.
+// Nebula creator feeds, blogs with a video enclosure, etc). It should be
+// registered last so more specific backends get first refusal.
+type RSSSource struct {
+	client *http.Client
+}
+
+func NewRSSSource() *RSSSource {
+	return &RSSSource{client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (s *RSSSource) Name() string { return "rss" }
+
+func (s *RSSSource) CanResolve(inputURL string) bool {
+	return len(inputURL) > 0
+}
+
+type genericFeed struct {
+	Title   string         `xml:"title"`
+	Entries []genericEntry `xml:"entry"`         // Atom
+	Items   []genericEntry `xml:"channel>item"`  // RSS 2.0
+}
+
+type genericEntry struct {
+	Title     string      `xml:"title"`
+	Link      genericLink `xml:"link"`
+	GUID      string      `xml:"guid"`
+	ID        string      `xml:"id"`
+	Published string      `xml:"published"`
+	PubDate   string      `xml:"pubDate"`
+}
+
+type genericLink struct {
+	Href string `xml:"href,attr"`
+	Text string `xml:",chardata"`
+}
+
+func (s *RSSSource) ResolveChannelURL(inputURL string) (*ChannelInfo, error) {
+	feed, err := s.fetch(inputURL)
+	if err != nil {
+		return nil, err
+	}
+	return &ChannelInfo{ID: inputURL, Name: feed.Title, URL: inputURL}, nil
+}
+
+func (s *RSSSource) FetchLatestVideos(channelURL string, limit int) ([]models.Video, error) {
+	feed, err := s.fetch(channelURL)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := feed.Entries
+	if len(entries) == 0 {
+		entries = feed.Items
+	}
+
+	var videos []models.Video
+	for _, e := range entries {
+		if len(videos) >= limit {
+			break
+		}
+
+		link := e.Link.Href
+		if link == "" {
+			link = e.Link.Text
+		}
+		id := e.ID
+		if id == "" {
+			id = e.GUID
+		}
+		if id == "" {
+			id = link
+		}
+
+		videos = append(videos, models.Video{
+			ID:        id,
+			Title:     e.Title,
+			URL:       link,
+			Published: parseFeedTime(e.Published, e.PubDate),
+		})
+	}
+	return videos, nil
+}
+
+func (s *RSSSource) fetch(feedURL string) (*genericFeed, error) {
+	resp, err := s.client.Get(feedURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("feed returned status %d", resp.StatusCode)
+	}
+
+	var feed genericFeed
+	if err := xml.NewDecoder(resp.Body).Decode(&feed); err != nil {
+		return nil, fmt.Errorf("failed to parse feed: %w", err)
+	}
+	return &feed, nil
+}
+
+func parseFeedTime(values ...string) time.Time {
+	layouts := []string{time.RFC3339, time.RFC1123Z, time.RFC1123}
+	for _, v := range values {
+		if v == "" {
+			continue
+		}
+		for _, layout := range layouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t
+			}
+		}
+	}
+	return time.Now()
+}
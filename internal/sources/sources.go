@@ -0,0 +1,72 @@
+// Package sources defines a backend-agnostic interface for resolving
+// channels and fetching their videos, so a Feed can mix YouTube
+// subscriptions with PeerTube instances, Atom feeds, and other backends.
+package sources
+
+import "github.com/erik/feeds/internal/models"
+
+// ChannelInfo is the backend-agnostic result of resolving a channel URL.
+type ChannelInfo struct {
+	ID   string
+	Name string
+	URL  string
+
+	// SourceType classifies what kind of thing URL points at within this
+	// backend - "channel", "playlist", "user", or "group". "" is treated as
+	// "channel" by callers (models.Channel.SourceType), which covers every
+	// backend that doesn't yet distinguish.
+	SourceType string
+}
+
+// ChannelResolver turns a user-supplied URL into channel metadata.
+type ChannelResolver interface {
+	// CanResolve reports whether this backend recognizes the given URL.
+	CanResolve(inputURL string) bool
+	ResolveChannelURL(inputURL string) (*ChannelInfo, error)
+}
+
+// LatestFetcher fetches a channel's most recent videos.
+type LatestFetcher interface {
+	FetchLatestVideos(channelURL string, limit int) ([]models.Video, error)
+}
+
+// HistoricalFetcher fetches a windowed slice of a channel's upload
+// history, for paginated backfill beyond what LatestFetcher exposes.
+type HistoricalFetcher interface {
+	FetchVideoRange(channelURL string, start, end int) ([]models.Video, error)
+}
+
+// ShortsClassifier determines which of a batch of videos are shorts.
+type ShortsClassifier interface {
+	CheckShortsStatus(videoIDs []string) map[string]bool
+}
+
+// Source bundles the capabilities a video backend may offer. Not every
+// backend implements HistoricalFetcher or ShortsClassifier; callers should
+// type-assert for those and fall back gracefully when absent.
+type Source interface {
+	Name() string
+	ChannelResolver
+	LatestFetcher
+}
+
+// registry holds the known backends in priority order; the first one whose
+// CanResolve returns true handles a given URL.
+var registry []Source
+
+// Register adds a backend to the registry. The api package registers every
+// backend explicitly at startup, in priority order, with RSS last since it
+// is a catch-all.
+func Register(s Source) {
+	registry = append(registry, s)
+}
+
+// Resolve finds the first registered backend that recognizes inputURL.
+func Resolve(inputURL string) (Source, bool) {
+	for _, s := range registry {
+		if s.CanResolve(inputURL) {
+			return s, true
+		}
+	}
+	return nil, false
+}
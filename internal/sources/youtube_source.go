@@ -0,0 +1,61 @@
+package sources
+
+import (
+	"strings"
+
+	"github.com/erik/feeds/internal/models"
+	"github.com/erik/feeds/internal/youtube"
+	"github.com/erik/feeds/internal/ytdlp"
+)
+
+// YouTubeSource adapts the existing internal/youtube RSS-based resolver and
+// internal/ytdlp windowed fetcher to the generic Source interface.
+type YouTubeSource struct {
+	ytdlp ytdlp.Client
+}
+
+// NewYouTubeSource builds the YouTube backend. ydl is used only for the
+// historical (windowed) fetch path, which needs the yt-dlp CLI.
+func NewYouTubeSource(ydl ytdlp.Client) *YouTubeSource {
+	return &YouTubeSource{ytdlp: ydl}
+}
+
+func (s *YouTubeSource) Name() string { return "youtube" }
+
+// CanResolve recognizes youtube.com/youtu.be URLs, plus bare handles and
+// channel IDs (the historical default when no scheme is present).
+func (s *YouTubeSource) CanResolve(inputURL string) bool {
+	return strings.Contains(inputURL, "youtube.com") ||
+		strings.Contains(inputURL, "youtu.be") ||
+		!strings.Contains(inputURL, "://")
+}
+
+func (s *YouTubeSource) ResolveChannelURL(inputURL string) (*ChannelInfo, error) {
+	info, err := youtube.ResolveChannelURL(inputURL)
+	if err != nil {
+		return nil, err
+	}
+	return &ChannelInfo{ID: info.ID, Name: info.Name, URL: info.URL, SourceType: info.SourceType}, nil
+}
+
+func (s *YouTubeSource) FetchLatestVideos(channelURL string, limit int) ([]models.Video, error) {
+	return youtube.FetchLatestVideos(channelURL, limit, true)
+}
+
+// FetchVideoRange implements HistoricalFetcher via yt-dlp's
+// --playlist-start/--playlist-end windowing.
+func (s *YouTubeSource) FetchVideoRange(channelURL string, start, end int) ([]models.Video, error) {
+	infos, err := s.ytdlp.GetChannelVideos(channelURL, start, end)
+	if err != nil {
+		return nil, err
+	}
+	videos := make([]models.Video, len(infos))
+	for i, v := range infos {
+		videos[i] = *v.ToModel(0, "")
+	}
+	return videos, nil
+}
+
+func (s *YouTubeSource) CheckShortsStatus(videoIDs []string) map[string]bool {
+	return youtube.CheckShortsStatus(videoIDs)
+}
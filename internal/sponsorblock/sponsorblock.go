@@ -1,28 +1,44 @@
 package sponsorblock
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 )
 
 const (
 	BaseURL = "https://sponsor.ajay.app"
+
+	// hashPrefixLen is how many hex characters of a video ID's SHA-256 hash
+	// we send to the privacy-preserving lookup endpoint, matching the
+	// prefix length official SponsorBlock clients use.
+	hashPrefixLen = 4
 )
 
 // Segment categories
 const (
-	CategorySponsor     = "sponsor"
-	CategoryIntro       = "intro"
-	CategoryOutro       = "outro"
-	CategoryInteraction = "interaction"
-	CategorySelfpromo   = "selfpromo"
+	CategorySponsor       = "sponsor"
+	CategoryIntro         = "intro"
+	CategoryOutro         = "outro"
+	CategoryInteraction   = "interaction"
+	CategorySelfpromo     = "selfpromo"
 	CategoryMusicOfftopic = "music_offtopic"
-	CategoryPreview     = "preview"
-	CategoryFiller      = "filler"
+	CategoryPreview       = "preview"
+	CategoryFiller        = "filler"
+
+	// CategoryChapter marks a named chapter rather than a skippable
+	// segment; its ActionType is also "chapter" and Description carries
+	// the chapter's title. It's fetched separately from DefaultCategories
+	// since chapters are for navigation, not skipping - see
+	// (*db.DB).GetChaptersForVideo.
+	CategoryChapter = "chapter"
 )
 
 // DefaultCategories are the categories we fetch by default
@@ -35,43 +51,215 @@ var DefaultCategories = []string{
 	CategoryPreview,
 }
 
+// FetchCategories extends DefaultCategories with chapter markers, so
+// callers that persist every fetched segment (the background worker and
+// the on-demand segments endpoint) cache chapter names alongside
+// skippable segments instead of requiring a separate fetch - see
+// (*db.DB).GetChaptersForVideo.
+var FetchCategories = append(append([]string{}, DefaultCategories...), CategoryChapter)
+
+// AllCategories are every category a user can set a skip preference for,
+// including the two (music_offtopic, filler) we don't fetch by default
+// since they're rarely marked and noisier to act on automatically.
+var AllCategories = []string{
+	CategorySponsor,
+	CategorySelfpromo,
+	CategoryInteraction,
+	CategoryIntro,
+	CategoryOutro,
+	CategoryPreview,
+	CategoryMusicOfftopic,
+	CategoryFiller,
+}
+
+// Preference actions a user can configure per category. These mirror the
+// options official SponsorBlock clients expose: skip the segment outright,
+// mute audio through it, show a "skip" button without auto-skipping, or
+// ignore the category entirely.
+const (
+	ActionSkip     = "skip"
+	ActionMute     = "mute"
+	ActionShowonly = "showonly"
+	ActionDisabled = "disabled"
+)
+
+// ValidActions are the only values GetSponsorBlockPrefs/SetSponsorBlockPref
+// accept.
+var ValidActions = []string{ActionSkip, ActionMute, ActionShowonly, ActionDisabled}
+
+// IsValidCategory reports whether category is one a user can set a
+// preference for.
+func IsValidCategory(category string) bool {
+	for _, c := range AllCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidAction reports whether action is one of ValidActions.
+func IsValidAction(action string) bool {
+	for _, a := range ValidActions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
 // Segment represents a SponsorBlock segment
 type Segment struct {
-	UUID       string    `json:"UUID"`
-	Segment    [2]float64 `json:"segment"`
-	Category   string    `json:"category"`
-	ActionType string    `json:"actionType"`
-	Votes      int       `json:"votes"`
-	Locked     int       `json:"locked"`
+	UUID        string     `json:"UUID"`
+	Segment     [2]float64 `json:"segment"`
+	Category    string     `json:"category"`
+	ActionType  string     `json:"actionType"`
+	Votes       int        `json:"votes"`
+	Locked      int        `json:"locked"`
+	Description string     `json:"description"`
 }
 
 // Client is a SponsorBlock API client
 type Client struct {
 	httpClient *http.Client
 	baseURL    string
+
+	// PrivacyMode routes GetSegments through the hash-prefix lookup
+	// (GetSegmentsPrivate) instead of sending the plaintext video ID
+	// (GetSegmentsPlain). Defaults to true; see FEEDS_SPONSORBLOCK_PRIVACY_MODE
+	// in cmd/server.
+	PrivacyMode bool
 }
 
-// NewClient creates a new SponsorBlock client
+// NewClient creates a new SponsorBlock client with privacy mode on.
 func NewClient() *Client {
 	return &Client{
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
-		baseURL: BaseURL,
+		baseURL:     BaseURL,
+		PrivacyMode: true,
 	}
 }
 
-// GetSegments fetches segments for a video from SponsorBlock API
+// hashedLookupResult is one video's entry in the response to a hash-prefix
+// lookup, which returns every video sharing that prefix.
+type hashedLookupResult struct {
+	VideoID  string    `json:"videoID"`
+	Hash     string    `json:"hash"`
+	Segments []Segment `json:"segments"`
+}
+
+// hashPrefix returns the first hashPrefixLen hex characters of videoID's
+// SHA-256 hash, the value sent to the privacy-preserving lookup endpoint in
+// place of the plaintext ID.
+func hashPrefix(videoID string) string {
+	sum := sha256.Sum256([]byte(videoID))
+	return hex.EncodeToString(sum[:])[:hashPrefixLen]
+}
+
+// GetSegments fetches segments for a video, using the hash-prefix lookup
+// when c.PrivacyMode is set (the default) and the plaintext lookup
+// otherwise.
 func (c *Client) GetSegments(videoID string, categories []string) ([]Segment, error) {
+	if c.PrivacyMode {
+		return c.GetSegmentsPrivate(videoID, categories)
+	}
+	return c.GetSegmentsPlain(videoID, categories)
+}
+
+// GetSegmentsPrivate fetches segments for a video from the SponsorBlock API
+// using its privacy-preserving hash-prefix lookup: instead of sending the
+// video ID directly, it sends the first hashPrefixLen hex characters of its
+// SHA-256 hash and filters the (possibly multi-video) response locally, so
+// the server only learns that some video sharing that prefix was looked up,
+// not which one.
+func (c *Client) GetSegmentsPrivate(videoID string, categories []string) ([]Segment, error) {
+	results, err := c.fetchByPrefix(hashPrefix(videoID), categories)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range results {
+		if r.VideoID == videoID {
+			return r.Segments, nil
+		}
+	}
+	return []Segment{}, nil
+}
+
+// GetSegmentsForPrefix fetches segments for every video sharing prefix (the
+// first hashPrefixLen hex characters of a video ID's SHA-256 hash) in a
+// single request, keyed by video ID. It's how a caller with a batch of
+// video IDs - e.g. the background worker prefetching newly saved videos -
+// gets privacy-preserving lookups without one request per video: videos
+// that happen to share a prefix are resolved together.
+func (c *Client) GetSegmentsForPrefix(prefix string, categories []string) (map[string][]Segment, error) {
+	results, err := c.fetchByPrefix(prefix, categories)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string][]Segment, len(results))
+	for _, r := range results {
+		out[r.VideoID] = r.Segments
+	}
+	return out, nil
+}
+
+// HashPrefix exposes hashPrefix for callers (the background worker) that
+// need to group video IDs by shared prefix before calling
+// GetSegmentsForPrefix.
+func HashPrefix(videoID string) string {
+	return hashPrefix(videoID)
+}
+
+func (c *Client) fetchByPrefix(prefix string, categories []string) ([]hashedLookupResult, error) {
 	if len(categories) == 0 {
 		categories = DefaultCategories
 	}
 
-	// Build URL with categories
 	params := url.Values{}
-	params.Set("videoID", videoID)
+	categoriesJSON, _ := json.Marshal(categories)
+	params.Set("categories", string(categoriesJSON))
+
+	reqURL := fmt.Sprintf("%s/api/skipSegments/%s?%s", c.baseURL, prefix, params.Encode())
+
+	req, err := http.NewRequest(http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Feeds/1.0 (https://github.com/erik/feeds)")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// 404 means no segments found for anything sharing this prefix - that's fine
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("SponsorBlock API returned status %d", resp.StatusCode)
+	}
+
+	var results []hashedLookupResult
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return results, nil
+}
+
+// GetSegmentsPlain fetches segments for a video via SponsorBlock's ordinary
+// (non-privacy-preserving) lookup, sending videoID directly. Used only when
+// PrivacyMode is explicitly disabled.
+func (c *Client) GetSegmentsPlain(videoID string, categories []string) ([]Segment, error) {
+	if len(categories) == 0 {
+		categories = DefaultCategories
+	}
 
-	// Add categories as JSON array
+	params := url.Values{}
+	params.Set("videoID", videoID)
 	categoriesJSON, _ := json.Marshal(categories)
 	params.Set("categories", string(categoriesJSON))
 
@@ -81,7 +269,6 @@ func (c *Client) GetSegments(videoID string, categories []string) ([]Segment, er
 	if err != nil {
 		return nil, err
 	}
-
 	req.Header.Set("User-Agent", "Feeds/1.0 (https://github.com/erik/feeds)")
 
 	resp, err := c.httpClient.Do(req)
@@ -90,11 +277,9 @@ func (c *Client) GetSegments(videoID string, categories []string) ([]Segment, er
 	}
 	defer resp.Body.Close()
 
-	// 404 means no segments found - that's fine
 	if resp.StatusCode == http.StatusNotFound {
 		return []Segment{}, nil
 	}
-
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("SponsorBlock API returned status %d", resp.StatusCode)
 	}
@@ -103,10 +288,87 @@ func (c *Client) GetSegments(videoID string, categories []string) ([]Segment, er
 	if err := json.NewDecoder(resp.Body).Decode(&segments); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
-
 	return segments, nil
 }
 
+// HashUserID returns the SHA-256 hash of a locally-generated anonymous
+// submitter ID, the value SponsorBlock's submission and voting endpoints
+// expect in place of a raw ID - see (*db.DB).GetOrCreateSponsorBlockUserID
+// for where that ID comes from. Unlike hashPrefix this isn't truncated: the
+// full hash is SponsorBlock's standard scheme for anonymizing submitters,
+// not a privacy-preserving lookup key.
+func HashUserID(anonID string) string {
+	sum := sha256.Sum256([]byte(anonID))
+	return hex.EncodeToString(sum[:])
+}
+
+// SubmitSegment submits a new segment for videoID to SponsorBlock, credited
+// to the submitter identified by hashedUserID (see HashUserID).
+func (c *Client) SubmitSegment(videoID, hashedUserID string, startTime, endTime float64, category, actionType string) error {
+	params := url.Values{}
+	params.Set("videoID", videoID)
+	params.Set("userID", hashedUserID)
+	params.Set("startTime", strconv.FormatFloat(startTime, 'f', 2, 64))
+	params.Set("endTime", strconv.FormatFloat(endTime, 'f', 2, 64))
+	params.Set("category", category)
+	params.Set("actionType", actionType)
+
+	reqURL := fmt.Sprintf("%s/api/skipSegments?%s", c.baseURL, params.Encode())
+
+	req, err := http.NewRequest(http.MethodPost, reqURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "Feeds/1.0 (https://github.com/erik/feeds)")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("SponsorBlock API returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// VoteOnSegment casts hashedUserID's (see HashUserID) vote on an existing
+// segment identified by uuid. upvote is true for an upvote, false for a
+// downvote, matching SponsorBlock's voteOnSponsorTime type values (1/0).
+func (c *Client) VoteOnSegment(uuid, hashedUserID string, upvote bool) error {
+	voteType := "0"
+	if upvote {
+		voteType = "1"
+	}
+
+	params := url.Values{}
+	params.Set("UUID", uuid)
+	params.Set("userID", hashedUserID)
+	params.Set("type", voteType)
+
+	reqURL := fmt.Sprintf("%s/api/voteOnSponsorTime?%s", c.baseURL, params.Encode())
+
+	req, err := http.NewRequest(http.MethodPost, reqURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "Feeds/1.0 (https://github.com/erik/feeds)")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("SponsorBlock API returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
 // CategoryInfo returns human-readable info about a category
 func CategoryInfo(category string) (name string, color string) {
 	switch category {
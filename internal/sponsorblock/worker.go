@@ -0,0 +1,172 @@
+package sponsorblock
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/erik/feeds/internal/db"
+)
+
+const (
+	workerPollInterval = 1 * time.Minute
+	workerBatchSize    = 25
+
+	// workerFetchDelay paces requests within a batch so the worker stays
+	// well under SponsorBlock's public rate limit even when it has a large
+	// backlog of videos to catch up on.
+	workerFetchDelay = 1 * time.Second
+
+	// maxCacheAge matches the TTL handleAPIGetSegments uses for on-demand
+	// lookups, so the background worker refreshes a video no more eagerly
+	// than a user opening it would have anyway.
+	maxCacheAge = 24 * time.Hour
+)
+
+// Worker periodically fetches SponsorBlock segments for videos that have
+// never been looked up, or whose cached lookup has gone stale, so segments
+// are usually already warm by the time a video is opened instead of adding
+// fetch latency to first playback.
+type Worker struct {
+	db     *db.DB
+	client *Client
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewWorker creates a Worker. Call Start to begin polling.
+func NewWorker(database *db.DB, client *Client) *Worker {
+	return &Worker{db: database, client: client}
+}
+
+// Start launches the poll loop in the background. It returns immediately;
+// call Stop, or cancel ctx, to stop it.
+func (w *Worker) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	w.cancel = cancel
+
+	w.wg.Add(1)
+	go w.run(ctx)
+}
+
+// Stop cancels the poll loop and waits for the in-flight batch to finish.
+func (w *Worker) Stop() {
+	if w.cancel != nil {
+		w.cancel()
+	}
+	w.wg.Wait()
+}
+
+func (w *Worker) run(ctx context.Context) {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(workerPollInterval)
+	defer ticker.Stop()
+
+	for {
+		w.fetchBatch(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Worker) fetchBatch(ctx context.Context) {
+	videoIDs, err := w.db.GetVideosNeedingSponsorBlockFetch(workerBatchSize, maxCacheAge)
+	if err != nil {
+		log.Printf("sponsorblock worker: failed to list videos needing fetch: %v", err)
+		return
+	}
+
+	if w.client.PrivacyMode {
+		w.fetchBatchByPrefix(ctx, videoIDs)
+		return
+	}
+
+	for _, videoID := range videoIDs {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		segments, err := w.client.GetSegmentsPlain(videoID, FetchCategories)
+		if err != nil {
+			log.Printf("sponsorblock worker: failed to fetch segments for %s: %v", videoID, err)
+			continue
+		}
+		w.save(videoID, segments)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(workerFetchDelay):
+		}
+	}
+}
+
+// fetchBatchByPrefix is fetchBatch's privacy-mode path: since the
+// hash-prefix lookup already returns segments for every video sharing a
+// prefix, videos are grouped by prefix first so each prefix is looked up
+// with a single request - videos whose 4-hex-character prefixes collide
+// share one request, the rest still take one request each.
+func (w *Worker) fetchBatchByPrefix(ctx context.Context, videoIDs []string) {
+	byPrefix := make(map[string][]string)
+	for _, videoID := range videoIDs {
+		prefix := HashPrefix(videoID)
+		byPrefix[prefix] = append(byPrefix[prefix], videoID)
+	}
+
+	for prefix, ids := range byPrefix {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		found, err := w.client.GetSegmentsForPrefix(prefix, FetchCategories)
+		if err != nil {
+			log.Printf("sponsorblock worker: failed to fetch segments for prefix %s: %v", prefix, err)
+			continue
+		}
+
+		for _, videoID := range ids {
+			w.save(videoID, found[videoID])
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(workerFetchDelay):
+		}
+	}
+}
+
+// save caches segments for videoID, or marks it as fetched with nothing
+// found so the next poll doesn't look it up again before maxCacheAge.
+func (w *Worker) save(videoID string, segments []Segment) {
+	if len(segments) > 0 {
+		dbSegments := make([]db.SponsorBlockSegment, len(segments))
+		for i, seg := range segments {
+			dbSegments[i] = db.SponsorBlockSegment{
+				VideoID:     videoID,
+				SegmentUUID: seg.UUID,
+				StartTime:   seg.Segment[0],
+				EndTime:     seg.Segment[1],
+				Category:    seg.Category,
+				ActionType:  seg.ActionType,
+				Votes:       seg.Votes,
+				Description: seg.Description,
+			}
+		}
+		if err := w.db.SaveSponsorBlockSegments(videoID, dbSegments); err != nil {
+			log.Printf("sponsorblock worker: failed to cache segments for %s: %v", videoID, err)
+		}
+	} else if err := w.db.MarkSponsorBlockFetched(videoID); err != nil {
+		log.Printf("sponsorblock worker: failed to mark fetch for %s: %v", videoID, err)
+	}
+}
@@ -0,0 +1,98 @@
+package youtube
+
+import (
+	"iter"
+	"time"
+
+	"github.com/erik/feeds/internal/models"
+)
+
+// BackfillOptions bounds how far BackfillChannel pages back through a
+// channel's upload history. Both backends page newest-first, so either
+// limit stops the iteration early rather than filtering after the fact.
+type BackfillOptions struct {
+	// MaxVideos stops iteration once this many videos have been yielded.
+	// Zero means no limit.
+	MaxVideos int
+	// Since stops iteration once a video published before this time is
+	// seen. Zero means no limit.
+	Since time.Time
+}
+
+// BackfillChannel pages through a channel's entire upload history, yielding
+// one video at a time so a caller can stream them straight into storage
+// without holding the whole history in memory. It uses the YouTube Data
+// API's playlistItems.list endpoint against the channel's uploads playlist
+// (see ChannelBackfiller) when apiKey is set, since that's an order of
+// magnitude faster and more reliable than scraping; a Piped continuation-
+// token loop (see piped.Client.FetchChannelPage) is the fallback for callers
+// with no API key configured. Either way, iteration stops once opts.MaxVideos
+// or opts.Since is reached, the yield func returns false, or the source is
+// exhausted.
+func BackfillChannel(channelID, apiKey string, opts BackfillOptions) iter.Seq2[models.Video, error] {
+	if apiKey != "" {
+		return backfillViaDataAPI(channelID, apiKey, opts)
+	}
+	return backfillViaPiped(channelID, opts)
+}
+
+func backfillViaDataAPI(channelID, apiKey string, opts BackfillOptions) iter.Seq2[models.Video, error] {
+	return func(yield func(models.Video, error) bool) {
+		backfiller := NewChannelBackfiller(apiKey)
+		pageToken := ""
+		count := 0
+		for {
+			videos, nextPageToken, _, err := backfiller.FetchPage(channelID, pageToken)
+			if err != nil {
+				yield(models.Video{}, err)
+				return
+			}
+			for _, v := range videos {
+				if !opts.Since.IsZero() && v.Published.Before(opts.Since) {
+					return
+				}
+				if !yield(v, nil) {
+					return
+				}
+				count++
+				if opts.MaxVideos > 0 && count >= opts.MaxVideos {
+					return
+				}
+			}
+			if nextPageToken == "" {
+				return
+			}
+			pageToken = nextPageToken
+		}
+	}
+}
+
+func backfillViaPiped(channelID string, opts BackfillOptions) iter.Seq2[models.Video, error] {
+	return func(yield func(models.Video, error) bool) {
+		nextpage := ""
+		count := 0
+		for {
+			videos, next, err := pipedClient.FetchChannelPage(channelID, nextpage)
+			if err != nil {
+				yield(models.Video{}, err)
+				return
+			}
+			for _, v := range videos {
+				if !opts.Since.IsZero() && v.Published.Before(opts.Since) {
+					return
+				}
+				if !yield(v, nil) {
+					return
+				}
+				count++
+				if opts.MaxVideos > 0 && count >= opts.MaxVideos {
+					return
+				}
+			}
+			if next == "" {
+				return
+			}
+			nextpage = next
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package youtube
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/erik/feeds/internal/models"
+)
+
+// errQuotaExceeded marks a FetchPage failure caused by the Data API's daily
+// quota being exhausted (HTTP 403), as opposed to a transient or permanent
+// request error. Check with IsQuotaExceeded.
+var errQuotaExceeded = errors.New("youtube data api: quota exceeded")
+
+// IsQuotaExceeded reports whether err was caused by the Data API's quota
+// being exhausted, so callers can back off and retry later instead of
+// treating the page fetch as permanently failed.
+func IsQuotaExceeded(err error) bool {
+	return errors.Is(err, errQuotaExceeded)
+}
+
+// ChannelBackfiller pages through a channel's entire upload history using
+// the YouTube Data API's playlistItems endpoint against the channel's
+// uploads playlist, rather than yt-dlp's flat-playlist scraping. It requires
+// an API key (YOUTUBE_API_KEY); callers should fall back to the yt-dlp
+// BackfillManager when one isn't configured.
+type ChannelBackfiller struct {
+	APIKey     string
+	HTTPClient *http.Client
+}
+
+// NewChannelBackfiller creates a backfiller. apiKey may be empty, in which
+// case FetchPage always fails with an explanatory error.
+func NewChannelBackfiller(apiKey string) *ChannelBackfiller {
+	return &ChannelBackfiller{
+		APIKey:     apiKey,
+		HTTPClient: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// uploadsPlaylistID derives a channel's uploads playlist ID from its channel
+// ID by swapping the "UC" prefix for "UU", a stable YouTube convention that
+// avoids an extra channels.list call just to look it up.
+func uploadsPlaylistID(channelID string) (string, error) {
+	if !strings.HasPrefix(channelID, "UC") {
+		return "", fmt.Errorf("not a channel ID: %q", channelID)
+	}
+	return "UU" + strings.TrimPrefix(channelID, "UC"), nil
+}
+
+type playlistItemsResponse struct {
+	NextPageToken string `json:"nextPageToken"`
+	PageInfo      struct {
+		TotalResults int `json:"totalResults"`
+	} `json:"pageInfo"`
+	Items []struct {
+		Snippet struct {
+			Title       string `json:"title"`
+			ChannelID   string `json:"channelId"`
+			ChannelName string `json:"channelTitle"`
+			PublishedAt string `json:"publishedAt"`
+			Thumbnails  map[string]struct {
+				URL string `json:"url"`
+			} `json:"thumbnails"`
+			ResourceID struct {
+				VideoID string `json:"videoId"`
+			} `json:"resourceId"`
+		} `json:"snippet"`
+	} `json:"items"`
+}
+
+// FetchPage fetches one page (up to 50 videos) of a channel's upload
+// history, returning the videos, the token for the next page (empty when
+// there isn't one), and the channel's total known upload count.
+func (b *ChannelBackfiller) FetchPage(channelID, pageToken string) (videos []models.Video, nextPageToken string, total int, err error) {
+	if b.APIKey == "" {
+		return nil, "", 0, fmt.Errorf("YOUTUBE_API_KEY not configured")
+	}
+
+	playlistID, err := uploadsPlaylistID(channelID)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	q := url.Values{
+		"part":       {"snippet"},
+		"playlistId": {playlistID},
+		"maxResults": {"50"},
+		"key":        {b.APIKey},
+	}
+	if pageToken != "" {
+		q.Set("pageToken", pageToken)
+	}
+
+	resp, err := b.HTTPClient.Get("https://www.googleapis.com/youtube/v3/playlistItems?" + q.Encode())
+	if err != nil {
+		return nil, "", 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusForbidden {
+		return nil, "", 0, fmt.Errorf("%w: status %d", errQuotaExceeded, resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", 0, fmt.Errorf("youtube data api: unexpected status %d", resp.StatusCode)
+	}
+
+	var parsed playlistItemsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, "", 0, fmt.Errorf("youtube data api: decode response: %w", err)
+	}
+
+	for _, item := range parsed.Items {
+		s := item.Snippet
+		published, _ := time.Parse(time.RFC3339, s.PublishedAt)
+		thumb := thumbnailURL(s.Thumbnails, s.ResourceID.VideoID)
+		videos = append(videos, models.Video{
+			ID:          s.ResourceID.VideoID,
+			Title:       s.Title,
+			ChannelName: s.ChannelName,
+			Thumbnail:   thumb,
+			Published:   published,
+			URL:         "https://www.youtube.com/watch?v=" + s.ResourceID.VideoID,
+		})
+	}
+
+	return videos, parsed.NextPageToken, parsed.PageInfo.TotalResults, nil
+}
+
+func thumbnailURL(thumbs map[string]struct {
+	URL string `json:"url"`
+}, videoID string) string {
+	for _, key := range []string{"high", "medium", "default"} {
+		if t, ok := thumbs[key]; ok && t.URL != "" {
+			return t.URL
+		}
+	}
+	if videoID != "" {
+		return fmt.Sprintf("https://i.ytimg.com/vi/%s/hqdefault.jpg", videoID)
+	}
+	return ""
+}
@@ -0,0 +1,180 @@
+package youtube
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/erik/feeds/internal/invidious"
+	"github.com/erik/feeds/internal/models"
+)
+
+// Chain tries a sequence of Sources in order for the YouTube import flow:
+// each Source already rotates across its own instance pool and disables
+// failing instances for a cooldown (see piped.Client/invidious.Client), so
+// Chain only needs to fall back to the next Source entirely once a
+// Source's whole pool is exhausted.
+type Chain struct {
+	mu      sync.RWMutex
+	sources []Source
+}
+
+// NewChain builds a chain that tries the given sources in order.
+func NewChain(sources ...Source) *Chain {
+	return &Chain{sources: sources}
+}
+
+// DefaultChain is yt's package-level import path: Piped first (fastest,
+// no yt-dlp/scraping), Invidious next, and the official RSS/oEmbed path
+// last as the slowest but always-available fallback. Reconfigure its
+// instance pools via SetPipedInstances/SetInvidiousInstances.
+var DefaultChain = NewChain(
+	&pipedSource{client: pipedClient},
+	&invidiousSource{client: invidiousClient},
+	officialSource{},
+)
+
+var invidiousClient = invidious.NewClient(nil)
+
+// SetInvidiousInstances reconfigures the pool of Invidious API hosts
+// backing the invidious Source.
+func SetInvidiousInstances(instances []string) {
+	invidiousClient.SetInstances(instances)
+}
+
+// InvidiousInstanceStatus returns each configured Invidious instance and
+// its disabled-until time (the zero value means healthy).
+func InvidiousInstanceStatus() map[string]time.Time {
+	return invidiousClient.Instances()
+}
+
+func (c *Chain) ResolveChannelURL(inputURL string) (*ChannelInfo, error) {
+	var lastErr error
+	for _, s := range c.order() {
+		info, err := s.ResolveChannelURL(inputURL)
+		if err == nil {
+			return info, nil
+		}
+		lastErr = fmt.Errorf("%s: %w", s.Name(), err)
+	}
+	return nil, fmt.Errorf("all sources failed: %w", lastErr)
+}
+
+func (c *Chain) ResolveVideoToChannel(videoURL string) (*ChannelInfo, error) {
+	var lastErr error
+	for _, s := range c.order() {
+		info, err := s.ResolveVideoToChannel(videoURL)
+		if err == nil {
+			return info, nil
+		}
+		lastErr = fmt.Errorf("%s: %w", s.Name(), err)
+	}
+	return nil, fmt.Errorf("all sources failed: %w", lastErr)
+}
+
+func (c *Chain) FetchLatestVideos(channelURL string, limit int, includeShorts bool) ([]models.Video, error) {
+	videos, _, err := c.FetchLatestVideosVia(channelURL, limit, includeShorts)
+	return videos, err
+}
+
+// FetchLatestVideosVia behaves like FetchLatestVideos but also reports which
+// Source's name served the result, so callers can tell whether
+// includeShorts was actually honored (only officialSource's UULF playlist
+// swap, see FetchLatestVideos in rss.go, excludes Shorts server-side; Piped
+// and Invidious ignore includeShorts entirely).
+func (c *Chain) FetchLatestVideosVia(channelURL string, limit int, includeShorts bool) ([]models.Video, string, error) {
+	var lastErr error
+	for _, s := range c.order() {
+		videos, err := s.FetchLatestVideos(channelURL, limit, includeShorts)
+		if err == nil && len(videos) > 0 {
+			return videos, s.Name(), nil
+		}
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %w", s.Name(), err)
+		}
+	}
+	if lastErr != nil {
+		return nil, "", fmt.Errorf("all sources failed: %w", lastErr)
+	}
+	return nil, "", nil
+}
+
+// CheckShortsStatus tries each source in order until one classifies at
+// least one of the requested videos, since a partial result from an
+// earlier source is still more useful than an error from it.
+func (c *Chain) CheckShortsStatus(videoIDs []string) map[string]bool {
+	for _, s := range c.order() {
+		if result := s.CheckShortsStatus(videoIDs); len(result) > 0 {
+			return result
+		}
+	}
+	return map[string]bool{}
+}
+
+// Health reports, per source, its configured instances and when each will
+// next be retried (zero time means healthy). Sources with no instance
+// pool of their own (the official path) are omitted.
+func (c *Chain) Health() map[string]map[string]time.Time {
+	health := make(map[string]map[string]time.Time)
+	for _, s := range c.order() {
+		if statuser, ok := s.(instanceStatuser); ok {
+			health[s.Name()] = statuser.InstanceStatus()
+		}
+	}
+	return health
+}
+
+// order returns a snapshot of the chain's current source order.
+func (c *Chain) order() []Source {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	sources := make([]Source, len(c.sources))
+	copy(sources, c.sources)
+	return sources
+}
+
+// Order returns the names of the chain's sources in the order they're
+// currently tried.
+func (c *Chain) Order() []string {
+	sources := c.order()
+	names := make([]string, len(sources))
+	for i, s := range sources {
+		names[i] = s.Name()
+	}
+	return names
+}
+
+// SetOrder reorders the chain's sources to match names, which must be a
+// permutation of the chain's existing source names (see Order). It lets
+// operators prefer, say, Piped over the official RSS path on networks where
+// YouTube itself is blocked.
+func (c *Chain) SetOrder(names []string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(names) != len(c.sources) {
+		return fmt.Errorf("source order must name exactly %d sources, got %d", len(c.sources), len(names))
+	}
+
+	byName := make(map[string]Source, len(c.sources))
+	for _, s := range c.sources {
+		byName[s.Name()] = s
+	}
+
+	reordered := make([]Source, len(names))
+	seen := make(map[string]bool, len(names))
+	for i, name := range names {
+		s, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("unknown source %q", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("duplicate source %q", name)
+		}
+		seen[name] = true
+		reordered[i] = s
+	}
+
+	c.sources = reordered
+	return nil
+}
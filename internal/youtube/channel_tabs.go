@@ -0,0 +1,50 @@
+package youtube
+
+import (
+	"fmt"
+
+	"github.com/erik/feeds/internal/models"
+)
+
+// Channel tab identifiers accepted by FetchChannelTab, matching the tabs
+// Invidious and YouTube itself split a channel page into.
+const (
+	TabVideos    = "videos"
+	TabShorts    = "shorts"
+	TabStreams   = "streams"
+	TabPlaylists = "playlists"
+)
+
+// FetchChannelTab fetches up to limit videos from a single tab of a
+// channel's page via Piped's continuation-token /channels/tabs endpoint,
+// paging until limit is reached or the tab is exhausted. Each returned
+// video has Tab set to the requested tab, so callers can upsert it without
+// tracking that separately (see db.UpsertVideo).
+func FetchChannelTab(channelID, tab string, limit int) ([]models.Video, error) {
+	switch tab {
+	case TabVideos, TabShorts, TabStreams, TabPlaylists:
+	default:
+		return nil, fmt.Errorf("youtube: unknown channel tab %q", tab)
+	}
+
+	var results []models.Video
+	continuation := ""
+	for len(results) < limit {
+		videos, next, err := pipedClient.FetchChannelTab(channelID, tab, continuation)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range videos {
+			v.Tab = tab
+			results = append(results, v)
+			if len(results) >= limit {
+				break
+			}
+		}
+		if next == "" {
+			break
+		}
+		continuation = next
+	}
+	return results, nil
+}
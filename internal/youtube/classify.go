@@ -0,0 +1,141 @@
+package youtube
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// classifyShortsMaxSecs mirrors piped.shortsMaxSecs for videos classified
+// via the watch-page scrape fallback, which has no package of its own to
+// share the constant with.
+const classifyShortsMaxSecs = 60
+
+// VideoMeta is a video's duration, shorts/livestream status, and (when
+// known) premiere start time, gathered in one pass by ClassifyVideos. Live
+// status here is a fast hint for newly-seen videos - internal/livestream's
+// poller remains the authority for ongoing upcoming/live/ended transitions
+// and scheduled/actual start timestamps.
+type VideoMeta struct {
+	Duration   int
+	IsShort    bool
+	IsLive     bool
+	PremiereAt *time.Time
+}
+
+// ClassifyVideos fetches duration, shorts, and livestream status for a
+// batch of video IDs in one pass, trying the fast Piped path first and
+// falling back to scraping each watch page's embedded player JSON for any
+// IDs Piped couldn't classify.
+func ClassifyVideos(videoIDs []string) map[string]VideoMeta {
+	results := make(map[string]VideoMeta)
+	if len(videoIDs) == 0 {
+		return results
+	}
+
+	for id, c := range pipedClient.ClassifyVideos(videoIDs) {
+		results[id] = VideoMeta{Duration: int(c.Duration), IsShort: c.IsShort, IsLive: c.IsLive}
+	}
+
+	var missing []string
+	for _, id := range videoIDs {
+		if _, ok := results[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 {
+		return results
+	}
+
+	for id, meta := range classifyVideosViaScrape(missing) {
+		results[id] = meta
+	}
+	return results
+}
+
+var (
+	lengthSecondsRegex  = regexp.MustCompile(`"lengthSeconds":"(\d+)"`)
+	isLiveRegex         = regexp.MustCompile(`"isLive":(true|false)`)
+	isUpcomingRegex     = regexp.MustCompile(`"isUpcoming":(true|false)`)
+	startTimestampRegex = regexp.MustCompile(`"startTimestamp":"([^"]+)"`)
+)
+
+// classifyVideosViaScrape is the fallback for videos no Piped instance
+// could classify: it fetches each watch page directly and extracts
+// lengthSeconds and the premiere start time from the embedded
+// ytInitialPlayerResponse JSON.
+func classifyVideosViaScrape(videoIDs []string) map[string]VideoMeta {
+	results := make(map[string]VideoMeta)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 5)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	for _, id := range videoIDs {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			meta, err := scrapeVideoMeta(client, id)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			results[id] = meta
+			mu.Unlock()
+		}(id)
+	}
+
+	wg.Wait()
+	return results
+}
+
+func scrapeVideoMeta(client *http.Client, videoID string) (VideoMeta, error) {
+	resp, err := client.Get(fmt.Sprintf("https://www.youtube.com/watch?v=%s", videoID))
+	if err != nil {
+		return VideoMeta{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return VideoMeta{}, fmt.Errorf("watch page returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return VideoMeta{}, err
+	}
+
+	match := lengthSecondsRegex.FindSubmatch(body)
+	if match == nil {
+		return VideoMeta{}, fmt.Errorf("lengthSeconds not found for video %s", videoID)
+	}
+	seconds, err := strconv.Atoi(string(match[1]))
+	if err != nil {
+		return VideoMeta{}, err
+	}
+
+	meta := VideoMeta{
+		Duration: seconds,
+		IsShort:  seconds > 0 && seconds <= classifyShortsMaxSecs,
+	}
+	if m := isLiveRegex.FindSubmatch(body); m != nil && string(m[1]) == "true" {
+		meta.IsLive = true
+	}
+	if m := isUpcomingRegex.FindSubmatch(body); m != nil && string(m[1]) == "true" {
+		if m := startTimestampRegex.FindSubmatch(body); m != nil {
+			if t, err := time.Parse(time.RFC3339, string(m[1])); err == nil {
+				meta.PremiereAt = &t
+			}
+		}
+	}
+	return meta, nil
+}
@@ -0,0 +1,24 @@
+package youtube
+
+import (
+	"time"
+
+	"github.com/erik/feeds/internal/piped"
+)
+
+// pipedClient is yt's fast, no-yt-dlp path: it's tried first for video
+// listing and shorts classification, falling back to the RSS feed and
+// HTTP scrape below only once every configured instance has failed.
+var pipedClient = piped.NewClient(nil)
+
+// SetPipedInstances reconfigures the pool of Piped API hosts
+// backing the fast path.
+func SetPipedInstances(instances []string) {
+	pipedClient.SetInstances(instances)
+}
+
+// PipedInstanceStatus returns each configured instance and its
+// disabled-until time (the zero value means healthy).
+func PipedInstanceStatus() map[string]time.Time {
+	return pipedClient.Instances()
+}
@@ -1,10 +1,12 @@
 package youtube
 
 import (
+	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strings"
 	"sync"
@@ -35,12 +37,17 @@ type Author struct {
 var channelIDRegex = regexp.MustCompile(`/channel/([^/]+)`)
 var handleRegex = regexp.MustCompile(`/@([^/]+)`)
 var videoIDRegex = regexp.MustCompile(`(?:v=|youtu\.be/|shorts/)([a-zA-Z0-9_-]{11})`)
+var playlistIDRegex = regexp.MustCompile(`[?&]list=([a-zA-Z0-9_-]+)`)
 
 // ChannelInfo contains basic channel metadata
 type ChannelInfo struct {
 	ID   string
 	Name string
 	URL  string
+
+	// SourceType is "playlist" when URL pointed at a playlist rather than a
+	// channel (see ExtractPlaylistID), "" ("channel") otherwise.
+	SourceType string
 }
 
 // Common shorts indicators in titles
@@ -65,6 +72,16 @@ func ExtractVideoID(url string) string {
 	return ""
 }
 
+// ExtractPlaylistID extracts the playlist ID from a YouTube playlist URL
+// (e.g. youtube.com/playlist?list=ID, or a video URL with a list= param).
+func ExtractPlaylistID(url string) string {
+	matches := playlistIDRegex.FindStringSubmatch(url)
+	if len(matches) > 1 {
+		return matches[1]
+	}
+	return ""
+}
+
 // ResolveChannelURL takes any YouTube channel URL format and returns channel info
 // Supports: /channel/ID, /@handle, /c/customname, /user/username
 func ResolveChannelURL(inputURL string) (*ChannelInfo, error) {
@@ -74,6 +91,12 @@ func ResolveChannelURL(inputURL string) (*ChannelInfo, error) {
 		inputURL = "https://www.youtube.com/" + strings.TrimPrefix(inputURL, "/")
 	}
 
+	// A list= param means this is a playlist, not a channel - resolve it via
+	// the playlist RSS feed instead of treating it as a channel/handle URL.
+	if playlistID := ExtractPlaylistID(inputURL); playlistID != "" {
+		return fetchPlaylistInfoByID(playlistID)
+	}
+
 	// If it's already a /channel/ URL, try RSS directly
 	if channelID := ExtractChannelID(inputURL); channelID != "" {
 		return fetchChannelInfoByID(channelID)
@@ -125,8 +148,58 @@ func ResolveChannelURL(inputURL string) (*ChannelInfo, error) {
 	return nil, fmt.Errorf("could not find channel ID for URL: %s", inputURL)
 }
 
-// fetchChannelInfoByID fetches channel info from RSS feed
+// oEmbedResponse is the subset of YouTube's oEmbed response we need.
+type oEmbedResponse struct {
+	AuthorName string `json:"author_name"`
+	AuthorURL  string `json:"author_url"`
+}
+
+// ResolveVideoToChannel resolves a video URL to its uploading channel via
+// YouTube's oEmbed endpoint, which requires no API key. The oEmbed
+// response's author_url is itself a channel URL (by /channel/ID or
+// /@handle), so it's handed to ResolveChannelURL to fill in the rest.
+func ResolveVideoToChannel(videoURL string) (*ChannelInfo, error) {
+	videoID := ExtractVideoID(videoURL)
+	if videoID == "" {
+		return nil, fmt.Errorf("could not extract video ID from URL: %s", videoURL)
+	}
+
+	oEmbedURL := "https://www.youtube.com/oembed?format=json&url=" +
+		url.QueryEscape(fmt.Sprintf("https://www.youtube.com/watch?v=%s", videoID))
+
+	resp, err := http.Get(oEmbedURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch oEmbed info: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("oEmbed returned status %d", resp.StatusCode)
+	}
+
+	var oe oEmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&oe); err != nil {
+		return nil, fmt.Errorf("failed to parse oEmbed response: %w", err)
+	}
+	if oe.AuthorURL == "" {
+		return nil, fmt.Errorf("oEmbed response missing author_url for video %s", videoID)
+	}
+
+	return ResolveChannelURL(oe.AuthorURL)
+}
+
+// fetchChannelInfoByID fetches channel info, preferring the fast Piped
+// path and falling back to the channel's RSS feed when every Piped
+// instance is unavailable.
 func fetchChannelInfoByID(channelID string) (*ChannelInfo, error) {
+	if name, err := pipedClient.ResolveChannel(channelID); err == nil && name != "" {
+		return &ChannelInfo{
+			ID:   channelID,
+			Name: name,
+			URL:  fmt.Sprintf("https://www.youtube.com/channel/%s", channelID),
+		}, nil
+	}
+
 	rssURL := fmt.Sprintf("https://www.youtube.com/feeds/videos.xml?channel_id=%s", channelID)
 
 	resp, err := http.Get(rssURL)
@@ -160,14 +233,63 @@ func fetchChannelInfoByID(channelID string) (*ChannelInfo, error) {
 	}, nil
 }
 
-// FetchLatestVideos fetches latest videos from a channel's RSS feed
-// If filterShorts is true, it will make additional HTTP requests to check each video
-func FetchLatestVideos(channelURL string, limit int) ([]models.Video, error) {
-	return FetchLatestVideosFiltered(channelURL, limit, false)
+// fetchPlaylistInfoByID fetches a playlist's metadata via its RSS feed,
+// the playlist_id equivalent of fetchChannelInfoByID.
+func fetchPlaylistInfoByID(playlistID string) (*ChannelInfo, error) {
+	rssURL := fmt.Sprintf("https://www.youtube.com/feeds/videos.xml?playlist_id=%s", playlistID)
+
+	resp, err := http.Get(rssURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch playlist RSS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("playlist RSS returned status %d", resp.StatusCode)
+	}
+
+	var feed Feed
+	if err := xml.NewDecoder(resp.Body).Decode(&feed); err != nil {
+		return nil, fmt.Errorf("failed to parse playlist RSS: %w", err)
+	}
+
+	name := strings.TrimSuffix(feed.Title, " - YouTube")
+	if name == "" && len(feed.Entries) > 0 {
+		name = feed.Entries[0].Author.Name
+	}
+
+	return &ChannelInfo{
+		ID:         playlistID,
+		Name:       name,
+		URL:        fmt.Sprintf("https://www.youtube.com/playlist?list=%s", playlistID),
+		SourceType: "playlist",
+	}, nil
 }
 
-// FetchLatestVideosFiltered fetches latest videos with optional shorts filtering
-func FetchLatestVideosFiltered(channelURL string, limit int, checkShortsURL bool) ([]models.Video, error) {
+// FetchLatestVideos fetches the latest videos from a channel's RSS feed.
+// includeShorts controls whether Shorts are excluded: when false and the
+// channel resolves to a "UC"-prefixed ID, the fetch swaps in YouTube's
+// auto-generated "Long-form videos" playlist (UULF+channelID[2:]), which
+// YouTube itself already filters Shorts out of - skipping the per-video
+// checkShortsURL probe entirely for that channel. Other channel ID shapes,
+// or includeShorts true, fall back to the historical channel_id feed.
+func FetchLatestVideos(channelURL string, limit int, includeShorts bool) ([]models.Video, error) {
+	return FetchLatestVideosFiltered(channelURL, limit, false, includeShorts)
+}
+
+// FetchLatestVideosFiltered fetches latest videos with optional shorts
+// filtering. checkShortsURL additionally makes a per-video HTTP request
+// (via IsShort) to catch shorts the title-hashtag heuristic misses;
+// includeShorts false prefers the UULF long-form playlist (see
+// FetchLatestVideos) over that probe wherever the channel ID supports it.
+func FetchLatestVideosFiltered(channelURL string, limit int, checkShortsURL bool, includeShorts bool) ([]models.Video, error) {
+	// Playlists are fetched via their own RSS endpoint - a playlist has no
+	// UULF long-form swap or Piped channel lookup, so it's kept as its own
+	// short-circuit rather than threading through the channel path below.
+	if playlistID := ExtractPlaylistID(channelURL); playlistID != "" {
+		return fetchVideosFromPlaylistID(playlistID, limit)
+	}
+
 	// First try direct channel ID extraction
 	channelID := ExtractChannelID(channelURL)
 
@@ -180,7 +302,39 @@ func FetchLatestVideosFiltered(channelURL string, limit int, checkShortsURL bool
 		channelID = info.ID
 	}
 
-	rssURL := fmt.Sprintf("https://www.youtube.com/feeds/videos.xml?channel_id=%s", channelID)
+	useLongFormPlaylist := !includeShorts && strings.HasPrefix(channelID, "UC")
+
+	// Try the fast Piped path first, requesting extra in case some results
+	// get filtered out as shorts below. The long-form playlist swap below is
+	// RSS-specific (Piped has no equivalent playlist endpoint), so this path
+	// still relies on the checkShortsURL probe when includeShorts is false.
+	if !useLongFormPlaylist {
+		if pipedVideos, err := pipedClient.FetchLatestVideos(channelID, limit*2); err == nil && len(pipedVideos) > 0 {
+			filtered := make([]models.Video, 0, limit)
+			for _, v := range pipedVideos {
+				if len(filtered) >= limit {
+					break
+				}
+				if hasShortsHashtag(v.Title) {
+					continue
+				}
+				if checkShortsURL && v.IsShort != nil && *v.IsShort {
+					continue
+				}
+				filtered = append(filtered, v)
+			}
+			if len(filtered) > 0 {
+				return filtered, nil
+			}
+		}
+	}
+
+	var rssURL string
+	if useLongFormPlaylist {
+		rssURL = fmt.Sprintf("https://www.youtube.com/feeds/videos.xml?playlist_id=UULF%s", channelID[2:])
+	} else {
+		rssURL = fmt.Sprintf("https://www.youtube.com/feeds/videos.xml?channel_id=%s", channelID)
+	}
 
 	resp, err := http.Get(rssURL)
 	if err != nil {
@@ -210,8 +364,9 @@ func FetchLatestVideosFiltered(channelURL string, limit int, checkShortsURL bool
 		if hasShortsHashtag(entry.Title) {
 			continue
 		}
-		// Optionally check URL (slower but more accurate)
-		if checkShortsURL && IsShort(videoID) {
+		// Optionally check URL (slower but more accurate) - not needed when
+		// the long-form playlist already excluded shorts server-side.
+		if !useLongFormPlaylist && checkShortsURL && IsShort(videoID) {
 			continue
 		}
 
@@ -228,6 +383,47 @@ func FetchLatestVideosFiltered(channelURL string, limit int, checkShortsURL bool
 	return videos, nil
 }
 
+// fetchVideosFromPlaylistID fetches a playlist's videos via its RSS feed.
+// Playlists have no Piped fast-path and no shorts-filtering heuristics of
+// their own - a playlist is assumed to already be curated to what its
+// owner wants in it.
+func fetchVideosFromPlaylistID(playlistID string, limit int) ([]models.Video, error) {
+	rssURL := fmt.Sprintf("https://www.youtube.com/feeds/videos.xml?playlist_id=%s", playlistID)
+
+	resp, err := http.Get(rssURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch playlist RSS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("playlist RSS returned status %d", resp.StatusCode)
+	}
+
+	var feed Feed
+	if err := xml.NewDecoder(resp.Body).Decode(&feed); err != nil {
+		return nil, fmt.Errorf("failed to parse playlist RSS: %w", err)
+	}
+
+	var videos []models.Video
+	for _, entry := range feed.Entries {
+		if len(videos) >= limit {
+			break
+		}
+		videoID := strings.TrimPrefix(entry.VideoID, "yt:")
+		videos = append(videos, models.Video{
+			ID:          videoID,
+			Title:       entry.Title,
+			ChannelName: entry.Author.Name,
+			Thumbnail:   fmt.Sprintf("https://i.ytimg.com/vi/%s/hqdefault.jpg", videoID),
+			Published:   entry.Published,
+			URL:         fmt.Sprintf("https://www.youtube.com/watch?v=%s", videoID),
+		})
+	}
+
+	return videos, nil
+}
+
 // hasShortsHashtag checks if title contains shorts hashtags
 func hasShortsHashtag(title string) bool {
 	titleLower := strings.ToLower(title)
@@ -257,9 +453,35 @@ func IsShort(videoID string) bool {
 	return resp.StatusCode == 200
 }
 
-// CheckShortsStatus checks multiple video IDs and returns a map of videoID -> isShort
-// Uses concurrent requests with a limit to avoid overwhelming the server
+// CheckShortsStatus checks multiple video IDs and returns a map of videoID -> isShort.
+// It tries the fast Piped path first and only falls back to the slower
+// HTTP scrape below for IDs no Piped instance could classify.
 func CheckShortsStatus(videoIDs []string) map[string]bool {
+	if len(videoIDs) == 0 {
+		return map[string]bool{}
+	}
+
+	results := pipedClient.CheckShortsStatus(videoIDs)
+
+	var missing []string
+	for _, id := range videoIDs {
+		if _, ok := results[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 {
+		return results
+	}
+
+	for id, isShort := range checkShortsStatusViaScrape(missing) {
+		results[id] = isShort
+	}
+	return results
+}
+
+// checkShortsStatusViaScrape is the original HEAD-request-based shorts
+// check, used as a fallback for video IDs Piped couldn't classify.
+func checkShortsStatusViaScrape(videoIDs []string) map[string]bool {
 	results := make(map[string]bool)
 	if len(videoIDs) == 0 {
 		return results
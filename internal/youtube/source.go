@@ -0,0 +1,155 @@
+package youtube
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/erik/feeds/internal/invidious"
+	"github.com/erik/feeds/internal/models"
+	"github.com/erik/feeds/internal/piped"
+)
+
+// Source is the common interface behind every way yt can resolve channels
+// and fetch videos: the official RSS/oEmbed path, and the Piped/Invidious
+// API pools. Chain tries Sources in order, so the import flow degrades
+// gracefully instead of failing outright when one backend is down.
+type Source interface {
+	Name() string
+	ResolveChannelURL(inputURL string) (*ChannelInfo, error)
+	ResolveVideoToChannel(videoURL string) (*ChannelInfo, error)
+	// FetchLatestVideos fetches a channel's most recent uploads.
+	// includeShorts is honored only by officialSource, which can swap in
+	// YouTube's UULF long-form playlist to exclude them server-side (see
+	// FetchLatestVideos in rss.go); other sources have no such playlist and
+	// always return whatever their own endpoint gives them.
+	FetchLatestVideos(channelURL string, limit int, includeShorts bool) ([]models.Video, error)
+	CheckShortsStatus(videoIDs []string) map[string]bool
+}
+
+// instanceStatuser is implemented by Sources backed by a pool of
+// third-party instances, so Chain can report their health.
+type instanceStatuser interface {
+	InstanceStatus() map[string]time.Time
+}
+
+// officialSource is the zero-config path: YouTube's own RSS feeds and
+// oEmbed endpoint. It has no instance pool of its own to report on.
+type officialSource struct{}
+
+func (officialSource) Name() string { return "official" }
+
+func (officialSource) ResolveChannelURL(inputURL string) (*ChannelInfo, error) {
+	return ResolveChannelURL(inputURL)
+}
+
+func (officialSource) ResolveVideoToChannel(videoURL string) (*ChannelInfo, error) {
+	return ResolveVideoToChannel(videoURL)
+}
+
+func (officialSource) FetchLatestVideos(channelURL string, limit int, includeShorts bool) ([]models.Video, error) {
+	return FetchLatestVideos(channelURL, limit, includeShorts)
+}
+
+func (officialSource) CheckShortsStatus(videoIDs []string) map[string]bool {
+	return CheckShortsStatus(videoIDs)
+}
+
+// pipedSource adapts a piped.Client to the Source interface.
+type pipedSource struct {
+	client *piped.Client
+}
+
+func (s *pipedSource) Name() string { return "piped" }
+
+func (s *pipedSource) ResolveChannelURL(inputURL string) (*ChannelInfo, error) {
+	channelID := ExtractChannelID(inputURL)
+	if channelID == "" {
+		return nil, fmt.Errorf("piped: not a resolvable channel URL: %s", inputURL)
+	}
+	name, err := s.client.ResolveChannel(channelID)
+	if err != nil {
+		return nil, err
+	}
+	return &ChannelInfo{ID: channelID, Name: name, URL: "https://www.youtube.com/channel/" + channelID}, nil
+}
+
+func (s *pipedSource) ResolveVideoToChannel(videoURL string) (*ChannelInfo, error) {
+	videoID := ExtractVideoID(videoURL)
+	if videoID == "" {
+		return nil, fmt.Errorf("piped: could not extract video ID from URL: %s", videoURL)
+	}
+	channelID, name, err := s.client.ResolveVideoToChannel(videoID)
+	if err != nil {
+		return nil, err
+	}
+	return &ChannelInfo{ID: channelID, Name: name, URL: "https://www.youtube.com/channel/" + channelID}, nil
+}
+
+// FetchLatestVideos ignores includeShorts: Piped's channel endpoint has no
+// equivalent to YouTube's UULF long-form playlist, so it always returns
+// whatever the instance gives it.
+func (s *pipedSource) FetchLatestVideos(channelURL string, limit int, includeShorts bool) ([]models.Video, error) {
+	channelID := ExtractChannelID(channelURL)
+	if channelID == "" {
+		return nil, fmt.Errorf("piped: not a resolvable channel URL: %s", channelURL)
+	}
+	return s.client.FetchLatestVideos(channelID, limit)
+}
+
+func (s *pipedSource) CheckShortsStatus(videoIDs []string) map[string]bool {
+	return s.client.CheckShortsStatus(videoIDs)
+}
+
+func (s *pipedSource) InstanceStatus() map[string]time.Time {
+	return s.client.Instances()
+}
+
+// invidiousSource adapts an invidious.Client to the Source interface.
+type invidiousSource struct {
+	client *invidious.Client
+}
+
+func (s *invidiousSource) Name() string { return "invidious" }
+
+func (s *invidiousSource) ResolveChannelURL(inputURL string) (*ChannelInfo, error) {
+	channelID := ExtractChannelID(inputURL)
+	if channelID == "" {
+		return nil, fmt.Errorf("invidious: not a resolvable channel URL: %s", inputURL)
+	}
+	name, err := s.client.ResolveChannel(channelID)
+	if err != nil {
+		return nil, err
+	}
+	return &ChannelInfo{ID: channelID, Name: name, URL: "https://www.youtube.com/channel/" + channelID}, nil
+}
+
+func (s *invidiousSource) ResolveVideoToChannel(videoURL string) (*ChannelInfo, error) {
+	videoID := ExtractVideoID(videoURL)
+	if videoID == "" {
+		return nil, fmt.Errorf("invidious: could not extract video ID from URL: %s", videoURL)
+	}
+	channelID, name, err := s.client.ResolveVideoToChannel(videoID)
+	if err != nil {
+		return nil, err
+	}
+	return &ChannelInfo{ID: channelID, Name: name, URL: "https://www.youtube.com/channel/" + channelID}, nil
+}
+
+// FetchLatestVideos ignores includeShorts for the same reason as
+// pipedSource.FetchLatestVideos: Invidious's channel videos endpoint has no
+// long-form-only variant.
+func (s *invidiousSource) FetchLatestVideos(channelURL string, limit int, includeShorts bool) ([]models.Video, error) {
+	channelID := ExtractChannelID(channelURL)
+	if channelID == "" {
+		return nil, fmt.Errorf("invidious: not a resolvable channel URL: %s", channelURL)
+	}
+	return s.client.FetchLatestVideos(channelID, limit)
+}
+
+func (s *invidiousSource) CheckShortsStatus(videoIDs []string) map[string]bool {
+	return s.client.CheckShortsStatus(videoIDs)
+}
+
+func (s *invidiousSource) InstanceStatus() map[string]time.Time {
+	return s.client.Instances()
+}
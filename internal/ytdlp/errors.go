@@ -0,0 +1,93 @@
+package ytdlp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Sentinel error classes a YTDLPError.Class can be. Use errors.Is(err,
+// ErrXxx) to test for one, e.g. to decide whether a channel needs cookies
+// (ErrLoginRequired) vs. is just temporarily unavailable (ErrTransient).
+var (
+	ErrRateLimited   = fmt.Errorf("yt-dlp: rate limited")
+	ErrForbidden     = fmt.Errorf("yt-dlp: forbidden")
+	ErrGeoBlocked    = fmt.Errorf("yt-dlp: not available in this region")
+	ErrPrivate       = fmt.Errorf("yt-dlp: video unavailable")
+	ErrRemoved       = fmt.Errorf("yt-dlp: video removed")
+	ErrCopyright     = fmt.Errorf("yt-dlp: blocked on copyright grounds")
+	ErrLoginRequired = fmt.Errorf("yt-dlp: login required")
+	ErrTransient     = fmt.Errorf("yt-dlp: transient error")
+)
+
+// permanentClasses are classes where retrying - with backoff or a new
+// IP/proxy lease - can't help, because the failure is a property of the
+// video or channel itself rather than the request.
+var permanentClasses = map[error]bool{
+	ErrForbidden:     true,
+	ErrGeoBlocked:    true,
+	ErrPrivate:       true,
+	ErrRemoved:       true,
+	ErrCopyright:     true,
+	ErrLoginRequired: true,
+}
+
+// classifier matches stderr substrings to the class they indicate. Order
+// matters: the first match wins, so more specific markers should come
+// before generic ones.
+type classifier struct {
+	class   error
+	markers []string
+}
+
+var classifiers = []classifier{
+	{ErrRateLimited, []string{"HTTP Error 429", "Too Many Requests"}},
+	{ErrLoginRequired, []string{"Sign in to confirm"}},
+	{ErrForbidden, []string{"HTTP Error 403"}},
+	{ErrGeoBlocked, []string{"not available in your country"}},
+	{ErrCopyright, []string{"blocked it on copyright grounds"}},
+	{ErrRemoved, []string{"This video has been removed", "account associated with this video has been terminated"}},
+	{ErrPrivate, []string{"Video unavailable", "Private video"}},
+}
+
+// YTDLPError classifies a failed yt-dlp invocation by parsing its stderr,
+// so callers can react to the failure mode - e.g. mark a channel as
+// needing cookies vs. temporarily unavailable - instead of pattern-matching
+// raw stderr themselves.
+type YTDLPError struct {
+	Class  error
+	Stderr string
+	Cause  error // the *exec.ExitError (or other) cmd.Run() returned
+}
+
+func (e *YTDLPError) Error() string {
+	return fmt.Sprintf("%v: %v (stderr: %s)", e.Class, e.Cause, strings.TrimSpace(e.Stderr))
+}
+
+func (e *YTDLPError) Unwrap() error { return e.Class }
+
+// Retryable reports whether this class is worth retrying at all - false
+// for classes where the failure won't change no matter how many times or
+// from how many addresses the request is repeated.
+func (e *YTDLPError) Retryable() bool {
+	return !permanentClasses[e.Class]
+}
+
+// classifyError wraps runErr (as returned by cmd.Run()) as a YTDLPError by
+// matching stderr against the same fatal-vs-retryable failure modes
+// ytsync's manager.go treats specially. Unmatched stderr classifies as
+// ErrTransient, the retryable default.
+func classifyError(runErr error, stderr string) *YTDLPError {
+	class := ErrTransient
+	for _, c := range classifiers {
+		for _, marker := range c.markers {
+			if strings.Contains(stderr, marker) {
+				class = c.class
+				break
+			}
+		}
+		if class != ErrTransient {
+			break
+		}
+	}
+	return &YTDLPError{Class: class, Stderr: stderr, Cause: runErr}
+}
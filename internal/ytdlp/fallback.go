@@ -0,0 +1,92 @@
+package ytdlp
+
+// FallbackClient wraps two Clients, trying primary for every call and
+// retrying against secondary only when primary errors - e.g. the yt-dlp
+// binary is missing, rate-limited, or blocked on this host, and secondary
+// is the pure-Go ytnative backend backed by a pool of Piped instances (see
+// internal/ytnative, internal/piped). Unlike FEEDS_BACKEND=auto, which
+// picks one backend at startup and never reconsiders, this re-decides on
+// every call, so a yt-dlp outage partway through the process's life
+// doesn't need a restart to route around.
+type FallbackClient struct {
+	primary   Client
+	secondary Client
+}
+
+// NewFallbackClient builds a FallbackClient. secondary is tried only after
+// primary's own call fails.
+func NewFallbackClient(primary, secondary Client) *FallbackClient {
+	return &FallbackClient{primary: primary, secondary: secondary}
+}
+
+var _ Client = (*FallbackClient)(nil)
+
+// Primary returns the wrapped primary Client, so callers that need to type-
+// assert down to a concrete backend (e.g. NewServer pulling the cookies
+// path and download queue out of a *YTDLP) can see through the wrapper.
+func (f *FallbackClient) Primary() Client {
+	return f.primary
+}
+
+func (f *FallbackClient) GetLatestVideos(channelURL string, limit int) ([]VideoInfo, error) {
+	if videos, err := f.primary.GetLatestVideos(channelURL, limit); err == nil {
+		return videos, nil
+	}
+	return f.secondary.GetLatestVideos(channelURL, limit)
+}
+
+func (f *FallbackClient) GetChannelVideos(channelURL string, start, end int) ([]VideoInfo, error) {
+	if videos, err := f.primary.GetChannelVideos(channelURL, start, end); err == nil {
+		return videos, nil
+	}
+	return f.secondary.GetChannelVideos(channelURL, start, end)
+}
+
+func (f *FallbackClient) GetStreamURL(videoURL string, quality string) (string, error) {
+	if url, err := f.primary.GetStreamURL(videoURL, quality); err == nil {
+		return url, nil
+	}
+	return f.secondary.GetStreamURL(videoURL, quality)
+}
+
+func (f *FallbackClient) GetAdaptiveStreamURLs(videoURL string, quality string) (string, string, error) {
+	if videoURL2, audioURL, err := f.primary.GetAdaptiveStreamURLs(videoURL, quality); err == nil {
+		return videoURL2, audioURL, nil
+	}
+	return f.secondary.GetAdaptiveStreamURLs(videoURL, quality)
+}
+
+func (f *FallbackClient) GetVideoInfo(videoURL string) (*VideoInfo, error) {
+	if info, err := f.primary.GetVideoInfo(videoURL); err == nil {
+		return info, nil
+	}
+	return f.secondary.GetVideoInfo(videoURL)
+}
+
+func (f *FallbackClient) GetDownloadURL(videoURL string, quality string) (string, string, error) {
+	if url, ext, err := f.primary.GetDownloadURL(videoURL, quality); err == nil {
+		return url, ext, nil
+	}
+	return f.secondary.GetDownloadURL(videoURL, quality)
+}
+
+func (f *FallbackClient) GetVideoDurations(videoIDs []string) (map[string]int, error) {
+	if durations, err := f.primary.GetVideoDurations(videoIDs); err == nil {
+		return durations, nil
+	}
+	return f.secondary.GetVideoDurations(videoIDs)
+}
+
+func (f *FallbackClient) GetFormats(videoURL string) ([]Format, error) {
+	if formats, err := f.primary.GetFormats(videoURL); err == nil {
+		return formats, nil
+	}
+	return f.secondary.GetFormats(videoURL)
+}
+
+// Version reports the primary backend's version - secondary is purely a
+// failover path, not a user-facing backend choice, so it shouldn't show up
+// in the health check.
+func (f *FallbackClient) Version() (string, error) {
+	return f.primary.Version()
+}
@@ -0,0 +1,34 @@
+package ytdlp
+
+import (
+	"strings"
+
+	"github.com/abadojack/whatlanggo"
+)
+
+// descriptionSampleLen bounds how much of a video's description feeds the
+// language detector, mirroring ytsync's own language tagging: more text
+// doesn't meaningfully improve confidence, and full descriptions can run to
+// thousands of characters.
+const descriptionSampleLen = 500
+
+// detectLanguage returns the ISO-639-1 code whatlanggo detects from title
+// and the first descriptionSampleLen runes of description, or "" if there
+// isn't enough text to reliably detect a script/language at all.
+func detectLanguage(title, description string) string {
+	runes := []rune(description)
+	if len(runes) > descriptionSampleLen {
+		runes = runes[:descriptionSampleLen]
+	}
+
+	text := strings.TrimSpace(title + " " + string(runes))
+	if text == "" {
+		return ""
+	}
+
+	info := whatlanggo.Detect(text)
+	if info.Lang < 0 {
+		return ""
+	}
+	return info.Lang.Iso6391()
+}
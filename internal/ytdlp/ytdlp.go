@@ -3,20 +3,71 @@ package ytdlp
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math"
+	"math/rand"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 	"time"
 
+	ffprobe "gopkg.in/vansante/go-ffprobe.v2"
+
+	"github.com/erik/feeds/internal/ippool"
+	"github.com/erik/feeds/internal/metrics"
 	"github.com/erik/feeds/internal/models"
 )
 
+// ErrCorruptDownload is returned when a downloaded file fails post-download
+// verification (wrong duration, missing video/audio stream, resolution
+// above the requested cap, or an unplayable container). The file is
+// deleted before this is returned, so callers can retry against a fresh
+// path rather than serve a broken one.
+var ErrCorruptDownload = errors.New("ytdlp: downloaded file failed integrity verification")
+
+// durationToleranceSecs is how far a downloaded file's ffprobe duration may
+// drift from the expected VideoInfo.Duration before it's considered corrupt
+// (rounding between yt-dlp's metadata and the muxed container is normal).
+const durationToleranceSecs = 2.0
+
+// DownloadResult describes a download that passed ffprobe verification.
+type DownloadResult struct {
+	Size     int64
+	Duration float64
+	Width    int
+	Height   int
+	VCodec   string
+	ACodec   string
+}
+
+// Retry policy for runWithRetry, mirroring the backoff shape ytsync's
+// manager.go uses for its own rate-limit retries.
+const (
+	retryInitialBackoff = 250 * time.Millisecond
+	retryMaxBackoff     = 8 * time.Second
+	maxRetryAttempts    = 5
+)
+
 type YTDLP struct {
 	BinPath     string
 	CookiesPath string
+
+	// Pool, if set, hands out source IPs/proxies for each invocation and
+	// cools one down on a 429 so retries go out a different address. Nil
+	// (the default) runs yt-dlp without an injected address, same as before
+	// Pool existed.
+	Pool *ippool.Pool
+
+	// FFProbePath is the ffprobe binary used to verify downloads in
+	// DownloadVideo/DownloadVideoWithProgress. Empty uses "ffprobe" from
+	// PATH; if that can't be run, verification is skipped rather than
+	// failing the download.
+	FFProbePath string
 }
 
 func New(binPath string, cookiesPath string) *YTDLP {
@@ -26,13 +77,94 @@ func New(binPath string, cookiesPath string) *YTDLP {
 	return &YTDLP{BinPath: binPath, CookiesPath: cookiesPath}
 }
 
+// runWithRetry runs the yt-dlp binary with args, capturing stdout/stderr.
+// On failure, stderr is classified (see classifyError): permanent classes
+// (ErrForbidden, ErrGeoBlocked, ErrPrivate, ErrRemoved, ErrCopyright,
+// ErrLoginRequired) return immediately as a *YTDLPError, since no amount of
+// retrying fixes them. Retryable classes (ErrRateLimited, ErrTransient)
+// retry up to maxRetryAttempts times with jittered exponential backoff
+// (retryInitialBackoff doubling up to retryMaxBackoff); if Pool is
+// configured, each attempt also acquires a lease and prepends its
+// --source-address/--proxy flags, and a rate-limited attempt cools its
+// lease down before moving to the next one.
+func (y *YTDLP) runWithRetry(args []string) (stdout, stderr bytes.Buffer, err error) {
+	backoff := retryInitialBackoff
+
+	for attempt := 0; attempt < maxRetryAttempts; attempt++ {
+		lease, ok := y.Pool.Acquire()
+		runArgs := args
+		if ok {
+			runArgs = append(append([]string{}, lease.Args()...), args...)
+		}
+
+		stdout.Reset()
+		stderr.Reset()
+		cmd := exec.Command(y.BinPath, runArgs...)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		runErr := cmd.Run()
+		if runErr == nil {
+			return stdout, stderr, nil
+		}
+
+		ytdlpErr := classifyError(runErr, stderr.String())
+		err = ytdlpErr
+		if !ytdlpErr.Retryable() {
+			return stdout, stderr, err
+		}
+		if ok && ytdlpErr.Class == ErrRateLimited {
+			lease.Cooldown()
+		}
+		if attempt == maxRetryAttempts-1 {
+			break
+		}
+
+		time.Sleep(jitter(backoff))
+		backoff *= 2
+		if backoff > retryMaxBackoff {
+			backoff = retryMaxBackoff
+		}
+	}
+	return stdout, stderr, err
+}
+
+// jitter returns a duration somewhere in [d/2, d], so retries across
+// concurrent calls don't all land on the same schedule.
+func jitter(d time.Duration) time.Duration {
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// Client is the extraction backend other packages depend on. YTDLP (this
+// package, shelling out to the yt-dlp binary) and ytnative.Client (a pure-Go
+// fallback with no binary dependency) both implement it; main.go picks
+// between them via FEEDS_BACKEND. GetChannelVideos and Version aren't part
+// of the minimal extraction surface but are needed by the backfill windowed
+// fetch and the auto-backend probe/health check respectively, so both
+// backends implement them too.
+type Client interface {
+	GetLatestVideos(channelURL string, limit int) ([]VideoInfo, error)
+	GetChannelVideos(channelURL string, start, end int) ([]VideoInfo, error)
+	GetStreamURL(videoURL string, quality string) (string, error)
+	GetAdaptiveStreamURLs(videoURL string, quality string) (string, string, error)
+	GetVideoInfo(videoURL string) (*VideoInfo, error)
+	GetDownloadURL(videoURL string, quality string) (string, string, error)
+	GetVideoDurations(videoIDs []string) (map[string]int, error)
+	GetFormats(videoURL string) ([]Format, error)
+	Version() (string, error)
+}
+
+var _ Client = (*YTDLP)(nil)
+
 func (y *YTDLP) appendCookiesArgs(args []string) []string {
 	if y.CookiesPath == "" {
+		metrics.YTDLPCookiesConfigured.WithLabelValues("false").Inc()
 		return args
 	}
 	if _, err := os.Stat(y.CookiesPath); err != nil {
+		metrics.YTDLPCookiesConfigured.WithLabelValues("false").Inc()
 		return args
 	}
+	metrics.YTDLPCookiesConfigured.WithLabelValues("true").Inc()
 	return append(args, "--cookies", y.CookiesPath)
 }
 
@@ -57,6 +189,13 @@ type VideoInfo struct {
 	URL         string      `json:"url"`
 	Description string      `json:"description"`
 	ViewCount   int64       `json:"view_count"`
+
+	// LiveStatus is one of "is_live", "is_upcoming", "was_live", "post_live",
+	// or "not_live"; see livestream.normalizeLiveStatus for the mapping onto
+	// our own none/upcoming/live/ended states.
+	LiveStatus          string `json:"live_status"`
+	ReleaseTimestamp    int64  `json:"release_timestamp"`
+	ConcurrentViewCount int64  `json:"concurrent_view_count"`
 }
 
 // GetBestThumbnail returns the best available thumbnail URL
@@ -91,14 +230,39 @@ func (y *YTDLP) GetLatestVideos(channelURL string, limit int) ([]VideoInfo, erro
 	}
 	args = y.appendCookiesArgs(args)
 	args = append(args, channelURL)
-	cmd := exec.Command(y.BinPath, args...)
+	stdout, _, err := y.runWithRetry(args)
+	if err != nil {
+		return nil, err
+	}
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	var videos []VideoInfo
+	decoder := json.NewDecoder(&stdout)
+	for decoder.More() {
+		var v VideoInfo
+		if err := decoder.Decode(&v); err != nil {
+			continue
+		}
+		videos = append(videos, v)
+	}
 
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("yt-dlp error: %v, stderr: %s", err, stderr.String())
+	return videos, nil
+}
+
+// GetChannelVideos fetches a windowed slice of a channel's upload history,
+// positions start-end inclusive (1-indexed), for paginated historical backfill.
+func (y *YTDLP) GetChannelVideos(channelURL string, start, end int) ([]VideoInfo, error) {
+	args := []string{
+		"--flat-playlist",
+		"--playlist-start", fmt.Sprintf("%d", start),
+		"--playlist-end", fmt.Sprintf("%d", end),
+		"--dump-json",
+		"--no-warnings",
+	}
+	args = y.appendCookiesArgs(args)
+	args = append(args, channelURL)
+	stdout, _, err := y.runWithRetry(args)
+	if err != nil {
+		return nil, err
 	}
 
 	var videos []VideoInfo
@@ -167,14 +331,9 @@ func (y *YTDLP) GetStreamURL(videoURL string, quality string) (string, error) {
 	}
 	args = y.appendCookiesArgs(args)
 	args = append(args, videoURL)
-	cmd := exec.Command(y.BinPath, args...)
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("yt-dlp error: %v, stderr: %s", err, stderr.String())
+	stdout, _, err := y.runWithRetry(args)
+	if err != nil {
+		return "", err
 	}
 
 	return string(bytes.TrimSpace(stdout.Bytes())), nil
@@ -192,14 +351,9 @@ func (y *YTDLP) GetAdaptiveStreamURLs(videoURL string, quality string) (string,
 	}
 	args = y.appendCookiesArgs(args)
 	args = append(args, videoURL)
-	cmd := exec.Command(y.BinPath, args...)
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		return "", "", fmt.Errorf("yt-dlp error: %v, stderr: %s", err, stderr.String())
+	stdout, _, err := y.runWithRetry(args)
+	if err != nil {
+		return "", "", err
 	}
 
 	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
@@ -223,19 +377,14 @@ func (y *YTDLP) GetDASHManifest(videoURL string) (string, error) {
 	}
 	args = y.appendCookiesArgs(args)
 	args = append(args, videoURL)
-	cmd := exec.Command(y.BinPath, args...)
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("yt-dlp error: %v, stderr: %s", err, stderr.String())
+	stdout, _, err := y.runWithRetry(args)
+	if err != nil {
+		return "", err
 	}
 
 	// Parse JSON to extract manifest URL
 	var result struct {
-		ManifestURL     string `json:"manifest_url"`
+		ManifestURL      string `json:"manifest_url"`
 		RequestedFormats []struct {
 			ManifestURL string `json:"manifest_url"`
 		} `json:"requested_formats"`
@@ -259,6 +408,8 @@ func (y *YTDLP) GetDASHManifest(videoURL string) (string, error) {
 
 // Version returns the yt-dlp version string.
 func (y *YTDLP) Version() (string, error) {
+	// --version doesn't talk to YouTube, so there's nothing a lease or
+	// retry could help with; run it directly rather than through runWithRetry.
 	cmd := exec.Command(y.BinPath, "--version")
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
@@ -269,7 +420,6 @@ func (y *YTDLP) Version() (string, error) {
 	return strings.TrimSpace(stdout.String()), nil
 }
 
-
 // GetVideoInfo fetches full metadata for a single video
 func (y *YTDLP) GetVideoInfo(videoURL string) (*VideoInfo, error) {
 	args := []string{
@@ -278,14 +428,9 @@ func (y *YTDLP) GetVideoInfo(videoURL string) (*VideoInfo, error) {
 	}
 	args = y.appendCookiesArgs(args)
 	args = append(args, videoURL)
-	cmd := exec.Command(y.BinPath, args...)
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("yt-dlp error: %v, stderr: %s", err, stderr.String())
+	stdout, _, err := y.runWithRetry(args)
+	if err != nil {
+		return nil, err
 	}
 
 	var info VideoInfo
@@ -296,15 +441,52 @@ func (y *YTDLP) GetVideoInfo(videoURL string) (*VideoInfo, error) {
 	return &info, nil
 }
 
-// Format represents available video formats
+// Format represents one available video format, as reported by yt-dlp's
+// --dump-json "formats" array - see GetFormats.
 type Format struct {
-	FormatID   string `json:"format_id"`
-	Ext        string `json:"ext"`
-	Resolution string `json:"resolution"`
-	Height     int    `json:"height"`
-	Filesize   int64  `json:"filesize"`
-	VCodec     string `json:"vcodec"`
-	ACodec     string `json:"acodec"`
+	FormatID       string  `json:"format_id"`
+	Ext            string  `json:"ext"`
+	Resolution     string  `json:"resolution"`
+	Height         int     `json:"height"`
+	FPS            float64 `json:"fps"`
+	TBR            float64 `json:"tbr"` // total bitrate in kbps
+	DynamicRange   string  `json:"dynamic_range"`
+	Filesize       int64   `json:"filesize"`
+	FilesizeApprox int64   `json:"filesize_approx"`
+	VCodec         string  `json:"vcodec"`
+	ACodec         string  `json:"acodec"`
+}
+
+// IsVideo reports whether the format carries a video stream, excluding
+// audio-only formats (vcodec "none").
+func (f Format) IsVideo() bool {
+	return f.VCodec != "" && f.VCodec != "none"
+}
+
+// GetFormats enumerates every format yt-dlp reports for videoURL, used by
+// internal/api's qualityProbe to offer real available resolutions/fps/
+// bitrate instead of a hardcoded rung list - see GetDownloadURL for the
+// format-selector strings those rungs feed into.
+func (y *YTDLP) GetFormats(videoURL string) ([]Format, error) {
+	args := []string{
+		"--dump-json",
+		"--no-playlist",
+	}
+	args = y.appendCookiesArgs(args)
+	args = append(args, videoURL)
+	stdout, _, err := y.runWithRetry(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Formats []Format `json:"formats"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse yt-dlp output: %v", err)
+	}
+
+	return result.Formats, nil
 }
 
 // GetDownloadURL gets the direct download URL for a specific quality
@@ -337,14 +519,9 @@ func (y *YTDLP) GetDownloadURL(videoURL string, quality string) (string, string,
 	}
 	args = y.appendCookiesArgs(args)
 	args = append(args, videoURL)
-	cmd := exec.Command(y.BinPath, args...)
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		return "", "", fmt.Errorf("yt-dlp error: %v, stderr: %s", err, stderr.String())
+	stdout, _, err := y.runWithRetry(args)
+	if err != nil {
+		return "", "", err
 	}
 
 	return string(bytes.TrimSpace(stdout.Bytes())), ext, nil
@@ -373,14 +550,9 @@ func (y *YTDLP) GetVideoDurations(videoIDs []string) (map[string]int, error) {
 	args = y.appendCookiesArgs(args)
 	args = append(args, urls...)
 
-	cmd := exec.Command(y.BinPath, args...)
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("yt-dlp error: %v, stderr: %s", err, stderr.String())
+	stdout, _, err := y.runWithRetry(args)
+	if err != nil {
+		return nil, err
 	}
 
 	durations := make(map[string]int)
@@ -401,12 +573,15 @@ func (y *YTDLP) GetVideoDurations(videoIDs []string) (map[string]int, error) {
 // DownloadVideo downloads a video to the specified path using yt-dlp's native downloader.
 // This is much faster than using GetAdaptiveStreamURLs + HTTP download because yt-dlp
 // handles YouTube's throttling, uses multiple connections, and has proper retry logic.
-// Returns the final file size.
-func (y *YTDLP) DownloadVideo(videoURL string, quality string, outputPath string) (int64, error) {
+// expectedDuration (seconds, from VideoInfo.Duration) is checked against the downloaded
+// file via ffprobe; pass 0 to skip that check. Returns ErrCorruptDownload, with the file
+// already deleted, if verification fails.
+func (y *YTDLP) DownloadVideo(videoURL string, quality string, outputPath string, expectedDuration int) (*DownloadResult, error) {
 	format := formatForQuality(quality, true)
 
 	args := []string{
 		"--force-ipv4",
+		"--continue", // resume from a partial .part file instead of restarting
 		"--format", format,
 		"--merge-output-format", "mp4",
 		"--output", outputPath,
@@ -417,30 +592,41 @@ func (y *YTDLP) DownloadVideo(videoURL string, quality string, outputPath string
 	args = y.appendCookiesArgs(args)
 	args = append(args, videoURL)
 
+	// A streaming download isn't worth the retry-with-backoff treatment
+	// runWithRetry gives the short-lived calls above: a rate limit here has
+	// already spent time on a partial file, so we just cool the lease down
+	// for next time and classify the error, rather than restarting the
+	// download.
+	lease, ok := y.Pool.Acquire()
+	if ok {
+		args = append(append([]string{}, lease.Args()...), args...)
+	}
+
 	cmd := exec.Command(y.BinPath, args...)
 
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
-		return 0, fmt.Errorf("yt-dlp download error: %v, stderr: %s", err, stderr.String())
-	}
-
-	// Get the final file size
-	info, err := os.Stat(outputPath)
-	if err != nil {
-		return 0, fmt.Errorf("failed to stat output file: %v", err)
+		ytdlpErr := classifyError(err, stderr.String())
+		if ok && ytdlpErr.Class == ErrRateLimited {
+			lease.Cooldown()
+		}
+		return nil, ytdlpErr
 	}
 
-	return info.Size(), nil
+	return y.verifyDownload(outputPath, expectedDuration, quality)
 }
 
 // DownloadVideoWithProgress downloads a video and reports progress via callback.
 // The callback receives (downloaded bytes, total bytes, percent).
 // For adaptive streams, video+audio are downloaded separately and then merged.
 // Progress is mapped: video=0-80%, audio=80-95%, merging=95-100%
-// outputPath should be the desired final path (e.g., "/tmp/video.mp4")
-func (y *YTDLP) DownloadVideoWithProgress(videoURL string, quality string, outputPath string, progressFn func(downloaded, total int64, percent float64)) (int64, error) {
+// outputPath should be the desired final path (e.g., "/tmp/video.mp4"). expectedDuration
+// and the returned DownloadResult/ErrCorruptDownload behave as in DownloadVideo. Canceling
+// ctx kills the in-flight yt-dlp process, so callers (e.g. downloader.Queue's cancellation)
+// can stop a running download rather than waiting it out.
+func (y *YTDLP) DownloadVideoWithProgress(ctx context.Context, videoURL string, quality string, outputPath string, expectedDuration int, progressFn func(downloaded, total int64, percent float64)) (*DownloadResult, error) {
 	format := formatForQuality(quality, true)
 
 	// yt-dlp adds extension based on merge format, so we strip .mp4 if present
@@ -448,6 +634,7 @@ func (y *YTDLP) DownloadVideoWithProgress(videoURL string, quality string, outpu
 
 	args := []string{
 		"--force-ipv4",
+		"--continue", // resume from a partial .part file instead of restarting
 		"--format", format,
 		"--merge-output-format", "mp4",
 		"--output", outputTemplate + ".%(ext)s",
@@ -459,18 +646,23 @@ func (y *YTDLP) DownloadVideoWithProgress(videoURL string, quality string, outpu
 	args = y.appendCookiesArgs(args)
 	args = append(args, videoURL)
 
-	cmd := exec.Command(y.BinPath, args...)
+	lease, leaseOK := y.Pool.Acquire()
+	if leaseOK {
+		args = append(append([]string{}, lease.Args()...), args...)
+	}
+
+	cmd := exec.CommandContext(ctx, y.BinPath, args...)
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
 	if err := cmd.Start(); err != nil {
-		return 0, fmt.Errorf("failed to start yt-dlp: %v", err)
+		return nil, fmt.Errorf("failed to start yt-dlp: %v", err)
 	}
 
 	// Track which download phase we're in (video=0, audio=1)
@@ -521,7 +713,14 @@ func (y *YTDLP) DownloadVideoWithProgress(videoURL string, quality string, outpu
 	}
 
 	if err := cmd.Wait(); err != nil {
-		return 0, fmt.Errorf("yt-dlp download error: %v, stderr: %s", err, stderr.String())
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		ytdlpErr := classifyError(err, stderr.String())
+		if leaseOK && ytdlpErr.Class == ErrRateLimited {
+			lease.Cooldown()
+		}
+		return nil, ytdlpErr
 	}
 
 	// The actual output file will be outputTemplate + ".mp4"
@@ -530,17 +729,87 @@ func (y *YTDLP) DownloadVideoWithProgress(videoURL string, quality string, outpu
 	// Rename to the requested path if different
 	if actualOutput != outputPath {
 		if err := os.Rename(actualOutput, outputPath); err != nil {
-			return 0, fmt.Errorf("failed to rename output file: %v", err)
+			return nil, fmt.Errorf("failed to rename output file: %v", err)
 		}
 	}
 
-	// Get the final file size
-	info, err := os.Stat(outputPath)
+	return y.verifyDownload(outputPath, expectedDuration, quality)
+}
+
+// expectedHeightCap returns the max pixel height implied by a quality
+// string like "1080"/"720" (0 = no cap, e.g. "best" or "audio"), mirroring
+// formatForQuality's own parsing of the same strings.
+func expectedHeightCap(quality string) int {
+	h, err := strconv.Atoi(quality)
+	if err != nil {
+		return 0
+	}
+	return h
+}
+
+// verifyDownload runs ffprobe against path and checks the result against
+// expectedDuration (seconds, 0 skips the check) and quality's resolution
+// cap, deleting path and returning ErrCorruptDownload if anything looks
+// wrong. If ffprobe itself can't be run (binary missing), verification is
+// skipped and the file is trusted as-is, so self-hosters without ffprobe
+// installed still get their downloads, just without this safety net.
+func (y *YTDLP) verifyDownload(path string, expectedDuration int, quality string) (*DownloadResult, error) {
+	if y.FFProbePath != "" {
+		ffprobe.SetFFProbeBinPath(y.FFProbePath)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	data, err := ffprobe.ProbeURL(ctx, path)
+	if err != nil {
+		bin := y.FFProbePath
+		if bin == "" {
+			bin = "ffprobe"
+		}
+		if _, lookErr := exec.LookPath(bin); lookErr == nil {
+			// ffprobe is installed, so the failure is the file's own.
+			os.Remove(path)
+			return nil, fmt.Errorf("%w: unplayable container: %v", ErrCorruptDownload, err)
+		}
+		log.Printf("ffprobe unavailable (%v) - skipping download integrity check for %s", err, path)
+		info, statErr := os.Stat(path)
+		if statErr != nil {
+			return nil, fmt.Errorf("failed to stat output file: %v", statErr)
+		}
+		return &DownloadResult{Size: info.Size()}, nil
+	}
+
+	videoStream := data.FirstVideoStream()
+	audioStream := data.FirstAudioStream()
+	if videoStream == nil || audioStream == nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("%w: missing video or audio stream", ErrCorruptDownload)
+	}
+
+	duration := data.Format.DurationSeconds
+	if expectedDuration > 0 && math.Abs(duration-float64(expectedDuration)) > durationToleranceSecs {
+		os.Remove(path)
+		return nil, fmt.Errorf("%w: duration %.1fs does not match expected %ds", ErrCorruptDownload, duration, expectedDuration)
+	}
+
+	if cap := expectedHeightCap(quality); cap > 0 && videoStream.Height > cap {
+		os.Remove(path)
+		return nil, fmt.Errorf("%w: resolution %dp exceeds requested cap %dp", ErrCorruptDownload, videoStream.Height, cap)
+	}
+
+	info, err := os.Stat(path)
 	if err != nil {
-		return 0, fmt.Errorf("failed to stat output file: %v", err)
+		return nil, fmt.Errorf("failed to stat output file: %v", err)
 	}
 
-	return info.Size(), nil
+	return &DownloadResult{
+		Size:     info.Size(),
+		Duration: duration,
+		Width:    videoStream.Width,
+		Height:   videoStream.Height,
+		VCodec:   videoStream.CodecName,
+		ACodec:   audioStream.CodecName,
+	}, nil
 }
 
 // parseSize parses size strings like "10.5MiB", "1.2GiB", "500KiB"
@@ -593,5 +862,6 @@ func (v *VideoInfo) ToModel(channelID int64, channelName string) *models.Video {
 		Duration:    v.Duration,
 		Published:   published,
 		URL:         videoURL,
+		Language:    detectLanguage(v.Title, v.Description),
 	}
 }
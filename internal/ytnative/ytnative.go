@@ -0,0 +1,324 @@
+// Package ytnative is a pure-Go fallback extraction backend for when no
+// yt-dlp binary is available on the host: video listing goes through the
+// existing RSS/Piped paths in internal/youtube, and stream/format
+// resolution goes through Piped's /streams endpoint, whose instances
+// already perform YouTube's signature deciphering server-side. See
+// FEEDS_BACKEND in cmd/server/main.go for backend selection.
+package ytnative
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/erik/feeds/internal/models"
+	"github.com/erik/feeds/internal/piped"
+	"github.com/erik/feeds/internal/youtube"
+	"github.com/erik/feeds/internal/ytdlp"
+)
+
+// videoDurationWorkers caps concurrent /streams lookups in
+// GetVideoDurations, matching the concurrency cap internal/youtube uses
+// for its own shorts-status scrape fallback.
+const videoDurationWorkers = 5
+
+// Client implements ytdlp.Client without shelling out to a binary.
+type Client struct {
+	piped *piped.Client
+}
+
+var _ ytdlp.Client = (*Client)(nil)
+
+// New builds a native client. instances configures the Piped API pool
+// used for stream resolution; nil uses piped.DefaultInstances.
+func New(instances []string) *Client {
+	return &Client{piped: piped.NewClient(instances)}
+}
+
+// GetLatestVideos delegates to internal/youtube's RSS/Piped-backed listing,
+// which already has no yt-dlp dependency.
+func (c *Client) GetLatestVideos(channelURL string, limit int) ([]ytdlp.VideoInfo, error) {
+	videos, err := youtube.FetchLatestVideos(channelURL, limit, true)
+	if err != nil {
+		return nil, err
+	}
+	return toVideoInfos(videos), nil
+}
+
+// GetChannelVideos approximates yt-dlp's windowed playlist fetch on top of
+// youtube.FetchLatestVideos, which returns only a channel's most recent
+// uploads, so this can only slice within that same recent window - it
+// can't reach deep history the way the yt-dlp backend can. Deeper history
+// without yt-dlp is reached by youtube.BackfillChannel instead (the YouTube
+// Data API, or Piped continuation pages when no API key is set).
+func (c *Client) GetChannelVideos(channelURL string, start, end int) ([]ytdlp.VideoInfo, error) {
+	if start < 1 || end < start {
+		return nil, fmt.Errorf("ytnative: invalid window [%d,%d]", start, end)
+	}
+	videos, err := youtube.FetchLatestVideos(channelURL, end, true)
+	if err != nil {
+		return nil, err
+	}
+	if start-1 >= len(videos) {
+		return nil, nil
+	}
+	if end > len(videos) {
+		end = len(videos)
+	}
+	return toVideoInfos(videos[start-1 : end]), nil
+}
+
+func toVideoInfos(videos []models.Video) []ytdlp.VideoInfo {
+	infos := make([]ytdlp.VideoInfo, len(videos))
+	for i, v := range videos {
+		infos[i] = ytdlp.VideoInfo{
+			ID:         v.ID,
+			Title:      v.Title,
+			Channel:    v.ChannelName,
+			Thumbnail:  v.Thumbnail,
+			Duration:   v.Duration,
+			WebpageURL: v.URL,
+		}
+	}
+	return infos
+}
+
+// GetVideoInfo fetches full metadata for a single video via Piped.
+func (c *Client) GetVideoInfo(videoURL string) (*ytdlp.VideoInfo, error) {
+	videoID := youtube.ExtractVideoID(videoURL)
+	if videoID == "" {
+		return nil, fmt.Errorf("ytnative: could not extract video ID from %s", videoURL)
+	}
+	s, err := c.piped.GetStreams(videoID)
+	if err != nil {
+		return nil, err
+	}
+	return &ytdlp.VideoInfo{
+		ID:         videoID,
+		Title:      s.Title,
+		Channel:    s.Channel,
+		ChannelURL: s.ChannelURL,
+		Thumbnail:  s.Thumbnail,
+		Duration:   int(s.Duration),
+		WebpageURL: videoURL,
+	}, nil
+}
+
+// GetStreamURL returns a direct stream URL at the closest available
+// quality. Piped's /streams response is adaptive (video and audio split)
+// almost without exception, so unlike the yt-dlp backend's progressive
+// formats this is video-only; callers that need audio too should use
+// GetAdaptiveStreamURLs.
+func (c *Client) GetStreamURL(videoURL string, quality string) (string, error) {
+	videoURLOut, _, err := c.GetAdaptiveStreamURLs(videoURL, quality)
+	return videoURLOut, err
+}
+
+// GetAdaptiveStreamURLs returns the best-matching video and audio stream
+// URLs for quality, a height like "1080" or "720" ("best" picks the
+// highest available).
+func (c *Client) GetAdaptiveStreamURLs(videoURL string, quality string) (string, string, error) {
+	videoID := youtube.ExtractVideoID(videoURL)
+	if videoID == "" {
+		return "", "", fmt.Errorf("ytnative: could not extract video ID from %s", videoURL)
+	}
+	s, err := c.piped.GetStreams(videoID)
+	if err != nil {
+		return "", "", err
+	}
+
+	videoFmt := bestFormatForHeight(s.VideoStreams, quality)
+	if videoFmt == nil {
+		return "", "", fmt.Errorf("ytnative: no video stream available for quality %q", quality)
+	}
+	audioURL := ""
+	if audioFmt := bestAudioFormat(s.AudioStreams); audioFmt != nil {
+		audioURL = audioFmt.URL
+	}
+	return videoFmt.URL, audioURL, nil
+}
+
+// GetDownloadURL returns a direct download URL and its file extension.
+// Like GetAdaptiveStreamURLs, the returned URL is video-only unless
+// quality is "audio" - native-backend downloads don't mux video+audio the
+// way the yt-dlp backend's merge-output-format does.
+func (c *Client) GetDownloadURL(videoURL string, quality string) (string, string, error) {
+	videoID := youtube.ExtractVideoID(videoURL)
+	if videoID == "" {
+		return "", "", fmt.Errorf("ytnative: could not extract video ID from %s", videoURL)
+	}
+	s, err := c.piped.GetStreams(videoID)
+	if err != nil {
+		return "", "", err
+	}
+
+	if quality == "audio" {
+		f := bestAudioFormat(s.AudioStreams)
+		if f == nil {
+			return "", "", fmt.Errorf("ytnative: no audio stream available")
+		}
+		return f.URL, extFromMimeType(f.MimeType, "m4a"), nil
+	}
+
+	f := bestFormatForHeight(s.VideoStreams, quality)
+	if f == nil {
+		return "", "", fmt.Errorf("ytnative: no video stream available for quality %q", quality)
+	}
+	return f.URL, extFromMimeType(f.MimeType, "mp4"), nil
+}
+
+// GetVideoDurations fetches durations for multiple videos concurrently via
+// Piped, mirroring the worker-pool pattern internal/youtube uses for its
+// own per-video lookups.
+func (c *Client) GetVideoDurations(videoIDs []string) (map[string]int, error) {
+	durations := make(map[string]int)
+	if len(videoIDs) == 0 {
+		return durations, nil
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, videoDurationWorkers)
+
+	for _, id := range videoIDs {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			s, err := c.piped.GetStreams(id)
+			if err != nil || s.Duration <= 0 {
+				return
+			}
+			mu.Lock()
+			durations[id] = int(s.Duration)
+			mu.Unlock()
+		}(id)
+	}
+	wg.Wait()
+	return durations, nil
+}
+
+// Version identifies this backend for the health check and the
+// FEEDS_BACKEND=auto probe in main.go, which otherwise call a yt-dlp
+// binary's --version.
+func (c *Client) Version() (string, error) {
+	return "ytnative (pure-Go, no yt-dlp binary required)", nil
+}
+
+// GetFormats reports every video and audio format Piped's /streams
+// response carries for videoURL, translated into ytdlp.Format so
+// internal/api's qualityProbe can treat both backends the same way.
+func (c *Client) GetFormats(videoURL string) ([]ytdlp.Format, error) {
+	videoID := youtube.ExtractVideoID(videoURL)
+	if videoID == "" {
+		return nil, fmt.Errorf("ytnative: could not extract video ID from %s", videoURL)
+	}
+	s, err := c.piped.GetStreams(videoID)
+	if err != nil {
+		return nil, err
+	}
+
+	formats := make([]ytdlp.Format, 0, len(s.VideoStreams)+len(s.AudioStreams))
+	for _, f := range s.VideoStreams {
+		formats = append(formats, ytdlp.Format{
+			Resolution: f.Quality,
+			Height:     qualityHeight(f.Quality),
+			FPS:        float64(qualityFPS(f.Quality)),
+			TBR:        float64(f.Bitrate) / 1000,
+			Ext:        extFromMimeType(f.MimeType, "mp4"),
+			VCodec:     "unknown",
+			ACodec:     "none",
+		})
+	}
+	for _, f := range s.AudioStreams {
+		formats = append(formats, ytdlp.Format{
+			Resolution: f.Quality,
+			TBR:        float64(f.Bitrate) / 1000,
+			Ext:        extFromMimeType(f.MimeType, "m4a"),
+			VCodec:     "none",
+			ACodec:     "unknown",
+		})
+	}
+	return formats, nil
+}
+
+func bestFormatForHeight(formats []piped.Format, quality string) *piped.Format {
+	target := 0
+	if quality != "" && quality != "best" {
+		if h, err := strconv.Atoi(quality); err == nil {
+			target = h
+		}
+	}
+
+	var best *piped.Format
+	bestHeight := -1
+	for i := range formats {
+		f := &formats[i]
+		h := qualityHeight(f.Quality)
+		if target > 0 && h > target {
+			continue
+		}
+		if h > bestHeight {
+			bestHeight = h
+			best = f
+		}
+	}
+	if best == nil && len(formats) > 0 {
+		best = &formats[0]
+	}
+	return best
+}
+
+func bestAudioFormat(formats []piped.Format) *piped.Format {
+	var best *piped.Format
+	bestBitrate := -1
+	for i := range formats {
+		if formats[i].Bitrate > bestBitrate {
+			bestBitrate = formats[i].Bitrate
+			best = &formats[i]
+		}
+	}
+	return best
+}
+
+// qualityHeight parses a Piped quality label like "1080p60" or "720p"
+// into its pixel height.
+func qualityHeight(quality string) int {
+	digits := strings.TrimSuffix(quality, "p60")
+	digits = strings.TrimSuffix(digits, "p30")
+	digits = strings.TrimSuffix(digits, "p")
+	h, _ := strconv.Atoi(digits)
+	return h
+}
+
+// qualityFPS parses a Piped quality label like "1080p60" into its frame
+// rate, defaulting to 30 when none is present (e.g. "720p").
+func qualityFPS(quality string) int {
+	if strings.HasSuffix(quality, "p60") {
+		return 60
+	}
+	if strings.HasSuffix(quality, "p30") {
+		return 30
+	}
+	if strings.HasSuffix(quality, "p") {
+		return 30
+	}
+	return 0
+}
+
+func extFromMimeType(mimeType, fallback string) string {
+	_, sub, ok := strings.Cut(mimeType, "/")
+	if !ok {
+		return fallback
+	}
+	if semi := strings.Index(sub, ";"); semi >= 0 {
+		sub = sub[:semi]
+	}
+	if sub == "" {
+		return fallback
+	}
+	return sub
+}